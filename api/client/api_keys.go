@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// CreateAPIKey creates a key for the caller. The secret is in the
+// response's Key and is never shown again.
+func (c *Client) CreateAPIKey(ctx context.Context, req *model.CreateAPIKeyRequest, opts ...RequestOption) (*model.APIKeySecretResponse, error) {
+	var k model.APIKeySecretResponse
+	if err := c.do(ctx, &request{method: http.MethodPost, path: "/api/v1/api-keys", body: req}, &k, opts); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// ListAPIKeys returns the caller's keys, or every key for an admin.
+func (c *Client) ListAPIKeys(ctx context.Context, opts ...RequestOption) ([]model.APIKeyResponse, error) {
+	var list model.APIKeyListResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: "/api/v1/api-keys"}, &list, opts); err != nil {
+		return nil, err
+	}
+	return list.APIKeys, nil
+}
+
+// RotateAPIKey replaces a key's secret; the old secret stops working.
+func (c *Client) RotateAPIKey(ctx context.Context, id uuid.UUID, opts ...RequestOption) (*model.APIKeySecretResponse, error) {
+	var k model.APIKeySecretResponse
+	if err := c.do(ctx, &request{method: http.MethodPost, path: pathID("/api/v1/api-keys/%s/rotate", id)}, &k, opts); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (c *Client) RevokeAPIKey(ctx context.Context, id uuid.UUID, opts ...RequestOption) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: pathID("/api/v1/api-keys/%s", id)}, nil, opts)
+}
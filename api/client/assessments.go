@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// AssessmentListOptions filters and pages the assessments listing.
+type AssessmentListOptions struct {
+	ListOptions
+	// OrganizationID, if set, lists only that organization's assessments.
+	OrganizationID *uuid.UUID
+	// Status, if set, is one of model.AssessmentStatuses.
+	Status string
+}
+
+func (c *Client) CreateAssessment(ctx context.Context, req *model.CreateAssessmentRequest, opts ...RequestOption) (*model.AssessmentResponse, error) {
+	var a model.AssessmentResponse
+	err := c.do(ctx, &request{method: http.MethodPost, path: "/api/v1/assessments", body: req, idempotent: true}, &a, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// GetAssessment returns an assessment with its findings summary.
+func (c *Client) GetAssessment(ctx context.Context, id uuid.UUID, opts ...RequestOption) (*model.AssessmentResponse, error) {
+	var a model.AssessmentResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: pathID("/api/v1/assessments/%s", id)}, &a, opts); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ListAssessments returns one page of assessments.
+func (c *Client) ListAssessments(ctx context.Context, opts *AssessmentListOptions, reqOpts ...RequestOption) (*model.AssessmentListResponse, error) {
+	var list model.AssessmentListResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: "/api/v1/assessments", query: opts.values()}, &list, reqOpts); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (o *AssessmentListOptions) values() url.Values {
+	if o == nil {
+		return (*ListOptions)(nil).values()
+	}
+	q := o.ListOptions.values()
+	if o.OrganizationID != nil {
+		q.Set("organization_id", o.OrganizationID.String())
+	}
+	if o.Status != "" {
+		q.Set("status", o.Status)
+	}
+	return q
+}
+
+// Assessments iterates over the assessments opts selects.
+func (c *Client) Assessments(ctx context.Context, opts *AssessmentListOptions) iter.Seq2[model.AssessmentResponse, error] {
+	var filter AssessmentListOptions
+	if opts != nil {
+		filter = *opts
+	}
+	return listAll(ctx, filter.ListOptions, func(ctx context.Context, page *ListOptions) ([]model.AssessmentResponse, *string, error) {
+		filter.ListOptions = *page
+		list, err := c.ListAssessments(ctx, &filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		return list.Assessments, list.NextCursor, nil
+	})
+}
+
+// UpdateAssessment changes the fields set in req of a DRAFT assessment.
+// Use IfMatch to guard against concurrent updates.
+func (c *Client) UpdateAssessment(ctx context.Context, id uuid.UUID, req *model.UpdateAssessmentRequest, opts ...RequestOption) (*model.AssessmentResponse, error) {
+	var a model.AssessmentResponse
+	if err := c.do(ctx, &request{method: http.MethodPatch, path: pathID("/api/v1/assessments/%s", id), body: req}, &a, opts); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// DeleteAssessment soft-deletes an assessment with its findings;
+// RestoreAssessment undoes it within the restore window.
+func (c *Client) DeleteAssessment(ctx context.Context, id uuid.UUID, opts ...RequestOption) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: pathID("/api/v1/assessments/%s", id)}, nil, opts)
+}
+
+func (c *Client) RestoreAssessment(ctx context.Context, id uuid.UUID, opts ...RequestOption) (*model.AssessmentResponse, error) {
+	var a model.AssessmentResponse
+	if err := c.do(ctx, &request{method: http.MethodPost, path: pathID("/api/v1/assessments/%s/restore", id)}, &a, opts); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// RunAssessment runs an assessment's analyzers over its target assets and
+// returns it once the run has finished.
+func (c *Client) RunAssessment(ctx context.Context, id uuid.UUID, opts ...RequestOption) (*model.AssessmentResponse, error) {
+	var a model.AssessmentResponse
+	err := c.do(ctx, &request{method: http.MethodPost, path: pathID("/api/v1/assessments/%s/run", id), idempotent: true}, &a, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ImportScanBundle stores the bundle of an offline scan as a completed
+// assessment of bundle.OrganizationID and returns it with its findings
+// summary.
+func (c *Client) ImportScanBundle(ctx context.Context, bundle *model.ScanBundle, opts ...RequestOption) (*model.AssessmentResponse, error) {
+	var a model.AssessmentResponse
+	err := c.do(ctx, &request{method: http.MethodPost, path: "/api/v1/assessments/import", body: bundle, idempotent: true}, &a, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ListRuns returns an assessment's runs, most recent first.
+func (c *Client) ListRuns(ctx context.Context, id uuid.UUID, opts ...RequestOption) ([]model.AssessmentRunResponse, error) {
+	var list model.AssessmentRunListResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: pathID("/api/v1/assessments/%s/runs", id)}, &list, opts); err != nil {
+		return nil, err
+	}
+	return list.Runs, nil
+}
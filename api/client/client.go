@@ -0,0 +1,314 @@
+// Package client is the Go client for the QRAP API.
+//
+// Requests and responses use the types in the model package. A Client
+// authenticates every request with a Bearer token or an API key, retries
+// requests refused by the rate limiter once Retry-After has passed, and
+// follows cursors to iterate over whole listings:
+//
+//	c, err := client.New(client.Config{
+//		BaseURL: "https://qrap.example.com",
+//		Auth:    client.APIKey(os.Getenv("QRAP_API_KEY")),
+//	})
+//	...
+//	for f, err := range c.Findings(ctx, &client.FindingListOptions{RiskLevels: []string{"CRITICAL"}}) {
+//		...
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultMaxRetries is the number of times a request is retried by
+	// default.
+	DefaultMaxRetries = 3
+	// DefaultMaxRetryWait is the longest a request waits before a retry by
+	// default. A longer Retry-After fails the request instead.
+	DefaultMaxRetryWait = 30 * time.Second
+
+	// retryBackoff is the first wait before retrying a failure that came
+	// without a Retry-After; it doubles on each attempt.
+	retryBackoff = 500 * time.Millisecond
+)
+
+// Auth sets the credentials of a request.
+type Auth func(r *http.Request)
+
+// BearerToken authenticates with a JWT.
+func BearerToken(token string) Auth {
+	return func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// APIKey authenticates with an API key.
+func APIKey(key string) Auth {
+	return func(r *http.Request) {
+		r.Header.Set("Authorization", "ApiKey "+key)
+	}
+}
+
+// Config configures a Client.
+type Config struct {
+	// BaseURL is the server's address, without the /api/v1 prefix.
+	BaseURL string
+	// Auth sets each request's credentials. Nil sends none, which only
+	// works against servers with authentication disabled (or mutual TLS
+	// configured on HTTPClient).
+	Auth Auth
+	// HTTPClient sends the requests; nil uses http.DefaultClient.
+	HTTPClient *http.Client
+	// UserAgent is sent with every request; empty uses "qrap-go-client".
+	UserAgent string
+	// MaxRetries is how often a failed request is retried: 0 uses
+	// DefaultMaxRetries, a negative value disables retries.
+	MaxRetries int
+	// MaxRetryWait caps the wait before a retry; 0 uses
+	// DefaultMaxRetryWait.
+	MaxRetryWait time.Duration
+}
+
+// Client calls the QRAP API. It is safe for concurrent use.
+type Client struct {
+	baseURL      *url.URL
+	auth         Auth
+	http         *http.Client
+	userAgent    string
+	maxRetries   int
+	maxRetryWait time.Duration
+}
+
+// New returns a Client for the server at cfg.BaseURL.
+func New(cfg Config) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(cfg.BaseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", cfg.BaseURL)
+	}
+	c := &Client{
+		baseURL:      base,
+		auth:         cfg.Auth,
+		http:         cfg.HTTPClient,
+		userAgent:    cfg.UserAgent,
+		maxRetries:   cfg.MaxRetries,
+		maxRetryWait: cfg.MaxRetryWait,
+	}
+	if c.http == nil {
+		c.http = http.DefaultClient
+	}
+	if c.userAgent == "" {
+		c.userAgent = "qrap-go-client"
+	}
+	if c.maxRetries == 0 {
+		c.maxRetries = DefaultMaxRetries
+	}
+	if c.maxRetryWait <= 0 {
+		c.maxRetryWait = DefaultMaxRetryWait
+	}
+	return c, nil
+}
+
+// RequestOption adjusts a single call.
+type RequestOption func(*request)
+
+// IfMatch makes an update or delete conditional on the resource still
+// having etag, as returned in the ETag header (see ResponseHeader).
+func IfMatch(etag string) RequestOption {
+	return func(r *request) { r.header.Set("If-Match", etag) }
+}
+
+// IdempotencyKey sets the Idempotency-Key of a create or run, replacing the
+// one the client generates. Reuse a key to safely repeat a request across
+// process restarts.
+func IdempotencyKey(key string) RequestOption {
+	return func(r *request) { r.header.Set("Idempotency-Key", key) }
+}
+
+// ResponseHeader stores the response headers of the call in h, for
+// example to read the ETag of a resource.
+func ResponseHeader(h *http.Header) RequestOption {
+	return func(r *request) { r.respHeader = h }
+}
+
+// request is one API call.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+	header http.Header
+	// idempotent is set for POST endpoints that honour Idempotency-Key; the
+	// client sends a key so the request can be retried.
+	idempotent bool
+	respHeader *http.Header
+}
+
+// do sends req, retrying as allowed, and decodes the response body into
+// out unless it is nil. Error responses are returned as *Error.
+func (c *Client) do(ctx context.Context, req *request, out interface{}, opts []RequestOption) error {
+	if req.header == nil {
+		req.header = http.Header{}
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	if req.idempotent && req.header.Get("Idempotency-Key") == "" {
+		req.header.Set("Idempotency-Key", uuid.NewString())
+	}
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, req, body)
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil || !c.replayable(req) {
+				return err
+			}
+			wait = backoff(attempt)
+		case resp.StatusCode >= 400:
+			apiErr := readError(resp)
+			if !c.retryable(req, apiErr) {
+				return apiErr
+			}
+			wait = apiErr.RetryAfter
+			if wait == 0 {
+				wait = backoff(attempt)
+			}
+			err = apiErr
+		default:
+			defer resp.Body.Close()
+			if req.respHeader != nil {
+				*req.respHeader = resp.Header
+			}
+			if out == nil || resp.StatusCode == http.StatusNoContent {
+				return nil
+			}
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return fmt.Errorf("failed to decode %s %s response: %w", req.method, req.path, err)
+			}
+			return nil
+		}
+
+		if attempt >= c.maxRetries || wait > c.maxRetryWait {
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// send makes one attempt at req.
+func (c *Client) send(ctx context.Context, req *request, body []byte) (*http.Response, error) {
+	// req.path is already escaped (see pathID).
+	u, err := url.Parse(c.baseURL.String() + req.path)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = req.query.Encode()
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range req.header {
+		httpReq.Header[name] = values
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.auth != nil {
+		c.auth(httpReq)
+	}
+	return c.http.Do(httpReq)
+}
+
+// replayable reports whether req may be sent again after an attempt whose
+// outcome is unknown: it either has no side effects beyond the first
+// attempt or carries an Idempotency-Key.
+func (c *Client) replayable(req *request) bool {
+	switch req.method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.header.Get("Idempotency-Key") != ""
+}
+
+// retryable reports whether req should be retried after failing with err.
+// Rate-limited requests never reached the handler and are always retried;
+// a 409 with Retry-After means an earlier attempt with the same
+// Idempotency-Key is still running.
+func (c *Client) retryable(req *request, err *Error) bool {
+	switch err.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusConflict:
+		return err.RetryAfter > 0 && req.header.Get("Idempotency-Key") != ""
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return c.replayable(req)
+	}
+	return false
+}
+
+// backoff returns the wait before retry attempt+1 of a failure without a
+// Retry-After: exponential with full jitter.
+func backoff(attempt int) time.Duration {
+	d := retryBackoff << min(attempt, 6)
+	return d/2 + rand.N(d/2+1)
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or as an HTTP
+// date; it returns 0 if the header is absent or invalid.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// pathID formats an escaped path from format and ids, each escaped as a
+// path segment.
+func pathID(format string, ids ...interface{}) string {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = url.PathEscape(fmt.Sprint(id))
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,420 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/quantun-opensource/qrap/api/internal/analyzer"
+	"github.com/quantun-opensource/qrap/api/internal/handler"
+	"github.com/quantun-opensource/qrap/api/internal/repository"
+	"github.com/quantun-opensource/qrap/api/internal/service"
+	"github.com/quantun-opensource/qrap/api/internal/tenant"
+	"github.com/quantun-opensource/qrap/api/model"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+const (
+	testJWTSecret = "client-test-secret"
+	testAPIKey    = "qrap_test_admin_key"
+)
+
+// testServer serves the API wired as cmd/server wires it: API key and JWT
+// authentication, tenant scoping, a per-subject rate limit of limit requests
+// per window, and Idempotency-Key replay. Without a pool the resource
+// handlers have no services, so only /health, /me and /openapi.json work.
+// requests counts the requests the server receives.
+func testServer(t *testing.T, pool *pgxpool.Pool, limit int, window time.Duration) (srv *httptest.Server, requests *atomic.Int32) {
+	t.Helper()
+	logger := zap.NewNop()
+	cursors := qmw.NewCursorCodec([]byte("test-cursor-secret"))
+	var (
+		orgSvc        *service.OrganizationService
+		assessmentSvc *service.AssessmentService
+		findingSvc    *service.FindingService
+		scope         = tenant.Unscoped
+	)
+	if pool != nil {
+		orgRepo := repository.NewOrganizationRepository(pool)
+		assessmentRepo := repository.NewAssessmentRepository(pool)
+		findingRepo := repository.NewFindingRepository(pool)
+		members := repository.NewMembershipRepository(pool)
+		analyzers := analyzer.NewRegistry(time.Second)
+		analyzers.SetFallback(analyzer.NewBaselineAnalyzer(), 0)
+		orgSvc = service.NewOrganizationService(orgRepo, members, time.Hour, logger)
+		assessmentSvc = service.NewAssessmentService(assessmentRepo, findingRepo, repository.NewRunRepository(pool), analyzers, time.Hour, logger)
+		findingSvc = service.NewFindingService(findingRepo, assessmentRepo, logger)
+		scope = tenant.Middleware(members, logger)
+	}
+	rateLimiter := qmw.NewRateLimiter(qmw.RateLimitConfig{
+		Name:              "subject",
+		RequestsPerWindow: limit,
+		Window:            window,
+		KeyFunc:           qmw.SubjectKey,
+	})
+	t.Cleanup(rateLimiter.Stop)
+
+	r := chi.NewRouter()
+	r.Use(chimw.RequestID)
+	r.Use(qmw.RequestID(chimw.GetReqID))
+	r.Get("/health", handler.NewHealthHandler().Check)
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(qmw.Auth(qmw.AuthConfig{
+			JWTSecret: testJWTSecret,
+			APIKeys:   []qmw.APIKeyEntry{{Key: testAPIKey, Subject: "ci-bot", Role: "admin"}},
+			SkipPaths: []string{"/api/v1/openapi.json"},
+			Logger:    logger,
+		}))
+		r.Use(scope)
+		r.Use(rateLimiter.Middleware())
+		r.Use(qmw.Idempotency(qmw.IdempotencyConfig{
+			Store: qmw.NewMemoryIdempotencyStore(),
+			Paths: []string{"/api/v1/organizations", "/api/v1/assessments", "/api/v1/assessments/import", "/api/v1/assessments/{id}/run"},
+		}))
+		r.Get("/openapi.json", handler.NewOpenAPIHandler().Spec)
+		r.Get("/me", handler.NewMeHandler().Get)
+		r.Mount("/organizations", handler.NewOrganizationHandler(orgSvc, cursors, logger).Routes())
+		r.Mount("/assessments", handler.NewAssessmentHandler(assessmentSvc, cursors, logger).Routes())
+		r.Mount("/findings", handler.NewFindingHandler(findingSvc, cursors, logger).Routes())
+	})
+
+	requests = &atomic.Int32{}
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		r.ServeHTTP(w, req)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+func newTestClient(t *testing.T, cfg Config) *Client {
+	t.Helper()
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	for _, base := range []string{"", "qrap.example.com", "ftp://qrap.example.com", "http://[::1"} {
+		if _, err := New(Config{BaseURL: base}); err == nil {
+			t.Errorf("New(%q): expected an error", base)
+		}
+	}
+}
+
+func TestClient_Auth(t *testing.T) {
+	srv, _ := testServer(t, nil, 100, time.Minute)
+	ctx := context.Background()
+
+	health, err := newTestClient(t, Config{BaseURL: srv.URL}).Health(ctx)
+	if err != nil || health.Status != "ok" {
+		t.Fatalf("Health = %+v, %v", health, err)
+	}
+
+	me, err := newTestClient(t, Config{BaseURL: srv.URL, Auth: APIKey(testAPIKey)}).Me(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if me.Subject != "ci-bot" || me.Role != "admin" || me.AuthMethod != "api_key" || len(me.Permissions) == 0 {
+		t.Errorf("api key: me = %+v", me)
+	}
+
+	token, err := qmw.CreateJWT(testJWTSecret, "alice", "viewer", "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	me, err = newTestClient(t, Config{BaseURL: srv.URL + "/", Auth: BearerToken(token)}).Me(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if me.Subject != "alice" || me.Role != "viewer" || me.AuthMethod != "jwt" {
+		t.Errorf("bearer: me = %+v", me)
+	}
+
+	doc, err := newTestClient(t, Config{BaseURL: srv.URL}).OpenAPI(ctx)
+	if err != nil || !strings.Contains(string(doc), `"openapi"`) {
+		t.Errorf("OpenAPI = %.40s, %v", doc, err)
+	}
+
+	_, err = newTestClient(t, Config{BaseURL: srv.URL, Auth: APIKey("wrong")}).Me(ctx)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Detail == "" || apiErr.RequestID == "" {
+		t.Fatalf("wrong key: err = %#v", err)
+	}
+	if !strings.Contains(err.Error(), "401") || IsNotFound(err) || StatusCode(err) != http.StatusUnauthorized {
+		t.Errorf("wrong key: %v", err)
+	}
+}
+
+// TestClient_RetryAfter checks that a rate-limited request waits out the
+// server's Retry-After and is retried, unless the wait is too long.
+func TestClient_RetryAfter(t *testing.T) {
+	srv, requests := testServer(t, nil, 1, time.Second)
+	ctx := context.Background()
+	c := newTestClient(t, Config{BaseURL: srv.URL, Auth: APIKey(testAPIKey)})
+
+	if _, err := c.Me(ctx); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := c.Me(ctx); err != nil {
+		t.Fatalf("rate-limited request was not retried: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Errorf("retried after %v, before Retry-After", elapsed)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("server saw %d requests, want 3", n)
+	}
+
+	impatient := newTestClient(t, Config{BaseURL: srv.URL, Auth: APIKey(testAPIKey), MaxRetryWait: 100 * time.Millisecond})
+	_, err := impatient.Me(ctx)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.RetryAfter != time.Second {
+		t.Fatalf("expected a 429 with Retry-After, got %#v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := c.Me(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled context: err = %v", err)
+	}
+}
+
+// TestClient_RetryIdempotencyKey checks that creates are retried after a
+// server error with the same Idempotency-Key, and requests without one are
+// not retried.
+func TestClient_RetryIdempotencyKey(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		first := len(keys) == 1
+		mu.Unlock()
+		if first {
+			qmw.WriteProblem(w, http.StatusServiceUnavailable, qmw.NewProblem(r, http.StatusServiceUnavailable, "idempotency check unavailable"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"00000000-0000-0000-0000-000000000001","name":"Acme","key":"qrap_secret"}`))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	c := newTestClient(t, Config{BaseURL: srv.URL})
+
+	org, err := c.CreateOrganization(ctx, &model.CreateOrganizationRequest{Name: "Acme"})
+	if err != nil || org.Name != "Acme" {
+		t.Fatalf("CreateOrganization = %+v, %v", org, err)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("Idempotency-Keys = %q, want the same key twice", keys)
+	}
+
+	keys = nil
+	_, err = c.CreateAPIKey(ctx, &model.CreateAPIKeyRequest{Name: "ci"})
+	if StatusCode(err) != http.StatusServiceUnavailable || len(keys) != 1 || keys[0] != "" {
+		t.Errorf("CreateAPIKey: err = %v after %d requests with keys %q", err, len(keys), keys)
+	}
+}
+
+// testPool connects to QRAP_TEST_DATABASE_URL and applies the migrations in a
+// throwaway schema that is dropped when the test ends.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("QRAP_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("QRAP_TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	schema := "qrap_test_" + strings.ReplaceAll(uuid.NewString()[:8], "-", "")
+
+	admin, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+	if _, err := admin.Exec(ctx, `CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema + ",public"
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		pool.Close()
+		cleanup, err := pgxpool.New(context.Background(), url)
+		if err == nil {
+			cleanup.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+			cleanup.Close()
+		}
+	})
+
+	files, _ := filepath.Glob("../../db/migrations/*.up.sql")
+	sort.Strings(files)
+	for _, f := range files {
+		sql, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			t.Fatalf("%s: %v", filepath.Base(f), err)
+		}
+	}
+	return pool
+}
+
+// TestClient_Workflow drives an assessment from creation to triage through
+// the client.
+func TestClient_Workflow(t *testing.T) {
+	srv, _ := testServer(t, testPool(t), 1000, time.Minute)
+	ctx := context.Background()
+	c := newTestClient(t, Config{BaseURL: srv.URL, Auth: APIKey(testAPIKey)})
+
+	key := uuid.NewString()
+	org, err := c.CreateOrganization(ctx, &model.CreateOrganizationRequest{Name: "Acme"}, IdempotencyKey(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := c.CreateOrganization(ctx, &model.CreateOrganizationRequest{Name: "Acme"}, IdempotencyKey(key))
+	if err != nil || again.ID != org.ID {
+		t.Fatalf("replayed create = %+v, %v; want org %s", again, err, org.ID)
+	}
+	if _, err := c.AddMember(ctx, org.ID, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	members, err := c.ListMembers(ctx, org.ID)
+	if err != nil || len(members) != 2 {
+		t.Fatalf("members = %+v, %v", members, err)
+	}
+	if err := c.RemoveMember(ctx, org.ID, "alice"); err != nil {
+		t.Errorf("RemoveMember: %v", err)
+	}
+
+	var header http.Header
+	created, err := c.CreateAssessment(ctx, &model.CreateAssessmentRequest{
+		Name:           "Quarterly",
+		OrganizationID: org.ID.String(),
+		TargetAssets:   []string{"tls://a.example", "tls://b.example", "ssh://c.example"},
+	}, ResponseHeader(&header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := header.Get("ETag")
+	if _, err := c.UpdateAssessment(ctx, created.ID, &model.UpdateAssessmentRequest{Name: ptr("Q3")}, IfMatch(etag)); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.UpdateAssessment(ctx, created.ID, &model.UpdateAssessmentRequest{Name: ptr("Q4")}, IfMatch(etag))
+	if StatusCode(err) != http.StatusPreconditionFailed {
+		t.Errorf("stale If-Match: err = %v", err)
+	}
+	_, err = c.UpdateAssessment(ctx, created.ID, &model.UpdateAssessmentRequest{})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || len(apiErr.Errors) == 0 {
+		t.Errorf("empty update: err = %#v", err)
+	}
+
+	run, err := c.RunAssessment(ctx, created.ID)
+	if err != nil || run.Status != "COMPLETED" {
+		t.Fatalf("run = %+v, %v", run, err)
+	}
+	got, err := c.GetAssessment(ctx, created.ID)
+	if err != nil || got.Summary == nil || got.Name != "Q3" {
+		t.Fatalf("assessment = %+v, %v", got, err)
+	}
+	runs, err := c.ListRuns(ctx, created.ID)
+	if err != nil || len(runs) != 1 {
+		t.Errorf("runs = %+v, %v", runs, err)
+	}
+
+	// Iterating page by page sees every finding once.
+	opts := &FindingListOptions{ListOptions: ListOptions{Limit: 1}, AssessmentIDs: []uuid.UUID{created.ID}}
+	seen := map[uuid.UUID]bool{}
+	for f, err := range c.Findings(ctx, opts) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[f.ID] {
+			t.Errorf("finding %s seen twice", f.ID)
+		}
+		seen[f.ID] = true
+	}
+	if len(seen) != got.Summary.TotalFindings || len(seen) < 2 {
+		t.Errorf("iterated %d findings, summary has %d", len(seen), got.Summary.TotalFindings)
+	}
+	page, err := c.ListFindings(ctx, &FindingListOptions{ListOptions: ListOptions{Count: true}, AssessmentIDs: []uuid.UUID{created.ID}})
+	if err != nil || page.TotalCount == nil || *page.TotalCount != len(seen) {
+		t.Errorf("ListFindings total = %v, %v", page.TotalCount, err)
+	}
+	for id := range seen {
+		f, err := c.UpdateFindingStatus(ctx, id, "ACKNOWLEDGED")
+		if err != nil || f.Status != "ACKNOWLEDGED" {
+			t.Errorf("UpdateFindingStatus = %+v, %v", f, err)
+		}
+		break
+	}
+
+	var assessments int
+	for a, err := range c.Assessments(ctx, &AssessmentListOptions{OrganizationID: &org.ID, Status: "COMPLETED"}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.ID != created.ID {
+			t.Errorf("unexpected assessment %s", a.ID)
+		}
+		assessments++
+	}
+	if assessments != 1 {
+		t.Errorf("iterated %d assessments, want 1", assessments)
+	}
+
+	if err := c.DeleteOrganization(ctx, org.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetOrganization(ctx, org.ID); !IsNotFound(err) {
+		t.Errorf("deleted org: err = %v", err)
+	}
+	for o, err := range c.Organizations(ctx, nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Errorf("deleted org %s still listed", o.ID)
+	}
+	if _, err := c.RestoreOrganization(ctx, org.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetOrganization(ctx, org.ID); err != nil {
+		t.Errorf("restored org: %v", err)
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
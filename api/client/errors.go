@@ -0,0 +1,81 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxErrorBody bounds how much of an error response is read.
+const maxErrorBody = 64 << 10
+
+// FieldError is one problem with a request that failed validation.
+type FieldError struct {
+	// Field is the JSON path of the offending field, such as
+	// "target_assets[2]"; it is empty for problems with the whole request.
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error is an error response from the API: an RFC 7807 problem.
+type Error struct {
+	StatusCode int          `json:"status"`
+	Type       string       `json:"type"`
+	Title      string       `json:"title"`
+	Detail     string       `json:"detail"`
+	Instance   string       `json:"instance"`
+	RequestID  string       `json:"request_id"`
+	Errors     []FieldError `json:"errors"`
+	// RetryAfter is the wait the server asked for before a retry, if any.
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *Error) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Title
+	}
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "qrap: %d %s", e.StatusCode, msg)
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " (request %s)", e.RequestID)
+	}
+	return b.String()
+}
+
+// StatusCode returns the HTTP status of err if it is, or wraps, an *Error,
+// and 0 otherwise.
+func StatusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether err is a 404 from the API.
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// readError reads and closes an error response. Bodies that are not
+// problem details leave only the status set.
+func readError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+	apiErr := &Error{}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	json.Unmarshal(body, apiErr)
+	apiErr.StatusCode = resp.StatusCode
+	apiErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+	if apiErr.Detail == "" && apiErr.Title == "" {
+		apiErr.Detail = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
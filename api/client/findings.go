@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// FindingListOptions filters, sorts and pages the findings listing. Zero
+// fields do not filter; values within a field are alternatives.
+type FindingListOptions struct {
+	ListOptions
+	AssessmentIDs   []uuid.UUID
+	OrganizationIDs []uuid.UUID
+	// RiskLevels are values of model.RiskLevels.
+	RiskLevels []string
+	// Categories are values of model.FindingCategories.
+	Categories []string
+	// Statuses are values of model.FindingStatuses.
+	Statuses []string
+	// Algorithms match the current algorithm, case-insensitively.
+	Algorithms []string
+	// Asset matches the affected asset by prefix, or as a glob if it
+	// contains '*' or '?'.
+	Asset            string
+	DiscoveredAfter  *time.Time
+	DiscoveredBefore *time.Time
+	// Query is a full-text search in web search syntax.
+	Query string
+	// Sort is one of model.FindingSorts; empty sorts by risk level.
+	Sort string
+}
+
+func (o *FindingListOptions) values() url.Values {
+	if o == nil {
+		return (*ListOptions)(nil).values()
+	}
+	q := o.ListOptions.values()
+	setList(q, "assessment_id", uuidStrings(o.AssessmentIDs))
+	setList(q, "organization_id", uuidStrings(o.OrganizationIDs))
+	setList(q, "risk_level", o.RiskLevels)
+	setList(q, "category", o.Categories)
+	setList(q, "status", o.Statuses)
+	setList(q, "algorithm", o.Algorithms)
+	if o.Asset != "" {
+		q.Set("asset", o.Asset)
+	}
+	if o.DiscoveredAfter != nil {
+		q.Set("discovered_after", o.DiscoveredAfter.Format(time.RFC3339))
+	}
+	if o.DiscoveredBefore != nil {
+		q.Set("discovered_before", o.DiscoveredBefore.Format(time.RFC3339))
+	}
+	if o.Query != "" {
+		q.Set("q", o.Query)
+	}
+	if o.Sort != "" {
+		q.Set("sort", o.Sort)
+	}
+	return q
+}
+
+// setList sets a multi-value filter as comma-separated values.
+func setList(q url.Values, key string, values []string) {
+	if len(values) > 0 {
+		q.Set(key, strings.Join(values, ","))
+	}
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = id.String()
+	}
+	return s
+}
+
+func (c *Client) GetFinding(ctx context.Context, id uuid.UUID, opts ...RequestOption) (*model.FindingResponse, error) {
+	var f model.FindingResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: pathID("/api/v1/findings/%s", id)}, &f, opts); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// ListFindings returns one page of findings.
+func (c *Client) ListFindings(ctx context.Context, opts *FindingListOptions, reqOpts ...RequestOption) (*model.FindingListResponse, error) {
+	var list model.FindingListResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: "/api/v1/findings", query: opts.values()}, &list, reqOpts); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Findings iterates over the findings opts selects, in its sort order.
+func (c *Client) Findings(ctx context.Context, opts *FindingListOptions) iter.Seq2[model.FindingResponse, error] {
+	var filter FindingListOptions
+	if opts != nil {
+		filter = *opts
+	}
+	return listAll(ctx, filter.ListOptions, func(ctx context.Context, page *ListOptions) ([]model.FindingResponse, *string, error) {
+		filter.ListOptions = *page
+		list, err := c.ListFindings(ctx, &filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		return list.Findings, list.NextCursor, nil
+	})
+}
+
+// UpdateFindingStatus sets a finding's triage status, one of
+// model.FindingStatuses.
+func (c *Client) UpdateFindingStatus(ctx context.Context, id uuid.UUID, status string, opts ...RequestOption) (*model.FindingResponse, error) {
+	var f model.FindingResponse
+	req := &request{
+		method: http.MethodPatch,
+		path:   pathID("/api/v1/findings/%s", id),
+		body:   &model.UpdateFindingStatusRequest{Status: status},
+	}
+	if err := c.do(ctx, req, &f, opts); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
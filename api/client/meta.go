@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// Health checks that the server is up. It needs no credentials.
+func (c *Client) Health(ctx context.Context, opts ...RequestOption) (*model.HealthResponse, error) {
+	var h model.HealthResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: "/health"}, &h, opts); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// Me returns the caller's identity, role and permissions.
+func (c *Client) Me(ctx context.Context, opts ...RequestOption) (*model.MeResponse, error) {
+	var me model.MeResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: "/api/v1/me"}, &me, opts); err != nil {
+		return nil, err
+	}
+	return &me, nil
+}
+
+// OpenAPI returns the server's OpenAPI 3.1 document.
+func (c *Client) OpenAPI(ctx context.Context, opts ...RequestOption) (json.RawMessage, error) {
+	var doc json.RawMessage
+	if err := c.do(ctx, &request{method: http.MethodGet, path: "/api/v1/openapi.json"}, &doc, opts); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// CreateOrganization creates an organization; the caller becomes its
+// first member.
+func (c *Client) CreateOrganization(ctx context.Context, req *model.CreateOrganizationRequest, opts ...RequestOption) (*model.OrganizationResponse, error) {
+	var org model.OrganizationResponse
+	err := c.do(ctx, &request{method: http.MethodPost, path: "/api/v1/organizations", body: req, idempotent: true}, &org, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (c *Client) GetOrganization(ctx context.Context, id uuid.UUID, opts ...RequestOption) (*model.OrganizationResponse, error) {
+	var org model.OrganizationResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: pathID("/api/v1/organizations/%s", id)}, &org, opts); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// ListOrganizations returns one page of the caller's organizations.
+func (c *Client) ListOrganizations(ctx context.Context, opts *ListOptions, reqOpts ...RequestOption) (*model.OrganizationListResponse, error) {
+	var list model.OrganizationListResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: "/api/v1/organizations", query: opts.values()}, &list, reqOpts); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Organizations iterates over the caller's organizations, starting at the
+// page opts selects.
+func (c *Client) Organizations(ctx context.Context, opts *ListOptions) iter.Seq2[model.OrganizationResponse, error] {
+	var start ListOptions
+	if opts != nil {
+		start = *opts
+	}
+	return listAll(ctx, start, func(ctx context.Context, opts *ListOptions) ([]model.OrganizationResponse, *string, error) {
+		list, err := c.ListOrganizations(ctx, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		return list.Organizations, list.NextCursor, nil
+	})
+}
+
+// UpdateOrganization changes the fields set in req. Use IfMatch to guard
+// against concurrent updates.
+func (c *Client) UpdateOrganization(ctx context.Context, id uuid.UUID, req *model.UpdateOrganizationRequest, opts ...RequestOption) (*model.OrganizationResponse, error) {
+	var org model.OrganizationResponse
+	if err := c.do(ctx, &request{method: http.MethodPatch, path: pathID("/api/v1/organizations/%s", id), body: req}, &org, opts); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// DeleteOrganization soft-deletes an organization with its assessments
+// and findings; RestoreOrganization undoes it within the restore window.
+func (c *Client) DeleteOrganization(ctx context.Context, id uuid.UUID, opts ...RequestOption) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: pathID("/api/v1/organizations/%s", id)}, nil, opts)
+}
+
+func (c *Client) RestoreOrganization(ctx context.Context, id uuid.UUID, opts ...RequestOption) (*model.OrganizationResponse, error) {
+	var org model.OrganizationResponse
+	if err := c.do(ctx, &request{method: http.MethodPost, path: pathID("/api/v1/organizations/%s/restore", id)}, &org, opts); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (c *Client) ListMembers(ctx context.Context, orgID uuid.UUID, opts ...RequestOption) ([]model.MembershipResponse, error) {
+	var list model.MembershipListResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: pathID("/api/v1/organizations/%s/members", orgID)}, &list, opts); err != nil {
+		return nil, err
+	}
+	return list.Members, nil
+}
+
+// AddMember gives subject access to an organization's data.
+func (c *Client) AddMember(ctx context.Context, orgID uuid.UUID, subject string, opts ...RequestOption) (*model.MembershipResponse, error) {
+	var m model.MembershipResponse
+	req := &request{
+		method:     http.MethodPost,
+		path:       pathID("/api/v1/organizations/%s/members", orgID),
+		body:       &model.AddMemberRequest{Subject: subject},
+		idempotent: true,
+	}
+	if err := c.do(ctx, req, &m, opts); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (c *Client) RemoveMember(ctx context.Context, orgID uuid.UUID, subject string, opts ...RequestOption) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: pathID("/api/v1/organizations/%s/members/%s", orgID, subject)}, nil, opts)
+}
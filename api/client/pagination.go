@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"strconv"
+)
+
+// ListOptions pages a listing. The zero value asks for the server's first
+// page at its default size.
+type ListOptions struct {
+	// Limit is the page size; 0 uses the server default (20), and the
+	// server caps it at 100.
+	Limit int
+	// Offset skips items. It is ignored when Cursor is set.
+	Offset int
+	// Cursor is a next_cursor or prev_cursor from an earlier page of the
+	// same listing.
+	Cursor string
+	// Count asks for total_count. It is off by default: counting costs an
+	// extra query.
+	Count bool
+}
+
+func (o *ListOptions) values() url.Values {
+	q := url.Values{}
+	if o == nil {
+		q.Set("count", "false")
+		return q
+	}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		q.Set("offset", strconv.Itoa(o.Offset))
+	}
+	if o.Cursor != "" {
+		q.Set("cursor", o.Cursor)
+	}
+	q.Set("count", strconv.FormatBool(o.Count))
+	return q
+}
+
+// listAll iterates over the items of a listing from the page that opts
+// selects onwards, fetching each following page by its cursor. fetch
+// returns a page's items and its next cursor. Iteration stops at the first
+// error, which is yielded with a zero item.
+func listAll[T any](ctx context.Context, opts ListOptions, fetch func(ctx context.Context, opts *ListOptions) ([]T, *string, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		opts.Count = false
+		for {
+			items, next, err := fetch(ctx, &opts)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if next == nil || len(items) == 0 {
+				return
+			}
+			opts.Cursor, opts.Offset = *next, 0
+		}
+	}
+}
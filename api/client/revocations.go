@@ -0,0 +1,18 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// Revoke revokes a token by jti or every token of a subject issued before
+// a cutoff.
+func (c *Client) Revoke(ctx context.Context, req *model.RevokeRequest, opts ...RequestOption) (*model.RevocationResponse, error) {
+	var rev model.RevocationResponse
+	if err := c.do(ctx, &request{method: http.MethodPost, path: "/api/v1/revocations", body: req}, &rev, opts); err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
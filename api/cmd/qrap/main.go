@@ -0,0 +1,117 @@
+// Command qrap runs QRAP assessments without a server or database.
+//
+//	qrap scan --zone-dir /zones tls://api.example.com:443 dnssec+file:///zones/example.com.zone
+//	qrap scan --targets-file targets.txt --format sarif --out qrap.sarif --fail-on HIGH
+//
+// scan analyzes the targets with the same analyzers and risk calculation as
+// the server, entirely in memory. Its JSON output is a scan bundle that
+// qrapctl import (POST /api/v1/assessments/import) stores on a server.
+//
+// Exit status is 0 on success, 1 on errors, 2 on usage errors and 3 when
+// --fail-on finds findings at or above the given risk level or, with
+// --fail-on-error (implied by --fail-on), an analyzer failed.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Exit statuses, as for qrapctl.
+const (
+	exitOK     = 0
+	exitError  = 1
+	exitUsage  = 2
+	exitPolicy = 3
+)
+
+// usageError is a mistake in the command line; it exits with exitUsage.
+type usageError struct{ msg string }
+
+func (e *usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// policyError reports findings that fail a --fail-on policy; it exits with
+// exitPolicy.
+type policyError struct{ msg string }
+
+func (e *policyError) Error() string { return e.msg }
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run executes the command line args and returns the exit status.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		printUsage(stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+	if args[0] != "scan" {
+		fmt.Fprintf(stderr, "qrap: unknown command %q\n\n", args[0])
+		printUsage(stderr)
+		return exitUsage
+	}
+
+	err := scan(ctx, args[1:], stdin, stdout, stderr)
+	var usageErr *usageError
+	var policyErr *policyError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "qrap scan: %v\n", err)
+		return exitUsage
+	case errors.As(err, &policyErr):
+		fmt.Fprintf(stderr, "qrap scan: %v\n", err)
+		return exitPolicy
+	}
+	fmt.Fprintf(stderr, "qrap scan: %v\n", err)
+	return exitError
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, `Usage: qrap scan [flags] TARGET...
+
+Runs an assessment of the targets in memory, without a server or database,
+and writes the findings and a summary.
+
+Flags:
+  --targets-file PATH  also read targets from PATH, one per line ("-" for stdin)
+  --name NAME          assessment name (default "offline scan")
+  --org ID             organization to record in the bundle for import
+  --enable ANALYZER    only run these analyzers (repeatable)
+  --disable ANALYZER   never run these analyzers (repeatable)
+  --format FORMAT      json (an importable scan bundle), sarif, cbom or csv (default json)
+  --out PATH           write to PATH instead of standard output
+  --fail-on LEVEL      exit with status 3 on findings at or above LEVEL
+  --fail-on-error      exit with status 3 on analyzer errors (default true
+                       with --fail-on; --fail-on-error=false turns it off)
+  --timeout 30s        bound on each analyzer call
+  --workers 16         concurrent analyzer calls
+  --per-host 2         concurrent analyzer calls per host (0 = unlimited)
+  --rate 0             analyzer calls started per second (0 = unlimited)
+  --retries 2          retries of a failed analyzer call
+  --plugin-dir PATH    analyzer plugins (default $QRAP_PLUGIN_DIR)
+  --allow-network CIDR let analyzers connect to this loopback, private or
+                       link-local network (repeatable)
+  --jwks-dir PATH      directory of jwks+file:// documents (default $QRAP_JWKS_DOCUMENT_DIR)
+  --zone-dir PATH      directory of dnssec+file:// zone files (default $QRAP_DNSSEC_ZONE_DIR)
+  --verbose            log analyzer activity to standard error
+`)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/quantun-opensource/qrap/api/internal/service"
+	"github.com/quantun-opensource/qrap/api/internal/validate"
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// runCmd runs qrap with stdin and no plugins.
+func runCmd(t *testing.T, stdin string, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	t.Setenv("QRAP_PLUGIN_DIR", "")
+	var out, errOut bytes.Buffer
+	code = run(context.Background(), args, strings.NewReader(stdin), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestRun_Usage(t *testing.T) {
+	cases := [][]string{
+		{},
+		{"bogus"},
+		{"scan"},
+		{"scan", "tls://a example:443"},
+		{"scan", "tls://a.example:443", "--format", "pdf"},
+		{"scan", "tls://a.example:443", "--fail-on", "SEVERE"},
+		{"scan", "tls://a.example:443", "--org", "acme"},
+		{"scan", "tls://a.example:443", "--enable", "nonexistent"},
+		{"scan", "tls://a.example:443", "--allow-network", "intranet"},
+		{"scan", "--bogus"},
+	}
+	for _, args := range cases {
+		if code, _, _ := runCmd(t, "", args...); code != exitUsage {
+			t.Errorf("%v: exit %d, want %d", args, code, exitUsage)
+		}
+	}
+	if code, _, _ := runCmd(t, "", "scan", "--targets-file", filepath.Join(t.TempDir(), "missing")); code != exitError {
+		t.Errorf("missing targets file: exit %d, want %d", code, exitError)
+	}
+}
+
+func TestScan_Bundle(t *testing.T) {
+	org := uuid.NewString()
+	code, stdout, stderr := runCmd(t, "# targets\ntls://b.example:443\n\n",
+		"scan", "tls://a.example:443", "--targets-file", "-", "--name", "nightly", "--org", org)
+	if code != exitOK {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "scanned 2 target(s): 4 finding(s)") {
+		t.Errorf("stderr = %q", stderr)
+	}
+
+	var b model.ScanBundle
+	if err := json.Unmarshal([]byte(stdout), &b); err != nil {
+		t.Fatalf("bundle %q: %v", stdout, err)
+	}
+	if b.Version != model.ScanBundleVersion || b.OrganizationID != org || b.Name != "nightly" ||
+		len(b.TargetAssets) != 2 || len(b.Findings) != 4 || b.OverallRisk != "CRITICAL" || b.Summary == nil || b.Summary.TotalFindings != 4 {
+		t.Errorf("bundle = %+v", b)
+	}
+	// The bundle is what POST /api/v1/assessments/import accepts.
+	var v validate.Validator
+	v.Struct(&b)
+	service.CheckScanBundle(&v, &b)
+	if err := v.Err(); err != nil {
+		t.Errorf("bundle does not validate: %v", err)
+	}
+}
+
+func TestScan_FailOn(t *testing.T) {
+	cases := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"scan", "tls://a.example:443"}, exitOK},
+		{[]string{"scan", "tls://a.example:443", "--fail-on", "critical"}, exitPolicy},
+		// With no analyzer left the target is an analyzer error, which
+		// fails the gate unless --fail-on-error=false.
+		{[]string{"scan", "tls://a.example:443", "--disable", "baseline"}, exitOK},
+		{[]string{"scan", "tls://a.example:443", "--disable", "baseline", "--fail-on", "LOW"}, exitPolicy},
+		{[]string{"scan", "tls://a.example:443", "--disable", "baseline", "--fail-on", "LOW", "--fail-on-error=false"}, exitOK},
+		{[]string{"scan", "tls://a.example:443", "--disable", "baseline", "--fail-on-error"}, exitPolicy},
+	}
+	for _, tc := range cases {
+		if code, _, stderr := runCmd(t, "", tc.args...); code != tc.want {
+			t.Errorf("%v: exit %d, want %d: %s", tc.args, code, tc.want, stderr)
+		}
+	}
+}
+
+func TestScan_Report(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "qrap.sarif")
+	code, stdout, stderr := runCmd(t, "", "scan", "tls://a.example:443", "--format", "sarif", "--out", out)
+	if code != exitOK || stdout != "" {
+		t.Fatalf("exit %d, stdout %q: %s", code, stdout, stderr)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string
+		Runs    []struct{ Results []struct{ RuleID string } }
+	}
+	if err := json.Unmarshal(data, &log); err != nil || log.Version != "2.1.0" || len(log.Runs[0].Results) != 2 {
+		t.Errorf("sarif = %s: %v", data, err)
+	}
+}
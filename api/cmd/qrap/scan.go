@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/quantun-opensource/qrap/api/internal/analyzer"
+	"github.com/quantun-opensource/qrap/api/internal/config"
+	"github.com/quantun-opensource/qrap/api/internal/report"
+	"github.com/quantun-opensource/qrap/api/internal/scanner"
+	"github.com/quantun-opensource/qrap/api/internal/service"
+	"github.com/quantun-opensource/qrap/api/internal/validate"
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// stringList is a repeatable flag; each value may also be a
+// comma-separated list.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
+
+// scanFlags are the flags of qrap scan.
+type scanFlags struct {
+	targetsFile string
+	name        string
+	org         string
+	enabled     stringList
+	disabled    stringList
+	format      string
+	out         string
+	failOn      string
+	failOnError bool
+	timeout     time.Duration
+	workers     int
+	perHost     int
+	rate        float64
+	retries     int
+	pluginDir   string
+	allow       stringList
+	jwksDir     string
+	zoneDir     string
+	verbose     bool
+
+	allowNetworks []netip.Prefix
+}
+
+func (f *scanFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.targetsFile, "targets-file", "", `read targets from this file, one per line ("-" for stdin)`)
+	fs.StringVar(&f.name, "name", "offline scan", "assessment name")
+	fs.StringVar(&f.org, "org", "", "organization ID to record in the bundle")
+	fs.Var(&f.enabled, "enable", "only run this analyzer (repeatable)")
+	fs.Var(&f.disabled, "disable", "never run this analyzer (repeatable)")
+	fs.StringVar(&f.format, "format", "json", "output format: json, sarif, cbom or csv")
+	fs.StringVar(&f.out, "out", "", "write to this file instead of standard output")
+	fs.StringVar(&f.failOn, "fail-on", "", "exit with status 3 on findings at or above this risk level")
+	fs.BoolVar(&f.failOnError, "fail-on-error", false, "exit with status 3 on analyzer errors (default true with --fail-on)")
+	fs.DurationVar(&f.timeout, "timeout", analyzer.DefaultTimeout, "bound on each analyzer call")
+	fs.IntVar(&f.workers, "workers", 16, "concurrent analyzer calls")
+	fs.IntVar(&f.perHost, "per-host", 2, "concurrent analyzer calls per host (0 = unlimited)")
+	fs.Float64Var(&f.rate, "rate", 0, "analyzer calls started per second (0 = unlimited)")
+	fs.IntVar(&f.retries, "retries", 2, "retries of a failed analyzer call")
+	fs.StringVar(&f.pluginDir, "plugin-dir", os.Getenv("QRAP_PLUGIN_DIR"), "analyzer plugin directory")
+	fs.Var(&f.allow, "allow-network", "internal network analyzers may connect to, as a CIDR prefix or IP (repeatable)")
+	fs.StringVar(&f.jwksDir, "jwks-dir", os.Getenv("QRAP_JWKS_DOCUMENT_DIR"), "directory of jwks+file:// documents")
+	fs.StringVar(&f.zoneDir, "zone-dir", os.Getenv("QRAP_DNSSEC_ZONE_DIR"), "directory of dnssec+file:// zone files")
+	fs.BoolVar(&f.verbose, "verbose", false, "log analyzer activity to standard error")
+}
+
+// parse parses args, allowing flags after targets, and returns the
+// targets.
+func (f *scanFlags) parse(fs *flag.FlagSet, args []string, stdin io.Reader) ([]string, error) {
+	var targets []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &usageError{msg: err.Error()}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		targets = append(targets, args[0])
+		args = args[1:]
+	}
+
+	if f.targetsFile != "" {
+		fromFile, err := readTargets(f.targetsFile, stdin)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, fromFile...)
+	}
+	if len(targets) == 0 {
+		return nil, usagef("no targets: pass them as arguments or with --targets-file")
+	}
+	for _, t := range targets {
+		if err := validate.Asset(t); err != nil {
+			return nil, usagef("invalid target %q: %v", t, err)
+		}
+	}
+
+	if !slices.Contains(report.Formats, strings.ToLower(f.format)) {
+		return nil, usagef("--format must be one of %s", strings.Join(report.Formats, ", "))
+	}
+	if f.failOn != "" && report.RiskRank(f.failOn) < 0 {
+		return nil, usagef("--fail-on must be one of %s", strings.Join(model.RiskLevels, ", "))
+	}
+	// A gate on findings also fails on analyzer errors, since the findings
+	// are then incomplete, unless --fail-on-error=false says otherwise.
+	if f.failOn != "" && !isFlagSet(fs, "fail-on-error") {
+		f.failOnError = true
+	}
+	if f.org != "" {
+		if _, err := uuid.Parse(f.org); err != nil {
+			return nil, usagef("invalid --org %q", f.org)
+		}
+	}
+	for _, s := range f.allow {
+		p, err := config.ParsePrefix(s)
+		if err != nil {
+			return nil, usagef("invalid --allow-network: %v", err)
+		}
+		f.allowNetworks = append(f.allowNetworks, p)
+	}
+	return targets, nil
+}
+
+// readTargets reads one target per line from path, or from stdin if path
+// is "-". Blank lines and lines starting with # are skipped.
+func readTargets(path string, stdin io.Reader) ([]string, error) {
+	r := stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var targets []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			targets = append(targets, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read targets: %w", err)
+	}
+	return targets, nil
+}
+
+func scan(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var f scanFlags
+	fs := flag.NewFlagSet("qrap scan", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	f.register(fs)
+	targets, err := f.parse(fs, args, stdin)
+	if err != nil {
+		return err
+	}
+
+	logger := zap.NewNop()
+	if f.verbose {
+		if logger, err = zap.NewDevelopment(); err != nil {
+			return err
+		}
+	}
+	limits := analyzer.DefaultPluginLimits()
+	analyzers, err := analyzer.NewStandardRegistry(ctx, analyzer.StandardConfig{
+		Timeout: f.timeout,
+		Scanner: scanner.Config{
+			Workers:  f.workers,
+			PerHost:  f.perHost,
+			Rate:     f.rate,
+			Attempts: f.retries + 1,
+		},
+		PluginDir:       f.pluginDir,
+		PluginLimits:    limits,
+		AllowNetworks:   f.allowNetworks,
+		JWKSDocumentDir: f.jwksDir,
+		DNSSECZoneDir:   f.zoneDir,
+	}, logger)
+	if err != nil {
+		return err
+	}
+	for _, name := range append(slices.Clone(f.enabled), f.disabled...) {
+		if !analyzers.Has(name) {
+			return usagef("unknown analyzer %q (have %s)", name, strings.Join(analyzers.Names(), ", "))
+		}
+	}
+
+	now := time.Now().UTC()
+	a := &model.Assessment{
+		ID:                uuid.New(),
+		Name:              f.name,
+		TargetAssets:      targets,
+		EnabledAnalyzers:  f.enabled,
+		DisabledAnalyzers: f.disabled,
+		CreatedBy:         "qrap scan",
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if f.org != "" {
+		a.OrganizationID = uuid.MustParse(f.org)
+	}
+	result := service.Scan(ctx, analyzers, a)
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("scan interrupted: %w", err)
+	}
+	for _, e := range result.Errors {
+		fmt.Fprintf(stderr, "qrap scan: %s on %s: %s\n", e.Analyzer, e.Asset, e.Error)
+	}
+
+	if err := writeOutput(stdout, f.out, f.format, a, result); err != nil {
+		return err
+	}
+	summary := service.Summarize(a, result.Findings)
+	fmt.Fprintf(stderr, "scanned %d target(s): %d finding(s) (%d critical, %d high, %d medium, %d low), risk %s, score %.1f, PQC ready %.0f%%\n",
+		len(targets), summary.TotalFindings, summary.CriticalFindings, summary.HighFindings, summary.MediumFindings, summary.LowFindings,
+		*a.OverallRisk, a.RiskScore, a.PqcReadiness)
+
+	if f.failOnError && len(result.Errors) > 0 {
+		return &policyError{msg: fmt.Sprintf("%d analyzer error(s); the findings may be incomplete", len(result.Errors))}
+	}
+	if f.failOn != "" {
+		findings := make([]model.FindingResponse, len(result.Findings))
+		for i := range result.Findings {
+			findings[i] = result.Findings[i].ToResponse()
+		}
+		if failing := report.AtOrAbove(findings, f.failOn); len(failing) > 0 {
+			return &policyError{msg: fmt.Sprintf("%d finding(s) at or above %s", len(failing), strings.ToUpper(f.failOn))}
+		}
+	}
+	return nil
+}
+
+// writeOutput writes the scan to path, or to stdout if path is empty: a
+// scan bundle for json, and a report for the other formats.
+func writeOutput(stdout io.Writer, path, format string, a *model.Assessment, result *analyzer.RunResult) (err error) {
+	w := stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = f
+	}
+
+	if strings.EqualFold(format, "json") {
+		return report.WriteBundle(w, service.NewScanBundle(a, result))
+	}
+	r := &report.Report{Assessment: a.ToResponse(), GeneratedAt: *a.CompletedAt}
+	r.Assessment.Summary = service.Summarize(a, result.Findings)
+	for i := range result.Findings {
+		r.Findings = append(r.Findings, result.Findings[i].ToResponse())
+	}
+	return report.Write(w, format, r)
+}
+
+// isFlagSet reports whether the flag name was given on the command line.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+func assessmentCreate(a *app, args []string) error {
+	fs := a.newFlagSet()
+	org := fs.String("org", "", "organization ID")
+	name := fs.String("name", "", "assessment name")
+	var targets, enabled, disabled stringList
+	fs.Var(&targets, "target", "asset to assess (repeatable)")
+	fs.Var(&enabled, "enable", "analyzer to run (repeatable; default all)")
+	fs.Var(&disabled, "disable", "analyzer to skip (repeatable)")
+	if _, err := a.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *org == "" || *name == "" {
+		return usagef("--org and --name are required")
+	}
+	orgID, err := parseID(*org)
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	as, err := c.CreateAssessment(a.ctx, &model.CreateAssessmentRequest{
+		Name:              *name,
+		OrganizationID:    orgID.String(),
+		TargetAssets:      targets,
+		EnabledAnalyzers:  enabled,
+		DisabledAnalyzers: disabled,
+	})
+	if err != nil {
+		return err
+	}
+	return a.print(as, func(w io.Writer) { printAssessments(w, *as) })
+}
+
+// assessmentRun runs an assessment; the server returns once it completes.
+func assessmentRun(a *app, args []string) error {
+	fs := a.newFlagSet()
+	var policy failOn
+	policy.register(fs)
+	positional, err := a.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	as, err := c.RunAssessment(a.ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := a.print(as, func(w io.Writer) { printAssessments(w, *as) }); err != nil {
+		return err
+	}
+	return policy.check(a, c, id)
+}
+
+func assessmentStatus(a *app, args []string) error {
+	fs := a.newFlagSet()
+	positional, err := a.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	as, err := c.GetAssessment(a.ctx, id)
+	if err != nil {
+		return err
+	}
+	return a.print(as, func(w io.Writer) { printAssessments(w, *as) })
+}
+
+// assessmentWait polls an assessment until it is COMPLETED or ARCHIVED,
+// for pipelines where another job starts the run.
+func assessmentWait(a *app, args []string) error {
+	fs := a.newFlagSet()
+	timeout := fs.Duration("timeout", 30*time.Minute, "give up after this long")
+	interval := fs.Duration("interval", 5*time.Second, "time between polls")
+	var policy failOn
+	policy.register(fs)
+	positional, err := a.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return usagef("--interval must be positive")
+	}
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(*timeout)
+	for {
+		as, err := c.GetAssessment(a.ctx, id)
+		if err != nil {
+			return err
+		}
+		if as.Status == "COMPLETED" || as.Status == "ARCHIVED" {
+			if err := a.print(as, func(w io.Writer) { printAssessments(w, *as) }); err != nil {
+				return err
+			}
+			return policy.check(a, c, id)
+		}
+		if time.Now().Add(*interval).After(deadline) {
+			return fmt.Errorf("assessment %s still %s after %s", id, as.Status, *timeout)
+		}
+		select {
+		case <-a.ctx.Done():
+			return errors.New("interrupted")
+		case <-time.After(*interval):
+		}
+	}
+}
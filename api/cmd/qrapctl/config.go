@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// configFile holds named connection profiles:
+//
+//	{
+//	  "current_profile": "prod",
+//	  "profiles": {
+//	    "prod": {"url": "https://qrap.example.com", "api_key": "qrap_..."},
+//	    "dev":  {"url": "http://localhost:8083", "output": "json"}
+//	  }
+//	}
+type configFile struct {
+	CurrentProfile string              `json:"current_profile,omitempty"`
+	Profiles       map[string]*profile `json:"profiles"`
+}
+
+// profile is the connection settings of one server. An empty field is
+// unset.
+type profile struct {
+	URL    string `json:"url,omitempty"`
+	APIKey string `json:"api_key,omitempty"`
+	Token  string `json:"token,omitempty"`
+	Output string `json:"output,omitempty"`
+}
+
+// connFlags are the connection flags every command takes.
+type connFlags struct {
+	configPath  string
+	profileName string
+	// set holds the settings given as flags.
+	set profile
+}
+
+func (c *connFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.configPath, "config", "", "config file (default $QRAP_CONFIG or <user config dir>/qrap/config.json)")
+	fs.StringVar(&c.profileName, "profile", "", "profile from the config file (default $QRAP_PROFILE or the current profile)")
+	fs.StringVar(&c.set.URL, "url", "", "server address (default $QRAP_URL)")
+	fs.StringVar(&c.set.APIKey, "api-key", "", "API key (default $QRAP_API_KEY)")
+	fs.StringVar(&c.set.Token, "token", "", "Bearer token (default $QRAP_TOKEN)")
+	fs.StringVar(&c.set.Output, "output", "", "output format: table or json (default $QRAP_OUTPUT or table)")
+}
+
+// path returns the config file's location.
+func (c *connFlags) path() (string, error) {
+	if c.configPath != "" {
+		return c.configPath, nil
+	}
+	if p := os.Getenv("QRAP_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("no config file location: %w", err)
+	}
+	return filepath.Join(dir, "qrap", "config.json"), nil
+}
+
+// load reads the config file; a missing file is an empty config.
+func (c *connFlags) load() (*configFile, string, error) {
+	path, err := c.path()
+	if err != nil {
+		return nil, "", err
+	}
+	cfg := &configFile{Profiles: map[string]*profile{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, path, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, "", fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]*profile{}
+	}
+	return cfg, path, nil
+}
+
+// resolve returns the effective settings: flags override environment
+// variables, which override the selected profile.
+func (c *connFlags) resolve() (profile, error) {
+	cfg, path, err := c.load()
+	if err != nil {
+		return profile{}, err
+	}
+	name := firstSet(c.profileName, os.Getenv("QRAP_PROFILE"), cfg.CurrentProfile)
+	var base profile
+	if name != "" {
+		p, ok := cfg.Profiles[name]
+		if !ok {
+			return profile{}, usagef("profile %q is not in %s", name, path)
+		}
+		base = *p
+	}
+	settings := profile{
+		URL:    firstSet(c.set.URL, os.Getenv("QRAP_URL"), base.URL),
+		APIKey: firstSet(c.set.APIKey, os.Getenv("QRAP_API_KEY")),
+		Token:  firstSet(c.set.Token, os.Getenv("QRAP_TOKEN")),
+		Output: firstSet(c.set.Output, os.Getenv("QRAP_OUTPUT"), base.Output, "table"),
+	}
+	// Credentials from flags or the environment replace the profile's
+	// rather than mixing with them.
+	if settings.APIKey == "" && settings.Token == "" {
+		settings.APIKey, settings.Token = base.APIKey, base.Token
+	}
+	if settings.Output != "table" && settings.Output != "json" {
+		return profile{}, usagef("output must be table or json, not %q", settings.Output)
+	}
+	return settings, nil
+}
+
+func firstSet(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// save writes cfg to path, readable only by the user: it holds credentials.
+func save(cfg *configFile, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+func configList(a *app, args []string) error {
+	fs := a.newFlagSet()
+	if _, err := a.parse(fs, args, 0); err != nil {
+		return err
+	}
+	cfg, _, err := a.conn.load()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CURRENT\tPROFILE\tURL\tAUTH")
+	for _, name := range names {
+		p := cfg.Profiles[name]
+		current := ""
+		if name == cfg.CurrentProfile {
+			current = "*"
+		}
+		auth := "none"
+		switch {
+		case p.APIKey != "":
+			auth = "api key"
+		case p.Token != "":
+			auth = "token"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", current, name, p.URL, auth)
+	}
+	return tw.Flush()
+}
+
+// configSet creates or updates a profile from the connection flags.
+func configSet(a *app, args []string) error {
+	fs := a.newFlagSet()
+	positional, err := a.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	name := positional[0]
+	cfg, path, err := a.conn.load()
+	if err != nil {
+		return err
+	}
+	p := cfg.Profiles[name]
+	if p == nil {
+		p = &profile{}
+		cfg.Profiles[name] = p
+	}
+	set := a.conn.set
+	if set.URL != "" {
+		p.URL = strings.TrimRight(set.URL, "/")
+	}
+	if set.APIKey != "" {
+		p.APIKey, p.Token = set.APIKey, ""
+	}
+	if set.Token != "" {
+		p.Token, p.APIKey = set.Token, ""
+	}
+	if set.Output != "" {
+		if set.Output != "table" && set.Output != "json" {
+			return usagef("output must be table or json, not %q", set.Output)
+		}
+		p.Output = set.Output
+	}
+	if cfg.CurrentProfile == "" {
+		cfg.CurrentProfile = name
+	}
+	if err := save(cfg, path); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stderr, "profile %q saved to %s\n", name, path)
+	return nil
+}
+
+func configUse(a *app, args []string) error {
+	fs := a.newFlagSet()
+	positional, err := a.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	cfg, path, err := a.conn.load()
+	if err != nil {
+		return err
+	}
+	if cfg.Profiles[positional[0]] == nil {
+		return usagef("profile %q is not in %s", positional[0], path)
+	}
+	cfg.CurrentProfile = positional[0]
+	return save(cfg, path)
+}
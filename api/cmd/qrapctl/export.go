@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/quantun-opensource/qrap/api/client"
+	"github.com/quantun-opensource/qrap/api/internal/report"
+)
+
+// export writes an assessment and all its findings as a report file.
+func export(a *app, args []string) error {
+	fs := a.newFlagSet()
+	format := fs.String("format", "", "report format: "+strings.Join(report.Formats, ", "))
+	file := fs.String("file", "", "write to this file instead of standard output")
+	var policy failOn
+	policy.register(fs)
+	positional, err := a.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(report.Formats, strings.ToLower(*format)) {
+		return usagef("--format must be one of %s", strings.Join(report.Formats, ", "))
+	}
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	as, err := c.GetAssessment(a.ctx, id)
+	if err != nil {
+		return err
+	}
+	r := &report.Report{Assessment: *as, GeneratedAt: time.Now().UTC()}
+	for f, err := range c.Findings(a.ctx, &client.FindingListOptions{AssessmentIDs: []uuid.UUID{id}}) {
+		if err != nil {
+			return err
+		}
+		r.Findings = append(r.Findings, f)
+	}
+
+	if err := writeReport(a.stdout, *file, *format, r); err != nil {
+		return err
+	}
+	if *file != "" {
+		fmt.Fprintf(a.stderr, "wrote %d finding(s) to %s\n", len(r.Findings), *file)
+	}
+	if err := policy.checkRuns(a, c, id); err != nil {
+		return err
+	}
+	return policy.checkFindings(r.Findings)
+}
+
+// writeReport renders r to path, or to stdout if path is empty.
+func writeReport(stdout io.Writer, path, format string, r *report.Report) error {
+	if path == "" {
+		return report.Write(stdout, format, r)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.Write(f, format, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+
+	"github.com/quantun-opensource/qrap/api/client"
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+func findingsList(a *app, args []string) error {
+	fs := a.newFlagSet()
+	var assessments, riskLevels, statuses stringList
+	fs.Var(&assessments, "assessment", "assessment ID (repeatable)")
+	fs.Var(&riskLevels, "risk-level", "risk level (repeatable)")
+	fs.Var(&statuses, "status", "finding status (repeatable)")
+	query := fs.String("q", "", "full-text search")
+	limit := fs.Int("limit", 0, "list at most this many findings (default all)")
+	var policy failOn
+	policy.register(fs)
+	if _, err := a.parse(fs, args, 0); err != nil {
+		return err
+	}
+	opts := &client.FindingListOptions{RiskLevels: riskLevels, Statuses: statuses, Query: *query}
+	for _, s := range assessments {
+		id, err := parseID(s)
+		if err != nil {
+			return err
+		}
+		opts.AssessmentIDs = append(opts.AssessmentIDs, id)
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	findings := []model.FindingResponse{}
+	for f, err := range c.Findings(a.ctx, opts) {
+		if err != nil {
+			return err
+		}
+		findings = append(findings, f)
+		if *limit > 0 && len(findings) == *limit {
+			break
+		}
+	}
+	if err := a.print(findings, func(w io.Writer) { printFindings(w, findings) }); err != nil {
+		return err
+	}
+	// Without --assessment the listing spans every visible assessment, so
+	// check the runs of each assessment a listed finding came from.
+	runIDs := opts.AssessmentIDs
+	if len(runIDs) == 0 {
+		runIDs = findingAssessments(findings)
+	}
+	if err := policy.checkRuns(a, c, runIDs...); err != nil {
+		return err
+	}
+	return policy.checkFindings(findings)
+}
+
+// findingAssessments returns the distinct assessments of findings, in the
+// order they first appear.
+func findingAssessments(findings []model.FindingResponse) []uuid.UUID {
+	var ids []uuid.UUID
+	seen := map[uuid.UUID]bool{}
+	for _, f := range findings {
+		if !seen[f.AssessmentID] {
+			seen[f.AssessmentID] = true
+			ids = append(ids, f.AssessmentID)
+		}
+	}
+	return ids
+}
+
+func printFindings(w io.Writer, findings []model.FindingResponse) {
+	fmt.Fprintln(w, "ID\tRISK\tSTATUS\tCATEGORY\tASSET\tALGORITHM\tTITLE")
+	for _, f := range findings {
+		algorithm := "-"
+		if f.CurrentAlgorithm != nil {
+			algorithm = *f.CurrentAlgorithm
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", shortID(f.ID), f.RiskLevel, f.Status, f.Category, f.AffectedAsset, algorithm, f.Title)
+	}
+}
+
+// shortID abbreviates an ID for tables, like a short commit hash.
+func shortID(id uuid.UUID) string {
+	return id.String()[:8]
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// importBundle uploads the JSON bundle of an offline scan (qrap scan) as a
+// completed assessment.
+func importBundle(a *app, args []string) error {
+	fs := a.newFlagSet()
+	org := fs.String("org", "", "organization ID (default the bundle's organization_id)")
+	name := fs.String("name", "", "assessment name (default the bundle's name)")
+	var policy failOn
+	policy.register(fs)
+	positional, err := a.parse(fs, args, 1)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(positional[0])
+	if err != nil {
+		return err
+	}
+	var bundle model.ScanBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return fmt.Errorf("%s is not a scan bundle: %w", positional[0], err)
+	}
+	if *org != "" {
+		id, err := parseID(*org)
+		if err != nil {
+			return err
+		}
+		bundle.OrganizationID = id.String()
+	}
+	if bundle.OrganizationID == "" {
+		return usagef("the bundle names no organization: pass --org")
+	}
+	if *name != "" {
+		bundle.Name = *name
+	}
+
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	as, err := c.ImportScanBundle(a.ctx, &bundle)
+	if err != nil {
+		return err
+	}
+	if err := a.print(as, func(w io.Writer) { printAssessments(w, *as) }); err != nil {
+		return err
+	}
+	return policy.check(a, c, as.ID)
+}
@@ -0,0 +1,237 @@
+// Command qrapctl manages QRAP organizations, assessments and findings from
+// a shell or a CI pipeline.
+//
+//	qrapctl org create --name Acme
+//	qrapctl assessment create --org <id> --name nightly --target tls://api.example.com:443
+//	qrapctl assessment run <id> --fail-on HIGH
+//	qrapctl export <id> --format sarif --file qrap.sarif
+//	qrapctl import scan.json --org <id>
+//
+// Exit status is 0 on success, 1 on errors, 2 on usage errors and 3 when
+// --fail-on finds unresolved findings at or above the given risk level.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/quantun-opensource/qrap/api/client"
+)
+
+// Exit statuses.
+const (
+	exitOK     = 0
+	exitError  = 1
+	exitUsage  = 2
+	exitPolicy = 3
+)
+
+// command runs a subcommand with its arguments.
+type command func(a *app, args []string) error
+
+// commands maps a command and subcommand to its implementation. Commands
+// without subcommands use "".
+var commands = map[string]map[string]command{
+	"org": {
+		"create": orgCreate,
+		"list":   orgList,
+	},
+	"assessment": {
+		"create": assessmentCreate,
+		"run":    assessmentRun,
+		"status": assessmentStatus,
+		"wait":   assessmentWait,
+	},
+	"findings": {
+		"list": findingsList,
+	},
+	"export": {
+		"": export,
+	},
+	"import": {
+		"": importBundle,
+	},
+	"config": {
+		"list": configList,
+		"set":  configSet,
+		"use":  configUse,
+	},
+}
+
+// usageError is a mistake in the command line; it exits with exitUsage.
+type usageError struct{ msg string }
+
+func (e *usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// policyError reports findings that fail a --fail-on policy; it exits with
+// exitPolicy.
+type policyError struct{ msg string }
+
+func (e *policyError) Error() string { return e.msg }
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run executes the command line args and returns the exit status.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		printUsage(stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+	subs, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "qrapctl: unknown command %q\n\n", args[0])
+		printUsage(stderr)
+		return exitUsage
+	}
+	name, rest := args[0], args[1:]
+	cmd, ok := subs[""]
+	if !ok {
+		if len(rest) == 0 || subs[rest[0]] == nil {
+			fmt.Fprintf(stderr, "qrapctl: %s needs one of: %s\n", name, strings.Join(subcommandNames(subs), ", "))
+			return exitUsage
+		}
+		name, cmd, rest = name+" "+rest[0], subs[rest[0]], rest[1:]
+	}
+
+	a := &app{ctx: ctx, name: name, stdout: stdout, stderr: stderr}
+	err := cmd(a, rest)
+	var usageErr *usageError
+	var policyErr *policyError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "qrapctl %s: %v\n", name, err)
+		return exitUsage
+	case errors.As(err, &policyErr):
+		fmt.Fprintf(stderr, "qrapctl %s: %v\n", name, err)
+		return exitPolicy
+	}
+	fmt.Fprintf(stderr, "qrapctl %s: %v\n", name, err)
+	return exitError
+}
+
+func subcommandNames(subs map[string]command) []string {
+	var names []string
+	for name := range subs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, `Usage: qrapctl <command> [flags]
+
+Commands:
+  org create --name NAME [--description TEXT]
+  org list [--limit N]
+  assessment create --org ID --name NAME --target ASSET... [--enable ANALYZER...] [--disable ANALYZER...]
+  assessment run ID [--fail-on LEVEL]
+  assessment status ID
+  assessment wait ID [--timeout 30m] [--interval 5s] [--fail-on LEVEL]
+  findings list [--assessment ID...] [--risk-level LEVEL...] [--status STATUS...] [--q QUERY] [--limit N] [--fail-on LEVEL]
+  export ID --format sarif|csv|cbom|json [--file PATH] [--fail-on LEVEL]
+  import FILE [--org ID] [--name NAME] [--fail-on LEVEL]
+  config list
+  config set PROFILE [--url URL] [--api-key KEY] [--token JWT] [--output table|json]
+  config use PROFILE
+
+Connection flags (every command):
+  --profile NAME   profile from the config file (QRAP_PROFILE)
+  --url URL        server address (QRAP_URL)
+  --api-key KEY    authenticate with an API key (QRAP_API_KEY)
+  --token JWT      authenticate with a Bearer token (QRAP_TOKEN)
+  --output FORMAT  table or json (QRAP_OUTPUT)
+  --config PATH    config file (QRAP_CONFIG)
+
+--fail-on exits with status 3 if the assessment has unresolved findings at
+or above LEVEL (CRITICAL, HIGH, MEDIUM, LOW or INFO).
+`)
+}
+
+// app is the state of one invocation.
+type app struct {
+	ctx    context.Context
+	name   string
+	stdout io.Writer
+	stderr io.Writer
+	conn   connFlags
+	// output is the resolved output format, set by client.
+	output string
+}
+
+// newFlagSet returns the flag set of a.name with the connection flags
+// registered.
+func (a *app) newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("qrapctl "+a.name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	a.conn.register(fs)
+	return fs
+}
+
+// parse parses args with fs, allowing flags after positional arguments,
+// and returns the positional arguments. want is how many are required.
+func (a *app) parse(fs *flag.FlagSet, args []string, want int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &usageError{msg: err.Error()}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) != want {
+		return nil, usagef("expected %d argument(s), got %d", want, len(positional))
+	}
+	return positional, nil
+}
+
+// client returns a client for the configured server.
+func (a *app) client() (*client.Client, error) {
+	settings, err := a.conn.resolve()
+	if err != nil {
+		return nil, err
+	}
+	if settings.URL == "" {
+		return nil, usagef("no server: set --url, QRAP_URL or a profile's url")
+	}
+	a.output = settings.Output
+	cfg := client.Config{BaseURL: settings.URL, UserAgent: "qrapctl"}
+	switch {
+	case settings.APIKey != "":
+		cfg.Auth = client.APIKey(settings.APIKey)
+	case settings.Token != "":
+		cfg.Auth = client.BearerToken(settings.Token)
+	}
+	return client.New(cfg)
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+var testAssessmentID = uuid.MustParse("00000000-0000-0000-0000-0000000000aa")
+
+// fakeServer serves one completed assessment with a CRITICAL finding and a
+// resolved HIGH one, which is also what importing a bundle creates, and
+// checks the API key.
+func fakeServer(t *testing.T) string {
+	t.Helper()
+	return fakeServerWith(t, nil)
+}
+
+// fakeServerWith is fakeServer with runErrors as the analyzer errors of the
+// assessment's latest run.
+func fakeServerWith(t *testing.T, runErrors []model.AnalyzerError) string {
+	t.Helper()
+	high := "HIGH"
+	assessment := model.AssessmentResponse{
+		ID: testAssessmentID, Name: "nightly", Status: "COMPLETED", OverallRisk: &high, RiskScore: 80,
+		Summary: &model.AssessmentSummary{TotalFindings: 2, CriticalFindings: 1, HighFindings: 1},
+	}
+	rsa := "RSA-2048"
+	findings := model.FindingListResponse{Findings: []model.FindingResponse{
+		{ID: uuid.New(), AssessmentID: testAssessmentID, Category: "HARVEST_NOW_DECRYPT_LATER", RiskLevel: "CRITICAL",
+			Title: "HNDL risk", AffectedAsset: "tls://a.example:443", CurrentAlgorithm: &rsa, Status: "OPEN"},
+		{ID: uuid.New(), AssessmentID: testAssessmentID, Category: "MISSING_PQC", RiskLevel: "HIGH",
+			Title: "No PQC", AffectedAsset: "tls://a.example:443", CurrentAlgorithm: &rsa, Status: "RESOLVED"},
+	}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/assessments/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != testAssessmentID.String() {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": 404, "title": "Not Found", "detail": "assessment not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(assessment)
+	})
+	mux.HandleFunc("GET /api/v1/assessments/{id}/runs", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != testAssessmentID.String() {
+			t.Errorf("runs of %s", r.PathValue("id"))
+		}
+		errs := runErrors
+		if errs == nil {
+			errs = []model.AnalyzerError{}
+		}
+		json.NewEncoder(w).Encode(model.AssessmentRunListResponse{Runs: []model.AssessmentRunResponse{
+			{ID: uuid.New(), AssessmentID: testAssessmentID, Status: "COMPLETED", Analyzers: []string{"baseline"}, Errors: errs},
+		}})
+	})
+	mux.HandleFunc("POST /api/v1/assessments/import", func(w http.ResponseWriter, r *http.Request) {
+		var b model.ScanBundle
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil || b.OrganizationID == "" || r.Header.Get("Idempotency-Key") == "" {
+			t.Errorf("import: bundle %+v: %v", b, err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(assessment)
+	})
+	mux.HandleFunc("GET /api/v1/findings", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("assessment_id"); got != "" && got != testAssessmentID.String() {
+			t.Errorf("assessment_id = %q", got)
+		}
+		json.NewEncoder(w).Encode(findings)
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "ApiKey qrap_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+// runCmd runs qrapctl with a config file in a temporary directory and no
+// QRAP_* environment.
+func runCmd(t *testing.T, configPath string, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	for _, env := range []string{"QRAP_PROFILE", "QRAP_URL", "QRAP_API_KEY", "QRAP_TOKEN", "QRAP_OUTPUT"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("QRAP_CONFIG", configPath)
+	var out, errOut bytes.Buffer
+	code = run(context.Background(), args, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestRun_Usage(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "config.json")
+	cases := [][]string{
+		{},
+		{"bogus"},
+		{"org"},
+		{"assessment", "status"},
+		{"assessment", "status", "not-a-uuid", "--url", "http://localhost"},
+		{"export", testAssessmentID.String(), "--format", "pdf"},
+		{"assessment", "run", testAssessmentID.String(), "--fail-on", "SEVERE"},
+		{"org", "list"}, // no server configured
+	}
+	for _, args := range cases {
+		if code, _, _ := runCmd(t, config, args...); code != exitUsage {
+			t.Errorf("%v: exit %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestRun_Profiles(t *testing.T) {
+	url := fakeServer(t)
+	config := filepath.Join(t.TempDir(), "qrap", "config.json")
+
+	if code, _, stderr := runCmd(t, config, "config", "set", "ci", "--url", url+"/", "--api-key", "qrap_test", "--output", "json"); code != exitOK {
+		t.Fatalf("config set: exit %d: %s", code, stderr)
+	}
+	if info, err := os.Stat(config); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("config file: %v, %v", info, err)
+	}
+	code, stdout, stderr := runCmd(t, config, "assessment", "status", testAssessmentID.String())
+	if code != exitOK {
+		t.Fatalf("status: exit %d: %s", code, stderr)
+	}
+	var as model.AssessmentResponse
+	if err := json.Unmarshal([]byte(stdout), &as); err != nil || as.Status != "COMPLETED" {
+		t.Fatalf("status output %q: %v", stdout, err)
+	}
+
+	// Flags override the profile.
+	code, stdout, _ = runCmd(t, config, "assessment", "status", testAssessmentID.String(), "--output", "table")
+	if code != exitOK || !strings.HasPrefix(stdout, "ID ") || !strings.Contains(stdout, "nightly") {
+		t.Errorf("table output: exit %d: %q", code, stdout)
+	}
+	if code, _, _ := runCmd(t, config, "assessment", "status", testAssessmentID.String(), "--api-key", "wrong"); code != exitError {
+		t.Errorf("wrong key: exit %d, want %d", code, exitError)
+	}
+	if code, _, _ := runCmd(t, config, "config", "use", "missing"); code != exitUsage {
+		t.Errorf("use missing profile: exit %d", code)
+	}
+}
+
+func TestRun_FailOn(t *testing.T) {
+	url := fakeServer(t)
+	config := filepath.Join(t.TempDir(), "config.json")
+	conn := []string{"--url", url, "--api-key", "qrap_test"}
+	id := testAssessmentID.String()
+
+	cases := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"findings", "list", "--assessment", id}, exitOK},
+		{[]string{"findings", "list", "--assessment", id, "--fail-on", "critical"}, exitPolicy},
+		{[]string{"assessment", "wait", id, "--fail-on", "HIGH"}, exitPolicy},
+		{[]string{"export", id, "--format", "json"}, exitOK},
+		{[]string{"assessment", "status", uuid.NewString()}, exitError},
+	}
+	for _, tc := range cases {
+		code, _, stderr := runCmd(t, config, append(tc.args, conn...)...)
+		if code != tc.want {
+			t.Errorf("%v: exit %d, want %d: %s", tc.args, code, tc.want, stderr)
+		}
+	}
+}
+
+// TestRun_FailOnAnalyzerErrors checks that --fail-on fails when the latest
+// run has analyzer errors, since its findings may then be incomplete.
+func TestRun_FailOnAnalyzerErrors(t *testing.T) {
+	url := fakeServerWith(t, []model.AnalyzerError{{Analyzer: "tls", Asset: "tls://a.example:443", Error: "connection refused"}})
+	config := filepath.Join(t.TempDir(), "config.json")
+	conn := []string{"--url", url, "--api-key", "qrap_test"}
+	id := testAssessmentID.String()
+
+	for _, args := range [][]string{
+		{"assessment", "wait", id, "--fail-on", "CRITICAL"},
+		{"findings", "list", "--assessment", id, "--fail-on", "CRITICAL"},
+		// Without --assessment, the runs of the listed findings' assessments.
+		{"findings", "list", "--fail-on", "CRITICAL"},
+		{"export", id, "--format", "json", "--fail-on", "CRITICAL"},
+	} {
+		code, _, stderr := runCmd(t, config, append(args, conn...)...)
+		if code != exitPolicy || !strings.Contains(stderr, "1 analyzer error(s)") {
+			t.Errorf("%v: exit %d, want %d: %s", args, code, exitPolicy, stderr)
+		}
+	}
+	// Without --fail-on, analyzer errors do not change the exit status.
+	if code, _, stderr := runCmd(t, config, append([]string{"export", id, "--format", "json"}, conn...)...); code != exitOK {
+		t.Errorf("export: exit %d: %s", code, stderr)
+	}
+}
+
+func TestRun_Export(t *testing.T) {
+	url := fakeServer(t)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "qrap.sarif")
+	code, stdout, stderr := runCmd(t, filepath.Join(dir, "config.json"),
+		"export", testAssessmentID.String(), "--format", "sarif", "--file", out, "--url", url, "--api-key", "qrap_test")
+	if code != exitOK || stdout != "" {
+		t.Fatalf("exit %d, stdout %q: %s", code, stdout, stderr)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string
+		Runs    []struct{ Results []struct{ RuleID string } }
+	}
+	if err := json.Unmarshal(data, &log); err != nil || log.Version != "2.1.0" || len(log.Runs[0].Results) != 2 {
+		t.Errorf("sarif = %s: %v", data, err)
+	}
+}
+
+func TestRun_Import(t *testing.T) {
+	url := fakeServer(t)
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.json")
+	bundle := filepath.Join(dir, "bundle.json")
+	if err := os.WriteFile(bundle, []byte(`{"version":1,"name":"offline scan","findings":[]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	conn := []string{"--url", url, "--api-key", "qrap_test", "--output", "json"}
+
+	if code, _, _ := runCmd(t, config, append([]string{"import", bundle}, conn...)...); code != exitUsage {
+		t.Errorf("import without organization: exit %d, want %d", code, exitUsage)
+	}
+	code, stdout, stderr := runCmd(t, config, append([]string{"import", bundle, "--org", uuid.NewString()}, conn...)...)
+	if code != exitOK {
+		t.Fatalf("import: exit %d: %s", code, stderr)
+	}
+	var as model.AssessmentResponse
+	if err := json.Unmarshal([]byte(stdout), &as); err != nil || as.ID != testAssessmentID {
+		t.Errorf("import output %q: %v", stdout, err)
+	}
+	if code, _, _ := runCmd(t, config, append([]string{"import", bundle, "--org", uuid.NewString(), "--fail-on", "CRITICAL"}, conn...)...); code != exitPolicy {
+		t.Errorf("import --fail-on: exit %d, want %d", code, exitPolicy)
+	}
+}
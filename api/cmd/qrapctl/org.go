@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+func orgCreate(a *app, args []string) error {
+	fs := a.newFlagSet()
+	name := fs.String("name", "", "organization name")
+	description := fs.String("description", "", "organization description")
+	if _, err := a.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *name == "" {
+		return usagef("--name is required")
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	org, err := c.CreateOrganization(a.ctx, &model.CreateOrganizationRequest{Name: *name, Description: *description})
+	if err != nil {
+		return err
+	}
+	return a.print(org, func(w io.Writer) { printOrganizations(w, *org) })
+}
+
+func orgList(a *app, args []string) error {
+	fs := a.newFlagSet()
+	limit := fs.Int("limit", 0, "list at most this many organizations (default all)")
+	if _, err := a.parse(fs, args, 0); err != nil {
+		return err
+	}
+	c, err := a.client()
+	if err != nil {
+		return err
+	}
+	orgs := []model.OrganizationResponse{}
+	for org, err := range c.Organizations(a.ctx, nil) {
+		if err != nil {
+			return err
+		}
+		orgs = append(orgs, org)
+		if *limit > 0 && len(orgs) == *limit {
+			break
+		}
+	}
+	return a.print(orgs, func(w io.Writer) { printOrganizations(w, orgs...) })
+}
+
+func printOrganizations(w io.Writer, orgs ...model.OrganizationResponse) {
+	fmt.Fprintln(w, "ID\tNAME\tCREATED")
+	for _, org := range orgs {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", org.ID, org.Name, org.CreatedAt)
+	}
+}
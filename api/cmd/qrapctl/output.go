@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+
+	"github.com/quantun-opensource/qrap/api/client"
+	"github.com/quantun-opensource/qrap/api/internal/report"
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// stringList is a repeatable flag; each value may also be a
+// comma-separated list.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
+
+func parseID(s string) (uuid.UUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, usagef("invalid ID %q", s)
+	}
+	return id, nil
+}
+
+// print writes v as indented JSON if the output format is json, and
+// otherwise calls table with a tab-separated writer.
+func (a *app) print(v interface{}, table func(w io.Writer)) error {
+	if a.output == "json" {
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+func printAssessments(w io.Writer, assessments ...model.AssessmentResponse) {
+	fmt.Fprintln(w, "ID\tNAME\tSTATUS\tRISK\tSCORE\tFINDINGS\tPQC READY")
+	for _, as := range assessments {
+		risk, findings, ready := "-", "-", "-"
+		if as.OverallRisk != nil {
+			risk = *as.OverallRisk
+		}
+		if as.Summary != nil {
+			findings = fmt.Sprint(as.Summary.TotalFindings)
+			ready = fmt.Sprintf("%.0f%%", as.Summary.PqcReadiness)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.1f\t%s\t%s\n", as.ID, as.Name, as.Status, risk, as.RiskScore, findings, ready)
+	}
+}
+
+// failOn is the --fail-on flag: a risk level, or empty for no policy.
+type failOn string
+
+func (f *failOn) register(fs *flag.FlagSet) {
+	fs.Var(f, "fail-on", "exit with status 3 on unresolved findings at or above this risk level, or when the assessment's latest run has analyzer errors")
+}
+
+func (f *failOn) String() string { return string(*f) }
+
+func (f *failOn) Set(v string) error {
+	if report.RiskRank(v) < 0 {
+		return fmt.Errorf("must be one of %s", strings.Join(model.RiskLevels, ", "))
+	}
+	*f = failOn(strings.ToUpper(v))
+	return nil
+}
+
+// check fetches the latest run and the findings of the assessment and
+// returns a policyError if the run has analyzer errors or any unresolved
+// finding is at or above the threshold.
+func (f failOn) check(a *app, c *client.Client, assessmentID uuid.UUID) error {
+	if f == "" {
+		return nil
+	}
+	if err := f.checkRuns(a, c, assessmentID); err != nil {
+		return err
+	}
+	var findings []model.FindingResponse
+	for finding, err := range c.Findings(a.ctx, &client.FindingListOptions{AssessmentIDs: []uuid.UUID{assessmentID}}) {
+		if err != nil {
+			return err
+		}
+		findings = append(findings, finding)
+	}
+	return f.checkFindings(findings)
+}
+
+// checkRuns returns a policyError if the latest run of one of the
+// assessments did not complete or has analyzer errors: its findings may then
+// be incomplete, so the absence of failing findings proves nothing.
+func (f failOn) checkRuns(a *app, c *client.Client, assessmentIDs ...uuid.UUID) error {
+	if f == "" {
+		return nil
+	}
+	for _, id := range assessmentIDs {
+		runs, err := c.ListRuns(a.ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(runs) == 0 {
+			continue
+		}
+		switch latest := runs[0]; {
+		case latest.Status != "COMPLETED":
+			return &policyError{msg: fmt.Sprintf("latest run of assessment %s is %s", id, latest.Status)}
+		case len(latest.Errors) > 0:
+			return &policyError{msg: fmt.Sprintf("latest run of assessment %s has %d analyzer error(s); its findings may be incomplete", id, len(latest.Errors))}
+		}
+	}
+	return nil
+}
+
+func (f failOn) checkFindings(findings []model.FindingResponse) error {
+	if f == "" {
+		return nil
+	}
+	if failing := report.AtOrAbove(findings, string(f)); len(failing) > 0 {
+		return &policyError{msg: fmt.Sprintf("%d unresolved finding(s) at or above %s", len(failing), f)}
+	}
+	return nil
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -13,10 +16,15 @@ import (
 	chimw "github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 
+	"github.com/quantun-opensource/qrap/api/internal/analyzer"
+	"github.com/quantun-opensource/qrap/api/internal/authz"
 	"github.com/quantun-opensource/qrap/api/internal/config"
 	"github.com/quantun-opensource/qrap/api/internal/handler"
+	"github.com/quantun-opensource/qrap/api/internal/oidc"
 	"github.com/quantun-opensource/qrap/api/internal/repository"
+	"github.com/quantun-opensource/qrap/api/internal/scanner"
 	"github.com/quantun-opensource/qrap/api/internal/service"
+	"github.com/quantun-opensource/qrap/api/internal/tenant"
 	qdb "github.com/quantun-opensource/qrap/shared/go/database"
 	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
 )
@@ -43,23 +51,69 @@ func main() {
 	orgRepo := repository.NewOrganizationRepository(pool)
 	assessmentRepo := repository.NewAssessmentRepository(pool)
 	findingRepo := repository.NewFindingRepository(pool)
+	runRepo := repository.NewRunRepository(pool)
+	membershipRepo := repository.NewMembershipRepository(pool)
+	apiKeyRepo := repository.NewAPIKeyRepository(pool)
+
+	// Token revocation
+	var revocations qmw.RevocationStore = repository.NewRevocationRepository(pool)
+	if cfg.RevocationStore == "memory" {
+		revocations = qmw.NewMemoryRevocationStore()
+	}
+
+	// Analyzers
+	limits := analyzer.DefaultPluginLimits()
+	limits.MaxMemoryBytes = uint64(cfg.PluginMemoryMB) << 20
+	limits.MaxCPUSeconds = uint64(cfg.PluginCPUSecs)
+	analyzers, err := analyzer.NewStandardRegistry(ctx, analyzer.StandardConfig{
+		Timeout: cfg.AnalyzerTimeout,
+		Scanner: scanner.Config{
+			Workers:  cfg.ScanWorkers,
+			PerHost:  cfg.ScanPerHost,
+			Rate:     float64(cfg.ScanRate),
+			Attempts: cfg.ScanRetries + 1,
+			Jitter:   cfg.ScanJitter,
+		},
+		PluginDir:       cfg.PluginDir,
+		PluginLimits:    limits,
+		AllowNetworks:   cfg.AnalyzerAllowNetworks,
+		JWKSDocumentDir: cfg.JWKSDocumentDir,
+		DNSSECZoneDir:   cfg.DNSSECZoneDir,
+	}, logger)
+	if err != nil {
+		logger.Fatal("failed to set up analyzers", zap.Error(err))
+	}
 
 	// Services
-	orgSvc := service.NewOrganizationService(orgRepo, logger)
-	assessmentSvc := service.NewAssessmentService(assessmentRepo, findingRepo, logger)
-	findingSvc := service.NewFindingService(findingRepo, logger)
+	orgSvc := service.NewOrganizationService(orgRepo, membershipRepo, cfg.RestoreWindow, logger)
+	assessmentSvc := service.NewAssessmentService(assessmentRepo, findingRepo, runRepo, analyzers, cfg.RestoreWindow, logger)
+	findingSvc := service.NewFindingService(findingRepo, assessmentRepo, logger)
+	apiKeySvc := service.NewAPIKeyService(apiKeyRepo, logger)
+
+	// Pagination cursors
+	if cfg.CursorSecret == "" {
+		logger.Warn("QRAP_CURSOR_SECRET is not set; pagination cursors will only be valid on this instance until it restarts")
+	}
+	cursors := qmw.NewCursorCodec([]byte(cfg.CursorSecret))
 
 	// Handlers
-	healthH := handler.NewHealthHandler()
-	orgH := handler.NewOrganizationHandler(orgSvc, logger)
-	assessmentH := handler.NewAssessmentHandler(assessmentSvc, logger)
-	findingH := handler.NewFindingHandler(findingSvc, logger)
+	handlers := &handler.Handlers{
+		Health:        handler.NewHealthHandler(),
+		OpenAPI:       handler.NewOpenAPIHandler(),
+		Me:            handler.NewMeHandler(),
+		Organizations: handler.NewOrganizationHandler(orgSvc, cursors, logger),
+		Assessments:   handler.NewAssessmentHandler(assessmentSvc, cursors, logger),
+		Findings:      handler.NewFindingHandler(findingSvc, cursors, logger),
+		APIKeys:       handler.NewAPIKeyHandler(apiKeySvc, logger),
+		Revocations:   handler.NewRevocationHandler(revocations, logger),
+	}
 
 	// Router
 	r := chi.NewRouter()
 
 	// --- Infrastructure middleware ---
 	r.Use(chimw.RequestID)
+	r.Use(qmw.RequestID(chimw.GetReqID))
 	r.Use(chimw.RealIP)
 	r.Use(chimw.Logger)
 	r.Use(chimw.Recoverer)
@@ -76,34 +130,157 @@ func main() {
 		r.Use(qmw.CORS(corsConfig))
 	}
 
-	// Rate limiting (100 req/min per IP)
-	rateLimiter := qmw.NewRateLimiter(qmw.DefaultRateLimitConfig())
-	defer rateLimiter.Stop()
-	r.Use(rateLimiter.Middleware())
+	// Rate limiting: a per-address guard on every route here, and per-subject
+	// budgets on /api/v1 after authentication (below). Counts are optionally
+	// shared across replicas.
+	var rateLimitStore qmw.RateLimitStore
+	if cfg.RateLimitStore == "postgres" {
+		rateLimitStore = repository.NewRateLimitRepository(pool)
+	}
+	ipLimit, ipWindow, _ := qmw.ParseRateLimit(cfg.RateLimitIP)
+	ipRateLimiter := qmw.NewRateLimiter(qmw.RateLimitConfig{
+		Name:              "ip",
+		RequestsPerWindow: ipLimit,
+		Window:            ipWindow,
+		Algorithm:         cfg.RateLimitAlgorithm,
+		KeyFunc:           qmw.IPKey,
+		Store:             rateLimitStore,
+		Logger:            logger,
+	})
+	defer ipRateLimiter.Stop()
+	r.Use(ipRateLimiter.Middleware())
+	subjectLimit, subjectWindow, _ := qmw.ParseRateLimit(cfg.RateLimit)
+	subjectRateLimiter := qmw.NewRateLimiter(qmw.RateLimitConfig{
+		Name:              "subject",
+		RequestsPerWindow: subjectLimit,
+		Window:            subjectWindow,
+		Algorithm:         cfg.RateLimitAlgorithm,
+		Rules:             cfg.RateLimitRules,
+		KeyFunc:           qmw.SubjectKey,
+		Store:             rateLimitStore,
+		Logger:            logger,
+	})
+	defer subjectRateLimiter.Stop()
 
 	// --- Auth middleware ---
-	authConfig := qmw.AuthConfig{
-		JWTSecret: cfg.JWTSecret,
-		JWTIssuer: cfg.JWTIssuer,
-		APIKeys:   qmw.ParseAPIKeyEntries(cfg.APIKeys),
-		SkipPaths: []string{"/health"},
-		Logger:    logger,
+	var jwtKeys qmw.KeySource
+	switch {
+	case cfg.JWKSURL != "":
+		remoteKeys, err := qmw.NewRemoteKeySet(ctx, qmw.RemoteKeySetConfig{
+			URL:             cfg.JWKSURL,
+			RefreshInterval: cfg.JWKSRefresh,
+			Logger:          logger,
+		})
+		if err != nil {
+			logger.Fatal("failed to load JWKS", zap.String("url", cfg.JWKSURL), zap.Error(err))
+		}
+		defer remoteKeys.Stop()
+		jwtKeys = remoteKeys
+	case strings.HasPrefix(strings.TrimSpace(cfg.JWTPublicKeys), "-----BEGIN"):
+		keys, err := qmw.ParsePublicKeysPEM([]byte(cfg.JWTPublicKeys))
+		if err != nil {
+			logger.Fatal("failed to parse JWT public keys", zap.Error(err))
+		}
+		jwtKeys = qmw.NewStaticKeySet(keys...)
+	case cfg.JWTPublicKeys != "":
+		keys, err := qmw.LoadKeyFile(cfg.JWTPublicKeys)
+		if err != nil {
+			logger.Fatal("failed to load JWT public keys", zap.Error(err))
+		}
+		jwtKeys = qmw.NewStaticKeySet(keys...)
 	}
 
-	// Health endpoint (unauthenticated)
-	r.Get("/health", healthH.Check)
+	apiKeys := qmw.ParseAPIKeyEntries(cfg.APIKeys)
+	for _, k := range apiKeys {
+		if !authz.ValidRole(k.Role) {
+			logger.Warn("API key has an unknown role and will be denied every permission",
+				zap.String("subject", k.Subject), zap.String("role", k.Role), zap.Strings("roles", authz.Roles))
+		}
+	}
 
-	// API routes (authenticated)
-	r.Route("/api/v1", func(r chi.Router) {
-		// Apply auth only if JWT secret or API keys are configured
-		if cfg.JWTSecret != "" || len(cfg.APIKeys) > 0 {
-			r.Use(qmw.Auth(authConfig))
+	for _, m := range cfg.ClientCertMappings {
+		if !authz.ValidRole(m.Role) {
+			logger.Warn("mTLS identity has an unknown role and will be denied every permission",
+				zap.String("match", m.Match+":"+m.Value), zap.String("role", m.Role), zap.Strings("roles", authz.Roles))
 		}
+	}
 
-		r.Mount("/organizations", orgH.Routes())
-		r.Mount("/assessments", assessmentH.Routes())
-		r.Mount("/findings", findingH.Routes())
-	})
+	authConfig := qmw.AuthConfig{
+		JWTSecret:     cfg.JWTSecret,
+		JWTKeys:       jwtKeys,
+		JWTIssuer:     cfg.JWTIssuer,
+		JWTAlgorithms: cfg.JWTAlgorithms,
+		APIKeys:       apiKeys,
+		APIKeyStore:   apiKeyRepo,
+		ClientCerts:   cfg.ClientCertMappings,
+		Revocations:   revocations,
+		SkipPaths:     []string{"/health", "/api/v1/openapi.json", "/api/v1/docs"},
+		Logger:        logger,
+	}
+
+	// Browser login (OIDC authorization code + PKCE, session cookies)
+	if cfg.OIDCIssuer != "" {
+		if !authz.ValidRole(cfg.OIDCDefaultRole) {
+			logger.Fatal("invalid QRAP_OIDC_DEFAULT_ROLE", zap.String("role", cfg.OIDCDefaultRole), zap.Strings("roles", authz.Roles))
+		}
+		sessions, err := qmw.NewSessionManager(qmw.SessionConfig{
+			Secret:   cfg.SessionSecret,
+			TTL:      cfg.SessionTTL,
+			Insecure: cfg.SessionInsecure,
+		})
+		if err != nil {
+			logger.Fatal("failed to configure sessions", zap.Error(err))
+		}
+		provider, err := oidc.NewProvider(ctx, oidc.Config{
+			IssuerURL:             cfg.OIDCIssuer,
+			ClientID:              cfg.OIDCClientID,
+			ClientSecret:          cfg.OIDCClientSecret,
+			RedirectURL:           cfg.OIDCRedirectURL,
+			Scopes:                cfg.OIDCScopes,
+			RoleClaim:             cfg.OIDCRoleClaim,
+			DefaultRole:           cfg.OIDCDefaultRole,
+			PostLogoutRedirectURL: cfg.OIDCPostLogoutURL,
+			Logger:                logger,
+		})
+		if err != nil {
+			logger.Fatal("failed to set up OIDC provider", zap.String("issuer", cfg.OIDCIssuer), zap.Error(err))
+		}
+		defer provider.Stop()
+		handlers.Auth = handler.NewAuthHandler(provider, sessions, revocations, logger)
+		authConfig.Sessions = sessions
+		authConfig.SkipPaths = append(authConfig.SkipPaths, "/api/v1/auth/login", "/api/v1/auth/callback", "/api/v1/auth/logout")
+	}
+
+	// API middleware. Auth applies unless QUANTUN_AUTH_DISABLED=true; keys in
+	// the api_keys table authenticate even when no other method is
+	// configured. Authenticated callers only see their organizations' data.
+	var apiMiddleware []func(http.Handler) http.Handler
+	if cfg.AuthEnabled() {
+		apiMiddleware = append(apiMiddleware, qmw.Auth(authConfig), tenant.Middleware(membershipRepo, logger))
+	} else {
+		logger.Warn("authentication disabled by QUANTUN_AUTH_DISABLED; every caller has full access")
+		apiMiddleware = append(apiMiddleware, tenant.Unscoped)
+	}
+	apiMiddleware = append(apiMiddleware,
+		subjectRateLimiter.Middleware(),
+		// Retried creates replay their first response. API key creation is
+		// left out so key secrets are never stored.
+		qmw.Idempotency(qmw.IdempotencyConfig{
+			Store: repository.NewIdempotencyRepository(pool),
+			TTL:   cfg.IdempotencyTTL,
+			Paths: []string{
+				"/api/v1/organizations",
+				"/api/v1/organizations/{id}/members",
+				"/api/v1/assessments",
+				"/api/v1/assessments/import",
+				"/api/v1/assessments/{id}/run",
+			},
+			Logger: logger,
+		}),
+	)
+
+	// Health endpoint (unauthenticated) and API routes (authenticated)
+	handlers.Mount(r, apiMiddleware...)
 
 	addr := fmt.Sprintf(":%s", cfg.Port)
 	srv := &http.Server{
@@ -113,13 +290,27 @@ func main() {
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	if cfg.TLSEnabled() {
+		srv.TLSConfig, err = serverTLSConfig(cfg)
+		if err != nil {
+			logger.Fatal("failed to configure TLS", zap.Error(err))
+		}
+	}
 
 	go func() {
 		logger.Info("starting QRAP API server",
 			zap.String("addr", addr),
-			zap.Bool("auth_enabled", cfg.JWTSecret != "" || len(cfg.APIKeys) > 0),
+			zap.Bool("auth_enabled", cfg.AuthEnabled()),
+			zap.Bool("tls", cfg.TLSEnabled()),
+			zap.Bool("client_certs", cfg.TLSClientCAFile != ""),
 		)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if cfg.TLSEnabled() {
+			err = srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Fatal("server error", zap.Error(err))
 		}
 	}()
@@ -137,3 +328,28 @@ func main() {
 	}
 	logger.Info("server stopped")
 }
+
+// serverTLSConfig returns the TLS settings for HTTPS. With a client CA,
+// client certificates are verified against it, either optionally (clients
+// may still use Bearer, ApiKey or session credentials) or for every
+// connection.
+func serverTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSClientCAFile == "" {
+		return tlsCfg, nil
+	}
+	pem, err := os.ReadFile(cfg.TLSClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in client CA file %s", cfg.TLSClientCAFile)
+	}
+	tlsCfg.ClientCAs = pool
+	tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	if cfg.TLSClientAuth == "require" {
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsCfg, nil
+}
@@ -1,6 +1,6 @@
 module github.com/quantun-opensource/qrap/api
 
-go 1.23.0
+go 1.27.0
 
 require (
 	github.com/go-chi/chi/v5 v5.2.1
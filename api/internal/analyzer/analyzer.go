@@ -0,0 +1,338 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/quantun-opensource/qrap/api/internal/scanner"
+	"github.com/quantun-opensource/qrap/api/internal/validate"
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// DefaultTimeout bounds a single Analyze call when no per-analyzer timeout is registered.
+const DefaultTimeout = 30 * time.Second
+
+// defaultMaxConcurrency caps how many Analyze calls a Registry runs at once
+// unless SetEngine supplies a differently configured scanner.
+const defaultMaxConcurrency = 8
+
+// Analyzer inspects a single target asset and reports findings.
+//
+// Implementations must be safe for concurrent use. Returned findings need not
+// carry ID, AssessmentID or DiscoveredAt; the caller fills those in.
+type Analyzer interface {
+	// Name is the stable identifier used to enable or disable the analyzer
+	// per assessment, e.g. "jwks".
+	Name() string
+	// Supports reports whether the analyzer handles the asset, typically by
+	// URI scheme or asset type.
+	Supports(asset string) bool
+	// Analyze inspects the asset. It must honour ctx cancellation.
+	Analyze(ctx context.Context, asset string) ([]model.Finding, error)
+}
+
+// Selection restricts which analyzers run for an assessment.
+//
+// If Enabled is non-empty only the named analyzers may run. Disabled
+// analyzers never run, even if also listed in Enabled.
+type Selection struct {
+	Enabled  []string
+	Disabled []string
+}
+
+func (s Selection) allows(name string) bool {
+	for _, d := range s.Disabled {
+		if d == name {
+			return false
+		}
+	}
+	if len(s.Enabled) == 0 {
+		return true
+	}
+	for _, e := range s.Enabled {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RunResult is the outcome of running the registry over a set of assets.
+type RunResult struct {
+	Findings []model.Finding
+	// Analyzers lists, sorted, the names of analyzers that were invoked.
+	Analyzers []string
+	// Errors lists analyzer failures and discarded findings, and, with an
+	// empty Analyzer, assets no selected analyzer supports.
+	Errors []model.AnalyzerError
+}
+
+type registration struct {
+	analyzer Analyzer
+	timeout  time.Duration
+}
+
+// Registry holds the analyzers available to the assessment pipeline and
+// dispatches assets to them.
+//
+// Every registered analyzer whose Supports returns true is run for an asset.
+// Assets no registered analyzer supports go to the fallback analyzer, if set.
+// Calls are scheduled on a scanner.Engine, which bounds global and per-host
+// concurrency and retries transient failures.
+type Registry struct {
+	mu             sync.RWMutex
+	entries        []registration
+	fallback       *registration
+	defaultTimeout time.Duration
+	engine         *scanner.Engine
+}
+
+// NewRegistry creates an empty registry. defaultTimeout applies to analyzers
+// registered without their own timeout; if <= 0, DefaultTimeout is used.
+func NewRegistry(defaultTimeout time.Duration) *Registry {
+	if defaultTimeout <= 0 {
+		defaultTimeout = DefaultTimeout
+	}
+	return &Registry{
+		defaultTimeout: defaultTimeout,
+		engine:         scanner.New(scanner.Config{Workers: defaultMaxConcurrency}),
+	}
+}
+
+// SetEngine replaces the scanner used to schedule analyzer calls. Per-call
+// timeouts still come from the registry; the engine's Timeout is unused.
+func (r *Registry) SetEngine(e *scanner.Engine) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.engine = e
+}
+
+// Register adds an analyzer. A timeout <= 0 uses the registry default.
+// Analyzer names must be unique.
+func (r *Registry) Register(a Analyzer, timeout time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.hasLocked(a.Name()) {
+		return fmt.Errorf("analyzer %q already registered", a.Name())
+	}
+	r.entries = append(r.entries, registration{analyzer: a, timeout: timeout})
+	return nil
+}
+
+// SetFallback sets the analyzer used for assets no other analyzer supports.
+func (r *Registry) SetFallback(a Analyzer, timeout time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.entries {
+		if e.analyzer.Name() == a.Name() {
+			return fmt.Errorf("analyzer %q already registered", a.Name())
+		}
+	}
+	r.fallback = &registration{analyzer: a, timeout: timeout}
+	return nil
+}
+
+// Names returns the sorted names of all registered analyzers, including the fallback.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.entries)+1)
+	for _, e := range r.entries {
+		names = append(names, e.analyzer.Name())
+	}
+	if r.fallback != nil {
+		names = append(names, r.fallback.analyzer.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Has reports whether an analyzer with the given name is registered.
+func (r *Registry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.hasLocked(name)
+}
+
+func (r *Registry) hasLocked(name string) bool {
+	if r.fallback != nil && r.fallback.analyzer.Name() == name {
+		return true
+	}
+	for _, e := range r.entries {
+		if e.analyzer.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// task is one (asset, analyzer) pair to execute.
+type task struct {
+	asset string
+	reg   registration
+}
+
+// plan resolves which analyzers run for each asset under sel.
+func (r *Registry) plan(assets []string, sel Selection) []task {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tasks []task
+	for _, asset := range assets {
+		matched := false
+		for _, e := range r.entries {
+			if !e.analyzer.Supports(asset) {
+				continue
+			}
+			matched = true
+			if sel.allows(e.analyzer.Name()) {
+				tasks = append(tasks, task{asset: asset, reg: e})
+			}
+		}
+		if !matched && r.fallback != nil && sel.allows(r.fallback.analyzer.Name()) {
+			tasks = append(tasks, task{asset: asset, reg: *r.fallback})
+		}
+	}
+	return tasks
+}
+
+// Run analyzes every asset with the analyzers selected for it. It is
+// RunWithProgress without a progress callback.
+func (r *Registry) Run(ctx context.Context, assets []string, sel Selection) *RunResult {
+	return r.RunWithProgress(ctx, assets, sel, nil)
+}
+
+// RunWithProgress analyzes every asset with the analyzers selected for it.
+// Analyzers run concurrently on the registry's scanner, each call bounded by
+// its timeout. Analyzer failures and findings that fail model validation are
+// collected in RunResult.Errors rather than aborting the run; valid findings
+// are returned in asset order regardless of completion order.
+//
+// progress, if non-nil, is called with the number of assets fully analyzed
+// each time that number grows. Calls are serialized.
+func (r *Registry) RunWithProgress(ctx context.Context, assets []string, sel Selection, progress func(scanned int)) *RunResult {
+	tasks := r.plan(assets, sel)
+	r.mu.RLock()
+	engine := r.engine
+	r.mu.RUnlock()
+
+	// Abandoned attempts (timed out, or superseded by a retry) may still
+	// return later; only attempts whose ctx is live may store findings.
+	var findingsMu sync.Mutex
+	findings := make([][]model.Finding, len(tasks))
+	jobs := make([]scanner.Task, len(tasks))
+	remaining := make(map[string]int)
+	for i, t := range tasks {
+		i, t := i, t
+		timeout := t.reg.timeout
+		if timeout <= 0 {
+			timeout = r.defaultTimeout
+		}
+		jobs[i] = scanner.Task{
+			Host:    scanner.HostKey(t.asset),
+			Timeout: timeout,
+			Run: func(ctx context.Context) error {
+				f, err := t.reg.analyzer.Analyze(ctx, t.asset)
+				findingsMu.Lock()
+				if ctx.Err() == nil {
+					findings[i] = f
+				}
+				findingsMu.Unlock()
+				return err
+			},
+		}
+		remaining[t.asset]++
+	}
+
+	// Assets no analyzer runs for count as scanned from the start, and are
+	// reported as errors: nothing was learned about them.
+	result := &RunResult{}
+	scanned := 0
+	for _, asset := range assets {
+		if remaining[asset] == 0 {
+			scanned++
+			result.Errors = append(result.Errors, model.AnalyzerError{
+				Asset: asset,
+				Error: "no enabled analyzer supports this asset",
+			})
+		}
+	}
+	if progress != nil && scanned > 0 {
+		progress(scanned)
+	}
+
+	outcomes := engine.Scan(ctx, jobs, func(i int, _ scanner.Result) {
+		asset := tasks[i].asset
+		remaining[asset]--
+		if remaining[asset] == 0 {
+			scanned++
+			if progress != nil {
+				progress(scanned)
+			}
+		}
+	})
+
+	findingsMu.Lock()
+	defer findingsMu.Unlock()
+
+	ran := make(map[string]bool)
+	for i, t := range tasks {
+		name := t.reg.analyzer.Name()
+		ran[name] = true
+		if err := outcomes[i].Err; err != nil {
+			var te *scanner.TimeoutError
+			msg := err.Error()
+			if errors.As(err, &te) {
+				msg = "analyzer " + msg
+			}
+			result.Errors = append(result.Errors, model.AnalyzerError{
+				Analyzer: name,
+				Asset:    t.asset,
+				Error:    msg,
+			})
+			continue
+		}
+		var (
+			invalid  int
+			firstErr error
+		)
+		for _, f := range findings[i] {
+			if f.AffectedAsset == "" {
+				f.AffectedAsset = t.asset
+			}
+			if err := validateFinding(&f); err != nil {
+				invalid++
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			result.Findings = append(result.Findings, f)
+		}
+		if invalid > 0 {
+			result.Errors = append(result.Errors, model.AnalyzerError{
+				Analyzer: name,
+				Asset:    t.asset,
+				Error:    fmt.Sprintf("discarded %d invalid finding(s): %v", invalid, firstErr),
+			})
+		}
+	}
+	for name := range ran {
+		result.Analyzers = append(result.Analyzers, name)
+	}
+	sort.Strings(result.Analyzers)
+	return result
+}
+
+// validateFinding checks the analyzer-supplied fields of f with the rules
+// of a scan bundle finding, so findings from analyzers, plugins and
+// imported bundles all meet the same rules (the findings table's enums and
+// column limits).
+func validateFinding(f *model.Finding) error {
+	bf := model.NewBundleFinding(f)
+	return validate.Struct(&bf)
+}
@@ -0,0 +1,165 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// stubAnalyzer is a configurable Analyzer for registry tests.
+type stubAnalyzer struct {
+	name    string
+	prefix  string
+	analyze func(ctx context.Context, asset string) ([]model.Finding, error)
+}
+
+func (s *stubAnalyzer) Name() string               { return s.name }
+func (s *stubAnalyzer) Supports(asset string) bool { return strings.HasPrefix(asset, s.prefix) }
+func (s *stubAnalyzer) Analyze(ctx context.Context, asset string) ([]model.Finding, error) {
+	if s.analyze != nil {
+		return s.analyze(ctx, asset)
+	}
+	return []model.Finding{{Category: "MISSING_PQC", RiskLevel: "HIGH", Title: s.name, Description: "stub"}}, nil
+}
+
+func titles(findings []model.Finding) []string {
+	out := make([]string, 0, len(findings))
+	for _, f := range findings {
+		out = append(out, f.AffectedAsset+":"+f.Title)
+	}
+	return out
+}
+
+func TestRegistry_DispatchAndFallback(t *testing.T) {
+	r := NewRegistry(time.Second)
+	if err := r.Register(&stubAnalyzer{name: "tls", prefix: "tls://"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(&stubAnalyzer{name: "tls-extra", prefix: "tls://"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetFallback(&stubAnalyzer{name: "baseline"}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	res := r.Run(context.Background(), []string{"tls://a:443", "host-b"}, Selection{})
+
+	want := []string{"tls://a:443:tls", "tls://a:443:tls-extra", "host-b:baseline"}
+	if got := titles(res.Findings); !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+	if want := []string{"baseline", "tls", "tls-extra"}; !reflect.DeepEqual(res.Analyzers, want) {
+		t.Errorf("analyzers = %v, want %v", res.Analyzers, want)
+	}
+	if len(res.Errors) != 0 {
+		t.Errorf("unexpected errors: %+v", res.Errors)
+	}
+}
+
+func TestRegistry_Selection(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.Register(&stubAnalyzer{name: "tls", prefix: "tls://"}, 0)
+	r.Register(&stubAnalyzer{name: "tls-extra", prefix: "tls://"}, 0)
+	r.SetFallback(&stubAnalyzer{name: "baseline"}, 0)
+
+	res := r.Run(context.Background(), []string{"tls://a:443", "host-b"}, Selection{Disabled: []string{"tls-extra", "baseline"}})
+	if want := []string{"tls://a:443:tls"}; !reflect.DeepEqual(titles(res.Findings), want) {
+		t.Errorf("disabled: findings = %v, want %v", titles(res.Findings), want)
+	}
+	// With the fallback disabled nothing analyzes host-b, which is reported.
+	if want := []model.AnalyzerError{{Asset: "host-b", Error: "no enabled analyzer supports this asset"}}; !reflect.DeepEqual(res.Errors, want) {
+		t.Errorf("disabled: errors = %+v, want %+v", res.Errors, want)
+	}
+
+	// A disabled specific analyzer must not cause the fallback to run instead.
+	res = r.Run(context.Background(), []string{"tls://a:443"}, Selection{Enabled: []string{"tls-extra", "baseline"}})
+	if want := []string{"tls://a:443:tls-extra"}; !reflect.DeepEqual(titles(res.Findings), want) {
+		t.Errorf("enabled: findings = %v, want %v", titles(res.Findings), want)
+	}
+}
+
+func TestRegistry_ErrorsTimeoutsAndPanics(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.Register(&stubAnalyzer{name: "failing", prefix: "x", analyze: func(ctx context.Context, asset string) ([]model.Finding, error) {
+		return nil, errors.New("connection refused")
+	}}, 0)
+	r.Register(&stubAnalyzer{name: "slow", prefix: "x", analyze: func(ctx context.Context, asset string) ([]model.Finding, error) {
+		time.Sleep(time.Second) // ignores ctx on purpose
+		return []model.Finding{{Title: "late"}}, nil
+	}}, 20*time.Millisecond)
+	r.Register(&stubAnalyzer{name: "panicky", prefix: "x", analyze: func(ctx context.Context, asset string) ([]model.Finding, error) {
+		panic("boom")
+	}}, 0)
+	r.Register(&stubAnalyzer{name: "bogus", prefix: "x", analyze: func(ctx context.Context, asset string) ([]model.Finding, error) {
+		return []model.Finding{{Category: "NOT_A_CATEGORY", RiskLevel: "HIGH", Title: "t", Description: "d"}}, nil
+	}}, 0)
+	r.Register(&stubAnalyzer{name: "ok", prefix: "x"}, 0)
+
+	start := time.Now()
+	res := r.Run(context.Background(), []string{"x1"}, Selection{})
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("run took %s; slow analyzer timeout not enforced", elapsed)
+	}
+
+	if want := []string{"x1:ok"}; !reflect.DeepEqual(titles(res.Findings), want) {
+		t.Errorf("findings = %v, want %v", titles(res.Findings), want)
+	}
+
+	got := map[string]string{}
+	for _, e := range res.Errors {
+		got[e.Analyzer] = e.Error
+	}
+	if len(got) != 4 {
+		t.Fatalf("expected 4 analyzer errors, got %+v", res.Errors)
+	}
+	if !strings.Contains(got["failing"], "connection refused") {
+		t.Errorf("failing error = %q", got["failing"])
+	}
+	if !strings.Contains(got["slow"], "timed out") {
+		t.Errorf("slow error = %q", got["slow"])
+	}
+	if !strings.Contains(got["panicky"], "panicked") {
+		t.Errorf("panicky error = %q", got["panicky"])
+	}
+	if !strings.Contains(got["bogus"], "category: must be one of") {
+		t.Errorf("bogus error = %q", got["bogus"])
+	}
+}
+
+func TestRegistry_Progress(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.Register(&stubAnalyzer{name: "tls", prefix: "tls://"}, 0)
+	r.Register(&stubAnalyzer{name: "tls-extra", prefix: "tls://"}, 0)
+
+	var reports []int
+	r.RunWithProgress(context.Background(), []string{"tls://a:443", "unsupported", "tls://b:443"}, Selection{}, func(scanned int) {
+		reports = append(reports, scanned)
+	})
+
+	// The unsupported asset counts immediately; each TLS asset counts once
+	// both of its analyzers have finished.
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(reports, want) {
+		t.Errorf("progress reports = %v, want %v", reports, want)
+	}
+}
+
+func TestRegistry_DuplicateNames(t *testing.T) {
+	r := NewRegistry(0)
+	if err := r.Register(&stubAnalyzer{name: "jwks"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(&stubAnalyzer{name: "jwks"}, 0); err == nil {
+		t.Error("expected duplicate registration to fail")
+	}
+	if err := r.SetFallback(&stubAnalyzer{name: "jwks"}, 0); err == nil {
+		t.Error("expected fallback with duplicate name to fail")
+	}
+	if !r.Has("jwks") || r.Has("dnssec") {
+		t.Errorf("Has reported wrong membership; names = %v", r.Names())
+	}
+}
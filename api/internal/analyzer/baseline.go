@@ -0,0 +1,40 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// BaselineAnalyzer is the fallback for assets no protocol-specific analyzer
+// understands. Without inspecting the asset it assumes classical key exchange
+// and reports the corresponding missing-PQC and harvest-now-decrypt-later risks.
+type BaselineAnalyzer struct{}
+
+// NewBaselineAnalyzer creates the fallback analyzer.
+func NewBaselineAnalyzer() *BaselineAnalyzer {
+	return &BaselineAnalyzer{}
+}
+
+// Name implements Analyzer.
+func (a *BaselineAnalyzer) Name() string { return "baseline" }
+
+// Supports implements Analyzer; the baseline applies to any asset.
+func (a *BaselineAnalyzer) Supports(asset string) bool { return true }
+
+// Analyze implements Analyzer.
+func (a *BaselineAnalyzer) Analyze(ctx context.Context, asset string) ([]model.Finding, error) {
+	return []model.Finding{
+		newFinding(asset, "MISSING_PQC", "HIGH",
+			fmt.Sprintf("No PQC protection on %s", asset),
+			fmt.Sprintf("Asset %s uses classical cryptography without post-quantum protection", asset),
+			"RSA-2048", "ML-KEM-768",
+			"Migrate to post-quantum key encapsulation mechanism ML-KEM-768 (FIPS 203)"),
+		newFinding(asset, "HARVEST_NOW_DECRYPT_LATER", "CRITICAL",
+			fmt.Sprintf("HNDL risk on %s", asset),
+			fmt.Sprintf("Asset %s is vulnerable to harvest-now-decrypt-later attacks", asset),
+			"RSA-2048", "ML-KEM-768",
+			"Prioritise migration of long-lived secrets; data encrypted today can be captured and decrypted later by quantum computers"),
+	}, nil
+}
@@ -0,0 +1,157 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quantun-opensource/qrap/api/internal/scanner"
+)
+
+// rsaDNSKEY returns an RFC 3110 public key field with a modulus of bits length.
+func rsaDNSKEY(bits int) string {
+	pub := []byte{3, 0x01, 0x00, 0x01}
+	n := make([]byte, bits/8)
+	n[0] = 0x80
+	n[len(n)-1] = 0x01
+	return base64.StdEncoding.EncodeToString(append(pub, n...))
+}
+
+func TestParseZone_Inventory(t *testing.T) {
+	ecKey := base64.StdEncoding.EncodeToString(make([]byte, 64))
+	rsaKey := rsaDNSKEY(1024)
+
+	// Compute the ECDSA key tag up front so the RRSIG can reference it.
+	pub, _ := base64.StdEncoding.DecodeString(ecKey)
+	ecTag := keyTag(dnssecECDSAP256SHA256, append([]byte{1, 0, 3, dnssecECDSAP256SHA256}, pub...))
+
+	zone := fmt.Sprintf(`$ORIGIN example.com.
+$TTL 3600
+@   IN SOA ns1 hostmaster ( 2024010101 7200 3600 1209600 3600 )
+; Created: 20230115000000 (Sun Jan 15 00:00:00 2023)
+@   IN DNSKEY 257 3 5 (
+        %s
+    ) ; KSK
+    3600 IN DNSKEY 256 3 ECDSAP256SHA256 %s
+    IN RRSIG A 13 2 3600 20240301000000 20240201000000 %d example.com. c2ln
+www IN A 192.0.2.1 ; "quoted; comment"
+sub IN DS 12345 5 1 0123456789ABCDEF0123456789ABCDEF01234567
+`, rsaKey, ecKey, ecTag)
+
+	inv, err := ParseZone(strings.NewReader(zone))
+	if err != nil {
+		t.Fatalf("ParseZone failed: %v", err)
+	}
+	if len(inv.Keys) != 2 {
+		t.Fatalf("expected 2 DNSKEYs, got %d", len(inv.Keys))
+	}
+
+	ksk, zsk := inv.Keys[0], inv.Keys[1]
+	if ksk.Owner != "example.com." || ksk.Role() != "KSK" || ksk.Algorithm != dnssecRSASHA1 || ksk.KeyBits != 1024 {
+		t.Errorf("unexpected KSK inventory: %+v", ksk)
+	}
+	if ksk.Created == nil || !ksk.Created.Equal(time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected KSK created from comment, got %v", ksk.Created)
+	}
+	if zsk.Role() != "ZSK" || zsk.AlgorithmName() != "ECDSAP256SHA256" || zsk.KeyBits != 256 || zsk.KeyTag != ecTag {
+		t.Errorf("unexpected ZSK inventory: %+v", zsk)
+	}
+	if zsk.Created == nil || !zsk.Created.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected ZSK created from RRSIG inception, got %v", zsk.Created)
+	}
+
+	if len(inv.DS) != 1 || inv.DS[0].Owner != "sub.example.com." || inv.DS[0].DigestType != 1 {
+		t.Errorf("unexpected DS inventory: %+v", inv.DS)
+	}
+}
+
+func TestDNSSECAnalyzer_Findings(t *testing.T) {
+	zone := fmt.Sprintf(`example.org. 86400 IN DNSKEY 257 3 8 %s
+example.org. 86400 IN DNSKEY 256 3 7 %s
+example.org. 86400 IN DNSKEY 256 3 13 %s
+`, rsaDNSKEY(2048), rsaDNSKEY(1024), base64.StdEncoding.EncodeToString(make([]byte, 64)))
+
+	a, err := NewDNSSECAnalyzer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.now = func() time.Time { return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	findings, err := a.AnalyzeZone("dnssec+file:///zones/example.org", strings.NewReader(zone))
+	if err != nil {
+		t.Fatalf("AnalyzeZone failed: %v", err)
+	}
+
+	counts := map[string]int{}
+	for _, f := range findings {
+		counts[f.Category]++
+	}
+	if counts["MISSING_PQC"] != 3 {
+		t.Errorf("expected MISSING_PQC for each of the 3 zone keys, got %d", counts["MISSING_PQC"])
+	}
+	// RSASHA1-NSEC3-SHA1 key: one for SHA-1, one for the 1024-bit modulus.
+	if counts["WEAK_ALGORITHM"] != 2 {
+		t.Errorf("expected 2 WEAK_ALGORITHM findings, got %d: %+v", counts["WEAK_ALGORITHM"], findings)
+	}
+}
+
+func TestParseZone_Errors(t *testing.T) {
+	cases := map[string]string{
+		"unbalanced":   "example.com. IN DNSKEY 257 3 8 ( AwEAAQ==\n",
+		"bad flags":    "example.com. IN DNSKEY abc 3 8 AwEAAQ==\n",
+		"bad base64":   "example.com. IN DNSKEY 257 3 8 !!!\n",
+		"unknown alg":  "example.com. IN DNSKEY 257 3 FOO AwEAAQ==\n",
+		"short RRSIG":  "example.com. IN RRSIG A 13 2\n",
+		"extra parens": "example.com. IN A 192.0.2.1 )\n",
+	}
+	for name, zone := range cases {
+		if _, err := ParseZone(strings.NewReader(zone)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestDNSSECAnalyzer_ZoneDir(t *testing.T) {
+	dir := t.TempDir()
+	zone := fmt.Sprintf("example.org. 86400 IN DNSKEY 257 3 8 %s\n", rsaDNSKEY(2048))
+	os.WriteFile(filepath.Join(dir, "example.org.zone"), []byte(zone), 0o644)
+	os.WriteFile(filepath.Join(dir, "broken.zone"), []byte("example.com. IN DNSKEY root:x:0:0 3 8 AwEAAQ==\n"), 0o644)
+
+	a, err := NewDNSSECAnalyzer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, asset := range []string{"dnssec+file://" + filepath.Join(dir, "example.org.zone"), "dnssec+file://example.org.zone"} {
+		if findings, err := a.Analyze(context.Background(), asset); err != nil || len(findings) != 1 {
+			t.Errorf("%s: expected 1 finding, got %+v, %v", asset, findings, err)
+		}
+	}
+
+	cases := map[string]string{
+		"dnssec+file:///etc/shadow":                  "outside the configured directory",
+		"dnssec+file://../../etc/passwd":             "outside the configured directory",
+		"dnssec+file://" + dir + "/../../etc/passwd": "outside the configured directory",
+		"dnssec+file://missing.zone":                 "does not exist",
+		"dnssec+file://broken.zone":                  "not a valid zone file",
+	}
+	for asset, want := range cases {
+		_, err := a.Analyze(context.Background(), asset)
+		if err == nil || !strings.Contains(err.Error(), want) || !scanner.IsPermanent(err) {
+			t.Errorf("%s: expected permanent %q error, got %v", asset, want, err)
+		}
+	}
+
+	// Without a zone directory, no zone file is read.
+	a, err = NewDNSSECAnalyzer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Analyze(context.Background(), "dnssec+file://"+filepath.Join(dir, "example.org.zone")); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("expected zone files to be disabled, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Errors for file assets. They deliberately carry no OS error text: assets
+// come from API callers and errors end up in run records, so they must not
+// reveal anything about files outside the configured directory.
+var (
+	errFileAssetsDisabled = errors.New("file assets are disabled: no directory is configured")
+	errFileOutsideDir     = errors.New("path is outside the configured directory")
+	errFileUnreadable     = errors.New("file does not exist or is not a readable regular file")
+)
+
+// fileDir confines the local files that file assets (jwks+file://,
+// dnssec+file://) may name to one directory. A nil *fileDir refuses all
+// files.
+type fileDir struct {
+	dir  string
+	root *os.Root
+}
+
+// openFileDir opens dir for file assets. An empty dir returns nil, which
+// disables them.
+func openFileDir(dir string) (*fileDir, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	root, err := os.OpenRoot(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", dir, err)
+	}
+	return &fileDir{dir: abs, root: root}, nil
+}
+
+// open opens the regular file at path, which is either relative to the
+// directory or an absolute path inside it. Paths that leave the directory,
+// lexically or through symbolic links, are refused.
+func (d *fileDir) open(path string) (*os.File, error) {
+	if d == nil {
+		return nil, errFileAssetsDisabled
+	}
+	rel := path
+	if filepath.IsAbs(path) {
+		var err error
+		if rel, err = filepath.Rel(d.dir, filepath.Clean(path)); err != nil {
+			return nil, errFileOutsideDir
+		}
+	}
+	if !filepath.IsLocal(rel) {
+		return nil, errFileOutsideDir
+	}
+	f, err := d.root.Open(rel)
+	if err != nil {
+		return nil, errFileUnreadable
+	}
+	if info, err := f.Stat(); err != nil || !info.Mode().IsRegular() {
+		f.Close()
+		return nil, errFileUnreadable
+	}
+	return f, nil
+}
@@ -0,0 +1,412 @@
+// Package analyzer contains the cryptographic analyzers that inspect target
+// assets and turn what they find into assessment findings.
+//
+// Analyzers return findings without ID or AssessmentID; the assessment
+// service stamps those before persisting.
+package analyzer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/quantun-opensource/qrap/api/internal/scanner"
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// Asset URI prefixes handled by JWKSAnalyzer. The remainder of the asset is
+// the http(s) URL of a JWKS document or of an OIDC issuer / discovery
+// document, or for jwks+file:// the path of either document on local disk.
+const (
+	jwksSchemePrefix     = "jwks+"
+	oidcSchemePrefix     = "oidc+"
+	jwksFileSchemePrefix = "jwks+file://"
+
+	oidcDiscoveryPath = "/.well-known/openid-configuration"
+
+	// maxJWKSDocumentBytes bounds how much of a remote document is read.
+	maxJWKSDocumentBytes = 1 << 20
+	// minRSAModulusBits is the smallest RSA modulus considered acceptable.
+	minRSAModulusBits = 2048
+	// minHMACSecretBits is the smallest HMAC secret considered acceptable.
+	minHMACSecretBits = 256
+)
+
+// jwkInfo describes a single key in a JWKS document for findings.
+type jwkInfo struct {
+	KeyID     string
+	KeyType   string
+	Algorithm string
+	Curve     string
+	Use       string
+	KeyBits   int
+}
+
+// jwk is the subset of RFC 7517 key members the analyzer inspects.
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	K   string `json:"k"`
+}
+
+type jwksDocument struct {
+	Keys []jwk `json:"keys"`
+}
+
+type oidcDiscoveryDocument struct {
+	Issuer                  string   `json:"issuer"`
+	JWKSURI                 string   `json:"jwks_uri"`
+	IDTokenSigningAlgValues []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// JWKSAnalyzer inventories the signing keys published by identity providers
+// and flags classical (non-PQC) and weak token signing algorithms.
+//
+// Supported assets:
+//   - jwks+https://idp.example.com/.well-known/jwks.json
+//   - oidc+https://idp.example.com (discovery document is fetched, then jwks_uri)
+//   - jwks+file:///srv/jwks/idp.json (a JWKS or discovery document obtained
+//     out of band, inside the analyzer's document directory)
+type JWKSAnalyzer struct {
+	client *http.Client
+	docs   *fileDir
+}
+
+// NewJWKSAnalyzer creates a JWKS analyzer. If client is nil,
+// NewGuardedHTTPClient(nil) is used, which refuses internal addresses.
+// jwks+file:// assets are read from documentDir; if it is empty they are
+// refused.
+func NewJWKSAnalyzer(client *http.Client, documentDir string) (*JWKSAnalyzer, error) {
+	if client == nil {
+		client = NewGuardedHTTPClient(nil)
+	}
+	docs, err := openFileDir(documentDir)
+	if err != nil {
+		return nil, fmt.Errorf("JWKS document directory: %w", err)
+	}
+	return &JWKSAnalyzer{client: client, docs: docs}, nil
+}
+
+// Name implements Analyzer.
+func (a *JWKSAnalyzer) Name() string { return "jwks" }
+
+// Supports reports whether the asset is a jwks+ or oidc+ URI.
+func (a *JWKSAnalyzer) Supports(asset string) bool {
+	return strings.HasPrefix(asset, jwksSchemePrefix+"http") || strings.HasPrefix(asset, oidcSchemePrefix+"http") ||
+		strings.HasPrefix(asset, jwksFileSchemePrefix)
+}
+
+// Analyze fetches the JWKS (via OIDC discovery if needed) named by asset and
+// returns findings for every key.
+func (a *JWKSAnalyzer) Analyze(ctx context.Context, asset string) ([]model.Finding, error) {
+	var (
+		keys      []jwk
+		discovery *oidcDiscoveryDocument
+	)
+
+	switch {
+	case strings.HasPrefix(asset, jwksFileSchemePrefix):
+		return a.analyzeFile(asset)
+
+	case strings.HasPrefix(asset, oidcSchemePrefix):
+		issuerURL := strings.TrimPrefix(asset, oidcSchemePrefix)
+		discoveryURL := issuerURL
+		if !strings.HasSuffix(discoveryURL, oidcDiscoveryPath) {
+			discoveryURL = strings.TrimSuffix(discoveryURL, "/") + oidcDiscoveryPath
+		}
+		body, err := a.fetch(ctx, discoveryURL)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := parseDiscovery(body)
+		if err != nil {
+			return nil, err
+		}
+		discovery = doc
+		body, err = a.fetch(ctx, doc.JWKSURI)
+		if err != nil {
+			return nil, err
+		}
+		if keys, err = parseJWKS(body); err != nil {
+			return nil, err
+		}
+
+	case strings.HasPrefix(asset, jwksSchemePrefix):
+		body, err := a.fetch(ctx, strings.TrimPrefix(asset, jwksSchemePrefix))
+		if err != nil {
+			return nil, err
+		}
+		if keys, err = parseJWKS(body); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, scanner.Permanent(fmt.Errorf("unsupported JWKS asset: %s", asset))
+	}
+
+	findings := jwksFindings(asset, keys)
+	if discovery != nil {
+		findings = append(findings, discoveryFindings(asset, discovery)...)
+	}
+	return findings, nil
+}
+
+// analyzeFile analyzes the document named by a jwks+file:// asset. Local
+// files do not change between attempts, so errors are never retried.
+func (a *JWKSAnalyzer) analyzeFile(asset string) ([]model.Finding, error) {
+	f, err := a.docs.open(strings.TrimPrefix(asset, jwksFileSchemePrefix))
+	if err != nil {
+		return nil, scanner.Permanent(fmt.Errorf("JWKS document: %w", err))
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxJWKSDocumentBytes+1))
+	if err != nil {
+		return nil, scanner.Permanent(fmt.Errorf("JWKS document: %w", errFileUnreadable))
+	}
+	if len(data) > maxJWKSDocumentBytes {
+		return nil, scanner.Permanent(fmt.Errorf("JWKS document exceeds %d bytes", maxJWKSDocumentBytes))
+	}
+	findings, err := a.AnalyzeDocument(asset, data)
+	if err != nil {
+		// The parse error would quote the file's contents.
+		return nil, scanner.Permanent(errors.New("not a valid JWKS or OIDC discovery document"))
+	}
+	return findings, nil
+}
+
+// AnalyzeDocument analyzes a JWKS or OIDC discovery document that has already
+// been obtained out of band. For discovery documents only the advertised
+// algorithms are checked, since the keys live behind jwks_uri.
+func (a *JWKSAnalyzer) AnalyzeDocument(asset string, data []byte) ([]model.Finding, error) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+	if _, ok := probe["keys"]; ok {
+		keys, err := parseJWKS(data)
+		if err != nil {
+			return nil, err
+		}
+		return jwksFindings(asset, keys), nil
+	}
+	doc, err := parseDiscovery(data)
+	if err != nil {
+		return nil, err
+	}
+	return discoveryFindings(asset, doc), nil
+}
+
+func (a *JWKSAnalyzer) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, scanner.Permanent(fmt.Errorf("build request for %s: %w", url, err))
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		err = fmt.Errorf("fetch %s: %w", url, err)
+		if isBlocked(err) {
+			err = scanner.Permanent(err)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("fetch %s: unexpected status %d", url, resp.StatusCode)
+		// Client errors will not go away on retry; 5xx and 429 might.
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			err = scanner.Permanent(err)
+		}
+		return nil, err
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSDocumentBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", url, err)
+	}
+	if len(body) > maxJWKSDocumentBytes {
+		return nil, scanner.Permanent(fmt.Errorf("document at %s exceeds %d bytes", url, maxJWKSDocumentBytes))
+	}
+	return body, nil
+}
+
+func parseJWKS(data []byte) ([]jwk, error) {
+	var doc jwksDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, scanner.Permanent(fmt.Errorf("invalid JWKS document: %w", err))
+	}
+	if doc.Keys == nil {
+		return nil, scanner.Permanent(fmt.Errorf("invalid JWKS document: missing \"keys\""))
+	}
+	return doc.Keys, nil
+}
+
+func parseDiscovery(data []byte) (*oidcDiscoveryDocument, error) {
+	var doc oidcDiscoveryDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, scanner.Permanent(fmt.Errorf("invalid OIDC discovery document: %w", err))
+	}
+	if doc.JWKSURI == "" {
+		return nil, scanner.Permanent(fmt.Errorf("invalid OIDC discovery document: missing \"jwks_uri\""))
+	}
+	return &doc, nil
+}
+
+func (k jwk) info() jwkInfo {
+	info := jwkInfo{
+		KeyID:     k.Kid,
+		KeyType:   k.Kty,
+		Algorithm: k.Alg,
+		Curve:     k.Crv,
+		Use:       k.Use,
+	}
+	switch k.Kty {
+	case "RSA":
+		if n, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.N, "=")); err == nil {
+			info.KeyBits = new(big.Int).SetBytes(n).BitLen()
+		}
+	case "EC", "OKP":
+		info.KeyBits = curveBits(k.Crv)
+	case "oct":
+		if secret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.K, "=")); err == nil {
+			info.KeyBits = len(secret) * 8
+		}
+	}
+	return info
+}
+
+func curveBits(crv string) int {
+	switch crv {
+	case "P-256", "secp256k1", "Ed25519", "X25519":
+		return 256
+	case "P-384":
+		return 384
+	case "P-521":
+		return 521
+	case "Ed448", "X448":
+		return 448
+	default:
+		return 0
+	}
+}
+
+// describe renders a key as e.g. "RS256 (RSA-2048)" for current_algorithm.
+func (i jwkInfo) describe() string {
+	var key string
+	switch i.KeyType {
+	case "RSA":
+		key = fmt.Sprintf("RSA-%d", i.KeyBits)
+	case "EC", "OKP":
+		key = i.Curve
+	case "oct":
+		key = fmt.Sprintf("HMAC secret %d-bit", i.KeyBits)
+	default:
+		key = i.KeyType
+	}
+	if i.Algorithm == "" {
+		return key
+	}
+	return fmt.Sprintf("%s (%s)", i.Algorithm, key)
+}
+
+func (i jwkInfo) label() string {
+	if i.KeyID != "" {
+		return fmt.Sprintf("key %q", i.KeyID)
+	}
+	return fmt.Sprintf("unnamed %s key", i.KeyType)
+}
+
+func jwksFindings(asset string, keys []jwk) []model.Finding {
+	var findings []model.Finding
+	for _, k := range keys {
+		info := k.info()
+		current := info.describe()
+
+		switch {
+		case strings.EqualFold(info.Algorithm, "none"):
+			findings = append(findings, newFinding(asset, "WEAK_ALGORITHM", "CRITICAL",
+				fmt.Sprintf("Unsigned token algorithm advertised by %s", info.label()),
+				fmt.Sprintf("JWKS at %s publishes %s with alg \"none\"; tokens using it carry no signature", asset, info.label()),
+				current, "ML-DSA-65",
+				"Remove the key and reject unsigned tokens at every relying party"))
+			continue
+		case info.Algorithm == "RSA1_5":
+			findings = append(findings, newFinding(asset, "WEAK_ALGORITHM", "HIGH",
+				fmt.Sprintf("RSAES-PKCS1-v1_5 key encryption on %s", info.label()),
+				fmt.Sprintf("JWKS at %s publishes %s for RSA1_5, which is vulnerable to padding-oracle attacks", asset, info.label()),
+				current, "ML-KEM-768",
+				"Switch token encryption to RSA-OAEP-256 now and plan migration to ML-KEM-768 (FIPS 203)"))
+		}
+
+		switch info.KeyType {
+		case "RSA":
+			if info.KeyBits > 0 && info.KeyBits < minRSAModulusBits {
+				findings = append(findings, newFinding(asset, "SHORT_KEY_LENGTH", "CRITICAL",
+					fmt.Sprintf("RSA modulus below %d bits on %s", minRSAModulusBits, info.label()),
+					fmt.Sprintf("JWKS at %s publishes %s with a %d-bit RSA modulus, which is classically factorable", asset, info.label(), info.KeyBits),
+					current, "ML-DSA-65",
+					"Rotate to at least RSA-3072 immediately and plan migration to ML-DSA-65 (FIPS 204)"))
+			}
+			findings = append(findings, missingPQCSignatureFinding(asset, info, current))
+
+		case "EC", "OKP":
+			findings = append(findings, missingPQCSignatureFinding(asset, info, current))
+
+		case "oct":
+			if info.KeyBits > 0 && info.KeyBits < minHMACSecretBits {
+				findings = append(findings, newFinding(asset, "SHORT_KEY_LENGTH", "HIGH",
+					fmt.Sprintf("Short HMAC secret on %s", info.label()),
+					fmt.Sprintf("JWKS at %s exposes %s with a %d-bit HMAC secret; secrets shorter than %d bits can be brute-forced", asset, info.label(), info.KeyBits, minHMACSecretBits),
+					current, "HS256 with a 256-bit secret",
+					"Rotate the secret to at least 256 random bits and stop publishing symmetric keys in a JWKS"))
+			}
+		}
+	}
+	return findings
+}
+
+func missingPQCSignatureFinding(asset string, info jwkInfo, current string) model.Finding {
+	return newFinding(asset, "MISSING_PQC", "HIGH",
+		fmt.Sprintf("Classical token signing key %s", info.label()),
+		fmt.Sprintf("JWKS at %s publishes %s (%s), which a cryptographically relevant quantum computer can forge", asset, info.label(), current),
+		current, "ML-DSA-65",
+		"Plan migration of token signing to ML-DSA-65 (FIPS 204), using a hybrid classical+ML-DSA scheme during the transition")
+}
+
+func discoveryFindings(asset string, doc *oidcDiscoveryDocument) []model.Finding {
+	var findings []model.Finding
+	for _, alg := range doc.IDTokenSigningAlgValues {
+		if strings.EqualFold(alg, "none") {
+			findings = append(findings, newFinding(asset, "WEAK_ALGORITHM", "CRITICAL",
+				"Unsigned ID tokens advertised by identity provider",
+				fmt.Sprintf("OIDC discovery for %s lists \"none\" in id_token_signing_alg_values_supported", asset),
+				alg, "ML-DSA-65",
+				"Remove \"none\" from the supported ID token signing algorithms"))
+		}
+	}
+	return findings
+}
+
+func newFinding(asset, category, riskLevel, title, description, current, recommended, remediation string) model.Finding {
+	return model.Finding{
+		Category:             category,
+		RiskLevel:            riskLevel,
+		Title:                title,
+		Description:          description,
+		AffectedAsset:        asset,
+		CurrentAlgorithm:     &current,
+		RecommendedAlgorithm: &recommended,
+		Remediation:          &remediation,
+	}
+}
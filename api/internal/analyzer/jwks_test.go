@@ -0,0 +1,276 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/quantun-opensource/qrap/api/internal/scanner"
+)
+
+// modulus returns a base64url RSA modulus of exactly bits length.
+func modulus(bits int) string {
+	n := make([]byte, bits/8)
+	n[0] = 0x80
+	n[len(n)-1] = 0x01
+	return base64.RawURLEncoding.EncodeToString(n)
+}
+
+func newJWKSAnalyzer(t *testing.T, client *http.Client, documentDir string) *JWKSAnalyzer {
+	t.Helper()
+	a, err := NewJWKSAnalyzer(client, documentDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func newJWKSServer(t *testing.T, keys []map[string]string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	})
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                srv.URL,
+			"jwks_uri":                              srv.URL + "/jwks.json",
+			"id_token_signing_alg_values_supported": []string{"RS256", "none"},
+		})
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func countCategory(findings []findingLike, category string) int {
+	n := 0
+	for _, f := range findings {
+		if f.category == category {
+			n++
+		}
+	}
+	return n
+}
+
+type findingLike struct {
+	category string
+	risk     string
+	current  string
+}
+
+func summarize(t *testing.T, asset string, a *JWKSAnalyzer) []findingLike {
+	t.Helper()
+	findings, err := a.Analyze(context.Background(), asset)
+	if err != nil {
+		t.Fatalf("Analyze(%s) failed: %v", asset, err)
+	}
+	out := make([]findingLike, 0, len(findings))
+	for _, f := range findings {
+		if f.AffectedAsset != asset {
+			t.Errorf("expected affected asset %q, got %q", asset, f.AffectedAsset)
+		}
+		out = append(out, findingLike{category: f.Category, risk: f.RiskLevel, current: *f.CurrentAlgorithm})
+	}
+	return out
+}
+
+func TestJWKSAnalyzer_ClassicalAndWeakKeys(t *testing.T) {
+	srv := newJWKSServer(t, []map[string]string{
+		{"kid": "rsa-2048", "kty": "RSA", "alg": "RS256", "use": "sig", "n": modulus(2048), "e": "AQAB"},
+		{"kid": "rsa-1024", "kty": "RSA", "alg": "RS256", "use": "sig", "n": modulus(1024), "e": "AQAB"},
+		{"kid": "ec", "kty": "EC", "alg": "ES256", "crv": "P-256", "x": "x", "y": "y"},
+		{"kid": "hmac", "kty": "oct", "alg": "HS256", "k": base64.RawURLEncoding.EncodeToString([]byte("short-secret"))},
+	})
+
+	a := newJWKSAnalyzer(t, srv.Client(), "")
+	findings := summarize(t, "jwks+"+srv.URL+"/jwks.json", a)
+
+	if got := countCategory(findings, "MISSING_PQC"); got != 3 {
+		t.Errorf("expected 3 MISSING_PQC findings (two RSA, one EC), got %d", got)
+	}
+	if got := countCategory(findings, "SHORT_KEY_LENGTH"); got != 2 {
+		t.Errorf("expected 2 SHORT_KEY_LENGTH findings (RSA-1024, short HMAC), got %d", got)
+	}
+
+	var sawRSA1024 bool
+	for _, f := range findings {
+		if f.category == "SHORT_KEY_LENGTH" && f.current == "RS256 (RSA-1024)" {
+			sawRSA1024 = true
+			if f.risk != "CRITICAL" {
+				t.Errorf("expected CRITICAL for RSA-1024, got %s", f.risk)
+			}
+		}
+	}
+	if !sawRSA1024 {
+		t.Errorf("expected SHORT_KEY_LENGTH finding for RS256 (RSA-1024), got %+v", findings)
+	}
+}
+
+func TestJWKSAnalyzer_OIDCDiscovery(t *testing.T) {
+	srv := newJWKSServer(t, []map[string]string{
+		{"kid": "ed", "kty": "OKP", "alg": "EdDSA", "crv": "Ed25519", "x": "x"},
+	})
+
+	a := newJWKSAnalyzer(t, srv.Client(), "")
+	findings := summarize(t, "oidc+"+srv.URL, a)
+
+	if got := countCategory(findings, "MISSING_PQC"); got != 1 {
+		t.Errorf("expected 1 MISSING_PQC finding, got %d", got)
+	}
+	if got := countCategory(findings, "WEAK_ALGORITHM"); got != 1 {
+		t.Errorf("expected WEAK_ALGORITHM finding for advertised \"none\", got %d", got)
+	}
+}
+
+func TestJWKSAnalyzer_FetchErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	a := newJWKSAnalyzer(t, srv.Client(), "")
+	if _, err := a.Analyze(context.Background(), "jwks+"+srv.URL+"/missing"); err == nil {
+		t.Error("expected error for 404 JWKS endpoint")
+	}
+	if _, err := a.Analyze(context.Background(), "oidc+"+srv.URL); err == nil {
+		t.Error("expected error for missing discovery document")
+	}
+}
+
+func TestJWKSAnalyzer_Supports(t *testing.T) {
+	a := newJWKSAnalyzer(t, nil, "")
+	cases := map[string]bool{
+		"jwks+https://idp.example.com/jwks.json": true,
+		"oidc+https://idp.example.com":           true,
+		"jwks+file:///srv/jwks/idp.json":         true,
+		"https://idp.example.com/jwks.json":      false,
+		"api-gateway":                            false,
+	}
+	for asset, want := range cases {
+		if got := a.Supports(asset); got != want {
+			t.Errorf("Supports(%q) = %v, want %v", asset, got, want)
+		}
+	}
+}
+
+func TestJWKSAnalyzer_Documents(t *testing.T) {
+	dir := t.TempDir()
+	doc := `{"keys":[
+		{"kid":"a","kty":"RSA","alg":"PS384","n":"` + modulus(3072) + `","e":"AQAB"},
+		{"kid":"b","kty":"EC","crv":"P-384"}
+	]}`
+	os.WriteFile(filepath.Join(dir, "idp.json"), []byte(doc), 0o644)
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"keys": "secret-ish content"}`), 0o644)
+	os.Mkdir(filepath.Join(dir, "sub"), 0o755)
+	secret := filepath.Join(t.TempDir(), "secret.json")
+	os.WriteFile(secret, []byte(doc), 0o644)
+	os.Symlink(secret, filepath.Join(dir, "link.json"))
+
+	a := newJWKSAnalyzer(t, nil, dir)
+	for _, asset := range []string{"jwks+file://" + filepath.Join(dir, "idp.json"), "jwks+file://idp.json"} {
+		findings := summarize(t, asset, a)
+		if len(findings) != 2 || findings[0].current != "PS384 (RSA-3072)" || findings[1].current != "P-384" {
+			t.Errorf("%s: unexpected findings %+v", asset, findings)
+		}
+	}
+
+	cases := map[string]string{
+		"jwks+file://" + secret:             "outside the configured directory",
+		"jwks+file://../secret.json":        "outside the configured directory",
+		"jwks+file://sub/../../secret.json": "outside the configured directory",
+		"jwks+file://link.json":             "does not exist",
+		"jwks+file://missing.json":          "does not exist",
+		"jwks+file://sub":                   "does not exist",
+		"jwks+file://broken.json":           "not a valid JWKS",
+	}
+	for asset, want := range cases {
+		_, err := a.Analyze(context.Background(), asset)
+		if err == nil || !strings.Contains(err.Error(), want) || !scanner.IsPermanent(err) {
+			t.Errorf("%s: expected permanent %q error, got %v", asset, want, err)
+		}
+		if err != nil && strings.Contains(err.Error(), "secret") {
+			t.Errorf("%s: error reveals file details: %v", asset, err)
+		}
+	}
+
+	// Without a document directory, no file is read.
+	if _, err := newJWKSAnalyzer(t, nil, "").Analyze(context.Background(), "jwks+file://"+filepath.Join(dir, "idp.json")); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("expected file assets to be disabled, got %v", err)
+	}
+	if _, err := NewJWKSAnalyzer(nil, filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for missing document directory")
+	}
+}
+
+func TestJWKSAnalyzer_BlocksInternalAddresses(t *testing.T) {
+	srv := newJWKSServer(t, []map[string]string{
+		{"kid": "ed", "kty": "OKP", "alg": "EdDSA", "crv": "Ed25519", "x": "x"},
+	})
+	redirects := 0
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/metadata":
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+		default:
+			redirects++
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		}
+	}))
+	t.Cleanup(redirector.Close)
+
+	// Loopback test servers are refused by default, without retries.
+	_, err := newJWKSAnalyzer(t, nil, "").Analyze(context.Background(), "jwks+"+srv.URL+"/jwks.json")
+	if err == nil || !strings.Contains(err.Error(), "not allowed") || !scanner.IsPermanent(err) {
+		t.Fatalf("expected permanent blocked-address error, got %v", err)
+	}
+
+	loopback := []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+	a := newJWKSAnalyzer(t, NewGuardedHTTPClient(loopback), "")
+	if findings := summarize(t, "jwks+"+srv.URL+"/jwks.json", a); len(findings) != 1 {
+		t.Errorf("allow-listed fetch: expected 1 finding, got %+v", findings)
+	}
+	// Redirect targets are checked again.
+	if _, err := a.Analyze(context.Background(), "jwks+"+redirector.URL+"/metadata"); err == nil || !strings.Contains(err.Error(), "169.254.169.254 are not allowed") {
+		t.Errorf("expected redirect to link-local address to be refused, got %v", err)
+	}
+	if _, err := a.Analyze(context.Background(), "jwks+"+redirector.URL+"/loop"); !errors.Is(err, errTooManyRedirects) || !scanner.IsPermanent(err) {
+		t.Errorf("expected too many redirects, got %v", err)
+	}
+	if redirects != maxRedirects+1 {
+		t.Errorf("followed %d redirects, want %d", redirects, maxRedirects+1)
+	}
+}
+
+func TestAddressAllowed(t *testing.T) {
+	allow := []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16")}
+	cases := map[string]bool{
+		"93.184.215.14":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"::1":             false,
+		"10.0.0.1":        false,
+		"10.1.2.3":        true,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"0.0.0.0":         false,
+		"224.0.0.1":       false,
+	}
+	for addr, want := range cases {
+		if got := addressAllowed(netip.MustParseAddr(addr), allow); got != want {
+			t.Errorf("addressAllowed(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
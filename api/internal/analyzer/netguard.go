@@ -0,0 +1,87 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// maxRedirects caps the redirects an analyzer follows for one request.
+const maxRedirects = 3
+
+// errTooManyRedirects is returned when a fetch exceeds maxRedirects.
+var errTooManyRedirects = fmt.Errorf("stopped after %d redirects", maxRedirects)
+
+// blockedAddressError reports a connection refused by NewGuardedHTTPClient.
+type blockedAddressError struct{ addr netip.Addr }
+
+func (e *blockedAddressError) Error() string {
+	return fmt.Sprintf("connections to %s are not allowed (loopback, private and link-local addresses are blocked)", e.addr)
+}
+
+// NewGuardedHTTPClient returns the HTTP client analyzers use to fetch
+// caller-supplied URLs. Target assets come from API callers, so the client
+// refuses to connect to loopback, private (RFC 1918, RFC 4193), link-local
+// (including cloud metadata at 169.254.169.254), multicast and unspecified
+// addresses unless they fall within allow. The check runs on the resolved
+// address of every connection, so DNS names pointing inward and redirects
+// to internal hosts are refused too. At most maxRedirects redirects are
+// followed, and environment proxies are ignored.
+func NewGuardedHTTPClient(allow []netip.Prefix) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("unexpected dial address %q: %w", address, err)
+			}
+			if addr := ap.Addr().Unmap(); !addressAllowed(addr, allow) {
+				return &blockedAddressError{addr: addr}
+			}
+			return nil
+		},
+	}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return errTooManyRedirects
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+// addressAllowed reports whether analyzers may connect to addr.
+func addressAllowed(addr netip.Addr, allow []netip.Prefix) bool {
+	if slices.ContainsFunc(allow, func(p netip.Prefix) bool { return p.Contains(addr) }) {
+		return true
+	}
+	return !(addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() ||
+		addr.IsUnspecified())
+}
+
+// isBlocked reports whether err comes from a refused connection or redirect,
+// which retrying cannot fix.
+func isBlocked(err error) bool {
+	var blocked *blockedAddressError
+	return errors.As(err, &blocked) || errors.Is(err, errTooManyRedirects)
+}
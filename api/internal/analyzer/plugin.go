@@ -0,0 +1,465 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// PluginProtocolVersion is the analyzer plugin protocol version this build speaks.
+// See docs/PLUGINS.md for the wire format.
+const PluginProtocolVersion = 1
+
+// PluginManifestFile is the manifest every plugin directory must contain.
+const PluginManifestFile = "plugin.json"
+
+// maxPluginStderrBytes bounds how much plugin stderr is kept for error reports.
+const maxPluginStderrBytes = 64 << 10
+
+var pluginNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// PluginManifest describes an external analyzer executable.
+type PluginManifest struct {
+	Name    string   `json:"name"`
+	Version string   `json:"version"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	// Timeout overrides the registry default for this plugin, e.g. "20s".
+	Timeout string `json:"timeout"`
+}
+
+// PluginLimits bounds the resources a plugin process may use.
+type PluginLimits struct {
+	// MaxMemoryBytes caps the plugin's data segment, which on Linux includes
+	// private writable mappings such as the heap. 0 disables the limit.
+	MaxMemoryBytes uint64
+	// MaxCPUSeconds caps the plugin's CPU time. 0 disables the limit.
+	MaxCPUSeconds uint64
+	// MaxOutputBytes caps a single protocol message from the plugin.
+	MaxOutputBytes int
+}
+
+// DefaultPluginLimits returns conservative limits for analyzer plugins.
+func DefaultPluginLimits() PluginLimits {
+	return PluginLimits{
+		MaxMemoryBytes: 512 << 20,
+		MaxCPUSeconds:  60,
+		MaxOutputBytes: 4 << 20,
+	}
+}
+
+// PluginCapabilities is what a plugin reports in the initialize handshake.
+type PluginCapabilities struct {
+	ProtocolVersion int `json:"protocol_version"`
+	// Schemes are asset URI schemes the plugin handles, e.g. "ssh" for ssh://host:22.
+	Schemes []string `json:"schemes"`
+	// Prefixes are literal asset prefixes the plugin handles. They must not
+	// be empty.
+	Prefixes []string `json:"prefixes"`
+}
+
+// PluginAnalyzer runs an external executable as an Analyzer. Each Analyze
+// call starts a fresh process, performs the initialize handshake, sends one
+// analyze request and shuts the process down.
+type PluginAnalyzer struct {
+	manifest     PluginManifest
+	dir          string
+	command      string
+	timeout      time.Duration
+	limits       PluginLimits
+	capabilities PluginCapabilities
+}
+
+// LoadPlugins loads every plugin found in immediate subdirectories of dir
+// that contain a plugin.json manifest. Each plugin is started once to learn
+// its capabilities. Plugins that fail to load are logged and skipped.
+func LoadPlugins(ctx context.Context, dir string, limits PluginLimits, logger *zap.Logger) ([]*PluginAnalyzer, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read plugin directory: %w", err)
+	}
+
+	var plugins []*PluginAnalyzer
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		pluginDir := filepath.Join(dir, e.Name())
+		if _, err := os.Stat(filepath.Join(pluginDir, PluginManifestFile)); err != nil {
+			continue
+		}
+		p, err := LoadPlugin(ctx, pluginDir, limits)
+		if err != nil {
+			logger.Error("failed to load analyzer plugin", zap.String("dir", pluginDir), zap.Error(err))
+			continue
+		}
+		logger.Info("loaded analyzer plugin",
+			zap.String("name", p.manifest.Name),
+			zap.String("version", p.manifest.Version),
+			zap.Strings("schemes", p.capabilities.Schemes),
+			zap.Strings("prefixes", p.capabilities.Prefixes),
+		)
+		plugins = append(plugins, p)
+	}
+	return plugins, nil
+}
+
+// LoadPlugin reads the manifest in dir and performs the capabilities handshake.
+func LoadPlugin(ctx context.Context, dir string, limits PluginLimits) (*PluginAnalyzer, error) {
+	data, err := os.ReadFile(filepath.Join(dir, PluginManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	var m PluginManifest
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if !pluginNamePattern.MatchString(m.Name) {
+		return nil, fmt.Errorf("invalid manifest: name %q must match %s", m.Name, pluginNamePattern)
+	}
+	if m.Command == "" {
+		return nil, fmt.Errorf("invalid manifest: command is required")
+	}
+
+	p := &PluginAnalyzer{manifest: m, dir: dir, command: m.Command, limits: limits}
+	if !filepath.IsAbs(p.command) {
+		p.command = filepath.Join(dir, p.command)
+	}
+	if m.Timeout != "" {
+		if p.timeout, err = time.ParseDuration(m.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid manifest timeout: %w", err)
+		}
+	}
+	if p.limits.MaxOutputBytes <= 0 {
+		p.limits.MaxOutputBytes = DefaultPluginLimits().MaxOutputBytes
+	}
+
+	handshakeTimeout := 10 * time.Second
+	if p.timeout > 0 && p.timeout < handshakeTimeout {
+		handshakeTimeout = p.timeout
+	}
+	hctx, cancel := context.WithTimeout(ctx, handshakeTimeout)
+	defer cancel()
+
+	sess, err := p.start(hctx)
+	if err != nil {
+		return nil, err
+	}
+	caps, err := sess.initialize()
+	sess.close()
+	if err != nil {
+		return nil, sess.wrap(err)
+	}
+	if len(caps.Schemes) == 0 && len(caps.Prefixes) == 0 {
+		return nil, fmt.Errorf("plugin %s declares no schemes or prefixes", m.Name)
+	}
+	// An empty prefix would match, and so claim, every asset.
+	if slices.Contains(caps.Schemes, "") || slices.Contains(caps.Prefixes, "") {
+		return nil, fmt.Errorf("plugin %s declares an empty scheme or prefix", m.Name)
+	}
+	p.capabilities = *caps
+	return p, nil
+}
+
+// Name implements Analyzer.
+func (p *PluginAnalyzer) Name() string { return p.manifest.Name }
+
+// Timeout is the per-call timeout from the manifest, or 0 for the registry default.
+func (p *PluginAnalyzer) Timeout() time.Duration { return p.timeout }
+
+// Supports implements Analyzer using the schemes and prefixes from the handshake.
+func (p *PluginAnalyzer) Supports(asset string) bool {
+	for _, scheme := range p.capabilities.Schemes {
+		if strings.HasPrefix(asset, scheme+"://") {
+			return true
+		}
+	}
+	for _, prefix := range p.capabilities.Prefixes {
+		if strings.HasPrefix(asset, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Analyze implements Analyzer.
+func (p *PluginAnalyzer) Analyze(ctx context.Context, asset string) ([]model.Finding, error) {
+	sess, err := p.start(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer sess.close()
+
+	if _, err := sess.initialize(); err != nil {
+		return nil, sess.wrap(err)
+	}
+
+	var result struct {
+		Findings []pluginFinding `json:"findings"`
+	}
+	if err := sess.call("analyze", map[string]string{"asset": asset}, &result, true); err != nil {
+		return nil, sess.wrap(err)
+	}
+	sess.notify("shutdown")
+
+	findings := make([]model.Finding, 0, len(result.Findings))
+	for i, pf := range result.Findings {
+		f := pf.toFinding(asset)
+		if err := validateFinding(&f); err != nil {
+			return nil, fmt.Errorf("plugin %s: finding %d: %w", p.manifest.Name, i, err)
+		}
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
+
+// pluginFinding is the wire form of a finding in an analyze response.
+type pluginFinding struct {
+	Category             string  `json:"category"`
+	RiskLevel            string  `json:"risk_level"`
+	Title                string  `json:"title"`
+	Description          string  `json:"description"`
+	AffectedAsset        string  `json:"affected_asset"`
+	CurrentAlgorithm     *string `json:"current_algorithm"`
+	RecommendedAlgorithm *string `json:"recommended_algorithm"`
+	Remediation          *string `json:"remediation"`
+}
+
+func (pf pluginFinding) toFinding(asset string) model.Finding {
+	f := model.Finding{
+		Category:             pf.Category,
+		RiskLevel:            pf.RiskLevel,
+		Title:                pf.Title,
+		Description:          pf.Description,
+		AffectedAsset:        pf.AffectedAsset,
+		CurrentAlgorithm:     pf.CurrentAlgorithm,
+		RecommendedAlgorithm: pf.RecommendedAlgorithm,
+		Remediation:          pf.Remediation,
+	}
+	if f.AffectedAsset == "" {
+		f.AffectedAsset = asset
+	}
+	return f
+}
+
+// --- JSON-RPC 2.0 over stdio ---
+
+type rpcRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      *int        `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int            `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// pluginSession is one running plugin process.
+type pluginSession struct {
+	ctx    context.Context
+	plugin *PluginAnalyzer
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Scanner
+	stderr *limitedBuffer
+	nextID int
+	closed sync.Once
+}
+
+func (p *PluginAnalyzer) start(ctx context.Context) (*pluginSession, error) {
+	// configurePluginCommand makes ctx cancellation kill the whole process
+	// group, not only the direct child.
+	cmd := exec.CommandContext(ctx, p.command, p.manifest.Args...)
+	cmd.Dir = p.dir
+	cmd.WaitDelay = 2 * time.Second
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		fmt.Sprintf("QRAP_PLUGIN_PROTOCOL=%d", PluginProtocolVersion),
+	}
+	configurePluginCommand(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", p.manifest.Name, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", p.manifest.Name, err)
+	}
+	stderr := &limitedBuffer{max: maxPluginStderrBytes}
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin %s: start: %w", p.manifest.Name, err)
+	}
+	if err := applyPluginLimits(cmd.Process.Pid, p.limits); err != nil {
+		killPluginProcess(cmd)
+		cmd.Wait()
+		return nil, fmt.Errorf("plugin %s: apply resource limits: %w", p.manifest.Name, err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64<<10), p.limits.MaxOutputBytes)
+
+	sess := &pluginSession{
+		ctx:    ctx,
+		plugin: p,
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: scanner,
+		stderr: stderr,
+	}
+	return sess, nil
+}
+
+func (s *pluginSession) initialize() (*PluginCapabilities, error) {
+	var caps PluginCapabilities
+	params := map[string]int{"protocol_version": PluginProtocolVersion}
+	if err := s.call("initialize", params, &caps, false); err != nil {
+		return nil, err
+	}
+	if caps.ProtocolVersion != PluginProtocolVersion {
+		return nil, fmt.Errorf("unsupported protocol version %d (want %d)", caps.ProtocolVersion, PluginProtocolVersion)
+	}
+	return &caps, nil
+}
+
+// call sends a request and decodes the matching response's result into out.
+// When strict is set, unknown fields in the result are rejected.
+func (s *pluginSession) call(method string, params, out interface{}, strict bool) error {
+	s.nextID++
+	id := s.nextID
+	if err := s.send(rpcRequest{JSONRPC: "2.0", ID: &id, Method: method, Params: params}); err != nil {
+		return err
+	}
+
+	if !s.stdout.Scan() {
+		if err := s.ctx.Err(); err != nil {
+			return fmt.Errorf("%s interrupted: %w", method, err)
+		}
+		if err := s.stdout.Err(); err != nil {
+			return fmt.Errorf("read %s response: %w", method, err)
+		}
+		return fmt.Errorf("plugin exited before responding to %s", method)
+	}
+
+	var resp rpcResponse
+	dec := json.NewDecoder(bytes.NewReader(s.stdout.Bytes()))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&resp); err != nil {
+		return fmt.Errorf("invalid %s response: %w", method, err)
+	}
+	if resp.JSONRPC != "2.0" || resp.ID == nil || *resp.ID != id {
+		return fmt.Errorf("invalid %s response: mismatched jsonrpc version or id", method)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s failed: %s (code %d)", method, resp.Error.Message, resp.Error.Code)
+	}
+
+	dec = json.NewDecoder(bytes.NewReader(resp.Result))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(out); err != nil {
+		return fmt.Errorf("invalid %s result: %w", method, err)
+	}
+	return nil
+}
+
+func (s *pluginSession) notify(method string) {
+	s.send(rpcRequest{JSONRPC: "2.0", Method: method})
+}
+
+func (s *pluginSession) send(req rpcRequest) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if _, err := s.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write %s request: %w", req.Method, err)
+	}
+	return nil
+}
+
+// close ends the session, giving the plugin a moment to exit after stdin
+// closes before killing it. It is safe to call more than once.
+func (s *pluginSession) close() {
+	s.closed.Do(func() {
+		s.stdin.Close()
+		done := make(chan error, 1)
+		go func() { done <- s.cmd.Wait() }()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			killPluginProcess(s.cmd)
+			<-done
+		}
+	})
+}
+
+// wrap annotates err with the plugin name and any stderr output. It ends the
+// session first: stderr is copied asynchronously and is only complete once
+// the process has been waited for.
+func (s *pluginSession) wrap(err error) error {
+	s.close()
+	msg := strings.TrimSpace(s.stderr.String())
+	if msg == "" {
+		return fmt.Errorf("plugin %s: %w", s.plugin.manifest.Name, err)
+	}
+	return fmt.Errorf("plugin %s: %w (stderr: %s)", s.plugin.manifest.Name, err, msg)
+}
+
+// limitedBuffer keeps at most max bytes and silently discards the rest.
+type limitedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.max - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
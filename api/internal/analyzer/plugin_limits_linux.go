@@ -0,0 +1,54 @@
+//go:build linux
+
+package analyzer
+
+import (
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+// configurePluginCommand places the plugin in its own process group so a
+// timeout kills any children it spawned as well.
+func configurePluginCommand(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		killPluginProcess(cmd)
+		return nil
+	}
+}
+
+func killPluginProcess(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	cmd.Process.Kill()
+}
+
+// applyPluginLimits sets data-segment and CPU rlimits on the started plugin.
+// There is a short window between start and prlimit during which the plugin
+// runs unlimited; the handshake has not begun, so it has no input yet.
+func applyPluginLimits(pid int, limits PluginLimits) error {
+	if limits.MaxMemoryBytes > 0 {
+		if err := prlimit(pid, syscall.RLIMIT_DATA, limits.MaxMemoryBytes); err != nil {
+			return err
+		}
+	}
+	if limits.MaxCPUSeconds > 0 {
+		if err := prlimit(pid, syscall.RLIMIT_CPU, limits.MaxCPUSeconds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func prlimit(pid, resource int, value uint64) error {
+	lim := syscall.Rlimit{Cur: value, Max: value}
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64,
+		uintptr(pid), uintptr(resource), uintptr(unsafe.Pointer(&lim)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package analyzer
+
+import "os/exec"
+
+// configurePluginCommand is a no-op outside Linux.
+func configurePluginCommand(cmd *exec.Cmd) {}
+
+func killPluginProcess(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+}
+
+// applyPluginLimits is a no-op outside Linux; plugins rely on the per-call
+// timeout alone.
+func applyPluginLimits(pid int, limits PluginLimits) error { return nil }
@@ -0,0 +1,171 @@
+package analyzer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPluginHelperProcess is not a real test: it is the plugin executable the
+// other tests launch, re-running the test binary in a given mode.
+func TestPluginHelperProcess(t *testing.T) {
+	if os.Getenv("QRAP_PLUGIN_PROTOCOL") == "" {
+		return
+	}
+	mode := os.Args[len(os.Args)-1]
+	runHelperPlugin(mode)
+	os.Exit(0)
+}
+
+func runHelperPlugin(mode string) {
+	in := bufio.NewScanner(os.Stdin)
+	reply := func(id *int, result string) {
+		fmt.Printf(`{"jsonrpc":"2.0","id":%d,"result":%s}`+"\n", *id, result)
+	}
+	for in.Scan() {
+		var req struct {
+			ID     *int   `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				Asset string `json:"asset"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(in.Bytes(), &req); err != nil {
+			os.Exit(2)
+		}
+		switch req.Method {
+		case "initialize":
+			if mode == "garbage" {
+				fmt.Println("hello, this is not json")
+				continue
+			}
+			if mode == "catchall" {
+				reply(req.ID, `{"protocol_version":1,"schemes":["ssh"],"prefixes":["helper:",""]}`)
+				continue
+			}
+			reply(req.ID, `{"protocol_version":1,"schemes":["ssh"],"prefixes":["helper:"]}`)
+		case "analyze":
+			switch mode {
+			case "hang":
+				time.Sleep(time.Minute)
+			case "crash":
+				fmt.Fprintln(os.Stderr, "fatal: cannot reach host")
+				os.Exit(3)
+			case "invalid":
+				reply(req.ID, `{"findings":[{"category":"NOT_A_CATEGORY","risk_level":"HIGH","title":"t","description":"d"}]}`)
+			case "extra":
+				reply(req.ID, `{"findings":[{"category":"MISSING_PQC","risk_level":"HIGH","title":"t","description":"d","score":9}]}`)
+			default:
+				reply(req.ID, fmt.Sprintf(`{"findings":[{"category":"MISSING_PQC","risk_level":"HIGH","title":"SSH host key is not post-quantum","description":"%s uses ssh-ed25519","current_algorithm":"Ed25519"}]}`, req.Params.Asset))
+			}
+		case "shutdown":
+			return
+		}
+	}
+}
+
+// writeHelperPlugin creates a plugin directory whose command is this test
+// binary running TestPluginHelperProcess in the given mode.
+func writeHelperPlugin(t *testing.T, root, name, mode string) string {
+	t.Helper()
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	manifest, _ := json.Marshal(PluginManifest{
+		Name:    name,
+		Version: "0.1.0",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestPluginHelperProcess$", "--", mode},
+		Timeout: "5s",
+	})
+	if err := os.WriteFile(filepath.Join(dir, PluginManifestFile), manifest, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoadPlugins_HandshakeAndAnalyze(t *testing.T) {
+	root := t.TempDir()
+	writeHelperPlugin(t, root, "ssh-helper", "valid")
+	writeHelperPlugin(t, root, "broken", "garbage")
+	writeHelperPlugin(t, root, "catchall", "catchall")
+	os.MkdirAll(filepath.Join(root, "no-manifest"), 0o755)
+
+	plugins, err := LoadPlugins(context.Background(), root, DefaultPluginLimits(), nil)
+	if err != nil {
+		t.Fatalf("LoadPlugins failed: %v", err)
+	}
+	if len(plugins) != 1 || plugins[0].Name() != "ssh-helper" {
+		t.Fatalf("expected only ssh-helper to load, got %d plugins", len(plugins))
+	}
+	p := plugins[0]
+	if p.Timeout() != 5*time.Second {
+		t.Errorf("timeout = %s, want 5s", p.Timeout())
+	}
+	if !p.Supports("ssh://host:22") || !p.Supports("helper:x") || p.Supports("tls://host:443") {
+		t.Error("Supports does not reflect handshake capabilities")
+	}
+
+	findings, err := p.Analyze(context.Background(), "ssh://host:22")
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if len(findings) != 1 || findings[0].AffectedAsset != "ssh://host:22" || *findings[0].CurrentAlgorithm != "Ed25519" {
+		t.Errorf("unexpected findings: %+v", findings)
+	}
+}
+
+func TestPluginAnalyzer_Failures(t *testing.T) {
+	root := t.TempDir()
+	cases := map[string]string{
+		"invalid": "category: must be one of",
+		"extra":   "unknown field",
+		"crash":   "cannot reach host",
+		"hang":    "deadline exceeded",
+	}
+	for mode, want := range cases {
+		t.Run(mode, func(t *testing.T) {
+			p, err := LoadPlugin(context.Background(), writeHelperPlugin(t, root, mode, mode), DefaultPluginLimits())
+			if err != nil {
+				t.Fatalf("LoadPlugin failed: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			_, err = p.Analyze(ctx, "ssh://host:22")
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error = %q, want it to contain %q", err, want)
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("Analyze took %s; plugin not killed on timeout", elapsed)
+			}
+		})
+	}
+}
+
+func TestLoadPlugin_InvalidManifest(t *testing.T) {
+	cases := map[string]string{
+		"unknown field": `{"name":"x","command":"run","entrypoint":"run"}`,
+		"bad name":      `{"name":"Bad Name","command":"run"}`,
+		"no command":    `{"name":"x"}`,
+		"bad timeout":   `{"name":"x","command":"run","timeout":"soon"}`,
+	}
+	for name, manifest := range cases {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, PluginManifestFile), []byte(manifest), 0o644)
+		if _, err := LoadPlugin(context.Background(), dir, DefaultPluginLimits()); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/quantun-opensource/qrap/api/internal/scanner"
+)
+
+// StandardConfig configures NewStandardRegistry.
+type StandardConfig struct {
+	// Timeout bounds each Analyze call; <= 0 uses DefaultTimeout.
+	Timeout time.Duration
+	// Scanner schedules the analyzer calls.
+	Scanner scanner.Config
+	// PluginDir holds analyzer plugins; empty loads none.
+	PluginDir    string
+	PluginLimits PluginLimits
+	// AllowNetworks lists internal networks analyzers may connect to; see
+	// NewGuardedHTTPClient.
+	AllowNetworks []netip.Prefix
+	// JWKSDocumentDir holds the documents of jwks+file:// assets; empty
+	// refuses them.
+	JWKSDocumentDir string
+	// DNSSECZoneDir holds the zone files of dnssec+file:// assets; empty
+	// refuses them.
+	DNSSECZoneDir string
+}
+
+// NewStandardRegistry returns the registry the server and offline scans
+// use: the built-in analyzers, the baseline analyzer as fallback, and the
+// plugins in cfg.PluginDir. A plugin that cannot be registered is logged and
+// skipped.
+func NewStandardRegistry(ctx context.Context, cfg StandardConfig, logger *zap.Logger) (*Registry, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	jwks, err := NewJWKSAnalyzer(NewGuardedHTTPClient(cfg.AllowNetworks), cfg.JWKSDocumentDir)
+	if err != nil {
+		return nil, err
+	}
+	dnssec, err := NewDNSSECAnalyzer(cfg.DNSSECZoneDir)
+	if err != nil {
+		return nil, err
+	}
+	r := NewRegistry(cfg.Timeout)
+	r.SetEngine(scanner.New(cfg.Scanner))
+	for _, a := range []Analyzer{
+		jwks,
+		dnssec,
+	} {
+		if err := r.Register(a, 0); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.SetFallback(NewBaselineAnalyzer(), 0); err != nil {
+		return nil, err
+	}
+	if cfg.PluginDir == "" {
+		return r, nil
+	}
+	plugins, err := LoadPlugins(ctx, cfg.PluginDir, cfg.PluginLimits, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load analyzer plugins: %w", err)
+	}
+	for _, p := range plugins {
+		if err := r.Register(p, p.Timeout()); err != nil {
+			logger.Error("failed to register analyzer plugin", zap.Error(err))
+		}
+	}
+	return r, nil
+}
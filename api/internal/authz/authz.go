@@ -0,0 +1,159 @@
+// Package authz defines QRAP's roles, the permission matrix that maps them
+// to API capabilities, and the middleware that enforces it per route.
+//
+// Roles are cumulative:
+//
+//	Permission            viewer  analyst  assessor  admin
+//	organizations:read      x        x        x        x
+//	organizations:write                                x
+//	assessments:read        x        x        x        x
+//	assessments:write                x        x        x
+//	assessments:run                           x        x
+//	findings:read           x        x        x        x
+//	api_keys:manage         x        x        x        x
+//	tokens:revoke                                      x
+//
+// The role comes from the authenticated identity (JWT "role" claim, API key
+// entry or session). Unknown roles have no permissions. API keys may carry
+// scopes that narrow their role's permissions further.
+package authz
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/quantun-opensource/qrap/api/internal/validate"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+// Role names.
+const (
+	RoleViewer   = "viewer"
+	RoleAnalyst  = "analyst"
+	RoleAssessor = "assessor"
+	RoleAdmin    = "admin"
+)
+
+// Roles lists the defined roles from least to most privileged.
+var Roles = []string{RoleViewer, RoleAnalyst, RoleAssessor, RoleAdmin}
+
+func init() {
+	validate.RegisterEnum("role", Roles)
+}
+
+// Permission is a capability checked by a route.
+type Permission string
+
+const (
+	OrganizationsRead  Permission = "organizations:read"
+	OrganizationsWrite Permission = "organizations:write"
+	AssessmentsRead    Permission = "assessments:read"
+	AssessmentsWrite   Permission = "assessments:write"
+	AssessmentsRun     Permission = "assessments:run"
+	FindingsRead       Permission = "findings:read"
+	FindingsTriage     Permission = "findings:triage"
+	APIKeysManage      Permission = "api_keys:manage"
+	TokensRevoke       Permission = "tokens:revoke"
+)
+
+var (
+	viewerPermissions   = []Permission{OrganizationsRead, AssessmentsRead, FindingsRead, APIKeysManage}
+	analystPermissions  = append(slices.Clone(viewerPermissions), AssessmentsWrite, FindingsTriage)
+	assessorPermissions = append(slices.Clone(analystPermissions), AssessmentsRun)
+	adminPermissions    = append(slices.Clone(assessorPermissions), OrganizationsWrite, TokensRevoke)
+)
+
+// matrix maps each role to its permissions.
+var matrix = map[string][]Permission{
+	RoleViewer:   viewerPermissions,
+	RoleAnalyst:  analystPermissions,
+	RoleAssessor: assessorPermissions,
+	RoleAdmin:    adminPermissions,
+}
+
+// ValidRole reports whether role is one of Roles.
+func ValidRole(role string) bool {
+	_, ok := matrix[role]
+	return ok
+}
+
+// Permissions returns the permissions granted to role, or nil for an
+// unknown role.
+func Permissions(role string) []Permission {
+	return slices.Clone(matrix[role])
+}
+
+// Allowed reports whether role has permission p.
+func Allowed(role string, p Permission) bool {
+	return slices.Contains(matrix[role], p)
+}
+
+// Effective returns the permissions of role narrowed to scopes. A nil scopes
+// slice means the identity is not restricted beyond its role.
+func Effective(role string, scopes []string) []Permission {
+	perms := Permissions(role)
+	if scopes == nil {
+		return perms
+	}
+	return slices.DeleteFunc(perms, func(p Permission) bool {
+		return !slices.Contains(scopes, string(p))
+	})
+}
+
+// Covers reports whether every permission in want is also in have.
+func Covers(have, want []Permission) bool {
+	for _, p := range want {
+		if !slices.Contains(have, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidPermission reports whether p is granted by any role.
+func ValidPermission(p Permission) bool {
+	return slices.Contains(adminPermissions, p)
+}
+
+// Require returns middleware that rejects requests whose role lacks p with
+// 403 and a problem details body that also names the missing permission
+// and the caller's role:
+//
+//	{"status": 403, "detail": "insufficient permissions", ..., "required_permission": "...", "role": "..."}
+//
+// Requests from a scoped API key are also rejected unless p is in scope.
+// Requests without an authenticated identity only reach handlers when
+// authentication is disabled (development mode) and are let through.
+func Require(p Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if qmw.AuthMethodFromContext(r.Context()) == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			role := qmw.RoleFromContext(r.Context())
+			scopes := qmw.ScopesFromContext(r.Context())
+			if !Allowed(role, p) || (scopes != nil && !slices.Contains(scopes, string(p))) {
+				WriteForbidden(w, r, role, p)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WriteForbidden writes the standard 403 response for a missing permission.
+func WriteForbidden(w http.ResponseWriter, r *http.Request, role string, p Permission) {
+	qmw.WriteProblem(w, http.StatusForbidden, forbiddenProblem{
+		Problem:            qmw.NewProblem(r, http.StatusForbidden, "insufficient permissions"),
+		RequiredPermission: string(p),
+		Role:               role,
+	})
+}
+
+// forbiddenProblem is the 403 problem body with its extension members.
+type forbiddenProblem struct {
+	qmw.Problem
+	RequiredPermission string `json:"required_permission"`
+	Role               string `json:"role"`
+}
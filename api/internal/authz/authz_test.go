@@ -0,0 +1,112 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+func TestMatrix(t *testing.T) {
+	cases := []struct {
+		perm Permission
+		want map[string]bool
+	}{
+		{OrganizationsRead, map[string]bool{RoleViewer: true, RoleAnalyst: true, RoleAssessor: true, RoleAdmin: true}},
+		{OrganizationsWrite, map[string]bool{RoleAdmin: true}},
+		{AssessmentsRead, map[string]bool{RoleViewer: true, RoleAnalyst: true, RoleAssessor: true, RoleAdmin: true}},
+		{AssessmentsWrite, map[string]bool{RoleAnalyst: true, RoleAssessor: true, RoleAdmin: true}},
+		{AssessmentsRun, map[string]bool{RoleAssessor: true, RoleAdmin: true}},
+		{FindingsRead, map[string]bool{RoleViewer: true, RoleAnalyst: true, RoleAssessor: true, RoleAdmin: true}},
+		{FindingsTriage, map[string]bool{RoleAnalyst: true, RoleAssessor: true, RoleAdmin: true}},
+		{APIKeysManage, map[string]bool{RoleViewer: true, RoleAnalyst: true, RoleAssessor: true, RoleAdmin: true}},
+		{TokensRevoke, map[string]bool{RoleAdmin: true}},
+	}
+	for _, tc := range cases {
+		for _, role := range append(Roles, "reader", "") {
+			if got := Allowed(role, tc.perm); got != tc.want[role] {
+				t.Errorf("Allowed(%q, %s) = %v, want %v", role, tc.perm, got, tc.want[role])
+			}
+		}
+	}
+	if Permissions("reader") != nil {
+		t.Error("unknown role should have no permissions")
+	}
+}
+
+func withIdentity(r *http.Request, role string) *http.Request {
+	ctx := context.WithValue(r.Context(), qmw.ContextKeyAuthMethod, qmw.AuthMethodAPIKey)
+	ctx = context.WithValue(ctx, qmw.ContextKeyRole, role)
+	return r.WithContext(ctx)
+}
+
+func TestRequire(t *testing.T) {
+	h := Require(AssessmentsRun)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, withIdentity(httptest.NewRequest("POST", "/run", nil), RoleAssessor))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("assessor: expected 204, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, withIdentity(httptest.NewRequest("POST", "/run", nil), RoleAnalyst))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("analyst: expected 403, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != qmw.ProblemContentType {
+		t.Errorf("403 Content-Type = %q", ct)
+	}
+	var body map[string]interface{}
+	json.NewDecoder(rec.Body).Decode(&body)
+	if body["status"] != float64(http.StatusForbidden) || body["error"] != "insufficient permissions" || body["required_permission"] != "assessments:run" || body["role"] != "analyst" {
+		t.Errorf("403 body = %v", body)
+	}
+
+	// No identity at all: authentication is disabled.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/run", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("auth disabled: expected 204, got %d", rec.Code)
+	}
+}
+
+func TestRequire_Scopes(t *testing.T) {
+	h := Require(AssessmentsRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	scoped := func(scopes []string) *http.Request {
+		r := withIdentity(httptest.NewRequest("GET", "/", nil), RoleAdmin)
+		return r.WithContext(context.WithValue(r.Context(), qmw.ContextKeyScopes, scopes))
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, scoped([]string{"findings:read"}))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("out of scope: expected 403, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, scoped([]string{"assessments:read"}))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("in scope: expected 204, got %d", rec.Code)
+	}
+}
+
+func TestEffective(t *testing.T) {
+	if got := Effective(RoleViewer, nil); len(got) != len(Permissions(RoleViewer)) {
+		t.Errorf("unscoped = %v", got)
+	}
+	// A scope outside the role grants nothing.
+	got := Effective(RoleViewer, []string{"findings:read", "assessments:run"})
+	if len(got) != 1 || got[0] != FindingsRead {
+		t.Errorf("scoped = %v", got)
+	}
+	if !Covers(Permissions(RoleAdmin), Permissions(RoleAnalyst)) || Covers(Permissions(RoleAnalyst), Permissions(RoleAssessor)) {
+		t.Error("Covers does not follow the role hierarchy")
+	}
+}
@@ -2,8 +2,14 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
 )
 
 // Config holds the application configuration loaded from environment variables.
@@ -13,12 +19,91 @@ type Config struct {
 	MLEngineURL string `json:"ml_engine_url"`
 	LogLevel    string `json:"log_level"`
 
-	// Auth configuration
+	// Auth configuration. Authentication is always on (keys in the api_keys
+	// table work without any of the settings below) unless AuthDisabled is
+	// set explicitly for local development.
+	AuthDisabled bool     `json:"auth_disabled"`
 	JWTSecret    string   `json:"jwt_secret"`
 	JWTIssuer    string   `json:"jwt_issuer"`
 	APIKeys      []string `json:"api_keys"` // format: "key:subject:role"
 	CORSOrigins  []string `json:"cors_origins"`
 	MaxBodyBytes int64    `json:"max_body_bytes"`
+
+	// Asymmetric JWT verification (RS256/ES256/EdDSA/ML-DSA-65/
+	// ML-DSA-65-Ed25519). JWTPublicKeys is a JWKS or PEM file path, or
+	// inline PEM. JWTAlgorithms restricts accepted algorithms; empty allows all.
+	JWKSURL       string        `json:"jwks_url"`
+	JWTPublicKeys string        `json:"-"`
+	JWKSRefresh   time.Duration `json:"jwks_refresh"`
+	JWTAlgorithms []string      `json:"jwt_algorithms"`
+
+	// Browser login via OIDC authorization code + PKCE. Enabled when
+	// OIDCIssuer is set; sessions are HMAC-signed cookies keyed by SessionSecret.
+	OIDCIssuer        string        `json:"oidc_issuer"`
+	OIDCClientID      string        `json:"oidc_client_id"`
+	OIDCClientSecret  string        `json:"-"`
+	OIDCRedirectURL   string        `json:"oidc_redirect_url"`
+	OIDCScopes        []string      `json:"oidc_scopes"`
+	OIDCRoleClaim     string        `json:"oidc_role_claim"`
+	OIDCDefaultRole   string        `json:"oidc_default_role"`
+	OIDCPostLogoutURL string        `json:"oidc_post_logout_url"`
+	SessionSecret     string        `json:"-"`
+	SessionTTL        time.Duration `json:"session_ttl"`
+	SessionInsecure   bool          `json:"session_insecure"`
+
+	// HTTPS and mutual TLS. The server speaks HTTPS when TLSCertFile and
+	// TLSKeyFile are set. With TLSClientCAFile, client certificates signed by
+	// that CA are verified (TLSClientAuth "optional" or "require") and those
+	// matching ClientCertMappings authenticate as the mapped identity.
+	TLSCertFile        string                  `json:"tls_cert_file"`
+	TLSKeyFile         string                  `json:"tls_key_file"`
+	TLSClientCAFile    string                  `json:"tls_client_ca_file"`
+	TLSClientAuth      string                  `json:"tls_client_auth"`
+	ClientCertMappings []qmw.ClientCertMapping `json:"mtls_identities"`
+
+	// Rate limiting. RateLimitIP guards every route per client address;
+	// RateLimit applies per authenticated subject (or address) to /api/v1,
+	// overridden per route and role by RateLimitRules. RateLimitStore selects
+	// where counts are kept: "memory" (per replica) or "postgres" (one budget
+	// across every replica).
+	RateLimitAlgorithm qmw.RateLimitAlgorithm `json:"rate_limit_algorithm"`
+	RateLimitIP        string                 `json:"rate_limit_ip"`
+	RateLimit          string                 `json:"rate_limit"`
+	RateLimitRules     []qmw.RateLimitRule    `json:"rate_limit_rules"`
+	RateLimitStore     string                 `json:"rate_limit_store"`
+
+	// RevocationStore selects where revoked tokens are recorded: "postgres"
+	// (shared by every instance) or "memory" (single instance, lost on restart).
+	RevocationStore string `json:"revocation_store"`
+
+	// IdempotencyTTL is how long Idempotency-Key responses are kept for replay.
+	IdempotencyTTL time.Duration `json:"idempotency_ttl"`
+
+	// RestoreWindow is how long deleted organizations and assessments can be
+	// restored before they are purged.
+	RestoreWindow time.Duration `json:"restore_window"`
+
+	// CursorSecret signs pagination cursors. If empty, a random key is used
+	// and cursors are only valid on the instance that issued them.
+	CursorSecret string `json:"-"`
+
+	// Analyzer configuration
+	AnalyzerTimeout time.Duration `json:"analyzer_timeout"`
+	PluginDir       string        `json:"plugin_dir"` // empty disables external analyzer plugins
+	PluginMemoryMB  int           `json:"plugin_memory_mb"`
+	PluginCPUSecs   int           `json:"plugin_cpu_seconds"`
+	// AnalyzerAllowNetworks lists loopback, private or link-local networks
+	// analyzers may still connect to; all others are refused.
+	AnalyzerAllowNetworks []netip.Prefix `json:"analyzer_allow_networks"`
+	JWKSDocumentDir       string         `json:"jwks_document_dir"` // empty refuses jwks+file:// assets
+	DNSSECZoneDir         string         `json:"dnssec_zone_dir"`   // empty refuses dnssec+file:// assets
+
+	// Scanner configuration
+	ScanWorkers int           `json:"scan_workers"`
+	ScanPerHost int           `json:"scan_per_host"` // 0 = unlimited
+	ScanRate    int           `json:"scan_rate"`     // analyzer calls started per second, 0 = unlimited
+	ScanRetries int           `json:"scan_retries"`  // retries after the first attempt
+	ScanJitter  time.Duration `json:"scan_jitter"`
 }
 
 // Load reads configuration from environment variables.
@@ -31,12 +116,140 @@ func Load() (*Config, error) {
 		JWTSecret:    getEnv("QUANTUN_JWT_SECRET", ""),
 		JWTIssuer:    getEnv("QUANTUN_JWT_ISSUER", "quantun"),
 		MaxBodyBytes: 1 << 20, // 1 MB
+		PluginDir:    getEnv("QRAP_PLUGIN_DIR", ""),
+
+		JWKSDocumentDir: getEnv("QRAP_JWKS_DOCUMENT_DIR", ""),
+		DNSSECZoneDir:   getEnv("QRAP_DNSSEC_ZONE_DIR", ""),
 	}
 
 	if cfg.DatabaseURL == "" {
 		return nil, fmt.Errorf("QRAP_DATABASE_URL is required")
 	}
 
+	analyzerTimeout, err := getEnvDuration("QRAP_ANALYZER_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	cfg.AnalyzerTimeout = analyzerTimeout
+
+	cfg.JWKSURL = getEnv("QUANTUN_JWKS_URL", "")
+	cfg.JWTPublicKeys = getEnv("QUANTUN_JWT_PUBLIC_KEYS", "")
+	if cfg.JWKSRefresh, err = getEnvDuration("QUANTUN_JWKS_REFRESH", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if algs := getEnv("QUANTUN_JWT_ALGORITHMS", ""); algs != "" {
+		for _, alg := range strings.Split(algs, ",") {
+			alg = strings.TrimSpace(alg)
+			if !slices.Contains(qmw.SupportedJWTAlgorithms, alg) {
+				return nil, fmt.Errorf("QUANTUN_JWT_ALGORITHMS: unsupported algorithm %q", alg)
+			}
+			cfg.JWTAlgorithms = append(cfg.JWTAlgorithms, alg)
+		}
+	}
+
+	cfg.OIDCIssuer = getEnv("QRAP_OIDC_ISSUER", "")
+	cfg.OIDCClientID = getEnv("QRAP_OIDC_CLIENT_ID", "")
+	cfg.OIDCClientSecret = getEnv("QRAP_OIDC_CLIENT_SECRET", "")
+	cfg.OIDCRedirectURL = getEnv("QRAP_OIDC_REDIRECT_URL", "")
+	cfg.OIDCScopes = strings.Fields(getEnv("QRAP_OIDC_SCOPES", "openid profile email"))
+	cfg.OIDCRoleClaim = getEnv("QRAP_OIDC_ROLE_CLAIM", "role")
+	cfg.OIDCDefaultRole = getEnv("QRAP_OIDC_DEFAULT_ROLE", "viewer")
+	cfg.OIDCPostLogoutURL = getEnv("QRAP_OIDC_POST_LOGOUT_URL", "")
+	cfg.SessionSecret = getEnv("QRAP_SESSION_SECRET", "")
+	if cfg.SessionTTL, err = getEnvDuration("QRAP_SESSION_TTL", 8*time.Hour); err != nil {
+		return nil, err
+	}
+	cfg.SessionInsecure = getEnv("QRAP_SESSION_INSECURE", "") == "true"
+	if cfg.OIDCIssuer != "" {
+		if cfg.OIDCClientID == "" || cfg.OIDCRedirectURL == "" {
+			return nil, fmt.Errorf("QRAP_OIDC_CLIENT_ID and QRAP_OIDC_REDIRECT_URL are required with QRAP_OIDC_ISSUER")
+		}
+		if len(cfg.SessionSecret) < 32 {
+			return nil, fmt.Errorf("QRAP_SESSION_SECRET of at least 32 bytes is required with QRAP_OIDC_ISSUER")
+		}
+	}
+
+	cfg.TLSCertFile = getEnv("QRAP_TLS_CERT_FILE", "")
+	cfg.TLSKeyFile = getEnv("QRAP_TLS_KEY_FILE", "")
+	cfg.TLSClientCAFile = getEnv("QRAP_TLS_CLIENT_CA_FILE", "")
+	cfg.TLSClientAuth = getEnv("QRAP_TLS_CLIENT_AUTH", "optional")
+	if identities := getEnv("QRAP_MTLS_IDENTITIES", ""); identities != "" {
+		if cfg.ClientCertMappings, err = qmw.ParseClientCertMappings(strings.Split(identities, ",")); err != nil {
+			return nil, fmt.Errorf("QRAP_MTLS_IDENTITIES: %w", err)
+		}
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, fmt.Errorf("QRAP_TLS_CERT_FILE and QRAP_TLS_KEY_FILE must be set together")
+	}
+	if cfg.TLSClientCAFile != "" && cfg.TLSCertFile == "" {
+		return nil, fmt.Errorf("QRAP_TLS_CLIENT_CA_FILE requires QRAP_TLS_CERT_FILE and QRAP_TLS_KEY_FILE")
+	}
+	if len(cfg.ClientCertMappings) > 0 && cfg.TLSClientCAFile == "" {
+		return nil, fmt.Errorf("QRAP_MTLS_IDENTITIES requires QRAP_TLS_CLIENT_CA_FILE")
+	}
+	if cfg.TLSClientAuth != "optional" && cfg.TLSClientAuth != "require" {
+		return nil, fmt.Errorf("QRAP_TLS_CLIENT_AUTH must be optional or require, got %q", cfg.TLSClientAuth)
+	}
+
+	cfg.RateLimitAlgorithm = qmw.RateLimitAlgorithm(getEnv("QRAP_RATE_LIMIT_ALGORITHM", string(qmw.RateLimitTokenBucket)))
+	if cfg.RateLimitAlgorithm != qmw.RateLimitTokenBucket && cfg.RateLimitAlgorithm != qmw.RateLimitFixedWindow {
+		return nil, fmt.Errorf("QRAP_RATE_LIMIT_ALGORITHM must be token_bucket or fixed_window, got %q", cfg.RateLimitAlgorithm)
+	}
+	cfg.RateLimitIP = getEnv("QRAP_RATE_LIMIT_IP", "1000/1m")
+	if _, _, err := qmw.ParseRateLimit(cfg.RateLimitIP); err != nil {
+		return nil, fmt.Errorf("QRAP_RATE_LIMIT_IP: %w", err)
+	}
+	cfg.RateLimit = getEnv("QRAP_RATE_LIMIT", "100/1m")
+	if _, _, err := qmw.ParseRateLimit(cfg.RateLimit); err != nil {
+		return nil, fmt.Errorf("QRAP_RATE_LIMIT: %w", err)
+	}
+	if cfg.RateLimitRules, err = qmw.ParseRateLimitRules(strings.Split(getEnv("QRAP_RATE_LIMITS", "POST /api/v1/assessments/{id}/run=10/1m"), ",")); err != nil {
+		return nil, fmt.Errorf("QRAP_RATE_LIMITS: %w", err)
+	}
+	cfg.RateLimitStore = getEnv("QRAP_RATE_LIMIT_STORE", "memory")
+	if cfg.RateLimitStore != "postgres" && cfg.RateLimitStore != "memory" {
+		return nil, fmt.Errorf("QRAP_RATE_LIMIT_STORE must be postgres or memory, got %q", cfg.RateLimitStore)
+	}
+
+	cfg.RevocationStore = getEnv("QRAP_REVOCATION_STORE", "postgres")
+	if cfg.RevocationStore != "postgres" && cfg.RevocationStore != "memory" {
+		return nil, fmt.Errorf("QRAP_REVOCATION_STORE must be postgres or memory, got %q", cfg.RevocationStore)
+	}
+
+	if cfg.IdempotencyTTL, err = getEnvDuration("QRAP_IDEMPOTENCY_TTL", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.RestoreWindow, err = getEnvDuration("QRAP_RESTORE_WINDOW", 30*24*time.Hour); err != nil {
+		return nil, err
+	}
+	cfg.CursorSecret = getEnv("QRAP_CURSOR_SECRET", "")
+
+	if cfg.AnalyzerAllowNetworks, err = getEnvPrefixes("QRAP_ANALYZER_ALLOW_NETWORKS"); err != nil {
+		return nil, err
+	}
+	if cfg.PluginMemoryMB, err = getEnvInt("QRAP_PLUGIN_MEMORY_MB", 512); err != nil {
+		return nil, err
+	}
+	if cfg.PluginCPUSecs, err = getEnvInt("QRAP_PLUGIN_CPU_SECONDS", 60); err != nil {
+		return nil, err
+	}
+
+	if cfg.ScanWorkers, err = getEnvInt("QRAP_SCAN_WORKERS", 16); err != nil {
+		return nil, err
+	}
+	if cfg.ScanPerHost, err = getEnvInt("QRAP_SCAN_PER_HOST", 2); err != nil {
+		return nil, err
+	}
+	if cfg.ScanRate, err = getEnvInt("QRAP_SCAN_RATE", 0); err != nil {
+		return nil, err
+	}
+	if cfg.ScanRetries, err = getEnvInt("QRAP_SCAN_RETRIES", 2); err != nil {
+		return nil, err
+	}
+	if cfg.ScanJitter, err = getEnvDuration("QRAP_SCAN_JITTER", 250*time.Millisecond); err != nil {
+		return nil, err
+	}
+
 	// Parse API keys (comma-separated, format: key:subject:role)
 	if apiKeysStr := getEnv("QUANTUN_API_KEYS", ""); apiKeysStr != "" {
 		cfg.APIKeys = strings.Split(apiKeysStr, ",")
@@ -47,12 +260,89 @@ func Load() (*Config, error) {
 		cfg.CORSOrigins = strings.Split(corsStr, ",")
 	}
 
+	cfg.AuthDisabled = getEnv("QUANTUN_AUTH_DISABLED", "") == "true"
+	if cfg.AuthDisabled && cfg.hasAuthSource() {
+		return nil, fmt.Errorf("QUANTUN_AUTH_DISABLED cannot be combined with configured credentials")
+	}
+
 	return cfg, nil
 }
 
+// TLSEnabled reports whether the server should serve HTTPS.
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != ""
+}
+
+// AuthEnabled reports whether requests must authenticate. It is true unless
+// QUANTUN_AUTH_DISABLED=true: with no credentials configured, only keys in
+// the api_keys table are accepted, so a missing setting fails closed.
+func (c *Config) AuthEnabled() bool {
+	return !c.AuthDisabled
+}
+
+// hasAuthSource reports whether any authentication method besides the
+// api_keys table is configured.
+func (c *Config) hasAuthSource() bool {
+	return c.JWTSecret != "" || c.JWKSURL != "" || c.JWTPublicKeys != "" || len(c.APIKeys) > 0 || c.OIDCIssuer != "" || len(c.ClientCertMappings) > 0
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s: must be a non-negative integer", key)
+	}
+	return n, nil
+}
+
+// getEnvPrefixes reads a comma-separated list of CIDR prefixes or single
+// IP addresses.
+func getEnvPrefixes(key string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, v := range strings.Split(getEnv(key, ""), ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		p, err := ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		prefixes = append(prefixes, p)
+	}
+	return prefixes, nil
+}
+
+// ParsePrefix parses a CIDR prefix such as 10.0.0.0/8, or a single IP
+// address as a prefix covering just that address.
+func ParsePrefix(s string) (netip.Prefix, error) {
+	if p, err := netip.ParsePrefix(s); err == nil {
+		return p.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%q is not an IP address or CIDR prefix", s)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
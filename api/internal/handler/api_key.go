@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/quantun-opensource/qrap/api/internal/authz"
+	"github.com/quantun-opensource/qrap/api/internal/service"
+	"github.com/quantun-opensource/qrap/api/internal/validate"
+	"github.com/quantun-opensource/qrap/api/model"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+type APIKeyHandler struct {
+	svc    *service.APIKeyService
+	logger *zap.Logger
+}
+
+func NewAPIKeyHandler(svc *service.APIKeyService, logger *zap.Logger) *APIKeyHandler {
+	return &APIKeyHandler{svc: svc, logger: logger}
+}
+
+func (h *APIKeyHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.With(authz.Require(authz.APIKeysManage)).Post("/", h.Create)
+	r.With(authz.Require(authz.APIKeysManage)).Get("/", h.List)
+	r.With(authz.Require(authz.APIKeysManage)).Post("/{id}/rotate", h.Rotate)
+	r.With(authz.Require(authz.APIKeysManage)).Delete("/{id}", h.Revoke)
+	return r
+}
+
+// callerAccess returns the caller's role and effective permissions. With
+// authentication disabled the caller is treated as an admin.
+func callerAccess(r *http.Request) (string, []authz.Permission) {
+	if qmw.AuthMethodFromContext(r.Context()) == "" {
+		return authz.RoleAdmin, authz.Permissions(authz.RoleAdmin)
+	}
+	role := qmw.RoleFromContext(r.Context())
+	return role, authz.Effective(role, qmw.ScopesFromContext(r.Context()))
+}
+
+// keyOwner returns the subject whose keys the caller may manage, or "" for
+// an admin, who may manage every key.
+func keyOwner(r *http.Request) string {
+	if role, _ := callerAccess(r); role == authz.RoleAdmin {
+		return ""
+	}
+	return actorFromRequest(r)
+}
+
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateAPIKeyRequest
+	callerRole, callerPerms := callerAccess(r)
+	if !decodeRequest(w, r, &req, func(v *validate.Validator) {
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			v.Add("expires_at", "must be in the future")
+		}
+		if req.Role == "" {
+			req.Role = callerRole
+		}
+		if !authz.ValidRole(req.Role) {
+			return // reported by the role's enum rule
+		}
+		for i, scope := range req.Scopes {
+			if !authz.Allowed(req.Role, authz.Permission(scope)) {
+				v.Addf(fmt.Sprintf("scopes[%d]", i), "%q is not granted by role %s", scope, req.Role)
+			}
+		}
+	}) {
+		return
+	}
+	if !authz.Covers(callerPerms, authz.Effective(req.Role, req.Scopes)) {
+		writeError(w, r, http.StatusForbidden, "cannot issue a key with permissions beyond your own")
+		return
+	}
+
+	subject := actorFromRequest(r)
+	key, secret, err := h.svc.Create(r.Context(), subject, subject, &req)
+	if err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to create API key")
+		return
+	}
+	writeJSON(w, http.StatusCreated, model.APIKeySecretResponse{APIKeyResponse: key.ToResponse(), Key: secret})
+}
+
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.svc.List(r.Context(), keyOwner(r))
+	if err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to list API keys")
+		return
+	}
+
+	resp := model.APIKeyListResponse{APIKeys: []model.APIKeyResponse{}}
+	for _, k := range keys {
+		resp.APIKeys = append(resp.APIKeys, k.ToResponse())
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *APIKeyHandler) Rotate(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid API key ID")
+		return
+	}
+
+	// The new secret grants the key's permissions, so the caller must hold
+	// them all, as for Create.
+	owner := keyOwner(r)
+	target, err := h.svc.Get(r.Context(), id, owner)
+	if err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to rotate API key")
+		return
+	}
+	if _, callerPerms := callerAccess(r); !authz.Covers(callerPerms, authz.Effective(target.Role, target.Scopes)) {
+		writeError(w, r, http.StatusForbidden, "cannot rotate a key with permissions beyond your own")
+		return
+	}
+
+	key, secret, err := h.svc.Rotate(r.Context(), id, owner)
+	if err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to rotate API key")
+		return
+	}
+	writeJSON(w, http.StatusOK, model.APIKeySecretResponse{APIKeyResponse: key.ToResponse(), Key: secret})
+}
+
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid API key ID")
+		return
+	}
+
+	if err := h.svc.Revoke(r.Context(), id, keyOwner(r)); err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to revoke API key")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
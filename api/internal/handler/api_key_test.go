@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/quantun-opensource/qrap/api/internal/authz"
+	"github.com/quantun-opensource/qrap/api/internal/repository"
+	"github.com/quantun-opensource/qrap/api/internal/service"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+func TestAPIKeyCreate_Validation(t *testing.T) {
+	h := NewAPIKeyHandler(nil, zap.NewNop())
+	scoped := func(r *http.Request, scopes ...string) *http.Request {
+		return r.WithContext(context.WithValue(r.Context(), qmw.ContextKeyScopes, scopes))
+	}
+
+	cases := []struct {
+		name   string
+		role   string
+		scopes []string
+		body   string
+		want   int
+	}{
+		{"missing name", authz.RoleAdmin, nil, `{}`, http.StatusBadRequest},
+		{"unknown role", authz.RoleAdmin, nil, `{"name":"ci","role":"root"}`, http.StatusBadRequest},
+		{"role escalation", authz.RoleAnalyst, nil, `{"name":"ci","role":"admin"}`, http.StatusForbidden},
+		{"scope outside role", authz.RoleAdmin, nil, `{"name":"ci","role":"viewer","scopes":["assessments:run"]}`, http.StatusBadRequest},
+		{"empty scopes", authz.RoleAdmin, nil, `{"name":"ci","scopes":[]}`, http.StatusBadRequest},
+		{"scoped caller widening", authz.RoleAdmin, []string{"api_keys:manage"}, `{"name":"ci","role":"viewer"}`, http.StatusForbidden},
+		{"expired", authz.RoleAdmin, nil, `{"name":"ci","expires_at":"2020-01-01T00:00:00Z"}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		req := asRole(httptest.NewRequest("POST", "/", strings.NewReader(tc.body)), tc.role)
+		if tc.scopes != nil {
+			req = scoped(req, tc.scopes...)
+		}
+		rec := httptest.NewRecorder()
+		h.Routes().ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d (%s)", tc.name, tc.want, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	pool := testPool(t)
+	repo := repository.NewAPIKeyRepository(pool)
+	h := NewAPIKeyHandler(service.NewAPIKeyService(repo, zap.NewNop()), zap.NewNop()).Routes()
+	ctx := context.Background()
+
+	call := func(subject, role, method, path, body string, out interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(context.WithValue(asRole(req, role).Context(), qmw.ContextKeySubject, subject))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if out != nil && rec.Code < 300 {
+			json.NewDecoder(rec.Body).Decode(out)
+		}
+		return rec.Code
+	}
+
+	var created struct {
+		ID, Key, Role string
+		Scopes        []string
+	}
+	if code := call("alice", authz.RoleAssessor, "POST", "/", `{"name":"ci","role":"viewer","scopes":["findings:read"]}`, &created); code != http.StatusCreated {
+		t.Fatalf("create: %d", code)
+	}
+	if !strings.HasPrefix(created.Key, "qrap_") || created.Role != "viewer" {
+		t.Fatalf("created = %+v", created)
+	}
+
+	entry, err := repo.LookupAPIKey(ctx, created.Key)
+	if err != nil || entry == nil || entry.Subject != "alice" || entry.Role != "viewer" || len(entry.Scopes) != 1 {
+		t.Fatalf("lookup after create: %+v, %v", entry, err)
+	}
+	if entry, _ := repo.LookupAPIKey(ctx, created.Key+"x"); entry != nil {
+		t.Error("tampered key accepted")
+	}
+
+	var list struct {
+		APIKeys []struct {
+			ID, Prefix string
+			Key        string
+			LastUsedAt *string `json:"last_used_at"`
+		} `json:"api_keys"`
+	}
+	if code := call("alice", authz.RoleAssessor, "GET", "/", "", &list); code != http.StatusOK || len(list.APIKeys) != 1 {
+		t.Fatalf("list: %d %+v", code, list)
+	}
+	if list.APIKeys[0].Key != "" || list.APIKeys[0].LastUsedAt == nil {
+		t.Errorf("listed key = %+v", list.APIKeys[0])
+	}
+	if code := call("bob", authz.RoleAssessor, "GET", "/", "", &list); code != http.StatusOK || len(list.APIKeys) != 0 {
+		t.Errorf("bob sees alice's keys: %+v", list)
+	}
+	if code := call("bob", authz.RoleAssessor, "DELETE", "/"+created.ID, "", nil); code != http.StatusNotFound {
+		t.Errorf("bob revoking alice's key: expected 404, got %d", code)
+	}
+
+	var rotated struct{ ID, Key string }
+	if code := call("alice", authz.RoleAssessor, "POST", "/"+created.ID+"/rotate", "", &rotated); code != http.StatusOK || rotated.ID != created.ID || rotated.Key == created.Key {
+		t.Fatalf("rotate: %d %+v", code, rotated)
+	}
+	if entry, _ := repo.LookupAPIKey(ctx, created.Key); entry != nil {
+		t.Error("old secret still valid after rotation")
+	}
+	if entry, _ := repo.LookupAPIKey(ctx, rotated.Key); entry == nil {
+		t.Error("rotated secret not valid")
+	}
+
+	if code := call("alice", authz.RoleAssessor, "DELETE", "/"+created.ID, "", nil); code != http.StatusNoContent {
+		t.Fatalf("revoke: %d", code)
+	}
+	if entry, _ := repo.LookupAPIKey(ctx, rotated.Key); entry != nil {
+		t.Error("revoked key still valid")
+	}
+	if code := call("alice", authz.RoleAssessor, "POST", "/"+created.ID+"/rotate", "", nil); code != http.StatusNotFound {
+		t.Errorf("rotating a revoked key: expected 404, got %d", code)
+	}
+}
+
+// TestAPIKeyRotate_Escalation checks that rotating a key, which returns its
+// new secret, needs every permission the key grants.
+func TestAPIKeyRotate_Escalation(t *testing.T) {
+	pool := testPool(t)
+	h := NewAPIKeyHandler(service.NewAPIKeyService(repository.NewAPIKeyRepository(pool), zap.NewNop()), zap.NewNop()).Routes()
+
+	call := func(subject, role string, scopes []string, method, path, body string, out interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		ctx := context.WithValue(asRole(req, role).Context(), qmw.ContextKeySubject, subject)
+		if scopes != nil {
+			ctx = context.WithValue(ctx, qmw.ContextKeyScopes, scopes)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req.WithContext(ctx))
+		if out != nil && rec.Code < 300 {
+			json.NewDecoder(rec.Body).Decode(out)
+		}
+		return rec.Code
+	}
+
+	var full struct{ ID string }
+	if code := call("alice", authz.RoleAssessor, nil, "POST", "/", `{"name":"full"}`, &full); code != http.StatusCreated {
+		t.Fatalf("create: %d", code)
+	}
+	manageOnly := []string{string(authz.APIKeysManage)}
+	// A key of alice's scoped down to api_keys:manage cannot obtain the
+	// secret of her full-role key.
+	if code := call("alice", authz.RoleAssessor, manageOnly, "POST", "/"+full.ID+"/rotate", "", nil); code != http.StatusForbidden {
+		t.Errorf("scoped key rotating its subject's full key: expected 403, got %d", code)
+	}
+	// Neither can a scoped-down admin key, which reaches every subject's keys.
+	if code := call("root", authz.RoleAdmin, manageOnly, "POST", "/"+full.ID+"/rotate", "", nil); code != http.StatusForbidden {
+		t.Errorf("scoped admin key rotating another subject's key: expected 403, got %d", code)
+	}
+	if code := call("alice", authz.RoleAssessor, nil, "POST", "/"+full.ID+"/rotate", "", nil); code != http.StatusOK {
+		t.Errorf("rotating with the key's permissions: expected 200, got %d", code)
+	}
+}
@@ -1,48 +1,50 @@
 package handler
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
-	"github.com/quantun-opensource/qrap/api/internal/model"
+	"github.com/quantun-opensource/qrap/api/internal/authz"
 	"github.com/quantun-opensource/qrap/api/internal/service"
+	"github.com/quantun-opensource/qrap/api/internal/validate"
+	"github.com/quantun-opensource/qrap/api/model"
 	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
 )
 
 type AssessmentHandler struct {
-	svc    *service.AssessmentService
-	logger *zap.Logger
+	svc     *service.AssessmentService
+	cursors *qmw.CursorCodec
+	logger  *zap.Logger
 }
 
-func NewAssessmentHandler(svc *service.AssessmentService, logger *zap.Logger) *AssessmentHandler {
-	return &AssessmentHandler{svc: svc, logger: logger}
+func NewAssessmentHandler(svc *service.AssessmentService, cursors *qmw.CursorCodec, logger *zap.Logger) *AssessmentHandler {
+	return &AssessmentHandler{svc: svc, cursors: cursors, logger: logger}
 }
 
 func (h *AssessmentHandler) Routes() chi.Router {
 	r := chi.NewRouter()
-	r.Post("/", h.Create)
-	r.Get("/", h.List)
-	r.Get("/{id}", h.Get)
-	r.Post("/{id}/run", h.Run)
+	r.With(authz.Require(authz.AssessmentsWrite)).Post("/", h.Create)
+	r.With(authz.Require(authz.AssessmentsRun)).Post("/import", h.Import)
+	r.With(authz.Require(authz.AssessmentsRead)).Get("/", h.List)
+	r.With(authz.Require(authz.AssessmentsRead)).Get("/{id}", h.Get)
+	r.With(authz.Require(authz.AssessmentsWrite)).Patch("/{id}", h.Update)
+	r.With(authz.Require(authz.AssessmentsWrite)).Delete("/{id}", h.Delete)
+	r.With(authz.Require(authz.AssessmentsWrite)).Post("/{id}/restore", h.Restore)
+	r.With(authz.Require(authz.AssessmentsRun)).Post("/{id}/run", h.Run)
+	r.With(authz.Require(authz.AssessmentsRead)).Get("/{id}/runs", h.ListRuns)
 	return r
 }
 
 func (h *AssessmentHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req model.CreateAssessmentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-	if req.Name == "" || req.OrganizationID == "" {
-		writeError(w, http.StatusBadRequest, "name and organization_id are required")
-		return
-	}
-	if len(req.Name) > maxNameLength {
-		writeError(w, http.StatusBadRequest, "name exceeds maximum length")
+	if !decodeRequest(w, r, &req, func(v *validate.Validator) {
+		h.checkAnalyzers(v, "enabled_analyzers", req.EnabledAnalyzers)
+		h.checkAnalyzers(v, "disabled_analyzers", req.DisabledAnalyzers)
+	}) {
 		return
 	}
 	if req.CreatedBy == "" {
@@ -51,54 +53,154 @@ func (h *AssessmentHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	assessment, err := h.svc.Create(r.Context(), &req)
 	if err != nil {
-		h.logger.Error("failed to create assessment", zap.Error(err))
-		writeError(w, http.StatusInternalServerError, "failed to create assessment")
+		writeServiceError(w, r, h.logger, err, "failed to create assessment")
 		return
 	}
+	w.Header().Set("ETag", etag(assessment.UpdatedAt))
 	writeJSON(w, http.StatusCreated, assessment.ToResponse())
 }
 
+// Import stores the scan bundle of an offline scan (qrap scan) as a
+// completed assessment. Importing results stands in for running the
+// assessment, so it needs the same permission.
+func (h *AssessmentHandler) Import(w http.ResponseWriter, r *http.Request) {
+	var req model.ScanBundle
+	if !decodeRequest(w, r, &req, func(v *validate.Validator) {
+		service.CheckScanBundle(v, &req)
+	}) {
+		return
+	}
+
+	assessment, summary, err := h.svc.Import(r.Context(), &req, actorFromRequest(r))
+	if err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to import scan bundle")
+		return
+	}
+	resp := assessment.ToResponse()
+	resp.Summary = summary
+	w.Header().Set("ETag", etag(assessment.UpdatedAt))
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// checkAnalyzers records an error for each of names that is not a
+// registered analyzer.
+func (h *AssessmentHandler) checkAnalyzers(v *validate.Validator, field string, names []string) {
+	for i, name := range names {
+		if !h.svc.HasAnalyzer(name) {
+			v.Addf(fmt.Sprintf("%s[%d]", field, i), "unknown analyzer %q", name)
+		}
+	}
+}
+
 func (h *AssessmentHandler) Get(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid assessment ID")
+		writeError(w, r, http.StatusBadRequest, "invalid assessment ID")
 		return
 	}
 
-	resp, err := h.svc.GetWithSummary(r.Context(), id)
+	assessment, summary, err := h.svc.GetWithSummary(r.Context(), id)
 	if err != nil {
-		writeError(w, http.StatusNotFound, "assessment not found")
+		writeServiceError(w, r, h.logger, err, "failed to get assessment")
 		return
 	}
+	resp := assessment.ToResponse()
+	resp.Summary = summary
+	w.Header().Set("ETag", etag(assessment.UpdatedAt))
 	writeJSON(w, http.StatusOK, resp)
 }
 
+func (h *AssessmentHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid assessment ID")
+		return
+	}
+	var req model.UpdateAssessmentRequest
+	if !decodeRequest(w, r, &req, func(v *validate.Validator) {
+		if req.Name == nil && req.TargetAssets == nil {
+			v.Add("", "name or target_assets is required")
+		}
+	}) {
+		return
+	}
+
+	assessment, err := h.svc.Update(r.Context(), id, &req, actorFromRequest(r), ifMatch(r))
+	if err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to update assessment")
+		return
+	}
+	w.Header().Set("ETag", etag(assessment.UpdatedAt))
+	writeJSON(w, http.StatusOK, assessment.ToResponse())
+}
+
+func (h *AssessmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid assessment ID")
+		return
+	}
+	if err := h.svc.Delete(r.Context(), id, actorFromRequest(r), ifMatch(r)); err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to delete assessment")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AssessmentHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid assessment ID")
+		return
+	}
+	assessment, err := h.svc.Restore(r.Context(), id, actorFromRequest(r))
+	if err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to restore assessment")
+		return
+	}
+	w.Header().Set("ETag", etag(assessment.UpdatedAt))
+	writeJSON(w, http.StatusOK, assessment.ToResponse())
+}
+
 func (h *AssessmentHandler) List(w http.ResponseWriter, r *http.Request) {
 	pg := qmw.ParsePagination(r)
-	status := r.URL.Query().Get("status")
-
+	page, err := listPage(h.cursors, assessmentsList, pg)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid cursor")
+		return
+	}
+	q := r.URL.Query()
+	status, orgStr := q.Get("status"), q.Get("organization_id")
+	var v validate.Validator
+	if status != "" {
+		v.Enum("status", status, "assessment_status")
+	}
+	if orgStr != "" {
+		v.UUID("organization_id", orgStr)
+	}
+	if err := v.Err(); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
 	var orgID *uuid.UUID
-	if orgStr := r.URL.Query().Get("organization_id"); orgStr != "" {
-		parsed, err := uuid.Parse(orgStr)
-		if err != nil {
-			writeError(w, http.StatusBadRequest, "invalid organization_id")
-			return
-		}
+	if orgStr != "" {
+		parsed := uuid.MustParse(orgStr)
 		orgID = &parsed
 	}
 
-	assessments, total, err := h.svc.List(r.Context(), orgID, status, pg.Offset, pg.Limit)
+	assessments, info, err := h.svc.List(r.Context(), orgID, status, page)
 	if err != nil {
-		h.logger.Error("failed to list assessments", zap.Error(err))
-		writeError(w, http.StatusInternalServerError, "failed to list assessments")
+		writeServiceError(w, r, h.logger, err, "failed to list assessments")
 		return
 	}
 
 	resp := model.AssessmentListResponse{
-		TotalCount: total,
-		Offset:     pg.Offset,
-		Limit:      pg.Limit,
+		Assessments: []model.AssessmentResponse{},
+		TotalCount:  info.Total,
+		Offset:      page.Offset,
+		Limit:       pg.Limit,
 	}
+	resp.NextCursor, resp.PrevCursor = pageCursors(h.cursors, assessmentsList, info)
 	for _, a := range assessments {
 		resp.Assessments = append(resp.Assessments, a.ToResponse())
 	}
@@ -108,15 +210,34 @@ func (h *AssessmentHandler) List(w http.ResponseWriter, r *http.Request) {
 func (h *AssessmentHandler) Run(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid assessment ID")
+		writeError(w, r, http.StatusBadRequest, "invalid assessment ID")
 		return
 	}
 
 	assessment, err := h.svc.Run(r.Context(), id)
 	if err != nil {
-		h.logger.Error("failed to run assessment", zap.Error(err))
-		writeError(w, http.StatusInternalServerError, "failed to run assessment")
+		writeServiceError(w, r, h.logger, err, "failed to run assessment")
 		return
 	}
 	writeJSON(w, http.StatusOK, assessment.ToResponse())
 }
+
+func (h *AssessmentHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid assessment ID")
+		return
+	}
+
+	runs, err := h.svc.ListRuns(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to list assessment runs")
+		return
+	}
+
+	resp := model.AssessmentRunListResponse{Runs: []model.AssessmentRunResponse{}}
+	for _, run := range runs {
+		resp.Runs = append(resp.Runs, run.ToResponse())
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/quantun-opensource/qrap/api/internal/oidc"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+const (
+	// loginStateCookie carries the sealed oidc.LoginState between login and callback.
+	loginStateCookie  = "qrap_oidc"
+	loginStatePurpose = "oidc-login"
+	loginStateTTL     = 10 * time.Minute
+)
+
+// AuthHandler serves the browser login flow: OIDC authorization code with
+// PKCE, ending in a session cookie accepted by qmw.Auth.
+type AuthHandler struct {
+	provider    *oidc.Provider
+	sessions    *qmw.SessionManager
+	revocations qmw.RevocationStore
+	csrf        *http.CrossOriginProtection
+	logger      *zap.Logger
+}
+
+// NewAuthHandler returns the login handler. revocations may be nil, in which
+// case a logged-out session cookie stays valid until it expires.
+func NewAuthHandler(provider *oidc.Provider, sessions *qmw.SessionManager, revocations qmw.RevocationStore, logger *zap.Logger) *AuthHandler {
+	return &AuthHandler{provider: provider, sessions: sessions, revocations: revocations, csrf: http.NewCrossOriginProtection(), logger: logger}
+}
+
+func (h *AuthHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/login", h.Login)
+	r.Get("/callback", h.Callback)
+	r.Post("/logout", h.Logout)
+	return r
+}
+
+// Login starts a login and redirects to the provider. An optional
+// return_to query parameter (a path on this site) is where the browser lands
+// after the callback.
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	state, err := oidc.NewLoginState(safeReturnTo(r.URL.Query().Get("return_to")))
+	if err != nil {
+		h.logger.Error("failed to create login state", zap.Error(err))
+		writeError(w, r, http.StatusInternalServerError, "failed to start login")
+		return
+	}
+	sealed, err := h.sessions.Seal(loginStatePurpose, state)
+	if err != nil {
+		h.logger.Error("failed to seal login state", zap.Error(err))
+		writeError(w, r, http.StatusInternalServerError, "failed to start login")
+		return
+	}
+	// Scope the cookie to the callback so it is not sent anywhere else.
+	callbackPath := path.Join(path.Dir(r.URL.Path), "callback")
+	http.SetCookie(w, h.sessions.Cookie(loginStateCookie, sealed, callbackPath, int(loginStateTTL.Seconds())))
+	http.Redirect(w, r, h.provider.AuthCodeURL(state), http.StatusFound)
+}
+
+// Callback completes a login: it checks state, redeems the code with the
+// PKCE verifier, verifies the ID token and issues the session cookie.
+func (h *AuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, h.sessions.Cookie(loginStateCookie, "", r.URL.Path, -1))
+
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		h.logger.Info("OIDC provider returned error", zap.String("error", e), zap.String("description", q.Get("error_description")))
+		writeError(w, r, http.StatusUnauthorized, "login failed: "+e)
+		return
+	}
+
+	c, err := r.Cookie(loginStateCookie)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "login state missing or expired")
+		return
+	}
+	var state oidc.LoginState
+	if err := h.sessions.Open(loginStatePurpose, c.Value, &state); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid login state")
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(state.State)) != 1 {
+		writeError(w, r, http.StatusBadRequest, "login state mismatch")
+		return
+	}
+	if q.Get("code") == "" {
+		writeError(w, r, http.StatusBadRequest, "missing authorization code")
+		return
+	}
+
+	id, err := h.provider.Exchange(r.Context(), q.Get("code"), &state)
+	if err != nil {
+		h.logger.Warn("OIDC code exchange failed", zap.Error(err))
+		writeError(w, r, http.StatusUnauthorized, "login failed")
+		return
+	}
+	if err := h.sessions.Issue(w, qmw.Session{Subject: id.Subject, Role: id.Role, Email: id.Email}); err != nil {
+		h.logger.Error("failed to issue session", zap.Error(err))
+		writeError(w, r, http.StatusInternalServerError, "failed to create session")
+		return
+	}
+	h.logger.Info("user signed in", zap.String("subject", id.Subject), zap.String("role", id.Role))
+
+	returnTo := state.ReturnTo
+	if returnTo == "" {
+		returnTo = "/"
+	}
+	http.Redirect(w, r, returnTo, http.StatusFound)
+}
+
+// Logout revokes and clears the session cookie and, if the provider supports
+// it, continues to the provider's logout endpoint.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if err := h.csrf.Check(r); err != nil {
+		writeError(w, r, http.StatusForbidden, "cross-origin request rejected")
+		return
+	}
+	if sess, err := h.sessions.FromRequest(r); err == nil && h.revocations != nil && sess.ID != "" {
+		if err := h.revocations.RevokeToken(r.Context(), sess.ID, time.Unix(sess.ExpiresAt, 0)); err != nil {
+			h.logger.Error("failed to revoke session", zap.Error(err))
+			writeError(w, r, http.StatusInternalServerError, "failed to sign out")
+			return
+		}
+	}
+	h.sessions.Clear(w)
+	to := h.provider.EndSessionURL()
+	if to == "" {
+		to = "/"
+	}
+	http.Redirect(w, r, to, http.StatusSeeOther)
+}
+
+// safeReturnTo accepts only local absolute paths, so the login flow cannot
+// be used as an open redirect. Browsers drop control characters and treat
+// a backslash as a slash, so "/\t/evil.example" would lead off-site: p is
+// refused if it holds one of those or a space, or its decoded path holds
+// one of those.
+func safeReturnTo(p string) string {
+	if strings.ContainsRune(p, ' ') || strings.IndexFunc(p, unsafeInRedirect) >= 0 {
+		return ""
+	}
+	u, err := url.Parse(p)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.User != nil || u.Opaque != "" {
+		return ""
+	}
+	if !strings.HasPrefix(u.Path, "/") || strings.HasPrefix(u.Path, "//") || strings.IndexFunc(u.Path, unsafeInRedirect) >= 0 {
+		return ""
+	}
+	return p
+}
+
+// unsafeInRedirect reports whether r is an ASCII control character or a
+// backslash.
+func unsafeInRedirect(r rune) bool {
+	return r < ' ' || r == 0x7f || r == '\\'
+}
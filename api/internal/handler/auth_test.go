@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/quantun-opensource/qrap/api/internal/oidc"
+	"github.com/quantun-opensource/qrap/api/internal/oidc/oidctest"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+// loginTestServer runs the API's auth routes and one protected endpoint
+// against a stand-in OIDC provider, wired the way cmd/server does it.
+func loginTestServer(t *testing.T) (*httptest.Server, *oidctest.Provider) {
+	t.Helper()
+	idp := oidctest.New("qrap-dashboard", "client-secret")
+	t.Cleanup(idp.Close)
+
+	var router http.Handler
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		router.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	provider, err := oidc.NewProvider(context.Background(), oidc.Config{
+		IssuerURL:    idp.Issuer(),
+		ClientID:     "qrap-dashboard",
+		ClientSecret: "client-secret",
+		RedirectURL:  srv.URL + "/api/v1/auth/callback",
+		RoleClaim:    "role",
+		DefaultRole:  "viewer",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(provider.Stop)
+	sessions, err := qmw.NewSessionManager(qmw.SessionConfig{Secret: strings.Repeat("s", 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revocations := qmw.NewMemoryRevocationStore()
+
+	r := chi.NewRouter()
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "dashboard") })
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(qmw.Auth(qmw.AuthConfig{
+			Sessions:    sessions,
+			Revocations: revocations,
+			SkipPaths:   []string{"/api/v1/auth/login", "/api/v1/auth/callback", "/api/v1/auth/logout"},
+		}))
+		r.Mount("/auth", NewAuthHandler(provider, sessions, revocations, zap.NewNop()).Routes())
+		r.Get("/whoami", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, qmw.SubjectFromContext(r.Context())+" "+qmw.RoleFromContext(r.Context()))
+		})
+	})
+	router = r
+	return srv, idp
+}
+
+func browser(t *testing.T, srv *httptest.Server) *http.Client {
+	t.Helper()
+	jar, _ := cookiejar.New(nil)
+	c := srv.Client()
+	c.Jar = jar
+	return c
+}
+
+func TestAuthHandler_LoginFlow(t *testing.T) {
+	srv, idp := loginTestServer(t)
+	idp.SetClaims(map[string]interface{}{"sub": "alice", "email": "alice@example.com", "role": "analyst"})
+	client := browser(t, srv)
+
+	resp, err := client.Get(srv.URL + "/api/v1/whoami")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("before login: expected 401, got %d", resp.StatusCode)
+	}
+
+	// login -> provider authorize -> callback -> return_to, all via redirects.
+	resp, err = client.Get(srv.URL + "/api/v1/auth/login?return_to=/api/v1/whoami")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "alice analyst" {
+		t.Fatalf("after login: %d %q", resp.StatusCode, body)
+	}
+
+	apiURL, _ := url.Parse(srv.URL + "/api/v1/")
+	var session *http.Cookie
+	for _, c := range client.Jar.Cookies(apiURL) {
+		if c.Name == "qrap_session" {
+			session = c
+		}
+		if c.Name == "qrap_oidc" {
+			t.Error("login state cookie sent outside the callback path")
+		}
+	}
+	if session == nil {
+		t.Fatal("no session cookie after login")
+	}
+
+	// Logout clears the session and continues to the provider's logout.
+	req, _ := http.NewRequest("POST", srv.URL+"/api/v1/auth/logout", nil)
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSeeOther || !strings.HasPrefix(resp.Header.Get("Location"), idp.Issuer()+"/logout") {
+		t.Errorf("logout: %d to %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	resp, _ = client.Get(srv.URL + "/api/v1/whoami")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("after logout: expected 401, got %d", resp.StatusCode)
+	}
+
+	// A copy of the cookie taken before logout is revoked too.
+	req, _ = http.NewRequest("GET", srv.URL+"/api/v1/whoami", nil)
+	req.AddCookie(session)
+	resp, err = srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("replayed session after logout: expected 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestAuthHandler_CallbackRejectsForgedState(t *testing.T) {
+	srv, _ := loginTestServer(t)
+	client := browser(t, srv)
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	// Start a login but never visit the provider.
+	resp, err := client.Get(srv.URL + "/api/v1/auth/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	authorize, _ := url.Parse(resp.Header.Get("Location"))
+
+	cases := map[string]string{
+		"state mismatch": "/api/v1/auth/callback?code=abc&state=attacker",
+		"unknown code":   "/api/v1/auth/callback?code=abc&state=" + url.QueryEscape(authorize.Query().Get("state")),
+	}
+	for name, path := range cases {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest && resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s: expected 400/401, got %d", name, resp.StatusCode)
+		}
+		for _, c := range resp.Cookies() {
+			if c.Name == "qrap_session" && c.MaxAge > 0 {
+				t.Errorf("%s: session issued", name)
+			}
+		}
+	}
+
+	// Without the state cookie (e.g. a callback URL replayed in another browser).
+	resp, _ = browser(t, srv).Get(srv.URL + cases["unknown code"])
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("missing state cookie: expected 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestSafeReturnTo(t *testing.T) {
+	cases := map[string]string{
+		"/findings?risk=HIGH":   "/findings?risk=HIGH",
+		"":                      "",
+		"https://evil.example/": "",
+		"//evil.example/":       "",
+		"/\\evil.example/":      "",
+		"/\t/evil.example/":     "",
+		"/%09/evil.example/":    "",
+		"/%5C/evil.example/":    "",
+		"/%2F/evil.example/":    "",
+		"/ /evil.example/":      "",
+		"/\x7f/evil.example/":   "",
+		"https:/evil.example/":  "",
+		"/assessments/a%20b":    "/assessments/a%20b",
+		"findings":              "",
+	}
+	for in, want := range cases {
+		if got := safeReturnTo(in); got != want {
+			t.Errorf("safeReturnTo(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/quantun-opensource/qrap/api/internal/service"
+	"github.com/quantun-opensource/qrap/api/internal/validate"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+// writeError answers r with an RFC 7807 problem carrying status and detail.
+func writeError(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	qmw.WriteProblem(w, status, qmw.NewProblem(r, status, detail))
+}
+
+// writeServiceError answers r with the status for a service error's kind
+// and its client-safe message. Errors of no known kind are logged and
+// reported as a 500 with failure as the detail, so internal details do not
+// reach clients.
+func writeServiceError(w http.ResponseWriter, r *http.Request, logger *zap.Logger, err error, failure string) {
+	var domainErr *service.Error
+	if errors.As(err, &domainErr) {
+		if status := errorStatus(domainErr.Kind); status != 0 {
+			writeError(w, r, status, domainErr.Message)
+			return
+		}
+	}
+	logger.Error(failure, zap.Error(err))
+	writeError(w, r, http.StatusInternalServerError, failure)
+}
+
+// errorStatus returns the HTTP status for a domain error kind, or 0.
+func errorStatus(kind error) int {
+	switch kind {
+	case service.ErrNotFound:
+		return http.StatusNotFound
+	case service.ErrValidation:
+		return http.StatusBadRequest
+	case service.ErrConflict, service.ErrInvalidState:
+		return http.StatusConflict
+	case service.ErrPreconditionFailed:
+		return http.StatusPreconditionFailed
+	}
+	return 0
+}
+
+// decodeRequest decodes r's JSON body into dst, a pointer to a request
+// struct, and validates it; check, if not nil, adds the handler's own
+// rules. On failure it writes a 400 problem listing every field error and
+// returns false.
+func decodeRequest(w http.ResponseWriter, r *http.Request, dst interface{}, check func(v *validate.Validator)) bool {
+	if err := validate.Decode(r.Body, dst); err != nil {
+		writeValidationError(w, r, err)
+		return false
+	}
+	var v validate.Validator
+	v.Struct(dst)
+	if check != nil {
+		check(&v)
+	}
+	if err := v.Err(); err != nil {
+		writeValidationError(w, r, err)
+		return false
+	}
+	return true
+}
+
+// validationProblem is the 400 problem body for invalid requests.
+type validationProblem struct {
+	qmw.Problem
+	Errors validate.Errors `json:"errors"`
+}
+
+// writeValidationError answers r with a 400 problem for err, which holds
+// validate.Errors.
+func writeValidationError(w http.ResponseWriter, r *http.Request, err error) {
+	var errs validate.Errors
+	errors.As(err, &errs)
+	qmw.WriteProblem(w, http.StatusBadRequest, validationProblem{
+		Problem: qmw.NewProblem(r, http.StatusBadRequest, err.Error()),
+		Errors:  errs,
+	})
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/quantun-opensource/qrap/api/internal/service"
+	"github.com/quantun-opensource/qrap/api/internal/validate"
+	"github.com/quantun-opensource/qrap/api/model"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+func TestWriteServiceError(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		detail string
+	}{
+		{&service.Error{Kind: service.ErrNotFound, Message: "assessment not found"}, http.StatusNotFound, "assessment not found"},
+		{&service.Error{Kind: service.ErrValidation, Message: "unknown analyzer: x"}, http.StatusBadRequest, "unknown analyzer: x"},
+		{&service.Error{Kind: service.ErrConflict, Message: "name taken"}, http.StatusConflict, "name taken"},
+		{&service.Error{Kind: service.ErrInvalidState, Message: "assessment is IN_PROGRESS"}, http.StatusConflict, "assessment is IN_PROGRESS"},
+		{&service.Error{Kind: service.ErrPreconditionFailed, Message: "modified"}, http.StatusPreconditionFailed, "modified"},
+		// Wrapping keeps the kind; the cause stays out of the response.
+		{fmt.Errorf("failed to update: %w", &service.Error{Kind: service.ErrConflict, Message: "name taken", Err: errors.New("pq: 23505")}), http.StatusConflict, "name taken"},
+		// Anything else is an internal error whose text is not exposed.
+		{errors.New("connection refused"), http.StatusInternalServerError, "failed to get thing"},
+		{&service.Error{Kind: errors.New("other"), Message: "secret"}, http.StatusInternalServerError, "failed to get thing"},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		writeServiceError(rec, httptest.NewRequest("GET", "/api/v1/things/1", nil), zap.NewNop(), tc.err, "failed to get thing")
+		if rec.Code != tc.status {
+			t.Errorf("%v: status = %d, want %d", tc.err, rec.Code, tc.status)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != qmw.ProblemContentType {
+			t.Errorf("%v: Content-Type = %q", tc.err, ct)
+		}
+		var p qmw.Problem
+		if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+			t.Fatal(err)
+		}
+		if p.Status != tc.status || p.Detail != tc.detail || p.Error != tc.detail || p.Instance != "/api/v1/things/1" {
+			t.Errorf("%v: problem = %+v", tc.err, p)
+		}
+	}
+}
+
+func TestDecodeRequest(t *testing.T) {
+	body := `{"name":"","organization_id":"nope","target_assets":["tls://a.example","bad asset"],"owner":"x"}`
+	rec := httptest.NewRecorder()
+	var req model.CreateAssessmentRequest
+	if decodeRequest(rec, httptest.NewRequest("POST", "/api/v1/assessments", strings.NewReader(body)), &req, nil) {
+		t.Fatal("unknown field accepted")
+	}
+	var p struct {
+		Status int
+		Errors []validate.FieldError
+	}
+	json.NewDecoder(rec.Body).Decode(&p)
+	if rec.Code != http.StatusBadRequest || p.Status != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != "owner" {
+		t.Errorf("unknown field: %d %+v", rec.Code, p)
+	}
+
+	body = `{"name":"","organization_id":"nope","target_assets":["tls://a.example","bad asset"]}`
+	rec = httptest.NewRecorder()
+	req = model.CreateAssessmentRequest{}
+	ok := decodeRequest(rec, httptest.NewRequest("POST", "/api/v1/assessments", strings.NewReader(body)), &req, func(v *validate.Validator) {
+		v.Add("enabled_analyzers[0]", "unknown analyzer")
+	})
+	if ok {
+		t.Fatal("invalid request accepted")
+	}
+	json.NewDecoder(rec.Body).Decode(&p)
+	var got []string
+	for _, e := range p.Errors {
+		got = append(got, e.Field)
+	}
+	if want := []string{"name", "organization_id", "target_assets[1]", "enabled_analyzers[0]"}; !slices.Equal(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
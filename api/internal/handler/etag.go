@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// etag returns the entity tag of a resource version, identified by its
+// updated_at.
+func etag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 10) + `"`
+}
+
+// ifMatch returns the resource versions listed in r's If-Match header. It
+// returns nil, which accepts any version, if the header is absent or "*".
+// Weak and malformed tags never match, so a header holding only those
+// yields an empty, non-nil list.
+func ifMatch(r *http.Request) []time.Time {
+	header := strings.TrimSpace(strings.Join(r.Header.Values("If-Match"), ","))
+	if header == "" || header == "*" {
+		return nil
+	}
+	versions := []time.Time{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		micros, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, time.UnixMicro(micros).UTC())
+	}
+	return versions
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIfMatch(t *testing.T) {
+	v1 := time.Date(2026, 3, 1, 12, 0, 0, 123456000, time.UTC)
+	v2 := v1.Add(time.Second)
+
+	cases := []struct {
+		name   string
+		header []string
+		want   []time.Time
+	}{
+		{"absent", nil, nil},
+		{"any", []string{"*"}, nil},
+		{"one", []string{etag(v1)}, []time.Time{v1}},
+		{"list", []string{etag(v1) + ", " + etag(v2)}, []time.Time{v1, v2}},
+		{"repeated", []string{etag(v1), etag(v2)}, []time.Time{v1, v2}},
+		{"weak never matches", []string{"W/" + etag(v1)}, []time.Time{}},
+		{"malformed", []string{`"abc", 123`}, []time.Time{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("PATCH", "/", nil)
+			for _, h := range tc.header {
+				r.Header.Add("If-Match", h)
+			}
+			got := ifMatch(r)
+			if (got == nil) != (tc.want == nil) || len(got) != len(tc.want) {
+				t.Fatalf("ifMatch = %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tc.want[i]) {
+					t.Errorf("version %d = %v, want %v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
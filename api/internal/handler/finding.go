@@ -2,77 +2,197 @@ package handler
 
 import (
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
-	"github.com/quantun-opensource/qrap/api/internal/model"
+	"github.com/quantun-opensource/qrap/api/internal/authz"
 	"github.com/quantun-opensource/qrap/api/internal/service"
+	"github.com/quantun-opensource/qrap/api/internal/validate"
+	"github.com/quantun-opensource/qrap/api/model"
 	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
 )
 
 type FindingHandler struct {
-	svc    *service.FindingService
-	logger *zap.Logger
+	svc     *service.FindingService
+	cursors *qmw.CursorCodec
+	logger  *zap.Logger
 }
 
-func NewFindingHandler(svc *service.FindingService, logger *zap.Logger) *FindingHandler {
-	return &FindingHandler{svc: svc, logger: logger}
+func NewFindingHandler(svc *service.FindingService, cursors *qmw.CursorCodec, logger *zap.Logger) *FindingHandler {
+	return &FindingHandler{svc: svc, cursors: cursors, logger: logger}
 }
 
 func (h *FindingHandler) Routes() chi.Router {
 	r := chi.NewRouter()
-	r.Get("/{id}", h.Get)
-	r.Get("/", h.List)
+	r.With(authz.Require(authz.FindingsRead)).Get("/{id}", h.Get)
+	r.With(authz.Require(authz.FindingsRead)).Get("/", h.List)
+	r.With(authz.Require(authz.FindingsTriage)).Patch("/{id}", h.UpdateStatus)
 	return r
 }
 
 func (h *FindingHandler) Get(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid finding ID")
+		writeError(w, r, http.StatusBadRequest, "invalid finding ID")
 		return
 	}
 
 	finding, err := h.svc.Get(r.Context(), id)
 	if err != nil {
-		writeError(w, http.StatusNotFound, "finding not found")
+		writeServiceError(w, r, h.logger, err, "failed to get finding")
 		return
 	}
 	writeJSON(w, http.StatusOK, finding.ToResponse())
 }
 
 func (h *FindingHandler) List(w http.ResponseWriter, r *http.Request) {
-	assessmentIDStr := r.URL.Query().Get("assessment_id")
-	if assessmentIDStr == "" {
-		writeError(w, http.StatusBadRequest, "assessment_id is required")
-		return
-	}
-	assessmentID, err := uuid.Parse(assessmentIDStr)
+	filter, err := parseFindingFilter(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid assessment_id")
+		writeValidationError(w, r, err)
 		return
 	}
 
+	// Cursors are bound to the sort order they were issued for.
+	list := findingsList + ":" + filter.Sort
 	pg := qmw.ParsePagination(r)
-	riskLevel := r.URL.Query().Get("risk_level")
-	category := r.URL.Query().Get("category")
+	page, err := listPage(h.cursors, list, pg)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid cursor")
+		return
+	}
 
-	findings, total, err := h.svc.ListByAssessment(r.Context(), assessmentID, riskLevel, category, pg.Offset, pg.Limit)
+	findings, info, err := h.svc.List(r.Context(), filter, page)
 	if err != nil {
-		h.logger.Error("failed to list findings", zap.Error(err))
-		writeError(w, http.StatusInternalServerError, "failed to list findings")
+		writeServiceError(w, r, h.logger, err, "failed to list findings")
 		return
 	}
 
 	resp := model.FindingListResponse{
-		TotalCount: total,
-		Offset:     pg.Offset,
+		Findings:   []model.FindingResponse{},
+		TotalCount: info.Total,
+		Offset:     page.Offset,
 		Limit:      pg.Limit,
 	}
+	resp.NextCursor, resp.PrevCursor = pageCursors(h.cursors, list, info)
 	for _, f := range findings {
 		resp.Findings = append(resp.Findings, f.ToResponse())
 	}
 	writeJSON(w, http.StatusOK, resp)
 }
+
+func (h *FindingHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid finding ID")
+		return
+	}
+	var req model.UpdateFindingStatusRequest
+	if !decodeRequest(w, r, &req, nil) {
+		return
+	}
+
+	finding, err := h.svc.UpdateStatus(r.Context(), id, req.Status)
+	if err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to update finding status")
+		return
+	}
+	writeJSON(w, http.StatusOK, finding.ToResponse())
+}
+
+// maxFilterValues bounds the values of one multi-value filter.
+const maxFilterValues = 50
+
+// parseFindingFilter reads the findings listing's query parameters. Multi-value
+// filters accept comma-separated values, repeated parameters, or both. The
+// error, if any, is a validate.Errors listing every invalid parameter.
+func parseFindingFilter(r *http.Request) (model.FindingFilter, error) {
+	q := r.URL.Query()
+	var (
+		filter model.FindingFilter
+		v      validate.Validator
+	)
+	filter.AssessmentIDs = queryUUIDs(&v, q, "assessment_id")
+	filter.OrganizationIDs = queryUUIDs(&v, q, "organization_id")
+	filter.RiskLevels = queryEnum(&v, q, "risk_level", "risk_level")
+	filter.Categories = queryEnum(&v, q, "category", "finding_category")
+	filter.Statuses = queryEnum(&v, q, "status", "finding_status")
+	filter.Algorithms = queryList(&v, q, "algorithm")
+	filter.Asset = q.Get("asset")
+	if len(filter.Asset) > validate.MaxAssetLength {
+		v.Addf("asset", "must be at most %d characters", validate.MaxAssetLength)
+	}
+	filter.DiscoveredAfter = queryTime(&v, q, "discovered_after")
+	filter.DiscoveredBefore = queryTime(&v, q, "discovered_before")
+	filter.Query = strings.TrimSpace(q.Get("q"))
+	if len(filter.Query) > 256 {
+		v.Add("q", "must be at most 256 characters")
+	}
+	filter.Sort = q.Get("sort")
+	if filter.Sort == "" {
+		filter.Sort = "risk_level"
+	}
+	v.Enum("sort", filter.Sort, "finding_sort")
+	return filter, v.Err()
+}
+
+// queryList returns the comma-separated values of every key parameter.
+func queryList(v *validate.Validator, q url.Values, key string) []string {
+	var values []string
+	for _, param := range q[key] {
+		for _, part := range strings.Split(param, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	if len(values) > maxFilterValues {
+		v.Addf(key, "accepts at most %d values", maxFilterValues)
+		return nil
+	}
+	return values
+}
+
+func queryUUIDs(v *validate.Validator, q url.Values, key string) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, s := range queryList(v, q, key) {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			v.Addf(key, "%q is not a UUID", s)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// queryEnum is queryList for values of the named enum; they are matched
+// case-insensitively and returned in upper case.
+func queryEnum(v *validate.Validator, q url.Values, key, enum string) []string {
+	values := queryList(v, q, key)
+	for i, s := range values {
+		values[i] = strings.ToUpper(s)
+		v.Enum(key, values[i], enum)
+	}
+	return values
+}
+
+// queryTime parses an RFC 3339 timestamp or a YYYY-MM-DD date.
+func queryTime(v *validate.Validator, q url.Values, key string) *time.Time {
+	s := q.Get(key)
+	if s == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, s); err != nil {
+			v.Add(key, "must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+			return nil
+		}
+	}
+	return &t
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/quantun-opensource/qrap/api/internal/repository"
+	"github.com/quantun-opensource/qrap/api/internal/tenant"
+	"github.com/quantun-opensource/qrap/api/internal/validate"
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+func TestParseFindingFilter(t *testing.T) {
+	id := uuid.New()
+	r := httptest.NewRequest("GET", "/?assessment_id="+id.String()+"&risk_level=critical,HIGH&risk_level=low"+
+		"&algorithm=RSA-2048&asset=tls://*.example.com&discovered_after=2026-01-01&q=%20harvest%20&sort=-discovered_at", nil)
+	f, err := parseFindingFilter(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.AssessmentIDs) != 1 || f.AssessmentIDs[0] != id {
+		t.Errorf("assessment IDs = %v", f.AssessmentIDs)
+	}
+	if !slices.Equal(f.RiskLevels, []string{"CRITICAL", "HIGH", "LOW"}) {
+		t.Errorf("risk levels = %v", f.RiskLevels)
+	}
+	if f.DiscoveredAfter == nil || !f.DiscoveredAfter.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("discovered_after = %v", f.DiscoveredAfter)
+	}
+	if f.Query != "harvest" || f.Sort != "-discovered_at" || f.Asset != "tls://*.example.com" {
+		t.Errorf("filter = %+v", f)
+	}
+
+	if f, _ := parseFindingFilter(httptest.NewRequest("GET", "/", nil)); f.Sort != "risk_level" {
+		t.Errorf("default sort = %q", f.Sort)
+	}
+
+	for _, bad := range []string{
+		"risk_level=SEVERE",
+		"category=WEAK_ALGORITHM,TYPO",
+		"status=CLOSED",
+		"assessment_id=not-a-uuid",
+		"discovered_before=yesterday",
+		"sort=title",
+		"sort=id",
+		"algorithm=" + strings.Repeat("a,", maxFilterValues+1),
+	} {
+		if _, err := parseFindingFilter(httptest.NewRequest("GET", "/?"+bad, nil)); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+
+	// Every invalid parameter is reported, not just the first.
+	_, err = parseFindingFilter(httptest.NewRequest("GET", "/?risk_level=SEVERE&status=CLOSED&sort=title", nil))
+	var errs validate.Errors
+	if !errors.As(err, &errs) || len(errs) != 3 || errs[0].Field != "risk_level" || errs[1].Field != "status" || errs[2].Field != "sort" {
+		t.Errorf("errors = %v", err)
+	}
+}
+
+// TestFindingFilters checks the filters, full-text search and sort orders
+// against Postgres, across two assessments.
+func TestFindingFilters(t *testing.T) {
+	pool := testPool(t)
+	alice := tenantClient{t, tenantRouter(pool), "alice"}
+
+	var org struct{ ID string }
+	if code := alice.do("POST", "/organizations/", `{"name":"Filters"}`, &org); code != http.StatusCreated {
+		t.Fatalf("create org: %d", code)
+	}
+	var assessments [2]struct{ ID string }
+	for i := range assessments {
+		body := fmt.Sprintf(`{"name":"A%d","organization_id":%q}`, i, org.ID)
+		if code := alice.do("POST", "/assessments/", body, &assessments[i]); code != http.StatusCreated {
+			t.Fatalf("create assessment: %d", code)
+		}
+	}
+
+	str := func(s string) *string { return &s }
+	now := time.Now().UTC().Truncate(time.Second)
+	findings := []model.Finding{
+		{Title: "RSA key exchange on gateway", Description: "Harvest now, decrypt later exposure", RiskLevel: "CRITICAL",
+			AffectedAsset: "tls://api.example.com", CurrentAlgorithm: str("RSA-2048"), DiscoveredAt: now.Add(-72 * time.Hour)},
+		{Title: "Short ECDSA key", Description: "P-192 curve in use", RiskLevel: "HIGH",
+			AffectedAsset: "tls://www.example.com", CurrentAlgorithm: str("ECDSA-P192"), DiscoveredAt: now.Add(-48 * time.Hour)},
+		{Title: "SHA-1 signatures", Description: "Deprecated hash", RiskLevel: "MEDIUM", Remediation: str("Rotate to ML-DSA"),
+			AffectedAsset: "ssh://bastion.internal", CurrentAlgorithm: str("rsa-2048"), DiscoveredAt: now.Add(-24 * time.Hour)},
+		{Title: "TLS 1.0 enabled", Description: "Legacy protocol", RiskLevel: "LOW",
+			AffectedAsset: "tls://legacy_app.example.com", DiscoveredAt: now},
+	}
+	for i := range findings {
+		findings[i].ID = uuid.New()
+		findings[i].AssessmentID = uuid.MustParse(assessments[i%2].ID)
+		findings[i].Category = "WEAK_ALGORITHM"
+	}
+	ctx := tenant.WithScope(context.Background(), tenant.Scope{Unrestricted: true})
+	if err := repository.NewFindingRepository(pool).CreateBatch(ctx, findings); err != nil {
+		t.Fatal(err)
+	}
+	if code := alice.do("PATCH", "/findings/"+findings[1].ID.String(), `{"status":"ACKNOWLEDGED"}`, nil); code != http.StatusOK {
+		t.Fatalf("triage: %d", code)
+	}
+
+	titles := func(query string) []string {
+		t.Helper()
+		var page struct{ Findings []struct{ Title string } }
+		if code := alice.do("GET", "/findings/?"+query, "", &page); code != http.StatusOK {
+			t.Fatalf("%s: %d", query, code)
+		}
+		var out []string
+		for _, f := range page.Findings {
+			out = append(out, f.Title)
+		}
+		return out
+	}
+	all := []string{findings[0].Title, findings[1].Title, findings[2].Title, findings[3].Title}
+	for _, tc := range []struct {
+		query string
+		want  []string
+	}{
+		{"organization_id=" + org.ID, all},
+		{"assessment_id=" + assessments[1].ID, []string{all[1], all[3]}},
+		{"risk_level=CRITICAL,LOW", []string{all[0], all[3]}},
+		{"status=ACKNOWLEDGED", []string{all[1]}},
+		{"status=OPEN&sort=-discovered_at", []string{all[3], all[2], all[0]}},
+		{"algorithm=RSA-2048", []string{all[0], all[2]}},
+		{"asset=tls://", []string{all[0], all[1], all[3]}},
+		{"asset=" + url.QueryEscape("tls://*.example.com") + "&sort=affected_asset", []string{all[0], all[3], all[1]}},
+		{"asset=" + url.QueryEscape("tls://legacy?app*"), []string{all[3]}},
+		{"asset=" + url.QueryEscape("tls://legacy_"), []string{all[3]}},
+		{"asset=tls://legacyXapp", nil},
+		{"discovered_after=" + url.QueryEscape(now.Add(-36*time.Hour).Format(time.RFC3339)), []string{all[2], all[3]}},
+		{"discovered_before=" + url.QueryEscape(now.Add(-36*time.Hour).Format(time.RFC3339)) + "&sort=discovered_at", []string{all[0], all[1]}},
+		{"q=harvest", []string{all[0]}},
+		{"q=" + url.QueryEscape(`"ML-DSA" OR protocol`), []string{all[2], all[3]}},
+		{"q=key+-ECDSA", []string{all[0]}},
+		{"sort=-risk_level", []string{all[3], all[2], all[1], all[0]}},
+	} {
+		if got := titles(tc.query); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.query, got, tc.want)
+		}
+	}
+
+	// A cursor only works with the sort it was issued for.
+	var page struct {
+		NextCursor *string `json:"next_cursor"`
+	}
+	alice.do("GET", "/findings/?limit=1&sort=discovered_at", "", &page)
+	if code := alice.do("GET", "/findings/?sort=risk_level&cursor="+url.QueryEscape(*page.NextCursor), "", nil); code != http.StatusBadRequest {
+		t.Errorf("cursor with another sort: expected 400, got %d", code)
+	}
+	if code := alice.do("PATCH", "/findings/"+findings[0].ID.String(), `{"status":"DONE"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("unknown status: expected 400, got %d", code)
+	}
+}
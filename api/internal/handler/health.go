@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/quantun-opensource/qrap/api/model"
 	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
 )
 
@@ -14,9 +15,9 @@ func NewHealthHandler() *HealthHandler {
 }
 
 func (h *HealthHandler) Check(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, http.StatusOK, map[string]string{
-		"status":  "ok",
-		"service": "qrap-api",
+	writeJSON(w, http.StatusOK, model.HealthResponse{
+		Status:  "ok",
+		Service: "qrap-api",
 	})
 }
 
@@ -26,12 +27,6 @@ func writeJSON(w http.ResponseWriter, status int, v interface{}) {
 	json.NewEncoder(w).Encode(v)
 }
 
-func writeError(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
-}
-
 // actorFromRequest extracts the authenticated subject from the request context,
 // falling back to "system" if no auth context is present.
 func actorFromRequest(r *http.Request) string {
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/quantun-opensource/qrap/api/internal/repository"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+// TestIdempotency_Postgres sends the same keyed request concurrently and
+// checks that it is handled once, then replayed from the database.
+func TestIdempotency_Postgres(t *testing.T) {
+	store := repository.NewIdempotencyRepository(testPool(t))
+	var calls atomic.Int32
+	h := qmw.Idempotency(qmw.IdempotencyConfig{Store: store})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Location", "/api/v1/organizations/o1")
+		w.Header().Set("ETag", `"1"`)
+		writeJSON(w, http.StatusCreated, map[string]string{"id": "o1"})
+	}))
+	do := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/organizations", strings.NewReader(body))
+		req.Header.Set(qmw.IdempotencyKeyHeader, "create-o1")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rec := do(`{"name":"Acme"}`); rec.Code != http.StatusCreated && rec.Code != http.StatusConflict {
+				t.Errorf("concurrent request: unexpected %d", rec.Code)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls.Load())
+	}
+
+	rec := do(`{"name":"Acme"}`)
+	if rec.Code != http.StatusCreated || rec.Header().Get("Idempotent-Replayed") != "true" ||
+		rec.Header().Get("Location") != "/api/v1/organizations/o1" || rec.Header().Get("ETag") != `"1"` || !strings.Contains(rec.Body.String(), `"o1"`) {
+		t.Errorf("replay: %d %v %s", rec.Code, rec.Header(), rec.Body)
+	}
+	if rec := do(`{"name":"Other"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("different body: expected 422, got %d", rec.Code)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want 1", calls.Load())
+	}
+}
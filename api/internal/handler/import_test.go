@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testBundle is a scan bundle as qrap scan writes it, for organization
+// orgID. Its stated risk is deliberately wrong: imports recompute it.
+func testBundle(orgID string) string {
+	return fmt.Sprintf(`{
+		"version": 1,
+		"organization_id": %q,
+		"name": "Offline scan",
+		"target_assets": ["tls://a.example:443", "dnssec+file:///zones/example.com.zone"],
+		"analyzers": ["baseline", "dnssec"],
+		"errors": [{"analyzer": "dnssec", "asset": "dnssec+file:///zones/example.com.zone", "error": "no such file"}],
+		"overall_risk": "LOW",
+		"risk_score": 0,
+		"started_at": "2026-01-15T10:00:00Z",
+		"completed_at": "2026-01-15T10:05:00Z",
+		"findings": [
+			{"category": "HARVEST_NOW_DECRYPT_LATER", "risk_level": "CRITICAL", "title": "HNDL risk",
+			 "description": "Classical key exchange", "affected_asset": "tls://a.example:443",
+			 "current_algorithm": "RSA-2048", "recommended_algorithm": "ML-KEM-768", "discovered_at": "2026-01-15T10:01:00Z"},
+			{"category": "MISSING_PQC", "risk_level": "HIGH", "title": "No PQC",
+			 "description": "No post-quantum protection", "affected_asset": "tls://a.example:443"}
+		]
+	}`, orgID)
+}
+
+func TestImportScanBundle(t *testing.T) {
+	h := tenantRouter(testPool(t))
+	alice := tenantClient{t, h, "alice"}
+	bob := tenantClient{t, h, "bob"}
+
+	var org struct{ ID string }
+	if code := alice.do("POST", "/organizations/", `{"name":"Acme"}`, &org); code != http.StatusCreated {
+		t.Fatalf("create org: %d", code)
+	}
+
+	var imported struct {
+		ID          string
+		Status      string
+		OverallRisk string  `json:"overall_risk"`
+		RiskScore   float64 `json:"risk_score"`
+		Summary     struct {
+			TotalFindings    int     `json:"total_findings"`
+			CriticalFindings int     `json:"critical_findings"`
+			PqcReadiness     float64 `json:"pqc_readiness_percentage"`
+			AssetsScanned    int     `json:"assets_scanned"`
+		}
+	}
+	rec := alice.send("POST", "/assessments/import", testBundle(org.ID), nil, &imported)
+	if rec.Code != http.StatusCreated || rec.Header().Get("ETag") == "" {
+		t.Fatalf("import: %d %s", rec.Code, rec.Body)
+	}
+	if imported.Status != "COMPLETED" || imported.OverallRisk != "CRITICAL" || imported.RiskScore != 75 ||
+		imported.Summary.TotalFindings != 2 || imported.Summary.CriticalFindings != 1 ||
+		imported.Summary.AssetsScanned != 2 || imported.Summary.PqcReadiness != 0 {
+		t.Errorf("imported = %+v", imported)
+	}
+
+	var findings struct {
+		Findings []struct {
+			Status       string
+			DiscoveredAt time.Time `json:"discovered_at"`
+		}
+	}
+	if code := alice.do("GET", "/findings?sort=discovered_at&assessment_id="+imported.ID, "", &findings); code != http.StatusOK || len(findings.Findings) != 2 {
+		t.Fatalf("findings: %d %+v", code, findings)
+	}
+	// A finding without discovered_at was discovered when the scan completed.
+	if f := findings.Findings; f[0].Status != "OPEN" || !f[0].DiscoveredAt.Equal(time.Date(2026, 1, 15, 10, 1, 0, 0, time.UTC)) ||
+		!f[1].DiscoveredAt.Equal(time.Date(2026, 1, 15, 10, 5, 0, 0, time.UTC)) {
+		t.Errorf("findings = %+v", f)
+	}
+
+	var runs struct {
+		Runs []struct {
+			Status        string
+			Analyzers     []string
+			Errors        []struct{ Analyzer string }
+			FindingsCount int `json:"findings_count"`
+		}
+	}
+	if code := alice.do("GET", "/assessments/"+imported.ID+"/runs", "", &runs); code != http.StatusOK || len(runs.Runs) != 1 {
+		t.Fatalf("runs: %d %+v", code, runs)
+	}
+	if run := runs.Runs[0]; run.Status != "COMPLETED" || len(run.Analyzers) != 2 || len(run.Errors) != 1 || run.FindingsCount != 2 {
+		t.Errorf("run = %+v", run)
+	}
+
+	// Other tenants cannot import into the organization.
+	if code := bob.do("POST", "/assessments/import", testBundle(org.ID), nil); code != http.StatusNotFound {
+		t.Errorf("other tenant import: expected 404, got %d", code)
+	}
+
+	// Invalid findings reject the whole bundle.
+	bad := strings.Replace(testBundle(org.ID), `"risk_level": "HIGH"`, `"risk_level": "SEVERE"`, 1)
+	rec = alice.send("POST", "/assessments/import", bad, nil, nil)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"findings[1].risk_level"`) {
+		t.Errorf("invalid finding: %d %s", rec.Code, rec.Body)
+	}
+	var list struct{ Assessments []struct{ ID string } }
+	if code := alice.do("GET", "/assessments?organization_id="+org.ID, "", &list); code != http.StatusOK || len(list.Assessments) != 1 {
+		t.Errorf("assessments after rejected import: %d %+v", code, list)
+	}
+}
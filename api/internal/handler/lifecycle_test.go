@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quantun-opensource/qrap/api/internal/analyzer"
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+func TestUpdateDeleteRestore(t *testing.T) {
+	h := tenantRouter(testPool(t))
+	alice := tenantClient{t, h, "alice"}
+	bob := tenantClient{t, h, "bob"}
+
+	var org struct{ ID, Name string }
+	created := alice.send("POST", "/organizations/", `{"name":"Before"}`, nil, &org)
+	if created.Code != http.StatusCreated || created.Header().Get("ETag") == "" {
+		t.Fatalf("create org: %d, ETag %q", created.Code, created.Header().Get("ETag"))
+	}
+	v1 := created.Header().Get("ETag")
+	if got := alice.send("GET", "/organizations/"+org.ID, "", nil, nil).Header().Get("ETag"); got != v1 {
+		t.Errorf("GET ETag %q, create ETag %q", got, v1)
+	}
+
+	// A conditional update moves the version on; the old version is stale.
+	updated := alice.send("PATCH", "/organizations/"+org.ID, `{"name":"After"}`, http.Header{"If-Match": {v1}}, &org)
+	if updated.Code != http.StatusOK || org.Name != "After" {
+		t.Fatalf("update org: %d %+v", updated.Code, org)
+	}
+	v2 := updated.Header().Get("ETag")
+	if v2 == "" || v2 == v1 {
+		t.Errorf("ETag after update = %q, before %q", v2, v1)
+	}
+	if code := alice.send("PATCH", "/organizations/"+org.ID, `{"description":"x"}`, http.Header{"If-Match": {v1}}, nil).Code; code != http.StatusPreconditionFailed {
+		t.Errorf("stale If-Match: expected 412, got %d", code)
+	}
+	if code := bob.send("PATCH", "/organizations/"+org.ID, `{"description":"x"}`, http.Header{"If-Match": {"*"}}, nil).Code; code != http.StatusNotFound {
+		t.Errorf("other tenant update: expected 404, got %d", code)
+	}
+
+	var other struct{ ID string }
+	if code := alice.do("POST", "/organizations/", `{"name":"Other"}`, &other); code != http.StatusCreated {
+		t.Fatalf("create second org: %d", code)
+	}
+	if code := alice.do("PATCH", "/organizations/"+other.ID, `{"name":"After"}`, nil); code != http.StatusConflict {
+		t.Errorf("duplicate name: expected 409, got %d", code)
+	}
+	dup := alice.send("POST", "/organizations/", `{"name":"Other"}`, nil, nil)
+	if dup.Code != http.StatusConflict || dup.Header().Get("Content-Type") != "application/problem+json" ||
+		!strings.Contains(dup.Body.String(), "an organization with this name already exists") {
+		t.Errorf("create with duplicate name: %d %s", dup.Code, dup.Body.String())
+	}
+
+	// Only DRAFT assessments can be edited, and running one is not deleted.
+	var draft, done struct {
+		ID           string
+		Name         string
+		TargetAssets []string `json:"target_assets"`
+	}
+	body := fmt.Sprintf(`{"name":"Draft","organization_id":%q,"target_assets":["tls://a.example"]}`, org.ID)
+	if code := alice.do("POST", "/assessments/", body, &draft); code != http.StatusCreated {
+		t.Fatalf("create assessment: %d", code)
+	}
+	if code := alice.do("PATCH", "/assessments/"+draft.ID, `{"name":"Renamed","target_assets":["tls://b.example"]}`, &draft); code != http.StatusOK ||
+		draft.Name != "Renamed" || len(draft.TargetAssets) != 1 || draft.TargetAssets[0] != "tls://b.example" {
+		t.Fatalf("update draft: %d %+v", code, draft)
+	}
+	body = fmt.Sprintf(`{"name":"Done","organization_id":%q,"target_assets":["tls://a.example"]}`, org.ID)
+	if code := alice.do("POST", "/assessments/", body, &done); code != http.StatusCreated {
+		t.Fatalf("create assessment: %d", code)
+	}
+	if code := alice.do("POST", "/assessments/"+done.ID+"/run", "", nil); code != http.StatusOK {
+		t.Fatalf("run assessment: %d", code)
+	}
+	if code := alice.do("PATCH", "/assessments/"+done.ID, `{"name":"Late"}`, nil); code != http.StatusConflict {
+		t.Errorf("update completed assessment: expected 409, got %d", code)
+	}
+
+	// Deleting an assessment hides it and its findings until it is restored.
+	var findings struct {
+		Findings []struct{ ID string }
+	}
+	if code := alice.do("GET", "/findings/?assessment_id="+done.ID, "", &findings); code != http.StatusOK || len(findings.Findings) == 0 {
+		t.Fatalf("list findings: %d, %d findings", code, len(findings.Findings))
+	}
+	finding := findings.Findings[0].ID
+	if code := alice.do("DELETE", "/assessments/"+done.ID, "", nil); code != http.StatusNoContent {
+		t.Fatalf("delete assessment: %d", code)
+	}
+	for _, path := range []string{"/assessments/" + done.ID, "/findings/" + finding, "/findings/?assessment_id=" + done.ID} {
+		if code := alice.do("GET", path, "", nil); code != http.StatusNotFound {
+			t.Errorf("GET %s after delete: expected 404, got %d", path, code)
+		}
+	}
+	if code := alice.do("POST", "/assessments/"+done.ID+"/restore", "", nil); code != http.StatusOK {
+		t.Fatalf("restore assessment: %d", code)
+	}
+	if code := alice.do("GET", "/findings/"+finding, "", nil); code != http.StatusOK {
+		t.Errorf("finding after restore: expected 200, got %d", code)
+	}
+	if code := alice.do("POST", "/assessments/"+done.ID+"/restore", "", nil); code != http.StatusConflict {
+		t.Errorf("restore live assessment: expected 409, got %d", code)
+	}
+
+	// Deleting the organization takes its assessments with it; restoring it
+	// brings back those, but not an assessment deleted on its own before.
+	if code := alice.do("DELETE", "/assessments/"+draft.ID, "", nil); code != http.StatusNoContent {
+		t.Fatalf("delete draft: %d", code)
+	}
+	if code := alice.send("DELETE", "/organizations/"+org.ID, "", http.Header{"If-Match": {v1}}, nil).Code; code != http.StatusPreconditionFailed {
+		t.Errorf("delete with stale If-Match: expected 412, got %d", code)
+	}
+	if code := alice.do("DELETE", "/organizations/"+org.ID, "", nil); code != http.StatusNoContent {
+		t.Fatalf("delete org: %d", code)
+	}
+	var list struct {
+		Organizations []struct{ ID string }
+	}
+	alice.do("GET", "/organizations/", "", &list)
+	for _, o := range list.Organizations {
+		if o.ID == org.ID {
+			t.Errorf("deleted organization is listed")
+		}
+	}
+	if code := alice.do("GET", "/assessments/"+done.ID, "", nil); code != http.StatusNotFound {
+		t.Errorf("assessment of deleted org: expected 404, got %d", code)
+	}
+	if code := alice.do("POST", "/assessments/"+draft.ID+"/restore", "", nil); code != http.StatusNotFound {
+		t.Errorf("restore assessment of deleted org: expected 404, got %d", code)
+	}
+	body = fmt.Sprintf(`{"name":"Intrude","organization_id":%q}`, org.ID)
+	if code := alice.do("POST", "/assessments/", body, nil); code != http.StatusNotFound {
+		t.Errorf("create assessment in deleted org: expected 404, got %d", code)
+	}
+	if code := bob.do("POST", "/organizations/"+org.ID+"/restore", "", nil); code != http.StatusNotFound {
+		t.Errorf("other tenant restore: expected 404, got %d", code)
+	}
+
+	if code := alice.do("POST", "/organizations/"+org.ID+"/restore", "", nil); code != http.StatusOK {
+		t.Fatalf("restore org: %d", code)
+	}
+	if code := alice.do("GET", "/assessments/"+done.ID, "", nil); code != http.StatusOK {
+		t.Errorf("assessment after org restore: expected 200, got %d", code)
+	}
+	if code := alice.do("GET", "/assessments/"+draft.ID, "", nil); code != http.StatusNotFound {
+		t.Errorf("separately deleted assessment after org restore: expected 404, got %d", code)
+	}
+}
+
+// cancellingAnalyzer cancels the run's request, as the server's request
+// timeout would, and then reports a finding.
+type cancellingAnalyzer struct{ cancel context.CancelFunc }
+
+func (a *cancellingAnalyzer) Name() string         { return "cancelling" }
+func (a *cancellingAnalyzer) Supports(string) bool { return true }
+
+func (a *cancellingAnalyzer) Analyze(ctx context.Context, asset string) ([]model.Finding, error) {
+	a.cancel()
+	return []model.Finding{{Category: "MISSING_PQC", RiskLevel: "HIGH", Title: "t", Description: "d"}}, nil
+}
+
+func TestRunFailureRestoresStatus(t *testing.T) {
+	pool := testPool(t)
+	h := tenantRouter(pool)
+	alice := tenantClient{t, h, "alice"}
+
+	var org, assessment struct{ ID string }
+	if code := alice.do("POST", "/organizations/", `{"name":"Acme"}`, &org); code != http.StatusCreated {
+		t.Fatalf("create org: %d", code)
+	}
+	body := fmt.Sprintf(`{"name":"A1","organization_id":%q,"target_assets":["tls://a.example"]}`, org.ID)
+	if code := alice.do("POST", "/assessments/", body, &assessment); code != http.StatusCreated {
+		t.Fatalf("create assessment: %d", code)
+	}
+
+	// The request is cancelled while the analyzers run, so storing the
+	// findings fails.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	analyzers := analyzer.NewRegistry(time.Second)
+	analyzers.SetFallback(&cancellingAnalyzer{cancel: cancel}, 0)
+	req := httptest.NewRequest("POST", "/assessments/"+assessment.ID+"/run", nil).WithContext(ctx)
+	req.Header.Set("X-Test-Subject", "alice")
+	rec := httptest.NewRecorder()
+	tenantRouterWith(pool, analyzers).ServeHTTP(rec, req)
+	if rec.Code < 400 {
+		t.Fatalf("cancelled run: %d %s", rec.Code, rec.Body)
+	}
+
+	var got struct{ Status string }
+	if code := alice.do("GET", "/assessments/"+assessment.ID, "", &got); code != http.StatusOK || got.Status != "DRAFT" {
+		t.Errorf("after failed run: %d, status %q, want DRAFT", code, got.Status)
+	}
+	var runs struct{ Runs []struct{ Status string } }
+	if code := alice.do("GET", "/assessments/"+assessment.ID+"/runs", "", &runs); code != http.StatusOK || len(runs.Runs) != 1 || runs.Runs[0].Status != "FAILED" {
+		t.Errorf("runs after failed run: %d %+v", code, runs)
+	}
+	// The assessment can be run again.
+	if code := alice.do("POST", "/assessments/"+assessment.ID+"/run", "", &got); code != http.StatusOK || got.Status != "COMPLETED" {
+		t.Errorf("rerun: %d, status %q", code, got.Status)
+	}
+}
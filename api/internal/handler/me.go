@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/quantun-opensource/qrap/api/internal/authz"
+	"github.com/quantun-opensource/qrap/api/model"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+type MeHandler struct{}
+
+func NewMeHandler() *MeHandler {
+	return &MeHandler{}
+}
+
+// Get returns the caller's identity and permissions. When authentication is
+// disabled every permission is reported, matching what authz.Require allows.
+func (h *MeHandler) Get(w http.ResponseWriter, r *http.Request) {
+	method := qmw.AuthMethodFromContext(r.Context())
+	if method == "" {
+		writeJSON(w, http.StatusOK, model.MeResponse{
+			Subject:     actorFromRequest(r),
+			Permissions: permissionNames(authz.Permissions(authz.RoleAdmin)),
+		})
+		return
+	}
+	role := qmw.RoleFromContext(r.Context())
+	writeJSON(w, http.StatusOK, model.MeResponse{
+		Subject:       qmw.SubjectFromContext(r.Context()),
+		Role:          role,
+		AuthMethod:    string(method),
+		Authenticated: true,
+		Permissions:   permissionNames(authz.Effective(role, qmw.ScopesFromContext(r.Context()))),
+	})
+}
+
+// permissionNames returns perms as strings, and an empty list for none.
+func permissionNames(perms []authz.Permission) []string {
+	names := make([]string, len(perms))
+	for i, p := range perms {
+		names[i] = string(p)
+	}
+	return names
+}
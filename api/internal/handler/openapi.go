@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/quantun-opensource/qrap/api/internal/openapi"
+)
+
+// OpenAPIHandler serves the OpenAPI document and the docs page rendering it.
+type OpenAPIHandler struct{}
+
+func NewOpenAPIHandler() *OpenAPIHandler {
+	return &OpenAPIHandler{}
+}
+
+// Spec serves the OpenAPI document.
+func (h *OpenAPIHandler) Spec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(openapi.JSON())
+}
+
+// Docs serves the docs page. It replaces the API's default
+// Content-Security-Policy, which forbids scripts, with one allowing the
+// page's own.
+func (h *OpenAPIHandler) Docs(w http.ResponseWriter, r *http.Request) {
+	page, csp := openapi.DocsPage()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", csp)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(page)
+}
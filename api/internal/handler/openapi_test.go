@@ -0,0 +1,382 @@
+package handler
+
+import (
+	"encoding"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/quantun-opensource/qrap/api/internal/authz"
+	"github.com/quantun-opensource/qrap/api/internal/openapi"
+	"github.com/quantun-opensource/qrap/api/internal/validate"
+	"github.com/quantun-opensource/qrap/api/model"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+var loadOpenAPI = sync.OnceValues(openapi.Load)
+
+func openAPIDoc(t *testing.T) *openapi.Document {
+	t.Helper()
+	doc, err := loadOpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// checkContract fails t unless rec, the response to req, matches the
+// OpenAPI document. Paths outside /api/v1 and /health are taken to be
+// relative to /api/v1, as in routers that mount the handlers at the root.
+func checkContract(t *testing.T, req *http.Request, rec *httptest.ResponseRecorder) {
+	t.Helper()
+	path := req.URL.Path
+	if path != "/health" && !strings.HasPrefix(path, "/api/v1/") {
+		path = "/api/v1" + path
+	}
+	if err := openAPIDoc(t).CheckResponse(req.Method, path, rec.Code, rec.Header(), rec.Body.Bytes()); err != nil {
+		t.Errorf("%s %s: %v", req.Method, req.URL, err)
+	}
+}
+
+// apiRouter mounts the handlers through Handlers.Mount, as cmd/server does,
+// with OIDC login enabled and without middleware. Only revocations have a
+// store; requests that reach any other service panic.
+func apiRouter() chi.Router {
+	logger := zap.NewNop()
+	cursors := qmw.NewCursorCodec([]byte("test-cursor-secret"))
+	r := chi.NewRouter()
+	(&Handlers{
+		Health:        NewHealthHandler(),
+		OpenAPI:       NewOpenAPIHandler(),
+		Auth:          NewAuthHandler(nil, nil, nil, logger),
+		Me:            NewMeHandler(),
+		Organizations: NewOrganizationHandler(nil, cursors, logger),
+		Assessments:   NewAssessmentHandler(nil, cursors, logger),
+		Findings:      NewFindingHandler(nil, cursors, logger),
+		APIKeys:       NewAPIKeyHandler(nil, logger),
+		Revocations:   NewRevocationHandler(qmw.NewMemoryRevocationStore(), logger),
+	}).Mount(r)
+	return r
+}
+
+// TestOpenAPI_Routes checks that the document describes exactly the routes
+// the server mounts.
+func TestOpenAPI_Routes(t *testing.T) {
+	var mounted []string
+	err := chi.Walk(apiRouter(), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		mounted = append(mounted, method+" "+strings.TrimSuffix(route, "/"))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var documented []string
+	for _, route := range openAPIDoc(t).Routes() {
+		documented = append(documented, route.String())
+	}
+	for _, route := range mounted {
+		if !slices.Contains(documented, route) {
+			t.Errorf("%s is mounted but not documented", route)
+		}
+	}
+	for _, route := range documented {
+		if !slices.Contains(mounted, route) {
+			t.Errorf("%s is documented but not mounted", route)
+		}
+	}
+}
+
+// TestOpenAPI_Contract checks the responses that need no database against
+// the document. The Postgres tests check the rest through tenantClient.
+func TestOpenAPI_Contract(t *testing.T) {
+	h := apiRouter()
+	id := "00000000-0000-0000-0000-000000000001"
+	cases := []struct {
+		role         string
+		method, path string
+		body         string
+		want         int
+	}{
+		{"", "GET", "/health", "", http.StatusOK},
+		{"", "GET", "/api/v1/openapi.json", "", http.StatusOK},
+		{"", "GET", "/api/v1/docs", "", http.StatusOK},
+		{"", "GET", "/api/v1/me", "", http.StatusOK},
+		{authz.RoleAssessor, "GET", "/api/v1/me", "", http.StatusOK},
+		{"reader", "GET", "/api/v1/me", "", http.StatusOK},
+
+		{authz.RoleAdmin, "POST", "/api/v1/organizations", `{"name":"Acme","owner":"bob"}`, http.StatusBadRequest},
+		{authz.RoleAdmin, "POST", "/api/v1/organizations", `{"name":""}`, http.StatusBadRequest},
+		{authz.RoleAdmin, "GET", "/api/v1/organizations?cursor=bogus", "", http.StatusBadRequest},
+		{authz.RoleAdmin, "GET", "/api/v1/organizations/not-a-uuid", "", http.StatusBadRequest},
+		{authz.RoleAdmin, "PATCH", "/api/v1/organizations/" + id, `{}`, http.StatusBadRequest},
+		{authz.RoleAdmin, "POST", "/api/v1/organizations/" + id + "/members", `{"subject":""}`, http.StatusBadRequest},
+		{authz.RoleAdmin, "POST", "/api/v1/assessments", `{"organization_id":"x","target_assets":["a b"]}`, http.StatusBadRequest},
+		{authz.RoleAdmin, "GET", "/api/v1/assessments?status=NOPE&organization_id=x", "", http.StatusBadRequest},
+		{authz.RoleAdmin, "PATCH", "/api/v1/assessments/" + id, `{"name":""}`, http.StatusBadRequest},
+		{authz.RoleAdmin, "POST", "/api/v1/assessments/x/run", "", http.StatusBadRequest},
+		{authz.RoleAdmin, "POST", "/api/v1/assessments/import", `{"version":2,"name":"scan","findings":[{"risk_level":"SEVERE"}]}`, http.StatusBadRequest},
+		{authz.RoleAnalyst, "POST", "/api/v1/assessments/import", `{}`, http.StatusForbidden},
+		{authz.RoleAdmin, "GET", "/api/v1/assessments/x/runs", "", http.StatusBadRequest},
+		{authz.RoleAdmin, "GET", "/api/v1/findings?risk_level=SEVERE&sort=title", "", http.StatusBadRequest},
+		{authz.RoleAdmin, "GET", "/api/v1/findings/x", "", http.StatusBadRequest},
+		{authz.RoleAdmin, "PATCH", "/api/v1/findings/" + id, `{"status":"DONE"}`, http.StatusBadRequest},
+		{authz.RoleAdmin, "POST", "/api/v1/api-keys", `{"name":"","scopes":[]}`, http.StatusBadRequest},
+		{authz.RoleViewer, "POST", "/api/v1/api-keys", `{"name":"ci","role":"admin"}`, http.StatusForbidden},
+		{authz.RoleAdmin, "POST", "/api/v1/api-keys/x/rotate", "", http.StatusBadRequest},
+		{authz.RoleAdmin, "DELETE", "/api/v1/api-keys/x", "", http.StatusBadRequest},
+		{authz.RoleAdmin, "POST", "/api/v1/revocations", `{"jti":"a","subject":"b"}`, http.StatusBadRequest},
+		{authz.RoleAdmin, "POST", "/api/v1/revocations", `{"jti":"a"}`, http.StatusCreated},
+		{authz.RoleAdmin, "POST", "/api/v1/revocations", `{"subject":"bob"}`, http.StatusCreated},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		if tc.role != "" {
+			req = asRole(req, tc.role)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s %s: expected %d, got %d: %s", tc.method, tc.path, tc.want, rec.Code, rec.Body)
+		}
+		checkContract(t, req, rec)
+	}
+
+	// Every permission check answers with the documented 403.
+	err := chi.Walk(h, func(method, route string, _ http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		if len(middlewares) == 0 {
+			return nil
+		}
+		req := asRole(httptest.NewRequest(method, strings.ReplaceAll(route, "{id}", id), strings.NewReader("{}")), "nobody")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code == http.StatusForbidden {
+			checkContract(t, req, rec)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// problemBody is every member a problem response may have.
+type problemBody struct {
+	qmw.Problem
+	Errors             validate.Errors `json:"errors,omitempty"`
+	RequiredPermission string          `json:"required_permission,omitempty"`
+	Role               string          `json:"role,omitempty"`
+}
+
+// TestOpenAPI_SchemasMatchModels checks the document's schemas against the
+// types the handlers encode and decode, so that neither changes alone.
+func TestOpenAPI_SchemasMatchModels(t *testing.T) {
+	doc := openAPIDoc(t)
+	types := map[string]reflect.Type{}
+	for name, v := range map[string]interface{}{
+		"Problem":                    problemBody{},
+		"FieldError":                 validate.FieldError{},
+		"Health":                     model.HealthResponse{},
+		"Me":                         model.MeResponse{},
+		"Organization":               model.OrganizationResponse{},
+		"OrganizationList":           model.OrganizationListResponse{},
+		"CreateOrganizationRequest":  model.CreateOrganizationRequest{},
+		"UpdateOrganizationRequest":  model.UpdateOrganizationRequest{},
+		"Membership":                 model.MembershipResponse{},
+		"MembershipList":             model.MembershipListResponse{},
+		"AddMemberRequest":           model.AddMemberRequest{},
+		"Assessment":                 model.AssessmentResponse{},
+		"AssessmentSummary":          model.AssessmentSummary{},
+		"AssessmentList":             model.AssessmentListResponse{},
+		"CreateAssessmentRequest":    model.CreateAssessmentRequest{},
+		"UpdateAssessmentRequest":    model.UpdateAssessmentRequest{},
+		"ImportBundleRequest":        model.ScanBundle{},
+		"BundleFinding":              model.BundleFinding{},
+		"AnalyzerError":              model.AnalyzerError{},
+		"AssessmentRun":              model.AssessmentRunResponse{},
+		"AssessmentRunList":          model.AssessmentRunListResponse{},
+		"Finding":                    model.FindingResponse{},
+		"FindingList":                model.FindingListResponse{},
+		"UpdateFindingStatusRequest": model.UpdateFindingStatusRequest{},
+		"APIKey":                     model.APIKeyResponse{},
+		"APIKeyWithSecret":           model.APIKeySecretResponse{},
+		"APIKeyList":                 model.APIKeyListResponse{},
+		"CreateAPIKeyRequest":        model.CreateAPIKeyRequest{},
+		"RevokeRequest":              model.RevokeRequest{},
+		"Revocation":                 model.RevocationResponse{},
+	} {
+		types[name] = reflect.TypeOf(v)
+	}
+	for name, typ := range types {
+		checkSchema(t, doc, types, name, typ)
+	}
+
+	var perms []string
+	for _, p := range authz.Permissions(authz.RoleAdmin) {
+		perms = append(perms, string(p))
+	}
+	for name, values := range map[string][]string{
+		"RiskLevel":        model.RiskLevels,
+		"FindingCategory":  model.FindingCategories,
+		"FindingStatus":    model.FindingStatuses,
+		"AssessmentStatus": model.AssessmentStatuses,
+		"Role":             authz.Roles,
+		"Permission":       perms,
+	} {
+		s, _ := doc.Schema(name)
+		if got := stringList(s["enum"]); !sameSet(got, values) {
+			t.Errorf("%s enum = %v, want %v", name, got, values)
+		}
+	}
+	op, _, _ := doc.Operation("GET", "/api/v1/findings")
+	for _, p := range op["parameters"].([]interface{}) {
+		if p := p.(map[string]interface{}); p["name"] == "sort" {
+			if got := stringList(p["schema"].(map[string]interface{})["enum"]); !sameSet(got, model.FindingSorts) {
+				t.Errorf("findings sort enum = %v, want %v", got, model.FindingSorts)
+			}
+		}
+	}
+}
+
+// checkSchema compares the properties of the schema called name with the
+// JSON fields of typ. Response fields are required unless omitempty;
+// request fields are required if their validate tag says so.
+func checkSchema(t *testing.T, doc *openapi.Document, types map[string]reflect.Type, name string, typ reflect.Type) {
+	t.Helper()
+	s, ok := doc.Schema(name)
+	if !ok {
+		t.Errorf("schema %s is missing", name)
+		return
+	}
+	props, _ := s["properties"].(map[string]interface{})
+	required := stringList(s["required"])
+	isRequest := strings.HasSuffix(name, "Request")
+	seen := map[string]bool{}
+	for _, f := range jsonFields(typ) {
+		seen[f.name] = true
+		prop, ok := props[f.name].(map[string]interface{})
+		if !ok {
+			t.Errorf("%s: field %s of %s is not documented", name, f.name, typ)
+			continue
+		}
+		wantRequired := !f.omitempty
+		if isRequest {
+			wantRequired = slices.Contains(strings.Split(f.validate, ","), "required")
+		}
+		if slices.Contains(required, f.name) != wantRequired {
+			t.Errorf("%s: %s required = %v, want %v", name, f.name, !wantRequired, wantRequired)
+		}
+
+		ft := f.typ
+		nullable := false
+		if ft.Kind() == reflect.Pointer {
+			ft, nullable = ft.Elem(), !f.omitempty && !isRequest
+		}
+		target := prop
+		if ref, ok := prop["$ref"].(string); ok {
+			target, _ = doc.Schema(ref[strings.LastIndex(ref, "/")+1:])
+			if named := types[ref[strings.LastIndex(ref, "/")+1:]]; named != nil && named != ft {
+				t.Errorf("%s: %s refers to %s, but %s is %s", name, f.name, ref, typ, ft)
+			}
+		}
+		schemaTypes := stringList(target["type"])
+		if s, ok := target["type"].(string); ok {
+			schemaTypes = []string{s}
+		}
+		if want := jsonType(ft); !slices.Contains(schemaTypes, want) {
+			t.Errorf("%s: %s has type %v, want %s", name, f.name, schemaTypes, want)
+		}
+		if slices.Contains(schemaTypes, "null") != nullable {
+			t.Errorf("%s: %s nullable = %v, want %v", name, f.name, !nullable, nullable)
+		}
+		if ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct {
+			items, _ := target["items"].(map[string]interface{})
+			ref, _ := items["$ref"].(string)
+			if types[ref[strings.LastIndex(ref, "/")+1:]] != ft.Elem() {
+				t.Errorf("%s: %s items are %q, want the schema of %s", name, f.name, ref, ft.Elem())
+			}
+		}
+	}
+	for prop := range props {
+		if !seen[prop] {
+			t.Errorf("%s: property %s is not a field of %s", name, prop, typ)
+		}
+	}
+}
+
+type jsonField struct {
+	name      string
+	typ       reflect.Type
+	omitempty bool
+	validate  string
+}
+
+// jsonFields lists the fields of typ as encoding/json sees them, promoting
+// the fields of embedded structs.
+func jsonFields(typ reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		if f.Anonymous && tag == "" {
+			fields = append(fields, jsonFields(f.Type)...)
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, jsonField{name, f.Type, strings.Contains(opts, "omitempty"), f.Tag.Get("validate")})
+	}
+	return fields
+}
+
+var textMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+
+// jsonType names the JSON type typ encodes to.
+func jsonType(typ reflect.Type) string {
+	if typ.Implements(textMarshaler) {
+		return "string"
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "object"
+}
+
+func stringList(v interface{}) []string {
+	list, _ := v.([]interface{})
+	var out []string
+	for _, s := range list {
+		if s, ok := s.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func sameSet(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	sort.Strings(a)
+	sort.Strings(b)
+	return slices.Equal(a, b)
+}
@@ -1,50 +1,46 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
-	"github.com/quantun-opensource/qrap/api/internal/model"
+	"github.com/quantun-opensource/qrap/api/internal/authz"
 	"github.com/quantun-opensource/qrap/api/internal/service"
+	"github.com/quantun-opensource/qrap/api/internal/validate"
+	"github.com/quantun-opensource/qrap/api/model"
 	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
 )
 
-// maxNameLength is the maximum allowed length for name fields.
-const maxNameLength = 255
-
 type OrganizationHandler struct {
-	svc    *service.OrganizationService
-	logger *zap.Logger
+	svc     *service.OrganizationService
+	cursors *qmw.CursorCodec
+	logger  *zap.Logger
 }
 
-func NewOrganizationHandler(svc *service.OrganizationService, logger *zap.Logger) *OrganizationHandler {
-	return &OrganizationHandler{svc: svc, logger: logger}
+func NewOrganizationHandler(svc *service.OrganizationService, cursors *qmw.CursorCodec, logger *zap.Logger) *OrganizationHandler {
+	return &OrganizationHandler{svc: svc, cursors: cursors, logger: logger}
 }
 
 func (h *OrganizationHandler) Routes() chi.Router {
 	r := chi.NewRouter()
-	r.Post("/", h.Create)
-	r.Get("/", h.List)
-	r.Get("/{id}", h.Get)
+	r.With(authz.Require(authz.OrganizationsWrite)).Post("/", h.Create)
+	r.With(authz.Require(authz.OrganizationsRead)).Get("/", h.List)
+	r.With(authz.Require(authz.OrganizationsRead)).Get("/{id}", h.Get)
+	r.With(authz.Require(authz.OrganizationsWrite)).Patch("/{id}", h.Update)
+	r.With(authz.Require(authz.OrganizationsWrite)).Delete("/{id}", h.Delete)
+	r.With(authz.Require(authz.OrganizationsWrite)).Post("/{id}/restore", h.Restore)
+	r.With(authz.Require(authz.OrganizationsRead)).Get("/{id}/members", h.ListMembers)
+	r.With(authz.Require(authz.OrganizationsWrite)).Post("/{id}/members", h.AddMember)
+	r.With(authz.Require(authz.OrganizationsWrite)).Delete("/{id}/members/{subject}", h.RemoveMember)
 	return r
 }
 
 func (h *OrganizationHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req model.CreateOrganizationRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
-		return
-	}
-	if req.Name == "" {
-		writeError(w, http.StatusBadRequest, "name is required")
-		return
-	}
-	if len(req.Name) > maxNameLength {
-		writeError(w, http.StatusBadRequest, "name exceeds maximum length")
+	if !decodeRequest(w, r, &req, nil) {
 		return
 	}
 	if req.CreatedBy == "" {
@@ -53,44 +49,154 @@ func (h *OrganizationHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	org, err := h.svc.Create(r.Context(), &req)
 	if err != nil {
-		h.logger.Error("failed to create organization", zap.Error(err))
-		writeError(w, http.StatusInternalServerError, "failed to create organization")
+		writeServiceError(w, r, h.logger, err, "failed to create organization")
 		return
 	}
+	w.Header().Set("ETag", etag(org.UpdatedAt))
 	writeJSON(w, http.StatusCreated, org.ToResponse())
 }
 
 func (h *OrganizationHandler) Get(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid organization ID")
+		writeError(w, r, http.StatusBadRequest, "invalid organization ID")
 		return
 	}
 	org, err := h.svc.Get(r.Context(), id)
 	if err != nil {
-		writeError(w, http.StatusNotFound, "organization not found")
+		writeServiceError(w, r, h.logger, err, "failed to get organization")
+		return
+	}
+	w.Header().Set("ETag", etag(org.UpdatedAt))
+	writeJSON(w, http.StatusOK, org.ToResponse())
+}
+
+func (h *OrganizationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid organization ID")
+		return
+	}
+	var req model.UpdateOrganizationRequest
+	if !decodeRequest(w, r, &req, func(v *validate.Validator) {
+		if req.Name == nil && req.Description == nil {
+			v.Add("", "name or description is required")
+		}
+	}) {
+		return
+	}
+
+	org, err := h.svc.Update(r.Context(), id, &req, actorFromRequest(r), ifMatch(r))
+	if err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to update organization")
+		return
+	}
+	w.Header().Set("ETag", etag(org.UpdatedAt))
+	writeJSON(w, http.StatusOK, org.ToResponse())
+}
+
+func (h *OrganizationHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid organization ID")
+		return
+	}
+	if err := h.svc.Delete(r.Context(), id, actorFromRequest(r), ifMatch(r)); err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to delete organization")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *OrganizationHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid organization ID")
+		return
+	}
+	org, err := h.svc.Restore(r.Context(), id, actorFromRequest(r))
+	if err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to restore organization")
 		return
 	}
+	w.Header().Set("ETag", etag(org.UpdatedAt))
 	writeJSON(w, http.StatusOK, org.ToResponse())
 }
 
 func (h *OrganizationHandler) List(w http.ResponseWriter, r *http.Request) {
 	pg := qmw.ParsePagination(r)
+	page, err := listPage(h.cursors, organizationsList, pg)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid cursor")
+		return
+	}
 
-	orgs, total, err := h.svc.List(r.Context(), pg.Offset, pg.Limit)
+	orgs, info, err := h.svc.List(r.Context(), page)
 	if err != nil {
-		h.logger.Error("failed to list organizations", zap.Error(err))
-		writeError(w, http.StatusInternalServerError, "failed to list organizations")
+		writeServiceError(w, r, h.logger, err, "failed to list organizations")
 		return
 	}
 
 	resp := model.OrganizationListResponse{
-		TotalCount: total,
-		Offset:     pg.Offset,
-		Limit:      pg.Limit,
+		Organizations: []model.OrganizationResponse{},
+		TotalCount:    info.Total,
+		Offset:        page.Offset,
+		Limit:         pg.Limit,
 	}
+	resp.NextCursor, resp.PrevCursor = pageCursors(h.cursors, organizationsList, info)
 	for _, o := range orgs {
 		resp.Organizations = append(resp.Organizations, o.ToResponse())
 	}
 	writeJSON(w, http.StatusOK, resp)
 }
+
+func (h *OrganizationHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid organization ID")
+		return
+	}
+	members, err := h.svc.ListMembers(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to list organization members")
+		return
+	}
+
+	resp := model.MembershipListResponse{Members: []model.MembershipResponse{}}
+	for _, m := range members {
+		resp.Members = append(resp.Members, m.ToResponse())
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *OrganizationHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid organization ID")
+		return
+	}
+	var req model.AddMemberRequest
+	if !decodeRequest(w, r, &req, nil) {
+		return
+	}
+
+	m, err := h.svc.AddMember(r.Context(), id, req.Subject, actorFromRequest(r))
+	if err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to add organization member")
+		return
+	}
+	writeJSON(w, http.StatusCreated, m.ToResponse())
+}
+
+func (h *OrganizationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid organization ID")
+		return
+	}
+	if err := h.svc.RemoveMember(r.Context(), id, chi.URLParam(r, "subject")); err != nil {
+		writeServiceError(w, r, h.logger, err, "failed to remove organization member")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"github.com/quantun-opensource/qrap/api/internal/repository"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+// Listing names bound into cursors, so a cursor only works on the listing
+// that issued it.
+const (
+	organizationsList = "organizations"
+	assessmentsList   = "assessments"
+	findingsList      = "findings"
+)
+
+// listPage turns pagination parameters into a repository page, decoding a
+// cursor issued for list.
+func listPage(cursors *qmw.CursorCodec, list string, pg qmw.Pagination) (repository.Page, error) {
+	page := repository.Page{Offset: pg.Offset, Limit: pg.Limit, Count: pg.Count}
+	if pg.Cursor != "" {
+		cur, err := cursors.Decode(list, pg.Cursor)
+		if err != nil {
+			return page, err
+		}
+		page.Cursor = &cur
+	}
+	return page, nil
+}
+
+// pageCursors encodes the cursors to a page's neighbours; nil marks either
+// end of the listing.
+func pageCursors(cursors *qmw.CursorCodec, list string, info repository.PageInfo) (next, prev *string) {
+	if info.Next != nil {
+		s := cursors.Encode(list, *info.Next)
+		next = &s
+	}
+	if info.Prev != nil {
+		s := cursors.Encode(list, *info.Prev)
+		prev = &s
+	}
+	return next, prev
+}
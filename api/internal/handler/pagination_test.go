@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/quantun-opensource/qrap/api/internal/repository"
+	"github.com/quantun-opensource/qrap/api/internal/tenant"
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+type findingPage struct {
+	Findings   []struct{ ID string }
+	TotalCount *int    `json:"total_count"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+}
+
+func (p findingPage) ids() []string {
+	var ids []string
+	for _, f := range p.Findings {
+		ids = append(ids, f.ID)
+	}
+	return ids
+}
+
+// TestCursorPagination walks the findings of an assessment with cursors in
+// both directions and checks the pages join up to the offset listing, ties in
+// risk level and discovery time included.
+func TestCursorPagination(t *testing.T) {
+	pool := testPool(t)
+	alice := tenantClient{t, tenantRouter(pool), "alice"}
+
+	var org, assessment struct{ ID string }
+	if code := alice.do("POST", "/organizations/", `{"name":"Paged"}`, &org); code != http.StatusCreated {
+		t.Fatalf("create org: %d", code)
+	}
+	if code := alice.do("POST", "/assessments/", fmt.Sprintf(`{"name":"A","organization_id":%q}`, org.ID), &assessment); code != http.StatusCreated {
+		t.Fatalf("create assessment: %d", code)
+	}
+	discovered := time.Now().UTC().Truncate(time.Microsecond)
+	var findings []model.Finding
+	for i := range 8 {
+		findings = append(findings, model.Finding{
+			ID:            uuid.New(),
+			AssessmentID:  uuid.MustParse(assessment.ID),
+			Category:      "WEAK_ALGORITHM",
+			RiskLevel:     model.RiskLevels[i%3],
+			Title:         fmt.Sprintf("finding %d", i),
+			Description:   "test",
+			AffectedAsset: "tls://example.com",
+			DiscoveredAt:  discovered.Add(-time.Duration(i/4) * time.Second),
+		})
+	}
+	ctx := tenant.WithScope(context.Background(), tenant.Scope{Unrestricted: true})
+	if err := repository.NewFindingRepository(pool).CreateBatch(ctx, findings); err != nil {
+		t.Fatal(err)
+	}
+
+	base := "/findings/?assessment_id=" + assessment.ID
+	var all findingPage
+	alice.do("GET", base+"&limit=100", "", &all)
+	if len(all.Findings) != 8 || all.TotalCount == nil || *all.TotalCount != 8 || all.NextCursor != nil || all.PrevCursor != nil {
+		t.Fatalf("offset listing: %+v", all)
+	}
+
+	var forward []string
+	var page findingPage
+	alice.do("GET", base+"&limit=3", "", &page)
+	firstCursor := *page.NextCursor
+	for {
+		forward = append(forward, page.ids()...)
+		if page.NextCursor == nil {
+			break
+		}
+		next := page
+		page = findingPage{}
+		if code := alice.do("GET", base+"&limit=3&cursor="+url.QueryEscape(*next.NextCursor), "", &page); code != http.StatusOK {
+			t.Fatalf("next page: %d", code)
+		}
+		if page.TotalCount != nil {
+			t.Error("cursor page was counted without count=true")
+		}
+	}
+	if !slices.Equal(forward, all.ids()) {
+		t.Errorf("forward walk = %v, want %v", forward, all.ids())
+	}
+
+	var backward []string
+	for page.PrevCursor != nil {
+		prev := page
+		page = findingPage{}
+		if code := alice.do("GET", base+"&limit=3&count=true&cursor="+url.QueryEscape(*prev.PrevCursor), "", &page); code != http.StatusOK {
+			t.Fatalf("previous page: %d", code)
+		}
+		if page.TotalCount == nil || *page.TotalCount != 8 {
+			t.Error("count=true was not counted")
+		}
+		backward = append(page.ids(), backward...)
+	}
+	if want := all.ids()[:6]; !slices.Equal(backward, want) {
+		t.Errorf("backward walk = %v, want %v", backward, want)
+	}
+
+	// Cursors are bound to their listing and cannot be altered.
+	if code := alice.do("GET", "/assessments/?cursor="+url.QueryEscape(firstCursor), "", nil); code != http.StatusBadRequest {
+		t.Errorf("findings cursor on assessments: expected 400, got %d", code)
+	}
+	if code := alice.do("GET", base+"&cursor=bogus", "", nil); code != http.StatusBadRequest {
+		t.Errorf("bogus cursor: expected 400, got %d", code)
+	}
+}
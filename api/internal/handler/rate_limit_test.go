@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quantun-opensource/qrap/api/internal/repository"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+// TestRateLimit_PostgresSharedAcrossReplicas runs concurrent requests through
+// two limiters backed by the same database and checks that exactly the
+// configured number get through, with either algorithm.
+func TestRateLimit_PostgresSharedAcrossReplicas(t *testing.T) {
+	store := repository.NewRateLimitRepository(testPool(t))
+	for _, algorithm := range []qmw.RateLimitAlgorithm{qmw.RateLimitFixedWindow, qmw.RateLimitTokenBucket} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			testSharedRateLimit(t, store, algorithm)
+		})
+	}
+}
+
+func testSharedRateLimit(t *testing.T, store qmw.RateLimitStore, algorithm qmw.RateLimitAlgorithm) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	var replicas []http.Handler
+	for range 2 {
+		rl := qmw.NewRateLimiter(qmw.RateLimitConfig{
+			Name:              string(algorithm),
+			RequestsPerWindow: 10,
+			Window:            time.Hour,
+			Algorithm:         algorithm,
+			Store:             store,
+		})
+		t.Cleanup(rl.Stop)
+		replicas = append(replicas, rl.Middleware()(ok))
+	}
+
+	var (
+		mu      sync.Mutex
+		allowed int
+		wg      sync.WaitGroup
+	)
+	for i := range 30 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = "203.0.113.7"
+			rec := httptest.NewRecorder()
+			replicas[i%2].ServeHTTP(rec, req)
+			if rec.Code == http.StatusOK {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != 10 {
+		t.Errorf("allowed %d requests across replicas, want 10", allowed)
+	}
+}
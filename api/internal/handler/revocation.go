@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/quantun-opensource/qrap/api/internal/authz"
+	"github.com/quantun-opensource/qrap/api/internal/validate"
+	"github.com/quantun-opensource/qrap/api/model"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+// defaultRevocationTTL is how long a jti stays denied when the caller does
+// not know the token's exp. It should be at least the longest token lifetime
+// the deployment issues.
+const defaultRevocationTTL = 30 * 24 * time.Hour
+
+type RevocationHandler struct {
+	store  qmw.RevocationStore
+	logger *zap.Logger
+}
+
+func NewRevocationHandler(store qmw.RevocationStore, logger *zap.Logger) *RevocationHandler {
+	return &RevocationHandler{store: store, logger: logger}
+}
+
+func (h *RevocationHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.With(authz.Require(authz.TokensRevoke)).Post("/", h.Create)
+	return r
+}
+
+// Create revokes a single token by jti (until expires_at, by default 30 days
+// from now) or every token of a subject issued at or before before (by
+// default now).
+func (h *RevocationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.RevokeRequest
+	now := time.Now()
+	if !decodeRequest(w, r, &req, func(v *validate.Validator) {
+		if (req.JTI == "") == (req.Subject == "") {
+			v.Add("", "exactly one of jti or subject is required")
+		}
+		if req.JTI != "" && req.Before != nil {
+			v.Add("before", "applies only to subject revocations")
+		}
+		if req.Subject != "" && req.ExpiresAt != nil {
+			v.Add("expires_at", "applies only to jti revocations")
+		}
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+			v.Add("expires_at", "must be in the future")
+		}
+	}) {
+		return
+	}
+
+	resp := model.RevocationResponse{RevokedBy: actorFromRequest(r)}
+	if req.JTI != "" {
+		expiresAt := now.Add(defaultRevocationTTL)
+		if req.ExpiresAt != nil {
+			expiresAt = *req.ExpiresAt
+		}
+		if err := h.store.RevokeToken(r.Context(), req.JTI, expiresAt); err != nil {
+			h.logger.Error("failed to revoke token", zap.Error(err))
+			writeError(w, r, http.StatusInternalServerError, "failed to revoke token")
+			return
+		}
+		h.logger.Info("token revoked", zap.String("jti", req.JTI), zap.String("actor", resp.RevokedBy))
+		resp.JTI = req.JTI
+		resp.ExpiresAt = formatTime(expiresAt)
+	} else {
+		before := now
+		if req.Before != nil {
+			before = *req.Before
+		}
+		if err := h.store.RevokeSubject(r.Context(), req.Subject, before); err != nil {
+			h.logger.Error("failed to revoke subject", zap.Error(err))
+			writeError(w, r, http.StatusInternalServerError, "failed to revoke subject")
+			return
+		}
+		h.logger.Info("subject tokens revoked", zap.String("subject", req.Subject), zap.Time("before", before), zap.String("actor", resp.RevokedBy))
+		resp.Subject = req.Subject
+		resp.Before = formatTime(before)
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func formatTime(t time.Time) *string {
+	s := t.UTC().Format(time.RFC3339)
+	return &s
+}
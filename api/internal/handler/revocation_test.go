@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/quantun-opensource/qrap/api/internal/authz"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+func TestRevocationCreate(t *testing.T) {
+	store := qmw.NewMemoryRevocationStore()
+	h := NewRevocationHandler(store, zap.NewNop()).Routes()
+	ctx := context.Background()
+	issued := time.Now().Add(-time.Hour).Unix()
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"empty", `{}`, http.StatusBadRequest},
+		{"both", `{"jti":"a","subject":"bob"}`, http.StatusBadRequest},
+		{"past expiry", `{"jti":"a","expires_at":"2020-01-01T00:00:00Z"}`, http.StatusBadRequest},
+		{"before on jti", `{"jti":"a","before":"2020-01-01T00:00:00Z"}`, http.StatusBadRequest},
+		{"expires_at on subject", `{"subject":"bob","expires_at":"2099-01-01T00:00:00Z"}`, http.StatusBadRequest},
+		{"jti", `{"jti":"tok-1"}`, http.StatusCreated},
+		{"subject", `{"subject":"bob"}`, http.StatusCreated},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, asRole(httptest.NewRequest("POST", "/", strings.NewReader(tc.body)), authz.RoleAdmin))
+		if rec.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d (%s)", tc.name, tc.want, rec.Code, rec.Body.String())
+		}
+	}
+
+	if revoked, _ := store.IsRevoked(ctx, "tok-1", "alice", issued); !revoked {
+		t.Error("jti not revoked")
+	}
+	if revoked, _ := store.IsRevoked(ctx, "", "bob", issued); !revoked {
+		t.Error("subject not revoked")
+	}
+	if revoked, _ := store.IsRevoked(ctx, "tok-2", "alice", issued); revoked {
+		t.Error("unrelated token revoked")
+	}
+}
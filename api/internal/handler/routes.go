@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Handlers are the handlers the server mounts. Auth is nil when OIDC login
+// is not configured; the other fields are required.
+type Handlers struct {
+	Health        *HealthHandler
+	OpenAPI       *OpenAPIHandler
+	Auth          *AuthHandler
+	Me            *MeHandler
+	Organizations *OrganizationHandler
+	Assessments   *AssessmentHandler
+	Findings      *FindingHandler
+	APIKeys       *APIKeyHandler
+	Revocations   *RevocationHandler
+}
+
+// Mount mounts /health and the /api/v1 routes on r. apiMiddleware (auth,
+// tenant scope, rate limits, ...) applies to /api/v1 only. The server and
+// the OpenAPI contract tests both mount their routes through Mount, so the
+// tests see exactly the routes that are served.
+func (h *Handlers) Mount(r chi.Router, apiMiddleware ...func(http.Handler) http.Handler) {
+	r.Get("/health", h.Health.Check)
+
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(apiMiddleware...)
+
+		// API description (unauthenticated)
+		r.Get("/openapi.json", h.OpenAPI.Spec)
+		r.Get("/docs", h.OpenAPI.Docs)
+		if h.Auth != nil {
+			r.Mount("/auth", h.Auth.Routes())
+		}
+		r.Get("/me", h.Me.Get)
+		r.Mount("/organizations", h.Organizations.Routes())
+		r.Mount("/assessments", h.Assessments.Routes())
+		r.Mount("/findings", h.Findings.Routes())
+		r.Mount("/api-keys", h.APIKeys.Routes())
+		r.Mount("/revocations", h.Revocations.Routes())
+	})
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/quantun-opensource/qrap/api/internal/analyzer"
+	"github.com/quantun-opensource/qrap/api/internal/authz"
+	"github.com/quantun-opensource/qrap/api/internal/repository"
+	"github.com/quantun-opensource/qrap/api/internal/service"
+	"github.com/quantun-opensource/qrap/api/internal/tenant"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+// testPool connects to QRAP_TEST_DATABASE_URL and applies the migrations in a
+// throwaway schema that is dropped when the test ends.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("QRAP_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("QRAP_TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	schema := "qrap_test_" + strings.ReplaceAll(uuid.NewString()[:8], "-", "")
+
+	admin, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+	if _, err := admin.Exec(ctx, `CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema + ",public"
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		pool.Close()
+		cleanup, err := pgxpool.New(context.Background(), url)
+		if err == nil {
+			cleanup.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+			cleanup.Close()
+		}
+	})
+
+	files, _ := filepath.Glob("../../../db/migrations/*.up.sql")
+	sort.Strings(files)
+	for _, f := range files {
+		sql, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			t.Fatalf("%s: %v", filepath.Base(f), err)
+		}
+	}
+	return pool
+}
+
+// tenantRouter wires the real repositories and handlers behind a stand-in
+// for qmw.Auth that takes the subject from the X-Test-Subject header.
+func tenantRouter(pool *pgxpool.Pool) http.Handler {
+	analyzers := analyzer.NewRegistry(time.Second)
+	analyzers.SetFallback(analyzer.NewBaselineAnalyzer(), 0)
+	return tenantRouterWith(pool, analyzers)
+}
+
+// tenantRouterWith is tenantRouter with assessments run by analyzers.
+func tenantRouterWith(pool *pgxpool.Pool, analyzers *analyzer.Registry) http.Handler {
+	logger := zap.NewNop()
+	orgRepo := repository.NewOrganizationRepository(pool)
+	assessmentRepo := repository.NewAssessmentRepository(pool)
+	findingRepo := repository.NewFindingRepository(pool)
+	runRepo := repository.NewRunRepository(pool)
+	members := repository.NewMembershipRepository(pool)
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), qmw.ContextKeySubject, r.Header.Get("X-Test-Subject"))
+			ctx = context.WithValue(ctx, qmw.ContextKeyRole, authz.RoleAdmin)
+			ctx = context.WithValue(ctx, qmw.ContextKeyAuthMethod, qmw.AuthMethodAPIKey)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	r.Use(tenant.Middleware(members, logger))
+	cursors := qmw.NewCursorCodec([]byte("test-cursor-secret"))
+	r.Mount("/organizations", NewOrganizationHandler(service.NewOrganizationService(orgRepo, members, time.Hour, logger), cursors, logger).Routes())
+	r.Mount("/assessments", NewAssessmentHandler(service.NewAssessmentService(assessmentRepo, findingRepo, runRepo, analyzers, time.Hour, logger), cursors, logger).Routes())
+	r.Mount("/findings", NewFindingHandler(service.NewFindingService(findingRepo, assessmentRepo, logger), cursors, logger).Routes())
+	return r
+}
+
+type tenantClient struct {
+	t       *testing.T
+	handler http.Handler
+	subject string
+}
+
+func (c tenantClient) do(method, path, body string, out interface{}) int {
+	c.t.Helper()
+	return c.send(method, path, body, nil, out).Code
+}
+
+// send is do with extra request headers, returning the whole response. The
+// response is checked against the OpenAPI document.
+func (c tenantClient) send(method, path, body string, header http.Header, out interface{}) *httptest.ResponseRecorder {
+	c.t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("X-Test-Subject", c.subject)
+	rec := httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, req)
+	checkContract(c.t, req, rec)
+	if out != nil && rec.Code < 300 {
+		if err := json.NewDecoder(rec.Body).Decode(out); err != nil {
+			c.t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+	return rec
+}
+
+func TestTenantIsolation(t *testing.T) {
+	h := tenantRouter(testPool(t))
+	alice := tenantClient{t, h, "alice"}
+	bob := tenantClient{t, h, "bob"}
+
+	var orgA, orgB struct{ ID string }
+	if code := alice.do("POST", "/organizations/", `{"name":"Org A"}`, &orgA); code != http.StatusCreated {
+		t.Fatalf("create org A: %d", code)
+	}
+	if code := bob.do("POST", "/organizations/", `{"name":"Org B"}`, &orgB); code != http.StatusCreated {
+		t.Fatalf("create org B: %d", code)
+	}
+	var assessment struct{ ID string }
+	body := fmt.Sprintf(`{"name":"A1","organization_id":%q,"target_assets":["tls://a.example"]}`, orgA.ID)
+	if code := alice.do("POST", "/assessments/", body, &assessment); code != http.StatusCreated {
+		t.Fatalf("create assessment: %d", code)
+	}
+	if code := alice.do("POST", "/assessments/"+assessment.ID+"/run", "", nil); code != http.StatusOK {
+		t.Fatalf("run assessment: %d", code)
+	}
+	var findings struct {
+		Findings []struct{ ID string }
+	}
+	if code := alice.do("GET", "/findings/?assessment_id="+assessment.ID, "", &findings); code != http.StatusOK || len(findings.Findings) == 0 {
+		t.Fatalf("alice list findings: %d, %d findings", code, len(findings.Findings))
+	}
+	finding := findings.Findings[0].ID
+
+	var list struct {
+		Organizations []struct{ ID string }
+		TotalCount    int `json:"total_count"`
+	}
+	if code := bob.do("GET", "/organizations/", "", &list); code != http.StatusOK || list.TotalCount != 1 || list.Organizations[0].ID != orgB.ID {
+		t.Fatalf("bob list organizations: %d %+v", code, list)
+	}
+	var assessments struct {
+		TotalCount int `json:"total_count"`
+	}
+	if code := bob.do("GET", "/assessments/", "", &assessments); code != http.StatusOK || assessments.TotalCount != 0 {
+		t.Fatalf("bob list assessments: %d %+v", code, assessments)
+	}
+
+	crossTenant := []struct{ method, path, body string }{
+		{"GET", "/organizations/" + orgA.ID, ""},
+		{"GET", "/organizations/" + orgA.ID + "/members", ""},
+		{"POST", "/organizations/" + orgA.ID + "/members", `{"subject":"bob"}`},
+		{"DELETE", "/organizations/" + orgA.ID + "/members/alice", ""},
+		{"POST", "/assessments/", fmt.Sprintf(`{"name":"intrude","organization_id":%q}`, orgA.ID)},
+		{"GET", "/assessments/" + assessment.ID, ""},
+		{"GET", "/assessments/" + assessment.ID + "/runs", ""},
+		{"POST", "/assessments/" + assessment.ID + "/run", ""},
+		{"GET", "/findings/?assessment_id=" + assessment.ID, ""},
+		{"GET", "/findings/" + finding, ""},
+	}
+	for _, tc := range crossTenant {
+		if code := bob.do(tc.method, tc.path, tc.body, nil); code != http.StatusNotFound {
+			t.Errorf("bob %s %s: expected 404, got %d", tc.method, tc.path, code)
+		}
+	}
+
+	// Membership opens the organization to bob.
+	if code := alice.do("POST", "/organizations/"+orgA.ID+"/members", `{"subject":"bob"}`, nil); code != http.StatusCreated {
+		t.Fatalf("add member: %d", code)
+	}
+	if code := bob.do("GET", "/assessments/"+assessment.ID, "", nil); code != http.StatusOK {
+		t.Errorf("bob after membership: expected 200, got %d", code)
+	}
+	if code := alice.do("DELETE", "/organizations/"+orgA.ID+"/members/bob", "", nil); code != http.StatusNoContent {
+		t.Fatalf("remove member: %d", code)
+	}
+	if code := bob.do("GET", "/findings/"+finding, "", nil); code != http.StatusNotFound {
+		t.Errorf("bob after removal: expected 404, got %d", code)
+	}
+}
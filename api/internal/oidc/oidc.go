@@ -0,0 +1,334 @@
+// Package oidc implements the relying-party side of the OpenID Connect
+// authorization code flow with PKCE, used to sign dashboard users in.
+//
+// A Provider is built from the issuer's discovery document. It produces the
+// authorization URL for a login, exchanges the returned code at the token
+// endpoint, and verifies the ID token against the issuer's JWKS.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+// maxResponseBytes caps discovery and token endpoint responses.
+const maxResponseBytes = 1 << 20
+
+// clockSkew tolerates small clock differences with the provider.
+const clockSkew = time.Minute
+
+// Config configures a Provider.
+type Config struct {
+	// IssuerURL is the provider's issuer identifier; discovery is fetched
+	// from IssuerURL + "/.well-known/openid-configuration".
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string // empty for public clients
+	// RedirectURL is this API's callback URL registered with the provider.
+	RedirectURL string
+	// Scopes requested at login. "openid" is always included.
+	Scopes []string
+	// RoleClaim names the ID token claim holding the user's role. A string
+	// or the first element of a string array is used.
+	RoleClaim string
+	// DefaultRole applies when the ID token has no role claim.
+	DefaultRole string
+	// PostLogoutRedirectURL is where the provider sends the browser after
+	// logout, if it supports RP-initiated logout.
+	PostLogoutRedirectURL string
+	// Client is used for discovery, JWKS and token requests. Default: 10
+	// second timeout.
+	Client *http.Client
+	Logger *zap.Logger
+}
+
+// metadata is the subset of the discovery document the flow needs.
+type metadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	EndSessionEndpoint    string   `json:"end_session_endpoint"`
+	CodeChallengeMethods  []string `json:"code_challenge_methods_supported"`
+}
+
+// Provider is a discovered OpenID provider.
+type Provider struct {
+	cfg  Config
+	meta metadata
+	keys *qmw.RemoteKeySet
+	now  func() time.Time
+}
+
+// NewProvider fetches the discovery document and the provider's JWKS. Call
+// Stop on shutdown.
+func NewProvider(ctx context.Context, cfg Config) (*Provider, error) {
+	if cfg.IssuerURL == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("OIDC issuer URL, client ID and redirect URL are required")
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	if !slices.Contains(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+
+	wellKnown := strings.TrimSuffix(cfg.IssuerURL, "/") + "/.well-known/openid-configuration"
+	var meta metadata
+	if err := getJSON(ctx, cfg.Client, wellKnown, &meta); err != nil {
+		return nil, fmt.Errorf("OIDC discovery: %w", err)
+	}
+	// OpenID Connect Discovery 1.0 section 4.3: the issuer must match exactly.
+	if meta.Issuer != cfg.IssuerURL {
+		return nil, fmt.Errorf("OIDC discovery: issuer %q does not match configured %q", meta.Issuer, cfg.IssuerURL)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC discovery: missing authorization, token or jwks endpoint")
+	}
+	if len(meta.CodeChallengeMethods) > 0 && !slices.Contains(meta.CodeChallengeMethods, "S256") {
+		return nil, fmt.Errorf("OIDC provider does not support PKCE S256")
+	}
+
+	keys, err := qmw.NewRemoteKeySet(ctx, qmw.RemoteKeySetConfig{URL: meta.JWKSURI, Client: cfg.Client, Logger: cfg.Logger})
+	if err != nil {
+		return nil, fmt.Errorf("OIDC JWKS: %w", err)
+	}
+	return &Provider{cfg: cfg, meta: meta, keys: keys, now: time.Now}, nil
+}
+
+// Stop ends background JWKS refresh.
+func (p *Provider) Stop() {
+	p.keys.Stop()
+}
+
+// LoginState is what must survive the round trip through the provider: the
+// state and nonce that bind the callback to this login, and the PKCE verifier.
+type LoginState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	ReturnTo string `json:"return_to,omitempty"`
+}
+
+// NewLoginState generates fresh random state, nonce and PKCE verifier.
+func NewLoginState(returnTo string) (*LoginState, error) {
+	values := make([]string, 3)
+	for i := range values {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("generate login state: %w", err)
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return &LoginState{State: values[0], Nonce: values[1], Verifier: values[2], ReturnTo: returnTo}, nil
+}
+
+// AuthCodeURL returns the provider URL that starts a login for s.
+func (p *Provider) AuthCodeURL(s *LoginState) string {
+	challenge := sha256.Sum256([]byte(s.Verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {s.State},
+		"nonce":                 {s.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	return withQuery(p.meta.AuthorizationEndpoint, q)
+}
+
+// EndSessionURL returns the provider's RP-initiated logout URL, or "" if
+// the provider does not advertise one.
+func (p *Provider) EndSessionURL() string {
+	if p.meta.EndSessionEndpoint == "" {
+		return ""
+	}
+	q := url.Values{"client_id": {p.cfg.ClientID}}
+	if p.cfg.PostLogoutRedirectURL != "" {
+		q.Set("post_logout_redirect_uri", p.cfg.PostLogoutRedirectURL)
+	}
+	return withQuery(p.meta.EndSessionEndpoint, q)
+}
+
+// Identity is the verified user from an ID token.
+type Identity struct {
+	Subject string
+	Email   string
+	Role    string
+}
+
+// Exchange redeems an authorization code and returns the verified identity
+// from the ID token. s must be the state the login was started with.
+func (p *Provider) Exchange(ctx context.Context, code string, s *LoginState) (*Identity, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"code_verifier": {s.Verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.cfg.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+	var tok struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return nil, fmt.Errorf("invalid token response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned HTTP %d: %s %s", resp.StatusCode, tok.Error, tok.ErrorDescription)
+	}
+	if tok.IDToken == "" {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+	return p.verifyIDToken(ctx, tok.IDToken, s.Nonce)
+}
+
+// verifyIDToken validates an ID token per OpenID Connect Core section 3.1.3.7.
+func (p *Provider) verifyIDToken(ctx context.Context, raw, nonce string) (*Identity, error) {
+	claimsJSON, err := qmw.VerifyJWT(ctx, raw, p.keys, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+	var claims struct {
+		Issuer    string   `json:"iss"`
+		Subject   string   `json:"sub"`
+		Audience  audience `json:"aud"`
+		AZP       string   `json:"azp"`
+		ExpiresAt int64    `json:"exp"`
+		IssuedAt  int64    `json:"iat"`
+		Nonce     string   `json:"nonce"`
+		Email     string   `json:"email"`
+	}
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return nil, fmt.Errorf("invalid ID token claims: %w", err)
+	}
+
+	now := p.now()
+	switch {
+	case claims.Issuer != p.meta.Issuer:
+		return nil, fmt.Errorf("ID token issuer %q does not match %q", claims.Issuer, p.meta.Issuer)
+	case !slices.Contains(claims.Audience, p.cfg.ClientID):
+		return nil, fmt.Errorf("ID token audience does not include client %q", p.cfg.ClientID)
+	case len(claims.Audience) > 1 && claims.AZP != p.cfg.ClientID:
+		return nil, fmt.Errorf("ID token azp %q does not match client %q", claims.AZP, p.cfg.ClientID)
+	case claims.ExpiresAt == 0 || now.Add(-clockSkew).Unix() > claims.ExpiresAt:
+		return nil, fmt.Errorf("ID token expired")
+	case claims.IssuedAt > now.Add(clockSkew).Unix():
+		return nil, fmt.Errorf("ID token issued in the future")
+	case claims.Nonce == "" || claims.Nonce != nonce:
+		return nil, fmt.Errorf("ID token nonce mismatch")
+	case claims.Subject == "":
+		return nil, fmt.Errorf("ID token missing sub")
+	}
+
+	id := &Identity{Subject: claims.Subject, Email: claims.Email, Role: p.cfg.DefaultRole}
+	if p.cfg.RoleClaim != "" {
+		var all map[string]json.RawMessage
+		json.Unmarshal(claimsJSON, &all)
+		if role := roleFromClaim(all[p.cfg.RoleClaim]); role != "" {
+			id.Role = role
+		}
+	}
+	return id, nil
+}
+
+// roleFromClaim accepts a string or a non-empty array of strings.
+func roleFromClaim(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var list []string
+	if json.Unmarshal(raw, &list) == nil && len(list) > 0 {
+		return list[0]
+	}
+	return ""
+}
+
+// audience decodes the "aud" claim, which may be a string or an array.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		*a = audience{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("aud must be a string or array of strings")
+	}
+	*a = list
+	return nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, rawURL string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: HTTP %d", rawURL, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(out); err != nil {
+		return fmt.Errorf("GET %s: invalid JSON: %w", rawURL, err)
+	}
+	return nil
+}
+
+// withQuery appends q to an endpoint that may already carry query parameters.
+func withQuery(endpoint string, q url.Values) string {
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
+	}
+	return endpoint + sep + q.Encode()
+}
@@ -0,0 +1,106 @@
+package oidc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quantun-opensource/qrap/api/internal/oidc/oidctest"
+)
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Provider) {
+	t.Helper()
+	idp := oidctest.New("qrap", "")
+	t.Cleanup(idp.Close)
+	p, err := NewProvider(context.Background(), Config{
+		IssuerURL:   idp.Issuer(),
+		ClientID:    "qrap",
+		RedirectURL: "http://localhost/api/v1/auth/callback",
+		RoleClaim:   "groups",
+		DefaultRole: "viewer",
+	})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+	t.Cleanup(p.Stop)
+	return p, idp
+}
+
+func TestNewProvider_IssuerMismatch(t *testing.T) {
+	idp := oidctest.New("qrap", "")
+	defer idp.Close()
+	_, err := NewProvider(context.Background(), Config{
+		IssuerURL:   idp.Issuer() + "/other",
+		ClientID:    "qrap",
+		RedirectURL: "http://localhost/cb",
+	})
+	if err == nil {
+		t.Fatal("expected discovery against a different issuer to fail")
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	p, _ := newTestProvider(t)
+	s, _ := NewLoginState("/findings")
+	u := p.AuthCodeURL(s)
+	for _, want := range []string{"response_type=code", "client_id=qrap", "code_challenge_method=S256", "scope=openid", "state=" + s.State, "nonce=" + s.Nonce} {
+		if !strings.Contains(u, want) {
+			t.Errorf("AuthCodeURL %q missing %q", u, want)
+		}
+	}
+	if strings.Contains(u, s.Verifier) {
+		t.Error("AuthCodeURL leaks the PKCE verifier")
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	p, idp := newTestProvider(t)
+	now := time.Now().Unix()
+	base := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss": idp.Issuer(), "aud": "qrap", "sub": "user-1", "nonce": "n-1",
+			"iat": now, "exp": now + 300, "email": "user@example.com",
+		}
+	}
+
+	id, err := p.verifyIDToken(context.Background(), idp.SignIDToken(base()), "n-1")
+	if err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+	if id.Subject != "user-1" || id.Email != "user@example.com" || id.Role != "viewer" {
+		t.Errorf("identity = %+v", id)
+	}
+
+	withGroups := base()
+	withGroups["groups"] = []string{"analyst", "other"}
+	if id, err := p.verifyIDToken(context.Background(), idp.SignIDToken(withGroups), "n-1"); err != nil || id.Role != "analyst" {
+		t.Errorf("role from array claim: %+v, %v", id, err)
+	}
+
+	cases := map[string]func(c map[string]interface{}){
+		"wrong issuer":       func(c map[string]interface{}) { c["iss"] = "https://evil.example" },
+		"wrong audience":     func(c map[string]interface{}) { c["aud"] = "other-client" },
+		"multi-aud no azp":   func(c map[string]interface{}) { c["aud"] = []string{"qrap", "other"} },
+		"expired":            func(c map[string]interface{}) { c["exp"] = now - 600 },
+		"issued in future":   func(c map[string]interface{}) { c["iat"] = now + 600 },
+		"nonce mismatch":     func(c map[string]interface{}) { c["nonce"] = "n-2" },
+		"missing nonce":      func(c map[string]interface{}) { delete(c, "nonce") },
+		"missing subject":    func(c map[string]interface{}) { delete(c, "sub") },
+		"missing expiration": func(c map[string]interface{}) { delete(c, "exp") },
+	}
+	for name, mutate := range cases {
+		c := base()
+		mutate(c)
+		if _, err := p.verifyIDToken(context.Background(), idp.SignIDToken(c), "n-1"); err == nil {
+			t.Errorf("%s: expected ID token to be rejected", name)
+		}
+	}
+
+	// Signed by someone else.
+	other := oidctest.New("qrap", "")
+	defer other.Close()
+	if _, err := p.verifyIDToken(context.Background(), other.SignIDToken(base()), "n-1"); err == nil {
+		t.Error("token signed by another provider: expected rejection")
+	}
+}
@@ -0,0 +1,197 @@
+"use strict";
+(function () {
+  var doc;
+
+  function el(tag, attrs) {
+    var node = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (k) {
+      if (k === "text") node.textContent = attrs[k];
+      else node.setAttribute(k, attrs[k]);
+    });
+    for (var i = 2; i < arguments.length; i++) {
+      var child = arguments[i];
+      if (child == null) continue;
+      node.appendChild(typeof child === "string" ? document.createTextNode(child) : child);
+    }
+    return node;
+  }
+
+  function resolve(ref) {
+    return ref.replace(/^#\//, "").split("/").reduce(function (cur, part) {
+      return cur && cur[part.replace(/~1/g, "/").replace(/~0/g, "~")];
+    }, doc);
+  }
+
+  function deref(obj) {
+    return obj && obj.$ref ? resolve(obj.$ref) : obj;
+  }
+
+  function refName(ref) {
+    return ref.split("/").pop();
+  }
+
+  function anchor(kind, name) {
+    return kind + "-" + name.replace(/[^A-Za-z0-9]+/g, "-");
+  }
+
+  // typeLabel describes a schema in one line, such as "Finding[]" or
+  // "string (uuid)".
+  function typeLabel(s) {
+    if (!s) return "any";
+    if (s.$ref) return refName(s.$ref);
+    var t = Array.isArray(s.type) ? s.type.join(" | ") : s.type || "any";
+    if (t === "array" && s.items) return typeLabel(s.items) + "[]";
+    if (s.format) t += " (" + s.format + ")";
+    return t;
+  }
+
+  function constraints(s) {
+    var parts = [];
+    if (s.enum) parts.push("one of " + s.enum.map(function (v) { return JSON.stringify(v); }).join(", "));
+    ["minLength", "maxLength", "minItems", "maxItems", "minimum", "maximum", "default"].forEach(function (k) {
+      if (s[k] !== undefined) parts.push(k + ": " + JSON.stringify(s[k]));
+    });
+    return parts.join("; ");
+  }
+
+  // schemaView renders a schema as a nested list of its properties, expanding
+  // references up to a few levels deep.
+  function schemaView(schema, depth) {
+    var s = deref(schema) || {};
+    var props = s.properties || (s.items && deref(s.items) && deref(s.items).properties);
+    var required = s.properties ? s.required || [] : (deref(s.items) || {}).required || [];
+    var list = el("ul", { "class": "schema" });
+    if (!props) {
+      var line = el("li", null, el("code", { text: typeLabel(schema) }));
+      var c = constraints(s);
+      if (c) line.appendChild(el("span", { "class": "muted", text: " " + c }));
+      list.appendChild(line);
+      return list;
+    }
+    Object.keys(props).forEach(function (name) {
+      var p = props[name];
+      var target = deref(p) || {};
+      var item = el("li", null,
+        el("code", { text: name }), " ",
+        el("span", { "class": "muted", text: typeLabel(p) }),
+        required.indexOf(name) >= 0 ? el("span", { "class": "required", text: "required" }) : null);
+      var text = [p.description || target.description, constraints(target)].filter(Boolean).join(" — ");
+      if (text) item.appendChild(el("div", { "class": "muted", text: text }));
+      var nested = deref(target.items) || target;
+      if (depth < 3 && nested.properties) item.appendChild(schemaView(p, depth + 1));
+      list.appendChild(item);
+    });
+    return list;
+  }
+
+  function parametersView(params) {
+    if (!params || !params.length) return null;
+    var body = el("tbody");
+    params.map(deref).forEach(function (p) {
+      body.appendChild(el("tr", null,
+        el("td", null, el("code", { text: p.name }), p.required ? el("span", { "class": "required", text: "required" }) : null),
+        el("td", { text: p["in"] }),
+        el("td", null, el("code", { text: typeLabel(p.schema) })),
+        el("td", { text: [p.description, constraints(deref(p.schema) || {})].filter(Boolean).join(" — ") })));
+    });
+    return el("table", null,
+      el("thead", null, el("tr", null, el("th", { text: "Parameter" }), el("th", { text: "In" }), el("th", { text: "Type" }), el("th", { text: "Description" }))),
+      body);
+  }
+
+  function contentView(content) {
+    var wrap = el("div");
+    Object.keys(content || {}).forEach(function (type) {
+      wrap.appendChild(el("div", { "class": "muted" }, el("code", { text: type })));
+      if (content[type].schema) wrap.appendChild(schemaView(content[type].schema, 0));
+    });
+    return wrap;
+  }
+
+  function operationView(method, path, op) {
+    var section = el("section", { "class": "op", id: anchor("op", op.operationId || method + path) },
+      el("header", null,
+        el("span", { "class": "method " + method, text: method }),
+        el("code", { "class": "path", text: path }),
+        el("span", { text: op.summary || "" })));
+    var body = el("div");
+    if (op.description) body.appendChild(el("p", { text: op.description }));
+    if (op.security && op.security.length === 0) body.appendChild(el("p", { "class": "muted", text: "No authentication required." }));
+    var params = parametersView(op.parameters);
+    if (params) body.appendChild(el("h4", { text: "Parameters" }), params);
+    if (op.requestBody) {
+      body.appendChild(el("h4", { text: "Request body" }));
+      body.appendChild(contentView(deref(op.requestBody).content));
+    }
+    body.appendChild(el("h4", { text: "Responses" }));
+    Object.keys(op.responses || {}).forEach(function (status) {
+      var r = deref(op.responses[status]);
+      var d = el("details", null, el("summary", null, el("span", { "class": "status", text: status }), " " + (r.description || "")));
+      if (r.content) d.appendChild(contentView(r.content));
+      body.appendChild(d);
+    });
+    section.appendChild(body);
+    return section;
+  }
+
+  function render() {
+    var nav = document.getElementById("nav");
+    var main = document.getElementById("main");
+    main.textContent = "";
+    main.appendChild(el("h1", { text: doc.info.title + " " + doc.info.version }));
+    if (doc.info.description) main.appendChild(el("p", { text: doc.info.description }));
+    main.appendChild(el("p", null, el("a", { href: "openapi.json", text: "openapi.json" })));
+
+    var byTag = {};
+    var tags = (doc.tags || []).map(function (t) { return t.name; });
+    Object.keys(doc.paths).forEach(function (path) {
+      Object.keys(doc.paths[path]).forEach(function (method) {
+        var op = doc.paths[path][method];
+        var tag = (op.tags || ["Other"])[0];
+        if (tags.indexOf(tag) < 0) tags.push(tag);
+        (byTag[tag] = byTag[tag] || []).push([method, path, op]);
+      });
+    });
+    tags.forEach(function (tag) {
+      if (!byTag[tag]) return;
+      nav.appendChild(el("h2", { text: tag }));
+      main.appendChild(el("h2", { id: anchor("tag", tag), text: tag }));
+      byTag[tag].forEach(function (entry) {
+        var op = entry[2];
+        nav.appendChild(el("a", { href: "#" + anchor("op", op.operationId || entry[0] + entry[1]), title: entry[1] },
+          el("span", { "class": "method " + entry[0], text: entry[0] }), " " + (op.summary || entry[1])));
+        main.appendChild(operationView(entry[0], entry[1], op));
+      });
+    });
+
+    var schemas = (doc.components && doc.components.schemas) || {};
+    nav.appendChild(el("h2", { text: "Schemas" }));
+    main.appendChild(el("h2", { text: "Schemas" }));
+    Object.keys(schemas).forEach(function (name) {
+      nav.appendChild(el("a", { href: "#" + anchor("schema", name), text: name }));
+      var section = el("section", { "class": "op", id: anchor("schema", name) },
+        el("header", null, el("code", { "class": "path", text: name })));
+      var body = el("div");
+      if (schemas[name].description) body.appendChild(el("p", { text: schemas[name].description }));
+      body.appendChild(schemaView(schemas[name], 0));
+      section.appendChild(body);
+      main.appendChild(section);
+    });
+    if (location.hash) {
+      var target = document.getElementById(location.hash.slice(1));
+      if (target) target.scrollIntoView();
+    }
+  }
+
+  fetch("openapi.json", { credentials: "same-origin" })
+    .then(function (res) {
+      if (!res.ok) throw new Error("HTTP " + res.status);
+      return res.json();
+    })
+    .then(function (d) { doc = d; render(); })
+    .catch(function (err) {
+      var main = document.getElementById("main");
+      main.textContent = "";
+      main.appendChild(el("p", { "class": "error", text: "Failed to load openapi.json: " + err.message }));
+    });
+})();
@@ -0,0 +1,271 @@
+// Package openapi holds the OpenAPI 3.1 document describing the API, the
+// docs page that renders it, and the response checks the contract tests
+// run against it.
+//
+// openapi.json is maintained by hand next to the handlers. The contract
+// tests in the handler package check every response the router sends in
+// tests against it, and the model types' JSON fields against its schemas,
+// so a change to either that is not made to the other fails the build.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	_ "embed"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+// JSON returns the OpenAPI document.
+func JSON() []byte {
+	return spec
+}
+
+// Route is an operation of the document: a method and a path template such
+// as /api/v1/organizations/{id}.
+type Route struct {
+	Method string
+	Path   string
+}
+
+func (r Route) String() string {
+	return r.Method + " " + r.Path
+}
+
+// Document is a parsed OpenAPI document.
+type Document struct {
+	root  map[string]interface{}
+	paths []pathTemplate
+}
+
+type pathTemplate struct {
+	template string
+	segments []string
+	item     map[string]interface{}
+}
+
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Load parses the embedded document.
+func Load() (*Document, error) {
+	return Parse(spec)
+}
+
+// Parse parses an OpenAPI document and checks that every $ref in it
+// resolves and that its schemas only use supported keywords.
+func Parse(data []byte) (*Document, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var root map[string]interface{}
+	if err := dec.Decode(&root); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	d := &Document{root: root}
+	if err := d.checkRefs(root, ""); err != nil {
+		return nil, err
+	}
+	paths, _ := root["paths"].(map[string]interface{})
+	for template, item := range paths {
+		item, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("path %s is not an object", template)
+		}
+		d.paths = append(d.paths, pathTemplate{template: template, segments: strings.Split(template, "/"), item: item})
+	}
+	sort.Slice(d.paths, func(i, j int) bool { return d.paths[i].template < d.paths[j].template })
+	return d, nil
+}
+
+// Routes returns every operation in the document.
+func (d *Document) Routes() []Route {
+	var routes []Route
+	for _, p := range d.paths {
+		for _, m := range methods {
+			if _, ok := p.item[m]; ok {
+				routes = append(routes, Route{Method: strings.ToUpper(m), Path: p.template})
+			}
+		}
+	}
+	return routes
+}
+
+// Schema returns the component schema called name.
+func (d *Document) Schema(name string) (map[string]interface{}, bool) {
+	v, err := d.resolve("#/components/schemas/" + name)
+	if err != nil {
+		return nil, false
+	}
+	s, ok := v.(map[string]interface{})
+	return s, ok
+}
+
+// Operation returns the operation serving method and path, a request path
+// such as /api/v1/organizations/3f2c..., and the template it matched. A
+// trailing slash is ignored, and literal segments win over parameters.
+func (d *Document) Operation(method, path string) (map[string]interface{}, string, bool) {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	segments := strings.Split(path, "/")
+	var best *pathTemplate
+	bestLiterals := -1
+	for i := range d.paths {
+		p := &d.paths[i]
+		if literals, ok := p.match(segments); ok && literals > bestLiterals {
+			best, bestLiterals = p, literals
+		}
+	}
+	if best == nil {
+		return nil, "", false
+	}
+	op, ok := best.item[strings.ToLower(method)].(map[string]interface{})
+	return op, best.template, ok
+}
+
+// match reports whether segments fit the template and how many of them
+// matched literally.
+func (p *pathTemplate) match(segments []string) (int, bool) {
+	if len(segments) != len(p.segments) {
+		return 0, false
+	}
+	literals := 0
+	for i, seg := range p.segments {
+		switch {
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			if segments[i] == "" {
+				return 0, false
+			}
+		case seg == segments[i]:
+			literals++
+		default:
+			return 0, false
+		}
+	}
+	return literals, true
+}
+
+// CheckResponse checks a response to method and path against the document:
+// the status must be documented (error statuses may fall back to the
+// default response), the Content-Type must be one the response declares,
+// and a JSON body must match the declared schema. A response without
+// content must have an empty body.
+func (d *Document) CheckResponse(method, path string, status int, header http.Header, body []byte) error {
+	op, template, ok := d.Operation(method, path)
+	if !ok {
+		return fmt.Errorf("no operation for %s %s", method, path)
+	}
+	responses, _ := op["responses"].(map[string]interface{})
+	resp, ok := responses[strconv.Itoa(status)]
+	if !ok && status >= 400 {
+		resp, ok = responses["default"]
+	}
+	if !ok {
+		return fmt.Errorf("status %d is not documented for %s %s", status, method, template)
+	}
+	r, err := d.deref(resp)
+	if err != nil {
+		return err
+	}
+	content, _ := r.(map[string]interface{})["content"].(map[string]interface{})
+	if len(content) == 0 {
+		if len(bytes.TrimSpace(body)) != 0 {
+			return fmt.Errorf("%d response to %s %s has a body but documents none", status, method, template)
+		}
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("%d response to %s %s has an invalid Content-Type %q", status, method, template, header.Get("Content-Type"))
+	}
+	media, ok := content[mediaType].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%d response to %s %s has Content-Type %s, which is not documented", status, method, template, mediaType)
+	}
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("%d response to %s %s is not valid JSON: %w", status, method, template, err)
+	}
+	var errs []string
+	d.validate(media["schema"], v, "", &errs)
+	if len(errs) > 0 {
+		return fmt.Errorf("%d response to %s %s does not match its schema: %s", status, method, template, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// resolve looks up a local reference such as #/components/schemas/Problem.
+func (d *Document) resolve(ref string) (interface{}, error) {
+	pointer, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %q: only local references are supported", ref)
+	}
+	var cur interface{} = d.root
+	for _, part := range strings.Split(pointer, "/") {
+		part = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("$ref %q does not resolve", ref)
+		}
+		if cur, ok = obj[part]; !ok {
+			return nil, fmt.Errorf("$ref %q does not resolve", ref)
+		}
+	}
+	return cur, nil
+}
+
+// deref follows v's $ref, if it has one.
+func (d *Document) deref(v interface{}) (interface{}, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return v, nil
+	}
+	ref, ok := obj["$ref"].(string)
+	if !ok {
+		return v, nil
+	}
+	return d.resolve(ref)
+}
+
+// checkRefs checks that every $ref under v, found at the JSON pointer at,
+// resolves, and checks the keywords of every schema.
+func (d *Document) checkRefs(v interface{}, at string) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			if _, err := d.resolve(ref); err != nil {
+				return fmt.Errorf("%s: %w", at, err)
+			}
+		}
+		for _, key := range sortedKeys(v) {
+			child, path := v[key], at+"/"+key
+			if key == "schema" || at == "/components/schemas" {
+				if err := checkKeywords(child, path); err != nil {
+					return err
+				}
+			}
+			if err := d.checkRefs(child, path); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if err := d.checkRefs(child, at+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	doc, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.root["openapi"] != "3.1.0" {
+		t.Errorf("openapi = %v", doc.root["openapi"])
+	}
+	ids := map[string]string{}
+	for _, route := range doc.Routes() {
+		op, _, _ := doc.Operation(route.Method, route.Path)
+		id, _ := op["operationId"].(string)
+		if id == "" {
+			t.Errorf("%s has no operationId", route)
+		} else if other, dup := ids[id]; dup {
+			t.Errorf("%s and %s share operationId %s", route, other, id)
+		}
+		ids[id] = route.String()
+		if responses, _ := op["responses"].(map[string]interface{}); responses["default"] == nil {
+			t.Errorf("%s has no default response", route)
+		}
+	}
+	if len(ids) < 30 {
+		t.Errorf("only %d operations", len(ids))
+	}
+
+	if _, err := Parse([]byte(`{"paths":{"/x":{"get":{"$ref":"#/components/missing"}}}}`)); err == nil {
+		t.Error("expected an error for a dangling $ref")
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	doc, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonHeader := http.Header{"Content-Type": {"application/json"}}
+	problemHeader := http.Header{"Content-Type": {"application/problem+json"}}
+	org := `{"id":"550e8400-e29b-41d4-a716-446655440000","name":"Acme","description":"","created_at":"2026-01-15T10:30:00Z","updated_at":"2026-01-15T10:30:00Z"}`
+	problem := `{"type":"about:blank","title":"Not Found","status":404,"detail":"organization not found","error":"organization not found"}`
+
+	cases := []struct {
+		name         string
+		method, path string
+		status       int
+		header       http.Header
+		body         string
+		wantErr      string
+	}{
+		{"valid", "GET", "/api/v1/organizations/550e8400-e29b-41d4-a716-446655440000", 200, jsonHeader, org, ""},
+		{"trailing slash", "POST", "/api/v1/organizations/", 201, jsonHeader, org, ""},
+		{"list", "GET", "/api/v1/organizations", 200, jsonHeader,
+			`{"organizations":[` + org + `],"offset":0,"limit":20,"next_cursor":null,"prev_cursor":null}`, ""},
+		{"problem", "GET", "/api/v1/organizations/x", 404, problemHeader, problem, ""},
+		{"default response", "GET", "/api/v1/organizations/x", 418, problemHeader, problem, ""},
+		{"no content", "DELETE", "/api/v1/organizations/x", 204, http.Header{}, "", ""},
+		{"html", "GET", "/api/v1/docs", 200, http.Header{"Content-Type": {"text/html; charset=utf-8"}}, "<!doctype html>", ""},
+
+		{"unknown path", "GET", "/api/v1/nothing", 200, jsonHeader, `{}`, "no operation"},
+		{"unknown method", "PUT", "/api/v1/organizations", 200, jsonHeader, `{}`, "no operation"},
+		{"undocumented status", "GET", "/api/v1/organizations/x", 202, jsonHeader, org, "not documented"},
+		{"undocumented content type", "GET", "/api/v1/organizations/x", 404, jsonHeader, problem, "not documented"},
+		{"body on 204", "DELETE", "/api/v1/organizations/x", 204, http.Header{}, "{}", "documents none"},
+		{"extra property", "GET", "/api/v1/organizations/x", 200, jsonHeader,
+			strings.Replace(org, `"name"`, `"owner":"bob","name"`, 1), "owner is not a documented property"},
+		{"missing property", "GET", "/api/v1/organizations/x", 200, jsonHeader,
+			strings.Replace(org, `"description":"",`, "", 1), "description is required"},
+		{"wrong type", "GET", "/api/v1/organizations/x", 200, jsonHeader,
+			strings.Replace(org, `"Acme"`, `42`, 1), "name: is integer, want string"},
+		{"bad format", "GET", "/api/v1/organizations/x", 200, jsonHeader,
+			strings.Replace(org, `"2026-01-15T10:30:00Z"`, `"yesterday"`, 1), "created_at"},
+		{"null list", "GET", "/api/v1/organizations", 200, jsonHeader,
+			`{"organizations":null,"offset":0,"limit":20,"next_cursor":null,"prev_cursor":null}`, "organizations: is null"},
+		{"nested enum", "GET", "/api/v1/findings/x", 200, jsonHeader,
+			`{"id":"550e8400-e29b-41d4-a716-446655440000","assessment_id":"550e8400-e29b-41d4-a716-446655440000","category":"WEAK_ALGORITHM",` +
+				`"risk_level":"SEVERE","title":"t","description":"d","affected_asset":"a","status":"OPEN","discovered_at":"2026-01-15T10:30:00Z"}`,
+			"risk_level: SEVERE is not one of"},
+	}
+	for _, tc := range cases {
+		err := doc.CheckResponse(tc.method, tc.path, tc.status, tc.header, []byte(tc.body))
+		switch {
+		case tc.wantErr == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+			t.Errorf("%s: error %v, want one containing %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestParseRejectsUnsupportedKeywords(t *testing.T) {
+	_, err := Parse([]byte(`{"components":{"schemas":{"Name":{"type":"object","properties":{"first":{"type":"string","pattern":"^a"}}}}}}`))
+	if err == nil || !strings.Contains(err.Error(), `/components/schemas/Name/properties/first: unsupported schema keyword "pattern"`) {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestDocsPage(t *testing.T) {
+	page, csp := DocsPage()
+	if !strings.Contains(string(page), "<script>"+docsJS+"</script>") || strings.Contains(string(page), "{{") {
+		t.Fatal("page does not inline its script")
+	}
+	hashes := regexp.MustCompile(`'sha256-[A-Za-z0-9+/=]+'`).FindAllString(csp, -1)
+	if len(hashes) != 2 || hashes[0] != sourceHash(docsJS) || hashes[1] != sourceHash(docsCSS) {
+		t.Errorf("csp = %s", csp)
+	}
+	if strings.Contains(csp, "unsafe") {
+		t.Errorf("csp allows unsafe sources: %s", csp)
+	}
+}
@@ -0,0 +1,235 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// annotations are schema keywords that do not constrain values.
+var annotations = map[string]bool{
+	"description": true, "title": true, "default": true, "example": true, "examples": true,
+	"deprecated": true, "readOnly": true, "writeOnly": true, "$comment": true,
+}
+
+// assertions are the schema keywords validate checks.
+var assertions = map[string]bool{
+	"$ref": true, "type": true, "enum": true, "const": true, "format": true,
+	"minLength": true, "maxLength": true, "minItems": true, "maxItems": true, "minProperties": true,
+	"minimum": true, "maximum": true, "items": true, "properties": true, "required": true,
+	"additionalProperties": true,
+}
+
+// checkKeywords reports the first keyword in schema, or in the schemas it
+// nests, that is neither an assertion validate checks nor an annotation, so
+// that the document cannot state a rule the contract tests would skip.
+func checkKeywords(schema interface{}, at string) error {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	for _, key := range sortedKeys(s) {
+		if !assertions[key] && !annotations[key] {
+			return fmt.Errorf("%s: unsupported schema keyword %q", at, key)
+		}
+	}
+	if props, ok := s["properties"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(props) {
+			if err := checkKeywords(props[name], at+"/properties/"+name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties"} {
+		if err := checkKeywords(s[key], at+"/"+key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// validate checks v against schema, appending a message for each problem
+// to errs. at is the location of v in the body, such as findings[2].status.
+func (d *Document) validate(schema, v interface{}, at string, errs *[]string) {
+	fail := func(format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		if at != "" {
+			msg = at + ": " + msg
+		}
+		*errs = append(*errs, msg)
+	}
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		fail("schema is not an object")
+		return
+	}
+	if t, ok := s["type"]; ok && !hasType(t, v) {
+		fail("is %s, want %v", typeOf(v), t)
+		return
+	}
+	for _, key := range sortedKeys(s) {
+		arg := s[key]
+		switch key {
+		case "$ref":
+			target, err := d.resolve(arg.(string))
+			if err != nil {
+				fail("%v", err)
+				continue
+			}
+			d.validate(target, v, at, errs)
+		case "enum":
+			if !slices.ContainsFunc(arg.([]interface{}), func(e interface{}) bool { return jsonEqual(e, v) }) {
+				fail("%v is not one of %v", v, arg)
+			}
+		case "const":
+			if !jsonEqual(arg, v) {
+				fail("%v is not %v", v, arg)
+			}
+		case "format":
+			if str, ok := v.(string); ok {
+				checkFormat(arg.(string), str, fail)
+			}
+		case "minLength", "maxLength":
+			if str, ok := v.(string); ok {
+				checkBound(key, utf8.RuneCountInString(str), arg, "characters", fail)
+			}
+		case "minItems", "maxItems":
+			if items, ok := v.([]interface{}); ok {
+				checkBound(key, len(items), arg, "items", fail)
+			}
+		case "minProperties":
+			if obj, ok := v.(map[string]interface{}); ok {
+				checkBound(key, len(obj), arg, "properties", fail)
+			}
+		case "minimum", "maximum":
+			if n, ok := v.(json.Number); ok {
+				x, _ := n.Float64()
+				limit, _ := arg.(json.Number).Float64()
+				if (key == "minimum" && x < limit) || (key == "maximum" && x > limit) {
+					fail("%v is out of range (%s %v)", n, key, limit)
+				}
+			}
+		case "items":
+			for i, item := range asArray(v) {
+				d.validate(arg, item, fmt.Sprintf("%s[%d]", at, i), errs)
+			}
+		case "properties":
+			obj, _ := v.(map[string]interface{})
+			props := arg.(map[string]interface{})
+			for _, name := range sortedKeys(props) {
+				if val, ok := obj[name]; ok {
+					d.validate(props[name], val, join(at, name), errs)
+				}
+			}
+		case "required":
+			if obj, ok := v.(map[string]interface{}); ok {
+				for _, name := range arg.([]interface{}) {
+					if _, ok := obj[name.(string)]; !ok {
+						fail("%s is required", name)
+					}
+				}
+			}
+		case "additionalProperties":
+			obj, _ := v.(map[string]interface{})
+			props, _ := s["properties"].(map[string]interface{})
+			for _, name := range sortedKeys(obj) {
+				if _, ok := props[name]; ok {
+					continue
+				}
+				if arg == false {
+					fail("%s is not a documented property", name)
+				} else if sub, ok := arg.(map[string]interface{}); ok {
+					d.validate(sub, obj[name], join(at, name), errs)
+				}
+			}
+		}
+	}
+}
+
+// hasType reports whether v is of the JSON type named by t, a type name or
+// a list of them.
+func hasType(t, v interface{}) bool {
+	if list, ok := t.([]interface{}); ok {
+		return slices.ContainsFunc(list, func(t interface{}) bool { return hasType(t, v) })
+	}
+	want, _ := t.(string)
+	got := typeOf(v)
+	return got == want || (want == "number" && got == "integer")
+}
+
+// typeOf names the JSON type of v.
+func typeOf(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func checkFormat(format, s string, fail func(string, ...interface{})) {
+	switch format {
+	case "uuid":
+		if !uuidPattern.MatchString(s) {
+			fail("%q is not a UUID", s)
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			fail("%q is not an RFC 3339 date-time", s)
+		}
+	default:
+		fail("unsupported format %q", format)
+	}
+}
+
+func checkBound(key string, n int, arg interface{}, unit string, fail func(string, ...interface{})) {
+	limit, _ := arg.(json.Number).Int64()
+	if (strings.HasPrefix(key, "min") && int64(n) < limit) || (strings.HasPrefix(key, "max") && int64(n) > limit) {
+		fail("has %d %s (%s %d)", n, unit, key, limit)
+	}
+}
+
+func jsonEqual(a, b interface{}) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
+
+func asArray(v interface{}) []interface{} {
+	items, _ := v.([]interface{})
+	return items
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func join(at, name string) string {
+	if at == "" {
+		return name
+	}
+	return at + "." + name
+}
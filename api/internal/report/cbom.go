@@ -0,0 +1,247 @@
+package report
+
+import (
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// cbomSpecVersion is the CycloneDX version whose cryptographic asset
+// components the CBOM uses.
+const cbomSpecVersion = "1.6"
+
+type cbom struct {
+	BOMFormat       string              `json:"bomFormat"`
+	SpecVersion     string              `json:"specVersion"`
+	SerialNumber    string              `json:"serialNumber"`
+	Version         int                 `json:"version"`
+	Metadata        cbomMetadata        `json:"metadata"`
+	Components      []cbomComponent     `json:"components"`
+	Vulnerabilities []cbomVulnerability `json:"vulnerabilities"`
+}
+
+type cbomMetadata struct {
+	Timestamp string        `json:"timestamp"`
+	Tools     cbomTools     `json:"tools"`
+	Component cbomComponent `json:"component"`
+}
+
+type cbomTools struct {
+	Components []cbomComponent `json:"components"`
+}
+
+type cbomComponent struct {
+	Type             string                `json:"type"`
+	BOMRef           string                `json:"bom-ref,omitempty"`
+	Name             string                `json:"name"`
+	CryptoProperties *cbomCryptoProperties `json:"cryptoProperties,omitempty"`
+	Properties       []cbomProperty        `json:"properties,omitempty"`
+}
+
+type cbomCryptoProperties struct {
+	AssetType           string                  `json:"assetType"`
+	AlgorithmProperties cbomAlgorithmProperties `json:"algorithmProperties"`
+}
+
+type cbomAlgorithmProperties struct {
+	Primitive                string `json:"primitive"`
+	ParameterSetIdentifier   string `json:"parameterSetIdentifier,omitempty"`
+	NistQuantumSecurityLevel int    `json:"nistQuantumSecurityLevel"`
+}
+
+type cbomProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cbomVulnerability struct {
+	BOMRef         string         `json:"bom-ref"`
+	ID             string         `json:"id"`
+	Source         cbomSource     `json:"source"`
+	Ratings        []cbomRating   `json:"ratings"`
+	Description    string         `json:"description"`
+	Detail         string         `json:"detail,omitempty"`
+	Recommendation string         `json:"recommendation,omitempty"`
+	Affects        []cbomAffects  `json:"affects"`
+	Properties     []cbomProperty `json:"properties"`
+}
+
+type cbomSource struct {
+	Name string `json:"name"`
+}
+
+type cbomRating struct {
+	Severity string `json:"severity"`
+	Method   string `json:"method"`
+}
+
+type cbomAffects struct {
+	Ref string `json:"ref"`
+}
+
+// WriteCBOM writes r as a CycloneDX 1.6 cryptographic bill of materials.
+// Each algorithm the findings name, in use or recommended, is a
+// cryptographic asset component listing the assets it was seen on, and
+// each finding is a vulnerability of the algorithm it concerns (or of the
+// assessment, for findings that name none).
+func WriteCBOM(w io.Writer, r *Report) error {
+	subject := "assessment/" + r.Assessment.ID.String()
+	doc := cbom{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  cbomSpecVersion,
+		SerialNumber: "urn:uuid:" + uuid.NewString(),
+		Version:      1,
+		Metadata: cbomMetadata{
+			Timestamp: r.generatedAt().Format("2006-01-02T15:04:05Z"),
+			Tools:     cbomTools{Components: []cbomComponent{{Type: "application", Name: "qrap"}}},
+			Component: cbomComponent{Type: "application", BOMRef: subject, Name: r.Assessment.Name},
+		},
+		Components:      []cbomComponent{},
+		Vulnerabilities: []cbomVulnerability{},
+	}
+
+	components := map[string]*cbomComponent{}
+	var order []string
+	addAlgorithm := func(name, asset string, inUse bool) string {
+		ref := "crypto/algorithm/" + strings.ToLower(name)
+		c, ok := components[ref]
+		if !ok {
+			c = &cbomComponent{
+				Type:             "cryptographic-asset",
+				BOMRef:           ref,
+				Name:             name,
+				CryptoProperties: algorithmProperties(name),
+			}
+			components[ref] = c
+			order = append(order, ref)
+		}
+		if inUse {
+			prop := cbomProperty{Name: "qrap:affected_asset", Value: asset}
+			if !slices.Contains(c.Properties, prop) {
+				c.Properties = append(c.Properties, prop)
+			}
+		}
+		return ref
+	}
+
+	for _, f := range r.Findings {
+		ref := subject
+		if f.CurrentAlgorithm != nil && *f.CurrentAlgorithm != "" {
+			ref = addAlgorithm(*f.CurrentAlgorithm, f.AffectedAsset, true)
+		}
+		if f.RecommendedAlgorithm != nil && *f.RecommendedAlgorithm != "" {
+			addAlgorithm(*f.RecommendedAlgorithm, "", false)
+		}
+		props := []cbomProperty{
+			{Name: "qrap:category", Value: f.Category},
+			{Name: "qrap:affected_asset", Value: f.AffectedAsset},
+		}
+		if f.Status != "" {
+			props = append(props, cbomProperty{Name: "qrap:status", Value: f.Status})
+		}
+		if f.RecommendedAlgorithm != nil {
+			props = append(props, cbomProperty{Name: "qrap:recommended_algorithm", Value: *f.RecommendedAlgorithm})
+		}
+		doc.Vulnerabilities = append(doc.Vulnerabilities, cbomVulnerability{
+			BOMRef:         "finding/" + f.ID.String(),
+			ID:             f.ID.String(),
+			Source:         cbomSource{Name: "QRAP"},
+			Ratings:        []cbomRating{{Severity: cbomSeverity(f.RiskLevel), Method: "other"}},
+			Description:    f.Title,
+			Detail:         f.Description,
+			Recommendation: deref(f.Remediation),
+			Affects:        []cbomAffects{{Ref: ref}},
+			Properties:     props,
+		})
+	}
+	for _, ref := range order {
+		doc.Components = append(doc.Components, *components[ref])
+	}
+	return writeIndented(w, doc)
+}
+
+// cbomSeverity maps a risk level to a CycloneDX severity.
+func cbomSeverity(risk string) string {
+	if risk == "" {
+		return "unknown"
+	}
+	return strings.ToLower(risk)
+}
+
+var parameterSetPattern = regexp.MustCompile(`-(\d+)$`)
+
+// algorithmProperties classifies an algorithm by name: its cryptographic
+// primitive and NIST post-quantum security level (0 for classical
+// algorithms a quantum computer breaks or weakens).
+func algorithmProperties(name string) *cbomCryptoProperties {
+	upper := strings.ToUpper(name)
+	props := cbomAlgorithmProperties{Primitive: "unknown"}
+	if m := parameterSetPattern.FindStringSubmatch(upper); m != nil {
+		props.ParameterSetIdentifier = m[1]
+	}
+	has := func(prefixes ...string) bool {
+		for _, p := range prefixes {
+			if strings.HasPrefix(upper, p) || strings.Contains(upper, "-"+p) || strings.Contains(upper, "_"+p) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case has("ML-KEM", "KYBER", "HQC", "FRODO"):
+		props.Primitive = "kem"
+		props.NistQuantumSecurityLevel = pqcLevel(props.ParameterSetIdentifier, map[string]int{"512": 1, "768": 3, "1024": 5})
+	case has("ML-DSA", "DILITHIUM"):
+		props.Primitive = "signature"
+		props.NistQuantumSecurityLevel = pqcLevel(props.ParameterSetIdentifier, map[string]int{"44": 2, "65": 3, "87": 5})
+	case has("SLH-DSA", "SPHINCS", "FALCON", "FN-DSA", "XMSS", "LMS"):
+		props.Primitive = "signature"
+		props.NistQuantumSecurityLevel = 1
+	case has("ECDH", "X25519", "X448", "DH", "FFDHE"):
+		props.Primitive = "key-agree"
+	case has("ECDSA", "ED25519", "ED448", "EDDSA", "DSA", "ES256", "ES384", "ES512", "PS256", "RS256", "RS384", "RS512"):
+		props.Primitive = "signature"
+	case has("RSA"):
+		props.Primitive = "pke"
+	case strings.Contains(upper, "GCM"), strings.Contains(upper, "CCM"), strings.Contains(upper, "POLY1305"):
+		props.Primitive = "ae"
+		props.NistQuantumSecurityLevel = symmetricLevel(upper)
+	case has("AES", "3DES", "DES", "CAMELLIA"):
+		props.Primitive = "block-cipher"
+		props.NistQuantumSecurityLevel = symmetricLevel(upper)
+	case has("RC4", "CHACHA20"):
+		props.Primitive = "stream-cipher"
+	case has("HMAC"):
+		props.Primitive = "mac"
+	case has("SHA", "MD5", "SHAKE"):
+		props.Primitive = "hash"
+	}
+	return &cbomCryptoProperties{AssetType: "algorithm", AlgorithmProperties: props}
+}
+
+// pqcLevel looks a post-quantum parameter set up in levels, defaulting to
+// level 1 for sets it does not know.
+func pqcLevel(set string, levels map[string]int) int {
+	if level, ok := levels[set]; ok {
+		return level
+	}
+	return 1
+}
+
+// symmetricLevel rates a symmetric cipher by key size: Grover's algorithm
+// halves it, so 128-bit keys are level 1, 192-bit level 3 and 256-bit
+// level 5.
+func symmetricLevel(name string) int {
+	switch {
+	case strings.Contains(name, "256"), strings.Contains(name, "CHACHA20"):
+		return 5
+	case strings.Contains(name, "192"):
+		return 3
+	case strings.Contains(name, "128"):
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+var csvHeader = []string{
+	"id", "assessment_id", "risk_level", "category", "status", "title", "description",
+	"affected_asset", "current_algorithm", "recommended_algorithm", "remediation", "discovered_at",
+}
+
+// WriteCSV writes r's findings as CSV with a header row.
+func WriteCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, f := range r.Findings {
+		record := []string{
+			f.ID.String(), f.AssessmentID.String(), f.RiskLevel, f.Category, f.Status, f.Title, f.Description,
+			f.AffectedAsset, deref(f.CurrentAlgorithm), deref(f.RecommendedAlgorithm), deref(f.Remediation), f.DiscoveredAt,
+		}
+		for i := range record {
+			record[i] = csvSafe(record[i])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// csvSafe quotes a cell that a spreadsheet would otherwise evaluate as a
+// formula. Titles and descriptions may come from plugins or scanned hosts.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
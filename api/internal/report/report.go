@@ -0,0 +1,96 @@
+// Package report renders an assessment and its findings in the formats
+// other tools consume: SARIF for code scanning dashboards, CSV for
+// spreadsheets and a CycloneDX cryptographic bill of materials (CBOM).
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// Formats are the names accepted by Write.
+var Formats = []string{"json", "sarif", "csv", "cbom"}
+
+// Report is an assessment with its findings.
+type Report struct {
+	Assessment model.AssessmentResponse `json:"assessment"`
+	Findings   []model.FindingResponse  `json:"findings"`
+	// GeneratedAt is when the report was produced; zero means now.
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+func (r *Report) generatedAt() time.Time {
+	if r.GeneratedAt.IsZero() {
+		return time.Now().UTC()
+	}
+	return r.GeneratedAt.UTC()
+}
+
+// Write renders r to w in format, one of Formats.
+func Write(w io.Writer, format string, r *Report) error {
+	switch strings.ToLower(format) {
+	case "json":
+		return WriteJSON(w, r)
+	case "sarif":
+		return WriteSARIF(w, r)
+	case "csv":
+		return WriteCSV(w, r)
+	case "cbom":
+		return WriteCBOM(w, r)
+	}
+	return fmt.Errorf("unknown report format %q (want one of %s)", format, strings.Join(Formats, ", "))
+}
+
+// WriteJSON writes r as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	out := *r
+	out.GeneratedAt = r.generatedAt()
+	if out.Findings == nil {
+		out.Findings = []model.FindingResponse{}
+	}
+	return writeIndented(w, out)
+}
+
+// WriteBundle writes the scan bundle of an offline scan as indented JSON.
+func WriteBundle(w io.Writer, b *model.ScanBundle) error {
+	return writeIndented(w, b)
+}
+
+func writeIndented(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// unresolvedStatuses are the triage states of findings that still need
+// action.
+var unresolvedStatuses = []string{"OPEN", "ACKNOWLEDGED"}
+
+// RiskRank returns the position of level in model.RiskLevels (0 is the
+// most severe), or -1 if it is not a risk level. Case is ignored.
+func RiskRank(level string) int {
+	return slices.Index(model.RiskLevels, strings.ToUpper(level))
+}
+
+// AtOrAbove returns the unresolved findings whose risk is threshold or
+// more severe. A finding with no status counts as unresolved.
+func AtOrAbove(findings []model.FindingResponse, threshold string) []model.FindingResponse {
+	limit := RiskRank(threshold)
+	var out []model.FindingResponse
+	for _, f := range findings {
+		if rank := RiskRank(f.RiskLevel); rank < 0 || rank > limit {
+			continue
+		}
+		if f.Status != "" && !slices.Contains(unresolvedStatuses, f.Status) {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
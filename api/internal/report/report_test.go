@@ -0,0 +1,208 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+func testReport() *Report {
+	str := func(s string) *string { return &s }
+	assessmentID := uuid.MustParse("00000000-0000-0000-0000-0000000000aa")
+	return &Report{
+		Assessment: model.AssessmentResponse{ID: assessmentID, Name: "Quarterly", Status: "COMPLETED", RiskScore: 75},
+		Findings: []model.FindingResponse{
+			{
+				ID: uuid.New(), AssessmentID: assessmentID, Category: "HARVEST_NOW_DECRYPT_LATER", RiskLevel: "CRITICAL",
+				Title: "HNDL risk", Description: "Key exchange is classical", AffectedAsset: "tls://a.example:443",
+				CurrentAlgorithm: str("RSA-2048"), RecommendedAlgorithm: str("ML-KEM-768"), Status: "OPEN",
+			},
+			{
+				ID: uuid.New(), AssessmentID: assessmentID, Category: "MISSING_PQC", RiskLevel: "HIGH",
+				Title: "=HYPERLINK(\"http://evil\")", Description: "No PQC", AffectedAsset: "tls://b.example:443",
+				CurrentAlgorithm: str("RSA-2048"), Remediation: str("Migrate"), Status: "RESOLVED",
+			},
+			{
+				ID: uuid.New(), AssessmentID: assessmentID, Category: "CERTIFICATE_EXPIRY", RiskLevel: "MEDIUM",
+				Title: "Expiring", Description: "Expires soon", AffectedAsset: "tls://a.example:443", Status: "ACKNOWLEDGED",
+			},
+		},
+		GeneratedAt: time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestAtOrAbove(t *testing.T) {
+	findings := testReport().Findings
+	cases := []struct {
+		threshold string
+		want      int
+	}{
+		{"CRITICAL", 1},
+		{"high", 1}, // the HIGH finding is resolved
+		{"MEDIUM", 2},
+		{"INFO", 2},
+	}
+	for _, tc := range cases {
+		if got := AtOrAbove(findings, tc.threshold); len(got) != tc.want {
+			t.Errorf("AtOrAbove(%s) = %d findings, want %d", tc.threshold, len(got), tc.want)
+		}
+	}
+	if RiskRank("SEVERE") != -1 || RiskRank("critical") != 0 {
+		t.Error("RiskRank")
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "SARIF", testReport()); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct{ ID string }
+				}
+			}
+			Results []struct {
+				RuleID    string
+				RuleIndex int
+				Level     string
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI string }
+					}
+				}
+				Properties map[string]interface{}
+			}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("log = %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 3 || len(run.Results) != 3 {
+		t.Fatalf("%d rules, %d results", len(run.Tool.Driver.Rules), len(run.Results))
+	}
+	for i, want := range []string{"error", "error", "warning"} {
+		res := run.Results[i]
+		if res.Level != want {
+			t.Errorf("result %d level = %s, want %s", i, res.Level, want)
+		}
+		if run.Tool.Driver.Rules[res.RuleIndex].ID != res.RuleID {
+			t.Errorf("result %d: ruleIndex %d does not point at %s", i, res.RuleIndex, res.RuleID)
+		}
+	}
+	if uri := run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "tls://a.example:443" {
+		t.Errorf("location = %s", uri)
+	}
+	if run.Results[0].Properties["security-severity"] != "9.5" {
+		t.Errorf("properties = %v", run.Results[0].Properties)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "csv", testReport()); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[0][0] != "id" || len(rows[1]) != len(csvHeader) {
+		t.Fatalf("rows = %v", rows)
+	}
+	if rows[1][2] != "CRITICAL" || rows[1][8] != "RSA-2048" || rows[1][9] != "ML-KEM-768" {
+		t.Errorf("row = %v", rows[1])
+	}
+	if !strings.HasPrefix(rows[2][5], "'=") {
+		t.Errorf("formula not neutralised: %q", rows[2][5])
+	}
+}
+
+func TestWriteCBOM(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "cbom", testReport()); err != nil {
+		t.Fatal(err)
+	}
+	var bom struct {
+		BOMFormat   string
+		SpecVersion string
+		Components  []struct {
+			BOMRef           string `json:"bom-ref"`
+			Name             string
+			CryptoProperties struct {
+				AlgorithmProperties struct {
+					Primitive                string
+					NistQuantumSecurityLevel int
+				}
+			}
+			Properties []struct{ Name, Value string }
+		}
+		Vulnerabilities []struct {
+			Ratings []struct{ Severity string }
+			Affects []struct{ Ref string }
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &bom); err != nil {
+		t.Fatal(err)
+	}
+	if bom.BOMFormat != "CycloneDX" || bom.SpecVersion != "1.6" {
+		t.Fatalf("bom = %+v", bom)
+	}
+	if len(bom.Components) != 2 {
+		t.Fatalf("components = %+v", bom.Components)
+	}
+	rsa, kem := bom.Components[0], bom.Components[1]
+	if rsa.Name != "RSA-2048" || rsa.CryptoProperties.AlgorithmProperties.Primitive != "pke" || len(rsa.Properties) != 2 {
+		t.Errorf("RSA component = %+v", rsa)
+	}
+	if kem.CryptoProperties.AlgorithmProperties.Primitive != "kem" || kem.CryptoProperties.AlgorithmProperties.NistQuantumSecurityLevel != 3 {
+		t.Errorf("ML-KEM component = %+v", kem)
+	}
+	if len(bom.Vulnerabilities) != 3 || bom.Vulnerabilities[0].Ratings[0].Severity != "critical" ||
+		bom.Vulnerabilities[0].Affects[0].Ref != rsa.BOMRef ||
+		bom.Vulnerabilities[2].Affects[0].Ref != "assessment/00000000-0000-0000-0000-0000000000aa" {
+		t.Errorf("vulnerabilities = %+v", bom.Vulnerabilities)
+	}
+}
+
+func TestAlgorithmProperties(t *testing.T) {
+	cases := []struct {
+		name      string
+		primitive string
+		level     int
+	}{
+		{"ML-DSA-65", "signature", 3},
+		{"ECDSA-P256", "signature", 0},
+		{"X25519", "key-agree", 0},
+		{"AES-256-GCM", "ae", 5},
+		{"AES-128-CBC", "block-cipher", 1},
+		{"SHA-1", "hash", 0},
+		{"Unobtainium", "unknown", 0},
+	}
+	for _, tc := range cases {
+		props := algorithmProperties(tc.name).AlgorithmProperties
+		if props.Primitive != tc.primitive || props.NistQuantumSecurityLevel != tc.level {
+			t.Errorf("%s: %s level %d, want %s level %d", tc.name, props.Primitive, props.NistQuantumSecurityLevel, tc.primitive, tc.level)
+		}
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, "pdf", testReport()); err == nil {
+		t.Error("expected an error")
+	}
+}
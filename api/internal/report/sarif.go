@@ -0,0 +1,194 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "QRAP"
+	toolURI      = "https://github.com/yazhsab/qbitel-qrap"
+)
+
+// categoryDescriptions describe the finding categories, which are the
+// SARIF rules.
+var categoryDescriptions = map[string]string{
+	"WEAK_ALGORITHM":            "Cryptographic algorithm considered weak",
+	"SHORT_KEY_LENGTH":          "Key shorter than recommended",
+	"DEPRECATED_PROTOCOL":       "Deprecated protocol version in use",
+	"MISSING_PQC":               "No post-quantum protection",
+	"CERTIFICATE_EXPIRY":        "Certificate expired or expiring",
+	"HARVEST_NOW_DECRYPT_LATER": "Data exposed to harvest-now-decrypt-later attacks",
+}
+
+// securitySeverity is the CVSS-like score code scanning dashboards sort
+// by, for each risk level.
+var securitySeverity = map[string]string{
+	"CRITICAL": "9.5",
+	"HIGH":     "8.0",
+	"MEDIUM":   "5.5",
+	"LOW":      "3.0",
+	"INFO":     "0.0",
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool              `json:"tool"`
+	Invocations []sarifInvocation      `json:"invocations"`
+	Results     []sarifResult          `json:"results"`
+	Properties  map[string]interface{} `json:"properties"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string            `json:"id"`
+	Name             string            `json:"name"`
+	ShortDescription sarifMessage      `json:"shortDescription"`
+	Properties       map[string]string `json:"properties,omitempty"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful bool   `json:"executionSuccessful"`
+	EndTimeUTC          string `json:"endTimeUtc"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	GUID       string                 `json:"guid"`
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// sarifLevel maps a risk level to a SARIF result level.
+func sarifLevel(risk string) string {
+	switch risk {
+	case "CRITICAL", "HIGH":
+		return "error"
+	case "MEDIUM":
+		return "warning"
+	}
+	return "note"
+}
+
+// WriteSARIF writes r as a SARIF 2.1.0 log with one rule per finding
+// category and one result per finding, located at its affected asset.
+func WriteSARIF(w io.Writer, r *Report) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{Name: toolName, InformationURI: toolURI, Rules: []sarifRule{}}},
+		Invocations: []sarifInvocation{{
+			ExecutionSuccessful: true,
+			EndTimeUTC:          r.generatedAt().Format("2006-01-02T15:04:05Z"),
+		}},
+		Results: []sarifResult{},
+		Properties: map[string]interface{}{
+			"assessment_id":   r.Assessment.ID,
+			"assessment_name": r.Assessment.Name,
+			"risk_score":      r.Assessment.RiskScore,
+		},
+	}
+
+	var rules []string
+	for _, f := range r.Findings {
+		if !slices.Contains(rules, f.Category) {
+			rules = append(rules, f.Category)
+		}
+	}
+	slices.Sort(rules)
+	for _, id := range rules {
+		desc := categoryDescriptions[id]
+		if desc == "" {
+			desc = id
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               id,
+			Name:             ruleName(id),
+			ShortDescription: sarifMessage{Text: desc},
+			Properties:       map[string]string{"tags": "security,cryptography,post-quantum"},
+		})
+	}
+
+	for _, f := range r.Findings {
+		props := map[string]interface{}{
+			"risk_level":        f.RiskLevel,
+			"security-severity": securitySeverity[f.RiskLevel],
+		}
+		if f.Status != "" {
+			props["status"] = f.Status
+		}
+		if f.CurrentAlgorithm != nil {
+			props["current_algorithm"] = *f.CurrentAlgorithm
+		}
+		if f.RecommendedAlgorithm != nil {
+			props["recommended_algorithm"] = *f.RecommendedAlgorithm
+		}
+		run.Results = append(run.Results, sarifResult{
+			GUID:       f.ID.String(),
+			RuleID:     f.Category,
+			RuleIndex:  slices.Index(rules, f.Category),
+			Level:      sarifLevel(f.RiskLevel),
+			Message:    sarifMessage{Text: findingMessage(f)},
+			Locations:  []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: f.AffectedAsset}}}},
+			Properties: props,
+		})
+	}
+
+	return writeIndented(w, sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
+
+// ruleName turns a category such as MISSING_PQC into MissingPqc.
+func ruleName(category string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(strings.ToLower(category), "_") {
+		if word != "" {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+func findingMessage(f model.FindingResponse) string {
+	msg := fmt.Sprintf("%s: %s", f.Title, f.Description)
+	if f.Remediation != nil && *f.Remediation != "" {
+		msg += " Remediation: " + *f.Remediation
+	}
+	return msg
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/quantun-opensource/qrap/api/model"
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+// lastUsedResolution bounds how often a key's last_used_at is written, so a
+// busy key does not turn every request into an UPDATE.
+const lastUsedResolution = time.Minute
+
+type APIKeyRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(pool *pgxpool.Pool) *APIKeyRepository {
+	return &APIKeyRepository{pool: pool}
+}
+
+const apiKeyColumns = `id, name, prefix, subject, role, scopes, expires_at, last_used_at, revoked_at, created_by, created_at, updated_at`
+
+func scanAPIKey(row pgx.Row) (*model.APIKey, error) {
+	var k model.APIKey
+	err := row.Scan(
+		&k.ID, &k.Name, &k.Prefix, &k.Subject, &k.Role, &k.Scopes,
+		&k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedBy, &k.CreatedAt, &k.UpdatedAt,
+	)
+	return &k, err
+}
+
+// ownerFilter restricts rows to keys owned by subject; an empty subject
+// matches every key.
+func ownerFilter(subject string, argIdx int) (string, []interface{}) {
+	if subject == "" {
+		return "", nil
+	}
+	return fmt.Sprintf(" AND subject = $%d", argIdx), []interface{}{subject}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, k *model.APIKey) error {
+	query := `
+		INSERT INTO api_keys (id, name, prefix, salt, key_hash, subject, role, scopes,
+		                      expires_at, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $11)
+	`
+	_, err := r.pool.Exec(ctx, query,
+		k.ID, k.Name, k.Prefix, k.Salt, k.KeyHash, k.Subject, k.Role, k.Scopes,
+		k.ExpiresAt, k.CreatedBy, k.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert API key: %w", dbError(err))
+	}
+	return nil
+}
+
+// GetByID returns a key owned by subject, or any key if subject is empty.
+func (r *APIKeyRepository) GetByID(ctx context.Context, id uuid.UUID, subject string) (*model.APIKey, error) {
+	filter, args := ownerFilter(subject, 2)
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1` + filter
+	k, err := scanAPIKey(r.pool.QueryRow(ctx, query, append([]interface{}{id}, args...)...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, notFound("API key")
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return k, nil
+}
+
+// List returns the keys owned by subject, or every key if subject is empty,
+// newest first. Revoked keys are included.
+func (r *APIKeyRepository) List(ctx context.Context, subject string) ([]model.APIKey, error) {
+	filter, args := ownerFilter(subject, 1)
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE TRUE` + filter + ` ORDER BY created_at DESC`
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+// Rotate replaces the secret of an active key. The old secret stops working
+// immediately.
+func (r *APIKeyRepository) Rotate(ctx context.Context, id uuid.UUID, subject, prefix string, salt, keyHash []byte) error {
+	filter, args := ownerFilter(subject, 6)
+	query := `
+		UPDATE api_keys SET prefix = $1, salt = $2, key_hash = $3, updated_at = $4
+		WHERE id = $5 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $4)` + filter
+	result, err := r.pool.Exec(ctx, query, append([]interface{}{prefix, salt, keyHash, time.Now().UTC(), id}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to rotate API key: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return notFound("API key")
+	}
+	return nil
+}
+
+// Revoke disables a key permanently. Revoking a revoked key is not found.
+func (r *APIKeyRepository) Revoke(ctx context.Context, id uuid.UUID, subject string) error {
+	filter, args := ownerFilter(subject, 3)
+	query := `UPDATE api_keys SET revoked_at = $1, updated_at = $1 WHERE id = $2 AND revoked_at IS NULL` + filter
+	result, err := r.pool.Exec(ctx, query, append([]interface{}{time.Now().UTC(), id}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return notFound("API key")
+	}
+	return nil
+}
+
+// LookupAPIKey implements qmw.APIKeyStore. The key's id selects at most one
+// row, whose salted hash is compared in constant time; revoked and expired
+// keys do not match.
+func (r *APIKeyRepository) LookupAPIKey(ctx context.Context, key string) (*qmw.APIKeyEntry, error) {
+	prefix, ok := qmw.APIKeyID(key)
+	if !ok {
+		return nil, nil
+	}
+
+	var (
+		id        uuid.UUID
+		salt      []byte
+		keyHash   []byte
+		entry     qmw.APIKeyEntry
+		expiresAt *time.Time
+		revokedAt *time.Time
+		lastUsed  *time.Time
+	)
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, salt, key_hash, subject, role, scopes, expires_at, revoked_at, last_used_at
+		FROM api_keys WHERE prefix = $1
+	`, prefix).Scan(&id, &salt, &keyHash, &entry.Subject, &entry.Role, &entry.Scopes, &expiresAt, &revokedAt, &lastUsed)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if !qmw.CheckAPIKeyHash(key, salt, keyHash) || revokedAt != nil || (expiresAt != nil && !now.Before(*expiresAt)) {
+		return nil, nil
+	}
+
+	if lastUsed == nil || now.Sub(*lastUsed) >= lastUsedResolution {
+		if _, err := r.pool.Exec(ctx, `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`, now, id); err != nil {
+			return nil, fmt.Errorf("failed to record API key use: %w", err)
+		}
+	}
+	return &entry, nil
+}
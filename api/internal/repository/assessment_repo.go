@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -9,7 +10,8 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
-	"github.com/quantun-opensource/qrap/api/internal/model"
+	"github.com/quantun-opensource/qrap/api/internal/tenant"
+	"github.com/quantun-opensource/qrap/api/model"
 )
 
 type AssessmentRepository struct {
@@ -20,52 +22,150 @@ func NewAssessmentRepository(pool *pgxpool.Pool) *AssessmentRepository {
 	return &AssessmentRepository{pool: pool}
 }
 
+// Create inserts a. The assessment's organization must be in the caller's
+// scope and not deleted.
 func (r *AssessmentRepository) Create(ctx context.Context, a *model.Assessment) error {
+	if !tenant.FromContext(ctx).Allows(a.OrganizationID) {
+		return notFound("organization")
+	}
+	var live bool
+	if err := r.pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM organizations WHERE id = $1 AND deleted_at IS NULL)`, a.OrganizationID,
+	).Scan(&live); err != nil {
+		return fmt.Errorf("failed to check organization: %w", err)
+	}
+	if !live {
+		return notFound("organization")
+	}
+
 	query := `
-		INSERT INTO assessments (id, name, organization_id, status, risk_score, target_assets, created_by, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO assessments (id, name, organization_id, status, risk_score, target_assets,
+		                         enabled_analyzers, disabled_analyzers, created_by, created_at, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $9, $10)
+		RETURNING created_at, updated_by, updated_at
 	`
-	_, err := r.pool.Exec(ctx, query,
-		a.ID, a.Name, a.OrganizationID, a.Status, a.RiskScore, a.TargetAssets, a.CreatedBy, a.CreatedAt,
-	)
+	err := r.pool.QueryRow(ctx, query,
+		a.ID, a.Name, a.OrganizationID, a.Status, a.RiskScore, a.TargetAssets,
+		nonNil(a.EnabledAnalyzers), nonNil(a.DisabledAnalyzers), a.CreatedBy, a.CreatedAt,
+	).Scan(&a.CreatedAt, &a.UpdatedBy, &a.UpdatedAt)
 	if err != nil {
-		return fmt.Errorf("failed to insert assessment: %w", err)
+		return fmt.Errorf("failed to insert assessment: %w", dbError(err))
 	}
 	return nil
 }
 
-func (r *AssessmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Assessment, error) {
+// Import inserts a, already COMPLETED, together with its findings and the
+// run that produced them, in one transaction. The organization must be in
+// the caller's scope and not deleted.
+func (r *AssessmentRepository) Import(ctx context.Context, a *model.Assessment, findings []model.Finding, run *model.AssessmentRun) error {
+	if !tenant.FromContext(ctx).Allows(a.OrganizationID) {
+		return notFound("organization")
+	}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var live bool
+	if err := tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM organizations WHERE id = $1 AND deleted_at IS NULL)`, a.OrganizationID,
+	).Scan(&live); err != nil {
+		return fmt.Errorf("failed to check organization: %w", err)
+	}
+	if !live {
+		return notFound("organization")
+	}
+
 	query := `
-		SELECT id, name, organization_id, status, overall_risk, risk_score,
-		       target_assets, assets_scanned, pqc_readiness, started_at, completed_at,
-		       created_by, created_at, updated_by, updated_at
-		FROM assessments WHERE id = $1
+		INSERT INTO assessments (id, name, organization_id, status, overall_risk, risk_score, target_assets,
+		                         enabled_analyzers, disabled_analyzers, assets_scanned, pqc_readiness,
+		                         started_at, completed_at, created_by, created_at, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $14, $15)
+		RETURNING created_at, updated_by, updated_at
 	`
-	a := &model.Assessment{}
-	err := r.pool.QueryRow(ctx, query, id).Scan(
+	err = tx.QueryRow(ctx, query,
+		a.ID, a.Name, a.OrganizationID, a.Status, a.OverallRisk, a.RiskScore, a.TargetAssets,
+		nonNil(a.EnabledAnalyzers), nonNil(a.DisabledAnalyzers), a.AssetsScanned, a.PqcReadiness,
+		a.StartedAt, a.CompletedAt, a.CreatedBy, a.CreatedAt,
+	).Scan(&a.CreatedAt, &a.UpdatedBy, &a.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert assessment: %w", dbError(err))
+	}
+
+	for i := range findings {
+		f := &findings[i]
+		_, err := tx.Exec(ctx, `
+			INSERT INTO findings (id, assessment_id, category, risk_level, title, description,
+			                      affected_asset, current_algorithm, recommended_algorithm, remediation, discovered_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			f.ID, f.AssessmentID, f.Category, f.RiskLevel, f.Title, f.Description,
+			f.AffectedAsset, f.CurrentAlgorithm, f.RecommendedAlgorithm, f.Remediation, f.DiscoveredAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert finding %d: %w", i, dbError(err))
+		}
+	}
+
+	errorsJSON, err := json.Marshal(run.Errors)
+	if err != nil {
+		return fmt.Errorf("failed to encode run errors: %w", err)
+	}
+	if run.Errors == nil {
+		errorsJSON = []byte("[]")
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO assessment_runs (id, assessment_id, status, analyzers, errors, findings_count,
+		                             started_at, completed_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		run.ID, run.AssessmentID, run.Status, nonNil(run.Analyzers), errorsJSON, run.FindingsCount,
+		run.StartedAt, run.CompletedAt, run.CreatedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert assessment run: %w", dbError(err))
+	}
+
+	return tx.Commit(ctx)
+}
+
+// assessmentColumns are the columns scanAssessment reads.
+const assessmentColumns = `
+	id, name, organization_id, status, overall_risk, risk_score,
+	target_assets, enabled_analyzers, disabled_analyzers,
+	assets_scanned, pqc_readiness, started_at, completed_at,
+	created_by, created_at, updated_by, updated_at`
+
+func scanAssessment(row pgx.Row, a *model.Assessment) error {
+	return row.Scan(
 		&a.ID, &a.Name, &a.OrganizationID, &a.Status, &a.OverallRisk, &a.RiskScore,
-		&a.TargetAssets, &a.AssetsScanned, &a.PqcReadiness, &a.StartedAt, &a.CompletedAt,
+		&a.TargetAssets, &a.EnabledAnalyzers, &a.DisabledAnalyzers,
+		&a.AssetsScanned, &a.PqcReadiness, &a.StartedAt, &a.CompletedAt,
 		&a.CreatedBy, &a.CreatedAt, &a.UpdatedBy, &a.UpdatedAt,
 	)
+}
+
+// GetByID returns an assessment that has not been deleted.
+func (r *AssessmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Assessment, error) {
+	query := `SELECT ` + assessmentColumns + ` FROM assessments WHERE id = $1 AND deleted_at IS NULL`
+	filter, args := scopeFilter(ctx, "organization_id", 2)
+	a := &model.Assessment{}
+	err := scanAssessment(r.pool.QueryRow(ctx, query+filter, append([]interface{}{id}, args...)...), a)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("assessment not found: %s", id)
+			return nil, notFound("assessment")
 		}
 		return nil, fmt.Errorf("failed to get assessment: %w", err)
 	}
 	return a, nil
 }
 
-func (r *AssessmentRepository) List(ctx context.Context, orgID *uuid.UUID, status string, offset, limit int) ([]model.Assessment, int, error) {
-	countQuery := `SELECT COUNT(*) FROM assessments WHERE 1=1`
-	listQuery := `
-		SELECT id, name, organization_id, status, overall_risk, risk_score,
-		       target_assets, assets_scanned, pqc_readiness, started_at, completed_at,
-		       created_by, created_at, updated_by, updated_at
-		FROM assessments WHERE 1=1
-	`
-	var args []interface{}
-	argIdx := 1
+func (r *AssessmentRepository) List(ctx context.Context, orgID *uuid.UUID, status string, page Page) ([]model.Assessment, PageInfo, error) {
+	countQuery := `SELECT COUNT(*) FROM assessments WHERE deleted_at IS NULL`
+	listQuery := `SELECT ` + assessmentColumns + ` FROM assessments WHERE deleted_at IS NULL`
+	filter, args := scopeFilter(ctx, "organization_id", 1)
+	countQuery += filter
+	listQuery += filter
+	argIdx := len(args) + 1
 
 	if orgID != nil {
 		filter := fmt.Sprintf(" AND organization_id = $%d", argIdx)
@@ -82,43 +182,51 @@ func (r *AssessmentRepository) List(ctx context.Context, orgID *uuid.UUID, statu
 		argIdx++
 	}
 
-	var total int
-	if err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
-		return nil, 0, fmt.Errorf("failed to count assessments: %w", err)
+	var info PageInfo
+	if page.Count {
+		var total int
+		if err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+			return nil, info, fmt.Errorf("failed to count assessments: %w", err)
+		}
+		info.Total = &total
 	}
 
-	listQuery += fmt.Sprintf(" ORDER BY created_at DESC OFFSET $%d LIMIT $%d", argIdx, argIdx+1)
-	args = append(args, offset, limit)
-
-	rows, err := r.pool.Query(ctx, listQuery, args...)
+	clause, pageArgs, err := createdKeyset.clause(page, argIdx)
+	if err != nil {
+		return nil, info, err
+	}
+	rows, err := r.pool.Query(ctx, listQuery+clause, append(args, pageArgs...)...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list assessments: %w", err)
+		return nil, info, fmt.Errorf("failed to list assessments: %w", err)
 	}
 	defer rows.Close()
 
 	var assessments []model.Assessment
 	for rows.Next() {
 		var a model.Assessment
-		if err := rows.Scan(
-			&a.ID, &a.Name, &a.OrganizationID, &a.Status, &a.OverallRisk, &a.RiskScore,
-			&a.TargetAssets, &a.AssetsScanned, &a.PqcReadiness, &a.StartedAt, &a.CompletedAt,
-			&a.CreatedBy, &a.CreatedAt, &a.UpdatedBy, &a.UpdatedAt,
-		); err != nil {
-			return nil, 0, fmt.Errorf("failed to scan assessment: %w", err)
+		if err := scanAssessment(rows, &a); err != nil {
+			return nil, info, fmt.Errorf("failed to scan assessment: %w", err)
 		}
 		assessments = append(assessments, a)
 	}
-	return assessments, total, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, info, fmt.Errorf("failed to list assessments: %w", err)
+	}
+	assessments, info = pageOf(assessments, page, info.Total, func(a *model.Assessment) []string {
+		return []string{cursorTime(a.CreatedAt), a.ID.String()}
+	})
+	return assessments, info, nil
 }
 
 func (r *AssessmentRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status, updatedBy string) error {
-	query := `UPDATE assessments SET status = $1, updated_by = $2, updated_at = $3 WHERE id = $4`
-	result, err := r.pool.Exec(ctx, query, status, updatedBy, time.Now().UTC(), id)
+	filter, args := scopeFilter(ctx, "organization_id", 5)
+	query := `UPDATE assessments SET status = $1, updated_by = $2, updated_at = $3 WHERE id = $4 AND deleted_at IS NULL` + filter
+	result, err := r.pool.Exec(ctx, query, append([]interface{}{status, updatedBy, time.Now().UTC(), id}, args...)...)
 	if err != nil {
 		return fmt.Errorf("failed to update assessment status: %w", err)
 	}
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("assessment not found: %s", id)
+		return notFound("assessment")
 	}
 	return nil
 }
@@ -129,14 +237,148 @@ func (r *AssessmentRepository) UpdateResults(ctx context.Context, id uuid.UUID,
 		UPDATE assessments
 		SET overall_risk = $1, risk_score = $2, pqc_readiness = $3, assets_scanned = $4,
 		    status = 'COMPLETED', completed_at = $5, updated_at = $5
-		WHERE id = $6
-	`
-	result, err := r.pool.Exec(ctx, query, overallRisk, riskScore, pqcReadiness, assetsScanned, now, id)
+		WHERE id = $6 AND deleted_at IS NULL`
+	filter, args := scopeFilter(ctx, "organization_id", 7)
+	result, err := r.pool.Exec(ctx, query+filter, append([]interface{}{overallRisk, riskScore, pqcReadiness, assetsScanned, now, id}, args...)...)
 	if err != nil {
 		return fmt.Errorf("failed to update assessment results: %w", err)
 	}
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("assessment not found: %s", id)
+		return notFound("assessment")
+	}
+	return nil
+}
+
+// UpdateProgress records how many target assets a running assessment has
+// analyzed so far. It does not touch updated_at: progress is not an edit.
+func (r *AssessmentRepository) UpdateProgress(ctx context.Context, id uuid.UUID, assetsScanned int) error {
+	filter, args := scopeFilter(ctx, "organization_id", 3)
+	query := `UPDATE assessments SET assets_scanned = $1 WHERE id = $2 AND deleted_at IS NULL` + filter
+	result, err := r.pool.Exec(ctx, query, append([]interface{}{assetsScanned, id}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to update assessment progress: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return notFound("assessment")
 	}
 	return nil
 }
+
+// Update changes the fields of upd that are set. Only DRAFT assessments can
+// be edited; others return ErrInvalidState. If ifMatch is non-empty, the
+// assessment is only updated while its updated_at is one of ifMatch;
+// otherwise Update returns ErrPreconditionFailed.
+func (r *AssessmentRepository) Update(ctx context.Context, id uuid.UUID, upd *model.UpdateAssessmentRequest, updatedBy string, ifMatch []time.Time) (*model.Assessment, error) {
+	args := []interface{}{upd.Name, upd.TargetAssets, updatedBy, id}
+	query := `
+		UPDATE assessments
+		SET name = COALESCE($1, name), target_assets = COALESCE($2, target_assets), updated_by = $3, updated_at = NOW()
+		WHERE id = $4 AND deleted_at IS NULL AND status = 'DRAFT'`
+	if ifMatch != nil {
+		args = append(args, ifMatch)
+		query += fmt.Sprintf(" AND updated_at = ANY($%d::timestamptz[])", len(args))
+	}
+	filter, scopeArgs := scopeFilter(ctx, "organization_id", len(args)+1)
+	query += filter + ` RETURNING ` + assessmentColumns
+
+	a := &model.Assessment{}
+	if err := scanAssessment(r.pool.QueryRow(ctx, query, append(args, scopeArgs...)...), a); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, r.preconditionError(ctx, id, func(status string) bool { return status == "DRAFT" })
+		}
+		return nil, fmt.Errorf("failed to update assessment: %w", dbError(err))
+	}
+	return a, nil
+}
+
+// Delete soft-deletes an assessment that is not running. ifMatch is as for
+// Update.
+func (r *AssessmentRepository) Delete(ctx context.Context, id uuid.UUID, deletedBy string, ifMatch []time.Time) error {
+	args := []interface{}{deletedBy, id}
+	query := `
+		UPDATE assessments SET deleted_by = $1, deleted_at = NOW()
+		WHERE id = $2 AND deleted_at IS NULL AND status <> 'IN_PROGRESS'`
+	if ifMatch != nil {
+		args = append(args, ifMatch)
+		query += fmt.Sprintf(" AND updated_at = ANY($%d::timestamptz[])", len(args))
+	}
+	filter, scopeArgs := scopeFilter(ctx, "organization_id", len(args)+1)
+	result, err := r.pool.Exec(ctx, query+filter, append(args, scopeArgs...)...)
+	if err != nil {
+		return fmt.Errorf("failed to delete assessment: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return r.preconditionError(ctx, id, func(status string) bool { return status != "IN_PROGRESS" })
+	}
+	return nil
+}
+
+// Restore undeletes an assessment deleted at or after since. It returns
+// ErrNotFound for an assessment deleted before since or whose organization
+// is deleted, and ErrInvalidState for one that is not deleted.
+func (r *AssessmentRepository) Restore(ctx context.Context, id uuid.UUID, restoredBy string, since time.Time) (*model.Assessment, error) {
+	filter, args := scopeFilter(ctx, "organization_id", 2)
+	var deletedAt *time.Time
+	err := r.pool.QueryRow(ctx, `
+		SELECT deleted_at FROM assessments
+		WHERE id = $1 AND organization_id IN (SELECT id FROM organizations WHERE deleted_at IS NULL)`+filter,
+		append([]interface{}{id}, args...)...,
+	).Scan(&deletedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, notFound("assessment")
+		}
+		return nil, fmt.Errorf("failed to get assessment: %w", err)
+	}
+	if deletedAt == nil {
+		return nil, invalidState("assessment is not deleted")
+	}
+	if deletedAt.Before(since) {
+		return nil, notFound("assessment")
+	}
+
+	query := `
+		UPDATE assessments SET deleted_by = NULL, deleted_at = NULL, updated_by = $1, updated_at = NOW()
+		WHERE id = $2 AND deleted_at = $3 RETURNING ` + assessmentColumns
+	a := &model.Assessment{}
+	if err := scanAssessment(r.pool.QueryRow(ctx, query, restoredBy, id, *deletedAt), a); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, notFound("assessment")
+		}
+		return nil, fmt.Errorf("failed to restore assessment: %w", dbError(err))
+	}
+	return a, nil
+}
+
+// Purge permanently removes assessments deleted before cutoff; the foreign
+// keys cascade to their findings and runs.
+func (r *AssessmentRepository) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.pool.Exec(ctx, `DELETE FROM assessments WHERE deleted_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge assessments: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// preconditionError explains why a conditional write to id matched no row:
+// the assessment is gone (ErrNotFound), is in a status the write does not
+// apply to (ErrInvalidState), or has changed (ErrPreconditionFailed).
+func (r *AssessmentRepository) preconditionError(ctx context.Context, id uuid.UUID, allowed func(status string) bool) error {
+	a, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !allowed(a.Status) {
+		return invalidState("assessment is %s", a.Status)
+	}
+	return &Error{Kind: ErrPreconditionFailed, Message: "assessment has been modified; fetch it again for a current ETag"}
+}
+
+// nonNil converts a nil slice to an empty one so TEXT[] NOT NULL columns
+// receive '{}' rather than NULL.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
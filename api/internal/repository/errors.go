@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Domain error kinds. Repositories and services return them wrapped in an
+// *Error carrying a client-safe message; test for them with errors.Is.
+var (
+	// ErrNotFound is returned when a row does not exist or lies outside the
+	// caller's organization scope. The two cases are deliberately
+	// indistinguishable.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a write would violate a uniqueness rule.
+	ErrConflict = errors.New("conflict")
+	// ErrInvalidState is returned when an operation does not apply to the
+	// resource's current state, such as editing a running assessment.
+	ErrInvalidState = errors.New("invalid state")
+	// ErrValidation is returned when input is rejected before or by the
+	// database, such as a value breaking a check constraint.
+	ErrValidation = errors.New("validation failed")
+	// ErrPreconditionFailed is returned when a conditional write finds that
+	// the row has changed since the version the caller read.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Error is a domain error. Kind is one of the error kinds above and Message
+// describes the failure in terms fit to return to API clients; Err, if set,
+// is the underlying cause and is only meant for logs.
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap exposes both the kind and the cause to errors.Is and errors.As.
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// notFound returns the ErrNotFound error for a missing resource.
+func notFound(resource string) error {
+	return &Error{Kind: ErrNotFound, Message: resource + " not found"}
+}
+
+// invalidState returns an ErrInvalidState error with a formatted message.
+func invalidState(format string, args ...interface{}) error {
+	return &Error{Kind: ErrInvalidState, Message: fmt.Sprintf(format, args...)}
+}
+
+// constraintMessages describes the constraints clients can break.
+// Violations of others get a generic message for their class.
+var constraintMessages = map[string]string{
+	"idx_organizations_name": "an organization with this name already exists",
+	"organizations_name_key": "an organization with this name already exists",
+}
+
+// dbError maps the Postgres errors a client can cause onto domain errors:
+// unique violations become ErrConflict, foreign key violations ErrNotFound,
+// and check, not-null, format and length violations ErrValidation. Other
+// errors are returned unchanged.
+func dbError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	var kind error
+	var message string
+	switch pgErr.Code {
+	case "23505": // unique_violation
+		kind, message = ErrConflict, "the resource already exists"
+	case "23503": // foreign_key_violation
+		kind, message = ErrNotFound, "a referenced resource does not exist"
+	case "23514", "23502", "22P02", "22001": // check, not null, text representation, string too long
+		kind, message = ErrValidation, "the request contains an invalid value"
+	default:
+		return err
+	}
+	if m, ok := constraintMessages[pgErr.ConstraintName]; ok {
+		message = m
+	}
+	return &Error{Kind: kind, Message: message, Err: err}
+}
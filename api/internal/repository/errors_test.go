@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestDBError(t *testing.T) {
+	cases := []struct {
+		pgErr   *pgconn.PgError
+		kind    error
+		message string
+	}{
+		{&pgconn.PgError{Code: "23505", ConstraintName: "idx_organizations_name"}, ErrConflict, "an organization with this name already exists"},
+		{&pgconn.PgError{Code: "23505", ConstraintName: "api_keys_prefix_key"}, ErrConflict, "the resource already exists"},
+		{&pgconn.PgError{Code: "23503"}, ErrNotFound, "a referenced resource does not exist"},
+		{&pgconn.PgError{Code: "22001"}, ErrValidation, "the request contains an invalid value"},
+	}
+	for _, tc := range cases {
+		err := fmt.Errorf("failed to insert: %w", dbError(tc.pgErr))
+		if !errors.Is(err, tc.kind) {
+			t.Errorf("%s: %v is not %v", tc.pgErr.Code, err, tc.kind)
+		}
+		var domainErr *Error
+		if !errors.As(err, &domainErr) || domainErr.Message != tc.message {
+			t.Errorf("%s: message = %v, want %q", tc.pgErr.Code, domainErr, tc.message)
+		}
+		var pgErr *pgconn.PgError
+		if !errors.As(err, &pgErr) {
+			t.Errorf("%s: cause lost", tc.pgErr.Code)
+		}
+	}
+
+	other := &pgconn.PgError{Code: "40001"}
+	if err := dbError(other); err != other {
+		t.Errorf("serialization failure mapped to %v", err)
+	}
+	plain := errors.New("connection reset")
+	if err := dbError(plain); err != plain {
+		t.Errorf("non-Postgres error mapped to %v", err)
+	}
+}
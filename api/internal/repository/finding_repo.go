@@ -3,12 +3,14 @@ package repository
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
-	"github.com/quantun-opensource/qrap/api/internal/model"
+	"github.com/quantun-opensource/qrap/api/model"
 )
 
 type FindingRepository struct {
@@ -20,6 +22,9 @@ func NewFindingRepository(pool *pgxpool.Pool) *FindingRepository {
 }
 
 func (r *FindingRepository) Create(ctx context.Context, f *model.Finding) error {
+	if err := r.checkAssessments(ctx, r.pool, []uuid.UUID{f.AssessmentID}); err != nil {
+		return err
+	}
 	query := `
 		INSERT INTO findings (id, assessment_id, category, risk_level, title, description,
 		                      affected_asset, current_algorithm, recommended_algorithm, remediation, discovered_at)
@@ -46,6 +51,16 @@ func (r *FindingRepository) CreateBatch(ctx context.Context, findings []model.Fi
 	}
 	defer tx.Rollback(ctx)
 
+	ids := make([]uuid.UUID, 0, len(findings))
+	for i := range findings {
+		if !slices.Contains(ids, findings[i].AssessmentID) {
+			ids = append(ids, findings[i].AssessmentID)
+		}
+	}
+	if err := r.checkAssessments(ctx, tx, ids); err != nil {
+		return err
+	}
+
 	for i := range findings {
 		f := &findings[i]
 		query := `
@@ -68,59 +83,121 @@ func (r *FindingRepository) CreateBatch(ctx context.Context, findings []model.Fi
 func (r *FindingRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Finding, error) {
 	query := `
 		SELECT id, assessment_id, category, risk_level, title, description,
-		       affected_asset, current_algorithm, recommended_algorithm, remediation, discovered_at, created_at
-		FROM findings WHERE id = $1
-	`
+		       affected_asset, current_algorithm, recommended_algorithm, remediation, status, discovered_at, created_at
+		FROM findings WHERE id = $1`
+	filter, args := assessmentScopeFilter(ctx, "assessment_id", 2)
 	f := &model.Finding{}
-	err := r.pool.QueryRow(ctx, query, id).Scan(
+	err := r.pool.QueryRow(ctx, query+filter, append([]interface{}{id}, args...)...).Scan(
 		&f.ID, &f.AssessmentID, &f.Category, &f.RiskLevel, &f.Title, &f.Description,
-		&f.AffectedAsset, &f.CurrentAlgorithm, &f.RecommendedAlgorithm, &f.Remediation, &f.DiscoveredAt, &f.CreatedAt,
+		&f.AffectedAsset, &f.CurrentAlgorithm, &f.RecommendedAlgorithm, &f.Remediation, &f.Status, &f.DiscoveredAt, &f.CreatedAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("finding not found: %s", id)
+			return nil, notFound("finding")
 		}
 		return nil, fmt.Errorf("failed to get finding: %w", err)
 	}
 	return f, nil
 }
 
-func (r *FindingRepository) ListByAssessment(ctx context.Context, assessmentID uuid.UUID, riskLevel, category string, offset, limit int) ([]model.Finding, int, error) {
-	countQuery := `SELECT COUNT(*) FROM findings WHERE assessment_id = $1`
-	listQuery := `
-		SELECT id, assessment_id, category, risk_level, title, description,
-		       affected_asset, current_algorithm, recommended_algorithm, remediation, discovered_at, created_at
-		FROM findings WHERE assessment_id = $1
-	`
-	args := []interface{}{assessmentID}
-	argIdx := 2
+// findingSorts maps each of model.FindingSorts to its keyset.
+var findingSorts = map[string]keyset{
+	"risk_level":      findingKeyset,
+	"-risk_level":     {{"risk_level", "risk_level", true}, {"discovered_at", "timestamptz", true}, {"id", "uuid", true}},
+	"discovered_at":   {{"discovered_at", "timestamptz", false}, {"id", "uuid", false}},
+	"-discovered_at":  {{"discovered_at", "timestamptz", true}, {"id", "uuid", true}},
+	"created_at":      {{"created_at", "timestamptz", false}, {"id", "uuid", false}},
+	"-created_at":     {{"created_at", "timestamptz", true}, {"id", "uuid", true}},
+	"affected_asset":  {{"affected_asset", "text", false}, {"id", "uuid", false}},
+	"-affected_asset": {{"affected_asset", "text", true}, {"id", "uuid", true}},
+}
+
+// findingSortKey returns f's value for a findingSorts column.
+func findingSortKey(f *model.Finding, column string) string {
+	switch column {
+	case "risk_level":
+		return f.RiskLevel
+	case "discovered_at":
+		return cursorTime(f.DiscoveredAt)
+	case "created_at":
+		return cursorTime(f.CreatedAt)
+	case "affected_asset":
+		return f.AffectedAsset
+	}
+	return f.ID.String()
+}
 
-	if riskLevel != "" {
-		filter := fmt.Sprintf(" AND risk_level = $%d", argIdx)
-		countQuery += filter
-		listQuery += filter
-		args = append(args, riskLevel)
-		argIdx++
+// List returns the findings in the caller's scope that match filter.
+func (r *FindingRepository) List(ctx context.Context, filter model.FindingFilter, page Page) ([]model.Finding, PageInfo, error) {
+	sort := filter.Sort
+	if sort == "" {
+		sort = "risk_level"
 	}
-	if category != "" {
-		filter := fmt.Sprintf(" AND category = $%d", argIdx)
-		countQuery += filter
-		listQuery += filter
-		args = append(args, category)
-		argIdx++
+	keys, ok := findingSorts[sort]
+	if !ok {
+		return nil, PageInfo{}, &Error{Kind: ErrValidation, Message: fmt.Sprintf("unknown sort %q", sort)}
 	}
 
-	var total int
-	if err := r.pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
-		return nil, 0, fmt.Errorf("failed to count findings: %w", err)
+	where, args := assessmentScopeFilter(ctx, "assessment_id", 1)
+	add := func(clause string, arg interface{}) {
+		args = append(args, arg)
+		where += fmt.Sprintf(clause, len(args))
+	}
+	if len(filter.AssessmentIDs) > 0 {
+		add(" AND assessment_id = ANY($%d::uuid[])", filter.AssessmentIDs)
+	}
+	if len(filter.OrganizationIDs) > 0 {
+		add(" AND assessment_id IN (SELECT id FROM assessments WHERE organization_id = ANY($%d::uuid[]))", filter.OrganizationIDs)
+	}
+	if len(filter.RiskLevels) > 0 {
+		add(" AND risk_level = ANY($%d::text[]::risk_level[])", filter.RiskLevels)
+	}
+	if len(filter.Categories) > 0 {
+		add(" AND category = ANY($%d::text[]::finding_category[])", filter.Categories)
+	}
+	if len(filter.Statuses) > 0 {
+		add(" AND status = ANY($%d::text[]::finding_status[])", filter.Statuses)
+	}
+	if len(filter.Algorithms) > 0 {
+		lower := make([]string, len(filter.Algorithms))
+		for i, a := range filter.Algorithms {
+			lower[i] = strings.ToLower(a)
+		}
+		add(" AND lower(current_algorithm) = ANY($%d::text[])", lower)
+	}
+	if filter.Asset != "" {
+		add(` AND affected_asset LIKE $%d ESCAPE '\'`, assetPattern(filter.Asset))
+	}
+	if filter.DiscoveredAfter != nil {
+		add(" AND discovered_at >= $%d", *filter.DiscoveredAfter)
+	}
+	if filter.DiscoveredBefore != nil {
+		add(" AND discovered_at < $%d", *filter.DiscoveredBefore)
+	}
+	if filter.Query != "" {
+		add(" AND search_vector @@ websearch_to_tsquery('english', $%d)", filter.Query)
 	}
 
-	listQuery += fmt.Sprintf(" ORDER BY risk_level ASC, discovered_at DESC OFFSET $%d LIMIT $%d", argIdx, argIdx+1)
-	args = append(args, offset, limit)
+	var info PageInfo
+	if page.Count {
+		var total int
+		if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM findings WHERE TRUE`+where, args...).Scan(&total); err != nil {
+			return nil, info, fmt.Errorf("failed to count findings: %w", err)
+		}
+		info.Total = &total
+	}
 
-	rows, err := r.pool.Query(ctx, listQuery, args...)
+	clause, pageArgs, err := keys.clause(page, len(args)+1)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list findings: %w", err)
+		return nil, info, err
+	}
+	query := `
+		SELECT id, assessment_id, category, risk_level, title, description,
+		       affected_asset, current_algorithm, recommended_algorithm, remediation, status, discovered_at, created_at
+		FROM findings WHERE TRUE` + where + clause
+	rows, err := r.pool.Query(ctx, query, append(args, pageArgs...)...)
+	if err != nil {
+		return nil, info, fmt.Errorf("failed to list findings: %w", err)
 	}
 	defer rows.Close()
 
@@ -129,13 +206,47 @@ func (r *FindingRepository) ListByAssessment(ctx context.Context, assessmentID u
 		var f model.Finding
 		if err := rows.Scan(
 			&f.ID, &f.AssessmentID, &f.Category, &f.RiskLevel, &f.Title, &f.Description,
-			&f.AffectedAsset, &f.CurrentAlgorithm, &f.RecommendedAlgorithm, &f.Remediation, &f.DiscoveredAt, &f.CreatedAt,
+			&f.AffectedAsset, &f.CurrentAlgorithm, &f.RecommendedAlgorithm, &f.Remediation, &f.Status, &f.DiscoveredAt, &f.CreatedAt,
 		); err != nil {
-			return nil, 0, fmt.Errorf("failed to scan finding: %w", err)
+			return nil, info, fmt.Errorf("failed to scan finding: %w", err)
 		}
 		findings = append(findings, f)
 	}
-	return findings, total, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, info, fmt.Errorf("failed to list findings: %w", err)
+	}
+	findings, info = pageOf(findings, page, info.Total, func(f *model.Finding) []string {
+		values := make([]string, len(keys))
+		for i, k := range keys {
+			values[i] = findingSortKey(f, k.column)
+		}
+		return values
+	})
+	return findings, info, nil
+}
+
+// assetPattern turns an asset filter into a LIKE pattern: a glob if it
+// contains '*' or '?', a prefix otherwise.
+func assetPattern(asset string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(asset)
+	if !strings.ContainsAny(asset, "*?") {
+		return escaped + "%"
+	}
+	return strings.NewReplacer("*", "%", "?", "_").Replace(escaped)
+}
+
+// UpdateStatus sets a finding's triage status.
+func (r *FindingRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
+	filter, args := assessmentScopeFilter(ctx, "assessment_id", 3)
+	result, err := r.pool.Exec(ctx, `UPDATE findings SET status = $2 WHERE id = $1`+filter,
+		append([]interface{}{id, status}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to update finding status: %w", dbError(err))
+	}
+	if result.RowsAffected() == 0 {
+		return notFound("finding")
+	}
+	return nil
 }
 
 func (r *FindingRepository) CountByAssessment(ctx context.Context, assessmentID uuid.UUID) (*model.AssessmentSummary, error) {
@@ -146,10 +257,10 @@ func (r *FindingRepository) CountByAssessment(ctx context.Context, assessmentID
 			COUNT(*) FILTER (WHERE risk_level = 'HIGH') AS high,
 			COUNT(*) FILTER (WHERE risk_level = 'MEDIUM') AS medium,
 			COUNT(*) FILTER (WHERE risk_level = 'LOW') AS low
-		FROM findings WHERE assessment_id = $1
-	`
+		FROM findings WHERE assessment_id = $1`
+	filter, args := assessmentScopeFilter(ctx, "assessment_id", 2)
 	s := &model.AssessmentSummary{}
-	err := r.pool.QueryRow(ctx, query, assessmentID).Scan(
+	err := r.pool.QueryRow(ctx, query+filter, append([]interface{}{assessmentID}, args...)...).Scan(
 		&s.TotalFindings, &s.CriticalFindings, &s.HighFindings, &s.MediumFindings, &s.LowFindings,
 	)
 	if err != nil {
@@ -157,3 +268,25 @@ func (r *FindingRepository) CountByAssessment(ctx context.Context, assessmentID
 	}
 	return s, nil
 }
+
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// checkAssessments returns ErrNotFound unless every assessment in ids exists
+// within the caller's scope.
+func (r *FindingRepository) checkAssessments(ctx context.Context, q querier, ids []uuid.UUID) error {
+	filter, args := scopeFilter(ctx, "organization_id", 2)
+	var n int
+	err := q.QueryRow(ctx, `SELECT COUNT(*) FROM assessments WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL`+filter,
+		append([]interface{}{ids}, args...)...,
+	).Scan(&n)
+	if err != nil {
+		return fmt.Errorf("failed to check assessments: %w", err)
+	}
+	if n != len(ids) {
+		return notFound("assessment")
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package repository
+
+import "testing"
+
+func TestAssetPattern(t *testing.T) {
+	for in, want := range map[string]string{
+		"tls://api.example.com": `tls://api.example.com%`,
+		"tls://*.example.com":   `tls://%.example.com`,
+		"host-?":                `host-_`,
+		`50%_off\`:              `50\%\_off\\%`,
+		"*_*":                   `%\_%`,
+	} {
+		if got := assetPattern(in); got != want {
+			t.Errorf("assetPattern(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+// IdempotencyRepository is the Postgres-backed qmw.IdempotencyStore. A key is
+// claimed by inserting its row, so only one replica handles a request; rows
+// with a NULL status_code are still being handled.
+type IdempotencyRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewIdempotencyRepository(pool *pgxpool.Pool) *IdempotencyRepository {
+	return &IdempotencyRepository{pool: pool}
+}
+
+var _ qmw.IdempotencyStore = (*IdempotencyRepository)(nil)
+
+// Reserve inserts the key, taking over an expired row. If the key is held,
+// the existing row is returned instead; a row that expires between the two
+// statements is retried once. Each call also deletes a batch of expired
+// keys, so the table does not grow without bound.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, key, fingerprint string, expiresAt time.Time) (*qmw.IdempotencyRecord, bool, error) {
+	purge := `
+		DELETE FROM idempotency_keys
+		WHERE key IN (SELECT key FROM idempotency_keys WHERE expires_at <= NOW() LIMIT 100)
+	`
+	if _, err := r.pool.Exec(ctx, purge); err != nil {
+		return nil, false, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+
+	insert := `
+		INSERT INTO idempotency_keys (key, fingerprint, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET
+		    fingerprint = EXCLUDED.fingerprint,
+		    status_code = NULL,
+		    headers = '{}',
+		    body = NULL,
+		    created_at = NOW(),
+		    expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+		RETURNING key
+	`
+	for range 2 {
+		var claimed string
+		err := r.pool.QueryRow(ctx, insert, key, fingerprint, expiresAt).Scan(&claimed)
+		if err == nil {
+			return &qmw.IdempotencyRecord{Fingerprint: fingerprint}, true, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+		}
+
+		rec, err := r.get(ctx, key)
+		if err != nil {
+			return nil, false, err
+		}
+		if rec != nil {
+			return rec, false, nil
+		}
+	}
+	return nil, false, fmt.Errorf("failed to reserve idempotency key: key changed concurrently")
+}
+
+func (r *IdempotencyRepository) get(ctx context.Context, key string) (*qmw.IdempotencyRecord, error) {
+	query := `
+		SELECT fingerprint, status_code, headers, body
+		FROM idempotency_keys
+		WHERE key = $1 AND expires_at > NOW()
+	`
+	var (
+		rec        qmw.IdempotencyRecord
+		statusCode *int
+		headers    []byte
+	)
+	err := r.pool.QueryRow(ctx, query, key).Scan(&rec.Fingerprint, &statusCode, &headers, &rec.Body)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	if statusCode != nil {
+		rec.Completed = true
+		rec.StatusCode = *statusCode
+		if err := json.Unmarshal(headers, &rec.Header); err != nil {
+			return nil, fmt.Errorf("failed to decode idempotent response headers: %w", err)
+		}
+	}
+	return &rec, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, rec *qmw.IdempotencyRecord) error {
+	header := rec.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	headers, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotent response headers: %w", err)
+	}
+	query := `
+		UPDATE idempotency_keys SET status_code = $2, headers = $3, body = $4
+		WHERE key = $1 AND fingerprint = $5
+	`
+	if _, err := r.pool.Exec(ctx, query, key, rec.StatusCode, headers, rec.Body, rec.Fingerprint); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND status_code IS NULL`, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+type MembershipRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewMembershipRepository(pool *pgxpool.Pool) *MembershipRepository {
+	return &MembershipRepository{pool: pool}
+}
+
+// OrganizationsForSubject returns the IDs of the organizations subject is a
+// member of. It implements tenant.MembershipLookup and is therefore not
+// itself scoped.
+func (r *MembershipRepository) OrganizationsForSubject(ctx context.Context, subject string) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx, `SELECT organization_id FROM organization_members WHERE subject = $1`, subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memberships: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan membership: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Add makes m.Subject a member of m.OrganizationID. Adding an existing
+// member is a no-op.
+func (r *MembershipRepository) Add(ctx context.Context, m *model.Membership) error {
+	filter, args := scopeFilter(ctx, "id", 2)
+	var visible bool
+	if err := r.pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM organizations WHERE id = $1 AND deleted_at IS NULL`+filter+`)`,
+		append([]interface{}{m.OrganizationID}, args...)...,
+	).Scan(&visible); err != nil {
+		return fmt.Errorf("failed to check organization: %w", err)
+	}
+	if !visible {
+		return notFound("organization")
+	}
+
+	query := `
+		INSERT INTO organization_members (organization_id, subject, created_by, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (organization_id, subject) DO NOTHING
+	`
+	if _, err := r.pool.Exec(ctx, query, m.OrganizationID, m.Subject, m.CreatedBy, m.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert membership: %w", dbError(err))
+	}
+	return nil
+}
+
+func (r *MembershipRepository) ListByOrganization(ctx context.Context, orgID uuid.UUID) ([]model.Membership, error) {
+	filter, args := scopeFilter(ctx, "organization_id", 2)
+	query := `
+		SELECT organization_id, subject, created_by, created_at
+		FROM organization_members WHERE organization_id = $1` + filter + `
+		ORDER BY created_at ASC, subject ASC
+	`
+	rows, err := r.pool.Query(ctx, query, append([]interface{}{orgID}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []model.Membership
+	for rows.Next() {
+		var m model.Membership
+		if err := rows.Scan(&m.OrganizationID, &m.Subject, &m.CreatedBy, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan member: %w", err)
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+func (r *MembershipRepository) Remove(ctx context.Context, orgID uuid.UUID, subject string) error {
+	filter, args := scopeFilter(ctx, "organization_id", 3)
+	query := `DELETE FROM organization_members WHERE organization_id = $1 AND subject = $2` + filter
+	result, err := r.pool.Exec(ctx, query, append([]interface{}{orgID, subject}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to delete membership: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return notFound("membership")
+	}
+	return nil
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
-	"github.com/quantun-opensource/qrap/api/internal/model"
+	"github.com/quantun-opensource/qrap/api/model"
 )
 
 type OrganizationRepository struct {
@@ -20,78 +20,225 @@ func NewOrganizationRepository(pool *pgxpool.Pool) *OrganizationRepository {
 	return &OrganizationRepository{pool: pool}
 }
 
-func (r *OrganizationRepository) Create(ctx context.Context, org *model.Organization) error {
-	query := `
-		INSERT INTO organizations (id, name, description, created_by, created_at)
-		VALUES ($1, $2, $3, $4, $5)
-	`
-	_, err := r.pool.Exec(ctx, query, org.ID, org.Name, org.Description, org.CreatedBy, org.CreatedAt)
+// Create inserts org and, when owner is non-empty, makes owner its first
+// member so the creator can see what they created.
+func (r *OrganizationRepository) Create(ctx context.Context, org *model.Organization, owner string) error {
+	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to insert organization: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	return nil
-}
+	defer tx.Rollback(ctx)
 
-func (r *OrganizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Organization, error) {
 	query := `
-		SELECT id, name, description, created_by, created_at, updated_by, updated_at
-		FROM organizations WHERE id = $1
+		INSERT INTO organizations (id, name, description, created_by, created_at, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $4, $5)
+		RETURNING created_at, updated_by, updated_at
 	`
-	var org model.Organization
-	err := r.pool.QueryRow(ctx, query, id).Scan(
+	if err := tx.QueryRow(ctx, query, org.ID, org.Name, org.Description, org.CreatedBy, org.CreatedAt).Scan(
+		&org.CreatedAt, &org.UpdatedBy, &org.UpdatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to insert organization: %w", dbError(err))
+	}
+	if owner != "" {
+		query = `
+			INSERT INTO organization_members (organization_id, subject, created_by, created_at)
+			VALUES ($1, $2, $3, $4)
+		`
+		if _, err := tx.Exec(ctx, query, org.ID, owner, org.CreatedBy, org.CreatedAt); err != nil {
+			return fmt.Errorf("failed to insert organization owner: %w", dbError(err))
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// organizationColumns are the columns scanOrganization reads.
+const organizationColumns = `id, name, description, created_by, created_at, updated_by, updated_at`
+
+func scanOrganization(row pgx.Row, org *model.Organization) error {
+	return row.Scan(
 		&org.ID, &org.Name, &org.Description,
 		&org.CreatedBy, &org.CreatedAt, &org.UpdatedBy, &org.UpdatedAt,
 	)
+}
+
+// GetByID returns an organization that has not been deleted.
+func (r *OrganizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Organization, error) {
+	filter, args := scopeFilter(ctx, "id", 2)
+	query := `SELECT ` + organizationColumns + ` FROM organizations WHERE id = $1 AND deleted_at IS NULL` + filter
+	var org model.Organization
+	err := scanOrganization(r.pool.QueryRow(ctx, query, append([]interface{}{id}, args...)...), &org)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("organization not found: %s", id)
+			return nil, notFound("organization")
 		}
 		return nil, fmt.Errorf("failed to get organization: %w", err)
 	}
 	return &org, nil
 }
 
-func (r *OrganizationRepository) List(ctx context.Context, offset, limit int) ([]model.Organization, int, error) {
-	var total int
-	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM organizations`).Scan(&total); err != nil {
-		return nil, 0, fmt.Errorf("failed to count organizations: %w", err)
+func (r *OrganizationRepository) List(ctx context.Context, page Page) ([]model.Organization, PageInfo, error) {
+	filter, args := scopeFilter(ctx, "id", 1)
+
+	var info PageInfo
+	if page.Count {
+		var total int
+		if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM organizations WHERE deleted_at IS NULL`+filter, args...).Scan(&total); err != nil {
+			return nil, info, fmt.Errorf("failed to count organizations: %w", err)
+		}
+		info.Total = &total
 	}
 
-	query := `
-		SELECT id, name, description, created_by, created_at, updated_by, updated_at
-		FROM organizations ORDER BY created_at DESC OFFSET $1 LIMIT $2
-	`
-	rows, err := r.pool.Query(ctx, query, offset, limit)
+	clause, pageArgs, err := createdKeyset.clause(page, len(args)+1)
+	if err != nil {
+		return nil, info, err
+	}
+	query := `SELECT ` + organizationColumns + ` FROM organizations WHERE deleted_at IS NULL` + filter + clause
+	rows, err := r.pool.Query(ctx, query, append(args, pageArgs...)...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list organizations: %w", err)
+		return nil, info, fmt.Errorf("failed to list organizations: %w", err)
 	}
 	defer rows.Close()
 
 	var orgs []model.Organization
 	for rows.Next() {
 		var org model.Organization
-		if err := rows.Scan(
-			&org.ID, &org.Name, &org.Description,
-			&org.CreatedBy, &org.CreatedAt, &org.UpdatedBy, &org.UpdatedAt,
-		); err != nil {
-			return nil, 0, fmt.Errorf("failed to scan organization: %w", err)
+		if err := scanOrganization(rows, &org); err != nil {
+			return nil, info, fmt.Errorf("failed to scan organization: %w", err)
 		}
 		orgs = append(orgs, org)
 	}
-	return orgs, total, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, info, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	orgs, info = pageOf(orgs, page, info.Total, func(o *model.Organization) []string {
+		return []string{cursorTime(o.CreatedAt), o.ID.String()}
+	})
+	return orgs, info, nil
 }
 
-func (r *OrganizationRepository) Update(ctx context.Context, id uuid.UUID, name, description, updatedBy string) error {
+// Update changes the fields of upd that are set. If ifMatch is non-empty,
+// the organization is only updated while its updated_at is one of ifMatch;
+// otherwise Update returns ErrPreconditionFailed.
+func (r *OrganizationRepository) Update(ctx context.Context, id uuid.UUID, upd *model.UpdateOrganizationRequest, updatedBy string, ifMatch []time.Time) (*model.Organization, error) {
+	args := []interface{}{upd.Name, upd.Description, updatedBy, id}
 	query := `
-		UPDATE organizations SET name = $1, description = $2, updated_by = $3, updated_at = $4
-		WHERE id = $5
-	`
-	result, err := r.pool.Exec(ctx, query, name, description, updatedBy, time.Now().UTC(), id)
+		UPDATE organizations
+		SET name = COALESCE($1, name), description = COALESCE($2, description), updated_by = $3, updated_at = NOW()
+		WHERE id = $4 AND deleted_at IS NULL`
+	if ifMatch != nil {
+		args = append(args, ifMatch)
+		query += fmt.Sprintf(" AND updated_at = ANY($%d::timestamptz[])", len(args))
+	}
+	filter, scopeArgs := scopeFilter(ctx, "id", len(args)+1)
+	query += filter + ` RETURNING ` + organizationColumns
+
+	var org model.Organization
+	err := scanOrganization(r.pool.QueryRow(ctx, query, append(args, scopeArgs...)...), &org)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, r.preconditionError(ctx, id)
+		}
+		return nil, fmt.Errorf("failed to update organization: %w", dbError(err))
+	}
+	return &org, nil
+}
+
+// Delete soft-deletes an organization together with its assessments, which
+// share its deleted_at so that Restore can bring back the same set. ifMatch
+// is as for Update.
+func (r *OrganizationRepository) Delete(ctx context.Context, id uuid.UUID, deletedBy string, ifMatch []time.Time) error {
+	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to update organization: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	args := []interface{}{deletedBy, id}
+	query := `UPDATE organizations SET deleted_by = $1, deleted_at = NOW() WHERE id = $2 AND deleted_at IS NULL`
+	if ifMatch != nil {
+		args = append(args, ifMatch)
+		query += fmt.Sprintf(" AND updated_at = ANY($%d::timestamptz[])", len(args))
+	}
+	filter, scopeArgs := scopeFilter(ctx, "id", len(args)+1)
+	result, err := tx.Exec(ctx, query+filter, append(args, scopeArgs...)...)
+	if err != nil {
+		return fmt.Errorf("failed to delete organization: %w", err)
 	}
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("organization not found: %s", id)
+		return r.preconditionError(ctx, id)
+	}
+
+	query = `UPDATE assessments SET deleted_by = $1, deleted_at = NOW() WHERE organization_id = $2 AND deleted_at IS NULL`
+	if _, err := tx.Exec(ctx, query, deletedBy, id); err != nil {
+		return fmt.Errorf("failed to delete organization assessments: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// Restore undeletes an organization deleted at or after since, and the
+// assessments that were deleted with it. It returns ErrNotFound for an
+// organization deleted before since and ErrInvalidState for one that is not
+// deleted.
+func (r *OrganizationRepository) Restore(ctx context.Context, id uuid.UUID, restoredBy string, since time.Time) (*model.Organization, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	filter, args := scopeFilter(ctx, "id", 2)
+	var deletedAt *time.Time
+	err = tx.QueryRow(ctx, `SELECT deleted_at FROM organizations WHERE id = $1`+filter+` FOR UPDATE`,
+		append([]interface{}{id}, args...)...,
+	).Scan(&deletedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, notFound("organization")
+		}
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	if deletedAt == nil {
+		return nil, invalidState("organization is not deleted")
+	}
+	if deletedAt.Before(since) {
+		return nil, notFound("organization")
+	}
+
+	var org model.Organization
+	query := `
+		UPDATE organizations SET deleted_by = NULL, deleted_at = NULL, updated_by = $1, updated_at = NOW()
+		WHERE id = $2 RETURNING ` + organizationColumns
+	if err := scanOrganization(tx.QueryRow(ctx, query, restoredBy, id), &org); err != nil {
+		return nil, fmt.Errorf("failed to restore organization: %w", dbError(err))
+	}
+	query = `
+		UPDATE assessments SET deleted_by = NULL, deleted_at = NULL, updated_by = $1, updated_at = NOW()
+		WHERE organization_id = $2 AND deleted_at = $3`
+	if _, err := tx.Exec(ctx, query, restoredBy, id, *deletedAt); err != nil {
+		return nil, fmt.Errorf("failed to restore organization assessments: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit organization restore: %w", err)
+	}
+	return &org, nil
+}
+
+// Purge permanently removes organizations deleted before cutoff; the
+// foreign keys cascade to their assessments, findings and members.
+func (r *OrganizationRepository) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.pool.Exec(ctx, `DELETE FROM organizations WHERE deleted_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge organizations: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// preconditionError explains why a conditional write to id matched no row:
+// the organization is gone (ErrNotFound) or has changed
+// (ErrPreconditionFailed).
+func (r *OrganizationRepository) preconditionError(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
 	}
-	return nil
+	return &Error{Kind: ErrPreconditionFailed, Message: "organization has been modified; fetch it again for a current ETag"}
 }
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+// Page selects part of a listing: Limit rows from Offset, or, with a Cursor,
+// the Limit rows after (or, for a backward cursor, before) the row the cursor
+// points at. Keyset pages stay stable while rows are inserted and need no
+// OFFSET scan.
+type Page struct {
+	Offset int
+	Limit  int
+	Cursor *qmw.Cursor
+	// Count asks for the total number of rows matching the filters.
+	Count bool
+}
+
+// PageInfo describes a listed page.
+type PageInfo struct {
+	// Total is set if the page asked for a count.
+	Total *int
+	// Next and Prev point at the neighbouring pages, and are nil at either
+	// end of the listing.
+	Next *qmw.Cursor
+	Prev *qmw.Cursor
+}
+
+// sortKey is one column of a keyset order.
+type sortKey struct {
+	column string
+	typ    string // SQL type the cursor's text value is cast to
+	desc   bool
+}
+
+// keyset is a listing's sort order. Its last column must be unique so the
+// order is total.
+type keyset []sortKey
+
+var (
+	// createdKeyset lists newest first.
+	createdKeyset = keyset{{"created_at", "timestamptz", true}, {"id", "uuid", true}}
+	// findingKeyset lists the most severe findings first, newest first
+	// within a risk level.
+	findingKeyset = keyset{{"risk_level", "risk_level", false}, {"discovered_at", "timestamptz", true}, {"id", "uuid", true}}
+)
+
+// clause returns the keyset condition for p's cursor (as " AND ..."), then
+// ORDER BY and OFFSET/LIMIT, binding its arguments from $argIdx. It selects
+// one row more than the limit so pageOf can tell whether a next page exists.
+func (k keyset) clause(p Page, argIdx int) (string, []interface{}, error) {
+	var (
+		b        strings.Builder
+		args     []interface{}
+		backward bool
+	)
+	if p.Cursor != nil {
+		if len(p.Cursor.Keys) != len(k) {
+			return "", nil, qmw.ErrInvalidCursor
+		}
+		backward = p.Cursor.Backward
+		// (k0 > v0) OR (k0 = v0 AND k1 > v1) OR ..., with each comparison
+		// following its column's direction.
+		b.WriteString(" AND (")
+		for i, key := range k {
+			if i > 0 {
+				b.WriteString(" OR ")
+			}
+			b.WriteString("(")
+			for j := range i {
+				fmt.Fprintf(&b, "%s = $%d::text::%s AND ", k[j].column, argIdx+j, k[j].typ)
+			}
+			op := ">"
+			if key.desc != backward {
+				op = "<"
+			}
+			fmt.Fprintf(&b, "%s %s $%d::text::%s)", key.column, op, argIdx+i, key.typ)
+		}
+		b.WriteString(")")
+		for _, v := range p.Cursor.Keys {
+			args = append(args, v)
+		}
+		argIdx += len(k)
+	}
+
+	b.WriteString(" ORDER BY ")
+	for i, key := range k {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(key.column)
+		if key.desc != backward {
+			b.WriteString(" DESC")
+		} else {
+			b.WriteString(" ASC")
+		}
+	}
+	offset := p.Offset
+	if p.Cursor != nil {
+		offset = 0
+	}
+	fmt.Fprintf(&b, " OFFSET $%d LIMIT $%d", argIdx, argIdx+1)
+	return b.String(), append(args, offset, p.Limit+1), nil
+}
+
+// pageOf trims the extra row selected by keyset.clause, puts a backward page
+// back in listing order, and builds the cursors to its neighbours from the
+// first and last rows' keys.
+func pageOf[T any](rows []T, p Page, total *int, keys func(*T) []string) ([]T, PageInfo) {
+	info := PageInfo{Total: total}
+	more := len(rows) > p.Limit
+	if more {
+		rows = rows[:p.Limit]
+	}
+	if len(rows) == 0 {
+		return rows, info
+	}
+
+	hasNext, hasPrev := more, p.Offset > 0
+	if p.Cursor != nil {
+		hasNext, hasPrev = more, true
+		if p.Cursor.Backward {
+			slices.Reverse(rows)
+			hasNext, hasPrev = true, more
+		}
+	}
+	if hasNext {
+		info.Next = &qmw.Cursor{Keys: keys(&rows[len(rows)-1])}
+	}
+	if hasPrev {
+		info.Prev = &qmw.Cursor{Keys: keys(&rows[0]), Backward: true}
+	}
+	return rows, info
+}
+
+// cursorTime formats a timestamp sort key; Postgres keeps microseconds, so
+// the value round-trips exactly.
+func cursorTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
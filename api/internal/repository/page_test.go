@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"slices"
+	"testing"
+
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+func TestKeysetClause(t *testing.T) {
+	clause, args, err := findingKeyset.clause(Page{Offset: 40, Limit: 20}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := " ORDER BY risk_level ASC, discovered_at DESC, id DESC OFFSET $3 LIMIT $4"; clause != want {
+		t.Errorf("offset clause = %q", clause)
+	}
+	if !slices.Equal(args, []interface{}{40, 21}) {
+		t.Errorf("offset args = %v", args)
+	}
+
+	cur := &qmw.Cursor{Keys: []string{"2026-10-18T09:00:00Z", "0b6e3f1c-7a1d-4c52-9e0f-3d2a1b4c5d6e"}}
+	clause, args, _ = createdKeyset.clause(Page{Offset: 40, Limit: 20, Cursor: cur}, 2)
+	want := " AND ((created_at < $2::text::timestamptz) OR (created_at = $2::text::timestamptz AND id < $3::text::uuid))" +
+		" ORDER BY created_at DESC, id DESC OFFSET $4 LIMIT $5"
+	if clause != want {
+		t.Errorf("forward clause = %q", clause)
+	}
+	if !slices.Equal(args, []interface{}{cur.Keys[0], cur.Keys[1], 0, 21}) {
+		t.Errorf("forward args = %v", args)
+	}
+
+	cur.Backward = true
+	clause, _, _ = createdKeyset.clause(Page{Limit: 20, Cursor: cur}, 1)
+	want = " AND ((created_at > $1::text::timestamptz) OR (created_at = $1::text::timestamptz AND id > $2::text::uuid))" +
+		" ORDER BY created_at ASC, id ASC OFFSET $3 LIMIT $4"
+	if clause != want {
+		t.Errorf("backward clause = %q", clause)
+	}
+
+	if _, _, err := findingKeyset.clause(Page{Limit: 20, Cursor: &qmw.Cursor{Keys: cur.Keys}}, 1); err != qmw.ErrInvalidCursor {
+		t.Errorf("cursor from another keyset: expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestPageOf(t *testing.T) {
+	keys := func(s *string) []string { return []string{*s} }
+
+	// First offset page with more to come.
+	rows, info := pageOf([]string{"a", "b", "c"}, Page{Limit: 2}, nil, keys)
+	if !slices.Equal(rows, []string{"a", "b"}) || info.Next == nil || info.Next.Keys[0] != "b" || info.Prev != nil {
+		t.Errorf("first page: %v %+v", rows, info)
+	}
+
+	// Last page after a forward cursor.
+	rows, info = pageOf([]string{"c"}, Page{Limit: 2, Cursor: &qmw.Cursor{Keys: []string{"b"}}}, nil, keys)
+	if !slices.Equal(rows, []string{"c"}) || info.Next != nil || info.Prev == nil || !info.Prev.Backward || info.Prev.Keys[0] != "c" {
+		t.Errorf("last page: %v %+v", rows, info)
+	}
+
+	// A backward page is selected in reverse and put back in order.
+	total := 5
+	rows, info = pageOf([]string{"b", "a"}, Page{Limit: 2, Cursor: &qmw.Cursor{Keys: []string{"c"}, Backward: true}}, &total, keys)
+	if !slices.Equal(rows, []string{"a", "b"}) || info.Prev != nil || info.Next == nil || info.Next.Keys[0] != "b" || *info.Total != 5 {
+		t.Errorf("backward page: %v %+v", rows, info)
+	}
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+// RateLimitRepository is the Postgres-backed qmw.RateLimitStore. Each
+// request is a single upsert (into rate_limits for fixed windows,
+// rate_limit_buckets for token buckets), so replicas sharing the database
+// share one budget per key. Time is taken from the database clock.
+type RateLimitRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRateLimitRepository(pool *pgxpool.Pool) *RateLimitRepository {
+	return &RateLimitRepository{pool: pool}
+}
+
+var _ qmw.RateLimitStore = (*RateLimitRepository)(nil)
+
+func (r *RateLimitRepository) Increment(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	query := `
+		INSERT INTO rate_limits (key, window_start, count)
+		VALUES ($1, NOW(), 1)
+		ON CONFLICT (key) DO UPDATE SET
+		    count = CASE WHEN rate_limits.window_start <= NOW() - $2 * INTERVAL '1 microsecond'
+		                 THEN 1 ELSE rate_limits.count + 1 END,
+		    window_start = CASE WHEN rate_limits.window_start <= NOW() - $2 * INTERVAL '1 microsecond'
+		                        THEN NOW() ELSE rate_limits.window_start END
+		RETURNING count, window_start
+	`
+	var (
+		count       int
+		windowStart time.Time
+	)
+	if err := r.pool.QueryRow(ctx, query, key, window.Microseconds()).Scan(&count, &windowStart); err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to increment rate limit: %w", err)
+	}
+	return count, windowStart, nil
+}
+
+// TakeToken refills and takes from the bucket in one upsert. The refilled
+// level is computed from the old row in each SET expression, and "allowed"
+// records whether a token was taken, since the new level alone cannot tell.
+func (r *RateLimitRepository) TakeToken(ctx context.Context, key string, limit int, window time.Duration) (int, time.Duration, error) {
+	const refilled = `LEAST($2::float8, rate_limit_buckets.tokens +
+		EXTRACT(EPOCH FROM NOW() - rate_limit_buckets.updated_at) * $3::float8)`
+	query := `
+		INSERT INTO rate_limit_buckets (key, tokens, allowed, updated_at)
+		VALUES ($1, $2::float8 - 1, TRUE, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+		    tokens = CASE WHEN ` + refilled + ` >= 1 THEN ` + refilled + ` - 1 ELSE ` + refilled + ` END,
+		    allowed = ` + refilled + ` >= 1,
+		    updated_at = NOW()
+		RETURNING tokens, allowed
+	`
+	rate := float64(limit) / window.Seconds()
+	var (
+		tokens  float64
+		allowed bool
+	)
+	if err := r.pool.QueryRow(ctx, query, key, limit, rate).Scan(&tokens, &allowed); err != nil {
+		return 0, 0, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	if !allowed {
+		return 0, time.Duration((1 - tokens) / rate * float64(time.Second)), nil
+	}
+	return int(tokens), 0, nil
+}
+
+func (r *RateLimitRepository) Purge(ctx context.Context, before time.Time) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM rate_limits WHERE window_start < $1`, before); err != nil {
+		return fmt.Errorf("failed to purge rate limits: %w", err)
+	}
+	if _, err := r.pool.Exec(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < $1`, before); err != nil {
+		return fmt.Errorf("failed to purge rate limit buckets: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+// RevocationRepository is the Postgres-backed qmw.RevocationStore, shared by
+// every API instance.
+type RevocationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRevocationRepository(pool *pgxpool.Pool) *RevocationRepository {
+	return &RevocationRepository{pool: pool}
+}
+
+var _ qmw.RevocationStore = (*RevocationRepository)(nil)
+
+// revokedBy records who revoked a token: the authenticated caller, or
+// "system" for revocations made outside a request (e.g. on logout).
+func revokedBy(ctx context.Context) string {
+	if s := qmw.SubjectFromContext(ctx); s != "" {
+		return s
+	}
+	return "system"
+}
+
+func (r *RevocationRepository) IsRevoked(ctx context.Context, jti, subject string, issuedAt int64) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1 AND $1 <> '' AND expires_at > NOW())
+		    OR EXISTS (SELECT 1 FROM revoked_subjects WHERE subject = $2 AND revoked_before >= to_timestamp($3))
+	`
+	var revoked bool
+	if err := r.pool.QueryRow(ctx, query, jti, subject, issuedAt).Scan(&revoked); err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return revoked, nil
+}
+
+// RevokeToken upserts the jti and drops entries whose tokens have expired.
+func (r *RevocationRepository) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, expires_at, revoked_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO UPDATE SET expires_at = GREATEST(revoked_tokens.expires_at, EXCLUDED.expires_at)
+	`
+	if _, err := r.pool.Exec(ctx, query, jti, expiresAt, revokedBy(ctx)); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	if _, err := r.pool.Exec(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= NOW()`); err != nil {
+		return fmt.Errorf("failed to purge expired revocations: %w", err)
+	}
+	return nil
+}
+
+func (r *RevocationRepository) RevokeSubject(ctx context.Context, subject string, before time.Time) error {
+	query := `
+		INSERT INTO revoked_subjects (subject, revoked_before, revoked_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (subject) DO UPDATE
+		SET revoked_before = GREATEST(revoked_subjects.revoked_before, EXCLUDED.revoked_before),
+		    revoked_by = CASE WHEN EXCLUDED.revoked_before > revoked_subjects.revoked_before
+		                      THEN EXCLUDED.revoked_by ELSE revoked_subjects.revoked_by END,
+		    updated_at = NOW()
+	`
+	if _, err := r.pool.Exec(ctx, query, subject, before, revokedBy(ctx)); err != nil {
+		return fmt.Errorf("failed to revoke subject: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+type RunRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRunRepository(pool *pgxpool.Pool) *RunRepository {
+	return &RunRepository{pool: pool}
+}
+
+func (r *RunRepository) Create(ctx context.Context, run *model.AssessmentRun) error {
+	filter, args := scopeFilter(ctx, "organization_id", 2)
+	var visible bool
+	if err := r.pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM assessments WHERE id = $1 AND deleted_at IS NULL`+filter+`)`,
+		append([]interface{}{run.AssessmentID}, args...)...,
+	).Scan(&visible); err != nil {
+		return fmt.Errorf("failed to check assessment: %w", err)
+	}
+	if !visible {
+		return notFound("assessment")
+	}
+
+	query := `
+		INSERT INTO assessment_runs (id, assessment_id, status, started_at, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := r.pool.Exec(ctx, query, run.ID, run.AssessmentID, run.Status, run.StartedAt, run.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to insert assessment run: %w", dbError(err))
+	}
+	return nil
+}
+
+func (r *RunRepository) Complete(ctx context.Context, id uuid.UUID, status string, analyzers []string, runErrors []model.AnalyzerError, findingsCount int) error {
+	if runErrors == nil {
+		runErrors = []model.AnalyzerError{}
+	}
+	errorsJSON, err := json.Marshal(runErrors)
+	if err != nil {
+		return fmt.Errorf("failed to encode run errors: %w", err)
+	}
+
+	query := `
+		UPDATE assessment_runs
+		SET status = $1, analyzers = $2, errors = $3, findings_count = $4, completed_at = $5
+		WHERE id = $6`
+	filter, args := assessmentScopeFilter(ctx, "assessment_id", 7)
+	result, err := r.pool.Exec(ctx, query+filter,
+		append([]interface{}{status, nonNil(analyzers), errorsJSON, findingsCount, time.Now().UTC(), id}, args...)...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete assessment run: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return notFound("assessment run")
+	}
+	return nil
+}
+
+func (r *RunRepository) ListByAssessment(ctx context.Context, assessmentID uuid.UUID, limit int) ([]model.AssessmentRun, error) {
+	query := `
+		SELECT id, assessment_id, status, analyzers, errors, findings_count, started_at, completed_at, created_by
+		FROM assessment_runs WHERE assessment_id = $1`
+	filter, args := assessmentScopeFilter(ctx, "assessment_id", 3)
+	query += filter + " ORDER BY started_at DESC LIMIT $2"
+	rows, err := r.pool.Query(ctx, query, append([]interface{}{assessmentID, limit}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assessment runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []model.AssessmentRun
+	for rows.Next() {
+		var (
+			run        model.AssessmentRun
+			errorsJSON []byte
+		)
+		if err := rows.Scan(
+			&run.ID, &run.AssessmentID, &run.Status, &run.Analyzers, &errorsJSON,
+			&run.FindingsCount, &run.StartedAt, &run.CompletedAt, &run.CreatedBy,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan assessment run: %w", err)
+		}
+		if err := json.Unmarshal(errorsJSON, &run.Errors); err != nil {
+			return nil, fmt.Errorf("failed to decode run errors: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/quantun-opensource/qrap/api/internal/tenant"
+)
+
+// scopeFilter returns an " AND ..." clause restricting column, which holds an
+// organization ID, to the caller's organizations, and the argument it binds
+// as $argIdx. It returns an empty clause for an unrestricted scope.
+func scopeFilter(ctx context.Context, column string, argIdx int) (string, []interface{}) {
+	s := tenant.FromContext(ctx)
+	if s.Unrestricted {
+		return "", nil
+	}
+	return fmt.Sprintf(" AND %s = ANY($%d::uuid[])", column, argIdx), []interface{}{scopeIDs(s)}
+}
+
+// assessmentScopeFilter is scopeFilter for tables that reference an
+// assessment rather than an organization. It also hides the rows of deleted
+// assessments, so it is never empty.
+func assessmentScopeFilter(ctx context.Context, column string, argIdx int) (string, []interface{}) {
+	s := tenant.FromContext(ctx)
+	if s.Unrestricted {
+		return fmt.Sprintf(" AND %s IN (SELECT id FROM assessments WHERE deleted_at IS NULL)", column), nil
+	}
+	clause := fmt.Sprintf(" AND %s IN (SELECT id FROM assessments WHERE deleted_at IS NULL AND organization_id = ANY($%d::uuid[]))", column, argIdx)
+	return clause, []interface{}{scopeIDs(s)}
+}
+
+// scopeIDs returns the scope's organization IDs as a non-nil slice so an
+// empty scope binds '{}' rather than NULL.
+func scopeIDs(s tenant.Scope) []uuid.UUID {
+	if s.OrganizationIDs == nil {
+		return []uuid.UUID{}
+	}
+	return s.OrganizationIDs
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/quantun-opensource/qrap/api/internal/tenant"
+)
+
+func TestScopeFilter(t *testing.T) {
+	org := uuid.New()
+
+	clause, args := scopeFilter(tenant.WithScope(context.Background(), tenant.Scope{Unrestricted: true}), "organization_id", 2)
+	if clause != "" || args != nil {
+		t.Errorf("unrestricted: got %q %v", clause, args)
+	}
+
+	clause, args = scopeFilter(tenant.WithScope(context.Background(), tenant.Scope{OrganizationIDs: []uuid.UUID{org}}), "organization_id", 2)
+	if clause != " AND organization_id = ANY($2::uuid[])" {
+		t.Errorf("clause = %q", clause)
+	}
+	if ids, ok := args[0].([]uuid.UUID); !ok || len(ids) != 1 || ids[0] != org {
+		t.Errorf("args = %v", args)
+	}
+
+	// No scope at all binds an empty array, never NULL, so nothing matches.
+	_, args = scopeFilter(context.Background(), "id", 1)
+	if ids, ok := args[0].([]uuid.UUID); !ok || ids == nil || len(ids) != 0 {
+		t.Errorf("empty scope args = %#v", args)
+	}
+
+	clause, _ = assessmentScopeFilter(context.Background(), "assessment_id", 3)
+	if clause != " AND assessment_id IN (SELECT id FROM assessments WHERE deleted_at IS NULL AND organization_id = ANY($3::uuid[]))" {
+		t.Errorf("assessment clause = %q", clause)
+	}
+
+	// Deleted assessments stay hidden from an unrestricted scope.
+	clause, args = assessmentScopeFilter(tenant.WithScope(context.Background(), tenant.Scope{Unrestricted: true}), "assessment_id", 3)
+	if clause != " AND assessment_id IN (SELECT id FROM assessments WHERE deleted_at IS NULL)" || args != nil {
+		t.Errorf("unrestricted assessment clause = %q %v", clause, args)
+	}
+}
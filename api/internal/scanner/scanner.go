@@ -0,0 +1,402 @@
+// Package scanner runs many independent network probes concurrently without
+// overloading any single target.
+//
+// An Engine executes Tasks on a bounded worker pool. Each Task names the
+// host it touches; the engine caps how many tasks run against one host at a
+// time, optionally caps how many attempts start per second overall, bounds
+// each attempt with a timeout and retries failed attempts with exponential
+// backoff and jitter.
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults applied by New to zero-valued Config fields.
+const (
+	DefaultWorkers     = 16
+	DefaultBaseBackoff = 200 * time.Millisecond
+	DefaultMaxBackoff  = 5 * time.Second
+)
+
+// Config tunes an Engine. Zero values select the defaults noted per field.
+type Config struct {
+	// Workers is the global concurrency limit. Default DefaultWorkers.
+	Workers int
+	// PerHost caps concurrent tasks sharing a Task.Host. 0 means unlimited.
+	PerHost int
+	// Rate caps attempts started per second across all workers. 0 means unlimited.
+	Rate float64
+	// Timeout bounds a single attempt when the task has no timeout of its own.
+	// 0 means no timeout.
+	Timeout time.Duration
+	// Attempts is the maximum number of tries per task, including the first.
+	// Default 1 (no retries).
+	Attempts int
+	// BaseBackoff is the delay before the first retry; it doubles per retry
+	// up to MaxBackoff. Defaults DefaultBaseBackoff and DefaultMaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Jitter delays each task's first attempt by a random duration in
+	// [0, Jitter) so probes to one network are not sent in lockstep.
+	Jitter time.Duration
+}
+
+// Task is one unit of scanning work.
+type Task struct {
+	// Host groups tasks for the per-host limit, typically HostKey(asset).
+	// Tasks with an empty Host are not subject to the per-host limit.
+	Host string
+	// Timeout overrides Config.Timeout for this task's attempts.
+	Timeout time.Duration
+	// Run performs one attempt. It should honour ctx; an attempt that does
+	// not return by its deadline is abandoned and counted as timed out.
+	Run func(ctx context.Context) error
+}
+
+// Result reports how a task ended.
+type Result struct {
+	Err      error
+	Attempts int
+	Elapsed  time.Duration
+}
+
+// Engine executes tasks under the limits in its Config. It is safe for
+// concurrent use; limits apply per Scan call.
+type Engine struct {
+	cfg Config
+
+	// rand returns a value in [0, 1); replaceable in tests.
+	rand  func() float64
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// New creates an Engine with cfg, filling in defaults.
+func New(cfg Config) *Engine {
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	if cfg.Attempts <= 0 {
+		cfg.Attempts = 1
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = DefaultBaseBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultMaxBackoff
+	}
+	if cfg.MaxBackoff < cfg.BaseBackoff {
+		cfg.MaxBackoff = cfg.BaseBackoff
+	}
+	return &Engine{cfg: cfg, rand: rand.Float64, sleep: sleepContext}
+}
+
+// Config returns the effective configuration.
+func (e *Engine) Config() Config { return e.cfg }
+
+// Scan runs every task and returns their results in task order. onDone, if
+// non-nil, is called once per task as it finishes; calls are serialized, so
+// onDone may update shared state without locking. Scan returns when all
+// tasks have finished or, after ctx is cancelled, been abandoned.
+func (e *Engine) Scan(ctx context.Context, tasks []Task, onDone func(i int, r Result)) []Result {
+	results := make([]Result, len(tasks))
+	if len(tasks) == 0 {
+		return results
+	}
+
+	s := &scan{
+		engine:  e,
+		tasks:   tasks,
+		pending: make([]int, len(tasks)),
+		active:  make(map[string]int),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	for i := range tasks {
+		s.pending[i] = i
+	}
+	if e.cfg.Rate > 0 {
+		s.limiter = &rateLimiter{interval: time.Duration(float64(time.Second) / e.cfg.Rate)}
+	}
+
+	// Wake idle workers when ctx ends so they can drain the queue.
+	stop := context.AfterFunc(ctx, func() {
+		s.mu.Lock()
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	})
+	defer stop()
+
+	var (
+		doneMu sync.Mutex
+		wg     sync.WaitGroup
+	)
+	workers := min(e.cfg.Workers, len(tasks))
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i, ok := s.next(ctx)
+				if !ok {
+					return
+				}
+				var r Result
+				if err := ctx.Err(); err != nil {
+					r.Err = err
+				} else {
+					r = e.runTask(ctx, tasks[i], s.limiter)
+				}
+				s.release(tasks[i].Host)
+				results[i] = r
+				if onDone != nil {
+					doneMu.Lock()
+					onDone(i, r)
+					doneMu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// scan holds the scheduling state of one Scan call.
+type scan struct {
+	engine  *Engine
+	tasks   []Task
+	limiter *rateLimiter
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []int
+	active  map[string]int
+}
+
+// next hands a worker the first pending task whose host has a free slot,
+// waiting if every pending task's host is saturated. Once ctx is done,
+// remaining tasks are handed out regardless of host limits so they can be
+// marked cancelled without running.
+func (s *scan) next(ctx context.Context) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		if len(s.pending) == 0 {
+			return 0, false
+		}
+		for pos, i := range s.pending {
+			host := s.tasks[i].Host
+			if ctx.Err() == nil && host != "" && s.engine.cfg.PerHost > 0 && s.active[host] >= s.engine.cfg.PerHost {
+				continue
+			}
+			s.pending = append(s.pending[:pos], s.pending[pos+1:]...)
+			if host != "" {
+				s.active[host]++
+			}
+			return i, true
+		}
+		s.cond.Wait()
+	}
+}
+
+func (s *scan) release(host string) {
+	if host == "" {
+		return
+	}
+	s.mu.Lock()
+	s.active[host]--
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+// runTask performs a task's attempts with backoff between them.
+func (e *Engine) runTask(ctx context.Context, t Task, limiter *rateLimiter) Result {
+	start := time.Now()
+	var r Result
+
+	if e.cfg.Jitter > 0 {
+		if err := e.sleep(ctx, time.Duration(e.rand()*float64(e.cfg.Jitter))); err != nil {
+			r.Err = err
+			r.Elapsed = time.Since(start)
+			return r
+		}
+	}
+
+	for r.Attempts < e.cfg.Attempts {
+		if r.Attempts > 0 {
+			if err := e.sleep(ctx, e.backoff(r.Attempts)); err != nil {
+				break
+			}
+		}
+		if limiter != nil {
+			if err := limiter.wait(ctx, e.sleep); err != nil {
+				if r.Err == nil {
+					r.Err = err
+				}
+				break
+			}
+		}
+		r.Attempts++
+		r.Err = e.attempt(ctx, t)
+		if r.Err == nil || IsPermanent(r.Err) || ctx.Err() != nil {
+			break
+		}
+	}
+	r.Elapsed = time.Since(start)
+	return r
+}
+
+// attempt runs t.Run once under the attempt timeout. The call runs on its own
+// goroutine so a task that ignores ctx cannot hold its worker past the
+// deadline, and a panic becomes a permanent error instead of crashing the scan.
+func (e *Engine) attempt(ctx context.Context, t Task) error {
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = e.cfg.Timeout
+	}
+	parent := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	// timedOut distinguishes our deadline from the caller cancelling. Tasks
+	// often set I/O deadlines from ctx, so their error can arrive a hair
+	// before ctx itself reports the deadline.
+	timedOut := func() bool {
+		if timeout <= 0 || parent.Err() != nil {
+			return false
+		}
+		deadline, _ := ctx.Deadline()
+		return ctx.Err() != nil || !time.Now().Before(deadline)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				done <- Permanent(fmt.Errorf("panicked: %v", rec))
+			}
+		}()
+		done <- t.Run(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil && timedOut() {
+			return &TimeoutError{Timeout: timeout}
+		}
+		return err
+	case <-ctx.Done():
+		if timedOut() {
+			return &TimeoutError{Timeout: timeout}
+		}
+		return ctx.Err()
+	}
+}
+
+// backoff returns the delay before retry number n (1-based): exponential
+// from BaseBackoff, capped at MaxBackoff, with "equal jitter" so the delay
+// is in [d/2, d).
+func (e *Engine) backoff(n int) time.Duration {
+	d := e.cfg.BaseBackoff
+	for i := 1; i < n && d < e.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > e.cfg.MaxBackoff {
+		d = e.cfg.MaxBackoff
+	}
+	half := d / 2
+	return half + time.Duration(e.rand()*float64(half))
+}
+
+// TimeoutError is returned for an attempt that did not finish within its timeout.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string { return fmt.Sprintf("timed out after %s", e.Timeout) }
+
+// Unwrap lets errors.Is(err, context.DeadlineExceeded) match.
+func (e *TimeoutError) Unwrap() error { return context.DeadlineExceeded }
+
+type permanentError struct{ err error }
+
+func (p *permanentError) Error() string { return p.err.Error() }
+func (p *permanentError) Unwrap() error { return p.err }
+
+// Permanent marks err as not worth retrying, e.g. a malformed response. A
+// nil err stays nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// HostKey derives the per-host limit key for an asset. For URIs such as
+// jwks+https://idp.example.com/keys or tls://10.0.0.1:443 it is the host
+// name; for host:port or bare hosts it is the host. Assets without a network
+// host, like file URIs, return "" and are not host-limited.
+func HostKey(asset string) string {
+	if strings.Contains(asset, "://") {
+		u, err := url.Parse(asset)
+		if err != nil {
+			return ""
+		}
+		return strings.ToLower(u.Hostname())
+	}
+	if host, _, err := net.SplitHostPort(asset); err == nil {
+		return strings.ToLower(host)
+	}
+	if i := strings.IndexAny(asset, "/ "); i >= 0 {
+		asset = asset[:i]
+	}
+	return strings.ToLower(asset)
+}
+
+// rateLimiter spaces attempt starts at least interval apart.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func (l *rateLimiter) wait(ctx context.Context, sleep func(context.Context, time.Duration) error) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+	return sleep(ctx, time.Until(at))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,277 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer is a local TCP listener whose per-connection behaviour is set
+// by handle. It tracks the number of connections and peak concurrency.
+type testServer struct {
+	ln     net.Listener
+	conns  atomic.Int32
+	mu     sync.Mutex
+	active int
+	peak   int
+}
+
+func newTestServer(t *testing.T, handle func(n int32, c net.Conn)) *testServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			n := s.conns.Add(1)
+			go func() {
+				defer c.Close()
+				s.mu.Lock()
+				s.active++
+				s.peak = max(s.peak, s.active)
+				s.mu.Unlock()
+				handle(n, c)
+				s.mu.Lock()
+				s.active--
+				s.mu.Unlock()
+			}()
+		}
+	}()
+	return s
+}
+
+func (s *testServer) addr() string { return s.ln.Addr().String() }
+
+func (s *testServer) peakConcurrency() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.peak
+}
+
+// probe dials addr and reads one line, honouring ctx for both.
+func probe(ctx context.Context, addr string) error {
+	var d net.Dialer
+	c, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		c.SetDeadline(deadline)
+	}
+	_, err = bufio.NewReader(c).ReadString('\n')
+	return err
+}
+
+func serveAfter(d time.Duration) func(int32, net.Conn) {
+	return func(_ int32, c net.Conn) {
+		time.Sleep(d)
+		c.Write([]byte("ok\n"))
+	}
+}
+
+func TestScan_ConcurrencyLimits(t *testing.T) {
+	a := newTestServer(t, serveAfter(20*time.Millisecond))
+	b := newTestServer(t, serveAfter(20*time.Millisecond))
+
+	var (
+		mu           sync.Mutex
+		active, peak int
+	)
+	task := func(host, addr string) Task {
+		return Task{Host: host, Run: func(ctx context.Context) error {
+			mu.Lock()
+			active++
+			peak = max(peak, active)
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				active--
+				mu.Unlock()
+			}()
+			return probe(ctx, addr)
+		}}
+	}
+	var tasks []Task
+	for i := 0; i < 8; i++ {
+		tasks = append(tasks, task("a", a.addr()), task("b", b.addr()))
+	}
+
+	e := New(Config{Workers: 3, PerHost: 2})
+	for i, r := range e.Scan(context.Background(), tasks, nil) {
+		if r.Err != nil || r.Attempts != 1 {
+			t.Errorf("task %d: err=%v attempts=%d", i, r.Err, r.Attempts)
+		}
+	}
+	if got := a.peakConcurrency(); got > 2 {
+		t.Errorf("host a peak concurrency = %d, want <= 2", got)
+	}
+	if got := b.peakConcurrency(); got > 2 {
+		t.Errorf("host b peak concurrency = %d, want <= 2", got)
+	}
+	if peak > 3 {
+		t.Errorf("global peak concurrency = %d, want <= 3", peak)
+	}
+}
+
+func TestScan_RetryWithBackoff(t *testing.T) {
+	// The first two connections are dropped without a response.
+	srv := newTestServer(t, func(n int32, c net.Conn) {
+		if n > 2 {
+			c.Write([]byte("ok\n"))
+		}
+	})
+
+	e := New(Config{Attempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond})
+	var permanentCalls atomic.Int32
+	res := e.Scan(context.Background(), []Task{
+		{Run: func(ctx context.Context) error { return probe(ctx, srv.addr()) }},
+		{Run: func(ctx context.Context) error {
+			permanentCalls.Add(1)
+			return Permanent(errors.New("malformed response"))
+		}},
+	}, nil)
+
+	if res[0].Err != nil || res[0].Attempts != 3 {
+		t.Errorf("flaky target: err=%v attempts=%d, want success on attempt 3", res[0].Err, res[0].Attempts)
+	}
+	if got := srv.conns.Load(); got != 3 {
+		t.Errorf("server saw %d connections, want 3", got)
+	}
+	if !IsPermanent(res[1].Err) || res[1].Attempts != 1 || permanentCalls.Load() != 1 {
+		t.Errorf("permanent error retried: err=%v attempts=%d", res[1].Err, res[1].Attempts)
+	}
+}
+
+func TestScan_Timeouts(t *testing.T) {
+	// Accepts and never answers.
+	silent := newTestServer(t, func(_ int32, c net.Conn) {
+		c.Read(make([]byte, 1))
+	})
+
+	e := New(Config{Timeout: time.Second})
+	start := time.Now()
+	res := e.Scan(context.Background(), []Task{
+		{Timeout: 30 * time.Millisecond, Run: func(ctx context.Context) error { return probe(ctx, silent.addr()) }},
+		{Timeout: 30 * time.Millisecond, Run: func(ctx context.Context) error {
+			time.Sleep(time.Second) // ignores ctx on purpose
+			return nil
+		}},
+	}, nil)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("scan took %s; per-task timeout not enforced", elapsed)
+	}
+	for i, r := range res {
+		var te *TimeoutError
+		if !errors.As(r.Err, &te) || te.Timeout != 30*time.Millisecond {
+			t.Errorf("task %d: err = %v, want 30ms timeout", i, r.Err)
+		}
+	}
+}
+
+func TestScan_PanicsAreErrors(t *testing.T) {
+	res := New(Config{Attempts: 3}).Scan(context.Background(), []Task{
+		{Run: func(ctx context.Context) error { panic("boom") }},
+	}, nil)
+	if !IsPermanent(res[0].Err) || res[0].Attempts != 1 {
+		t.Errorf("err=%v attempts=%d, want one permanent panic error", res[0].Err, res[0].Attempts)
+	}
+}
+
+func TestScan_JitterBackoffAndProgress(t *testing.T) {
+	e := New(Config{Workers: 1, Attempts: 4, BaseBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Jitter: time.Second})
+	e.rand = func() float64 { return 0.5 }
+	var slept []time.Duration
+	e.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	var done []int
+	e.Scan(context.Background(), []Task{
+		{Run: func(ctx context.Context) error { return errors.New("refused") }},
+		{Run: func(ctx context.Context) error { return nil }},
+	}, func(i int, r Result) {
+		done = append(done, i)
+	})
+
+	// Task 0: jitter, then backoffs of 100ms, 200ms, 300ms (capped), each
+	// with equal jitter at 0.5. Task 1: jitter only.
+	want := []time.Duration{
+		500 * time.Millisecond,
+		75 * time.Millisecond, 150 * time.Millisecond, 225 * time.Millisecond,
+		500 * time.Millisecond,
+	}
+	if len(slept) != len(want) {
+		t.Fatalf("sleeps = %v, want %v", slept, want)
+	}
+	for i := range want {
+		if slept[i] != want[i] {
+			t.Errorf("sleep %d = %s, want %s", i, slept[i], want[i])
+		}
+	}
+	if len(done) != 2 || done[0] != 0 || done[1] != 1 {
+		t.Errorf("progress callbacks = %v, want [0 1]", done)
+	}
+}
+
+func TestScan_Rate(t *testing.T) {
+	e := New(Config{Workers: 5, Rate: 100})
+	tasks := make([]Task, 5)
+	for i := range tasks {
+		tasks[i] = Task{Run: func(ctx context.Context) error { return nil }}
+	}
+	start := time.Now()
+	e.Scan(context.Background(), tasks, nil)
+	// Five starts at 100/s are spaced 10ms apart: at least 40ms overall.
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("scan took %s, want >= 40ms at 100 starts/s", elapsed)
+	}
+}
+
+func TestScan_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var ran atomic.Int32
+	res := New(Config{PerHost: 1}).Scan(ctx, []Task{
+		{Host: "a", Run: func(ctx context.Context) error { ran.Add(1); return nil }},
+		{Host: "a", Run: func(ctx context.Context) error { ran.Add(1); return nil }},
+	}, nil)
+	if ran.Load() != 0 {
+		t.Errorf("%d tasks ran after cancellation", ran.Load())
+	}
+	for i, r := range res {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("task %d: err = %v, want context.Canceled", i, r.Err)
+		}
+	}
+}
+
+func TestHostKey(t *testing.T) {
+	cases := map[string]string{
+		"jwks+https://IdP.example.com/.well-known/jwks.json": "idp.example.com",
+		"oidc+https://idp.example.com":                       "idp.example.com",
+		"tls://10.0.0.1:443":                                 "10.0.0.1",
+		"api.example.com:443":                                "api.example.com",
+		"[2001:db8::1]:443":                                  "2001:db8::1",
+		"db-primary":                                         "db-primary",
+		"dnssec+file:///zones/example.com":                   "",
+	}
+	for asset, want := range cases {
+		if got := HostKey(asset); got != want {
+			t.Errorf("HostKey(%q) = %q, want %q", asset, got, want)
+		}
+	}
+}
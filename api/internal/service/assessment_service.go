@@ -2,30 +2,43 @@ package service
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
-	"github.com/quantun-opensource/qrap/api/internal/model"
+	"github.com/quantun-opensource/qrap/api/internal/analyzer"
 	"github.com/quantun-opensource/qrap/api/internal/repository"
+	"github.com/quantun-opensource/qrap/api/model"
 )
 
+// progressInterval is the minimum time between assets_scanned updates while
+// an assessment runs.
+const progressInterval = time.Second
+
 type AssessmentService struct {
 	assessmentRepo *repository.AssessmentRepository
 	findingRepo    *repository.FindingRepository
+	runRepo        *repository.RunRepository
+	analyzers      *analyzer.Registry
+	restoreWindow  time.Duration
 	logger         *zap.Logger
 }
 
 func NewAssessmentService(
 	assessmentRepo *repository.AssessmentRepository,
 	findingRepo *repository.FindingRepository,
+	runRepo *repository.RunRepository,
+	analyzers *analyzer.Registry,
+	restoreWindow time.Duration,
 	logger *zap.Logger,
 ) *AssessmentService {
 	return &AssessmentService{
 		assessmentRepo: assessmentRepo,
 		findingRepo:    findingRepo,
+		runRepo:        runRepo,
+		analyzers:      analyzers,
+		restoreWindow:  restoreWindow,
 		logger:         logger,
 	}
 }
@@ -33,18 +46,20 @@ func NewAssessmentService(
 func (s *AssessmentService) Create(ctx context.Context, req *model.CreateAssessmentRequest) (*model.Assessment, error) {
 	orgID, err := uuid.Parse(req.OrganizationID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid organization_id: %w", err)
+		return nil, &Error{Kind: ErrValidation, Message: "invalid organization_id", Err: err}
 	}
 
 	assessment := &model.Assessment{
-		ID:             uuid.New(),
-		Name:           req.Name,
-		OrganizationID: orgID,
-		Status:         "DRAFT",
-		RiskScore:      0,
-		TargetAssets:   req.TargetAssets,
-		CreatedBy:      req.CreatedBy,
-		CreatedAt:      time.Now().UTC(),
+		ID:                uuid.New(),
+		Name:              req.Name,
+		OrganizationID:    orgID,
+		Status:            "DRAFT",
+		RiskScore:         0,
+		TargetAssets:      req.TargetAssets,
+		EnabledAnalyzers:  req.EnabledAnalyzers,
+		DisabledAnalyzers: req.DisabledAnalyzers,
+		CreatedBy:         req.CreatedBy,
+		CreatedAt:         time.Now().UTC(),
 	}
 
 	if assessment.TargetAssets == nil {
@@ -60,122 +75,313 @@ func (s *AssessmentService) Create(ctx context.Context, req *model.CreateAssessm
 	return assessment, nil
 }
 
+// HasAnalyzer reports whether name is a registered analyzer.
+func (s *AssessmentService) HasAnalyzer(name string) bool {
+	return s.analyzers.Has(name)
+}
+
 func (s *AssessmentService) Get(ctx context.Context, id uuid.UUID) (*model.Assessment, error) {
 	return s.assessmentRepo.GetByID(ctx, id)
 }
 
-func (s *AssessmentService) GetWithSummary(ctx context.Context, id uuid.UUID) (*model.AssessmentResponse, error) {
+// GetWithSummary returns the assessment and a summary of its findings. The
+// summary is nil if it could not be computed.
+func (s *AssessmentService) GetWithSummary(ctx context.Context, id uuid.UUID) (*model.Assessment, *model.AssessmentSummary, error) {
 	a, err := s.assessmentRepo.GetByID(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	resp := a.ToResponse()
-
 	summary, err := s.findingRepo.CountByAssessment(ctx, id)
 	if err != nil {
 		s.logger.Warn("failed to get finding summary", zap.Error(err))
-	} else {
-		summary.PqcReadiness = a.PqcReadiness
-		summary.AssetsScanned = a.AssetsScanned
-		resp.Summary = summary
+		return a, nil, nil
 	}
-
-	return &resp, nil
+	summary.PqcReadiness = a.PqcReadiness
+	summary.AssetsScanned = a.AssetsScanned
+	return a, summary, nil
 }
 
-func (s *AssessmentService) List(ctx context.Context, orgID *uuid.UUID, status string, offset, limit int) ([]model.Assessment, int, error) {
-	if limit <= 0 || limit > 100 {
-		limit = 50
+func (s *AssessmentService) List(ctx context.Context, orgID *uuid.UUID, status string, page repository.Page) ([]model.Assessment, repository.PageInfo, error) {
+	if page.Limit <= 0 || page.Limit > 100 {
+		page.Limit = 50
 	}
-	return s.assessmentRepo.List(ctx, orgID, status, offset, limit)
+	return s.assessmentRepo.List(ctx, orgID, status, page)
 }
 
-func (s *AssessmentService) Run(ctx context.Context, id uuid.UUID) (*model.Assessment, error) {
+// Run analyzes the assessment's target assets and stores the findings and
+// results. If the run fails after the assessment went IN_PROGRESS, the
+// assessment is put back in its previous status so it can be run again.
+func (s *AssessmentService) Run(ctx context.Context, id uuid.UUID) (_ *model.Assessment, err error) {
 	a, err := s.assessmentRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
 	if a.Status != "DRAFT" && a.Status != "COMPLETED" {
-		return nil, fmt.Errorf("assessment cannot be run in status %s", a.Status)
+		return nil, &Error{Kind: ErrInvalidState, Message: "assessment cannot be run in status " + a.Status}
 	}
 
 	if err := s.assessmentRepo.UpdateStatus(ctx, id, "IN_PROGRESS", "system"); err != nil {
 		return nil, err
 	}
+	stored := false
+	defer func() {
+		if err != nil && !stored {
+			s.restoreStatus(ctx, a)
+		}
+	}()
+
+	run := &model.AssessmentRun{
+		ID:           uuid.New(),
+		AssessmentID: id,
+		Status:       "RUNNING",
+		StartedAt:    time.Now().UTC(),
+		CreatedBy:    "system",
+	}
+	if err := s.runRepo.Create(ctx, run); err != nil {
+		return nil, err
+	}
+	if err := s.assessmentRepo.UpdateProgress(ctx, id, 0); err != nil {
+		s.logger.Warn("failed to reset assessment progress", zap.Error(err))
+	}
 
-	// Simulate scanning target assets and producing findings
-	findings := s.analyzeAssets(id, a.TargetAssets)
+	result := s.analyzeAssets(ctx, a)
+	for _, e := range result.Errors {
+		s.logger.Warn("analyzer failed",
+			zap.String("assessment_id", id.String()),
+			zap.String("analyzer", e.Analyzer),
+			zap.String("asset", e.Asset),
+			zap.String("error", e.Error),
+		)
+	}
 
-	if err := s.findingRepo.CreateBatch(ctx, findings); err != nil {
+	if err := s.findingRepo.CreateBatch(ctx, result.Findings); err != nil {
 		s.logger.Error("failed to persist findings", zap.Error(err))
+		s.failRun(ctx, run.ID, result)
 		return nil, err
 	}
 
-	overallRisk, riskScore, pqcReadiness := s.calculateRisk(findings)
+	overallRisk, riskScore, pqcReadiness := calculateRisk(result.Findings, a.TargetAssets, result.Errors)
 
 	if err := s.assessmentRepo.UpdateResults(ctx, id, overallRisk, riskScore, pqcReadiness, len(a.TargetAssets)); err != nil {
+		s.failRun(ctx, run.ID, result)
 		return nil, err
 	}
+	stored = true
+
+	if err := s.runRepo.Complete(ctx, run.ID, "COMPLETED", result.Analyzers, result.Errors, len(result.Findings)); err != nil {
+		s.logger.Error("failed to record assessment run", zap.Error(err))
+	}
 
 	s.logger.Info("assessment completed",
 		zap.String("id", id.String()),
 		zap.String("risk", overallRisk),
 		zap.Float64("score", riskScore),
-		zap.Int("findings", len(findings)),
+		zap.Int("findings", len(result.Findings)),
+		zap.Int("analyzer_errors", len(result.Errors)),
 	)
 
 	return s.assessmentRepo.GetByID(ctx, id)
 }
 
-func (s *AssessmentService) analyzeAssets(assessmentID uuid.UUID, assets []string) []model.Finding {
-	var findings []model.Finding
+// Import stores a scan bundle as a COMPLETED assessment with its findings
+// and a run record, and returns it with a summary of the findings. The risk
+// results are recomputed from the findings rather than taken from the
+// bundle.
+func (s *AssessmentService) Import(ctx context.Context, b *model.ScanBundle, importedBy string) (*model.Assessment, *model.AssessmentSummary, error) {
+	orgID, err := uuid.Parse(b.OrganizationID)
+	if err != nil {
+		return nil, nil, &Error{Kind: ErrValidation, Message: "invalid organization_id", Err: err}
+	}
 	now := time.Now().UTC()
+	completed := parseTimeOr(b.CompletedAt, now)
+	started := parseTimeOr(b.StartedAt, completed)
+
+	a := &model.Assessment{
+		ID:                uuid.New(),
+		Name:              b.Name,
+		OrganizationID:    orgID,
+		Status:            "COMPLETED",
+		TargetAssets:      b.TargetAssets,
+		EnabledAnalyzers:  b.EnabledAnalyzers,
+		DisabledAnalyzers: b.DisabledAnalyzers,
+		AssetsScanned:     len(b.TargetAssets),
+		StartedAt:         &started,
+		CompletedAt:       &completed,
+		CreatedBy:         importedBy,
+		CreatedAt:         now,
+	}
+	if a.TargetAssets == nil {
+		a.TargetAssets = []string{}
+	}
 
-	for _, asset := range assets {
-		// Default finding: flag every asset as potentially missing PQC
-		rsa := "RSA-2048"
-		rec := "ML-KEM-768"
-		rem := "Migrate to post-quantum key encapsulation mechanism ML-KEM-768 (FIPS 203)"
-
-		findings = append(findings, model.Finding{
-			ID:                   uuid.New(),
-			AssessmentID:         assessmentID,
-			Category:             "MISSING_PQC",
-			RiskLevel:            "HIGH",
-			Title:                fmt.Sprintf("No PQC protection on %s", asset),
-			Description:          fmt.Sprintf("Asset %s uses classical cryptography without post-quantum protection", asset),
-			AffectedAsset:        asset,
-			CurrentAlgorithm:     &rsa,
-			RecommendedAlgorithm: &rec,
-			Remediation:          &rem,
-			DiscoveredAt:         now,
-		})
-
-		// Check for HNDL risk
-		hndlRem := "Prioritise migration of long-lived secrets; data encrypted today can be captured and decrypted later by quantum computers"
-		findings = append(findings, model.Finding{
+	findings := make([]model.Finding, len(b.Findings))
+	for i, bf := range b.Findings {
+		findings[i] = model.Finding{
 			ID:                   uuid.New(),
-			AssessmentID:         assessmentID,
-			Category:             "HARVEST_NOW_DECRYPT_LATER",
-			RiskLevel:            "CRITICAL",
-			Title:                fmt.Sprintf("HNDL risk on %s", asset),
-			Description:          fmt.Sprintf("Asset %s is vulnerable to harvest-now-decrypt-later attacks", asset),
-			AffectedAsset:        asset,
-			CurrentAlgorithm:     &rsa,
-			RecommendedAlgorithm: &rec,
-			Remediation:          &hndlRem,
-			DiscoveredAt:         now,
-		})
+			AssessmentID:         a.ID,
+			Category:             bf.Category,
+			RiskLevel:            bf.RiskLevel,
+			Title:                bf.Title,
+			Description:          bf.Description,
+			AffectedAsset:        bf.AffectedAsset,
+			CurrentAlgorithm:     bf.CurrentAlgorithm,
+			RecommendedAlgorithm: bf.RecommendedAlgorithm,
+			Remediation:          bf.Remediation,
+			DiscoveredAt:         parseTimeOr(bf.DiscoveredAt, completed),
+		}
+	}
+	overallRisk, riskScore, pqcReadiness := calculateRisk(findings, a.TargetAssets, b.Errors)
+	a.OverallRisk, a.RiskScore, a.PqcReadiness = &overallRisk, riskScore, pqcReadiness
+
+	run := &model.AssessmentRun{
+		ID:            uuid.New(),
+		AssessmentID:  a.ID,
+		Status:        "COMPLETED",
+		Analyzers:     b.Analyzers,
+		Errors:        b.Errors,
+		FindingsCount: len(findings),
+		StartedAt:     started,
+		CompletedAt:   &completed,
+		CreatedBy:     importedBy,
+	}
+
+	if err := s.assessmentRepo.Import(ctx, a, findings, run); err != nil {
+		s.logger.Error("failed to import scan bundle", zap.Error(err))
+		return nil, nil, err
+	}
+	s.logger.Info("scan bundle imported",
+		zap.String("id", a.ID.String()),
+		zap.String("by", importedBy),
+		zap.Int("findings", len(findings)),
+	)
+	return a, Summarize(a, findings), nil
+}
+
+// parseTimeOr parses an RFC 3339 time, returning def if s is empty or
+// invalid.
+func parseTimeOr(s string, def time.Time) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return def
+	}
+	return t.UTC()
+}
+
+// Update applies req to a DRAFT assessment. ifMatch lists the acceptable
+// updated_at versions; nil accepts any.
+func (s *AssessmentService) Update(ctx context.Context, id uuid.UUID, req *model.UpdateAssessmentRequest, updatedBy string, ifMatch []time.Time) (*model.Assessment, error) {
+	a, err := s.assessmentRepo.Update(ctx, id, req, updatedBy, ifMatch)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("assessment updated", zap.String("id", id.String()), zap.String("by", updatedBy))
+	return a, nil
+}
+
+// Delete soft-deletes an assessment that is not running. Assessments
+// deleted longer ago than the restore window are purged along the way.
+func (s *AssessmentService) Delete(ctx context.Context, id uuid.UUID, deletedBy string, ifMatch []time.Time) error {
+	if err := s.assessmentRepo.Delete(ctx, id, deletedBy, ifMatch); err != nil {
+		return err
+	}
+	s.logger.Info("assessment deleted", zap.String("id", id.String()), zap.String("by", deletedBy))
+
+	if n, err := s.assessmentRepo.Purge(ctx, time.Now().Add(-s.restoreWindow)); err != nil {
+		s.logger.Warn("failed to purge deleted assessments", zap.Error(err))
+	} else if n > 0 {
+		s.logger.Info("purged deleted assessments", zap.Int64("count", n))
+	}
+	return nil
+}
+
+// Restore undeletes an assessment deleted within the restore window.
+func (s *AssessmentService) Restore(ctx context.Context, id uuid.UUID, restoredBy string) (*model.Assessment, error) {
+	a, err := s.assessmentRepo.Restore(ctx, id, restoredBy, time.Now().Add(-s.restoreWindow))
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("assessment restored", zap.String("id", id.String()), zap.String("by", restoredBy))
+	return a, nil
+}
+
+// ListRuns returns the most recent runs of an assessment, newest first.
+func (s *AssessmentService) ListRuns(ctx context.Context, id uuid.UUID) ([]model.AssessmentRun, error) {
+	if _, err := s.assessmentRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.runRepo.ListByAssessment(ctx, id, 50)
+}
+
+// analyzeAssets dispatches the assessment's target assets to the analyzer
+// registry and stamps the resulting findings with IDs. assets_scanned is
+// updated as assets complete, throttled to one write per progressInterval;
+// the final count is written with the results.
+func (s *AssessmentService) analyzeAssets(ctx context.Context, a *model.Assessment) *analyzer.RunResult {
+	var lastWrite time.Time
+	progress := func(scanned int) {
+		if scanned == len(a.TargetAssets) || time.Since(lastWrite) < progressInterval {
+			return
+		}
+		lastWrite = time.Now()
+		if err := s.assessmentRepo.UpdateProgress(ctx, a.ID, scanned); err != nil {
+			s.logger.Warn("failed to update assessment progress", zap.Error(err))
+		}
+	}
+
+	return analyze(ctx, s.analyzers, a, progress)
+}
+
+// analyze dispatches a's target assets to analyzers and stamps the
+// resulting findings with IDs. progress, if not nil, is called with the
+// number of assets analyzed so far.
+func analyze(ctx context.Context, analyzers *analyzer.Registry, a *model.Assessment, progress func(scanned int)) *analyzer.RunResult {
+	result := analyzers.RunWithProgress(ctx, a.TargetAssets, analyzer.Selection{
+		Enabled:  a.EnabledAnalyzers,
+		Disabled: a.DisabledAnalyzers,
+	}, progress)
+
+	now := time.Now().UTC()
+	for i := range result.Findings {
+		result.Findings[i].ID = uuid.New()
+		result.Findings[i].AssessmentID = a.ID
+		result.Findings[i].DiscoveredAt = now
 	}
+	return result
+}
 
-	return findings
+// restoreStatus puts a failed run's assessment back in the status and
+// progress it had before the run. It is best effort, and runs even if ctx
+// was cancelled (for example by the request timeout), since otherwise the
+// assessment would stay IN_PROGRESS and could never be run or deleted.
+func (s *AssessmentService) restoreStatus(ctx context.Context, a *model.Assessment) {
+	ctx = context.WithoutCancel(ctx)
+	if err := s.assessmentRepo.UpdateStatus(ctx, a.ID, a.Status, "system"); err != nil {
+		s.logger.Error("failed to restore assessment status after failed run",
+			zap.String("id", a.ID.String()), zap.String("status", a.Status), zap.Error(err))
+		return
+	}
+	if err := s.assessmentRepo.UpdateProgress(ctx, a.ID, a.AssetsScanned); err != nil {
+		s.logger.Warn("failed to restore assessment progress", zap.Error(err))
+	}
 }
 
-func (s *AssessmentService) calculateRisk(findings []model.Finding) (overallRisk string, riskScore float64, pqcReadiness float64) {
+// failRun marks a run as failed. It is best effort: the caller is already
+// returning the original error.
+func (s *AssessmentService) failRun(ctx context.Context, runID uuid.UUID, result *analyzer.RunResult) {
+	ctx = context.WithoutCancel(ctx)
+	if err := s.runRepo.Complete(ctx, runID, "FAILED", result.Analyzers, result.Errors, 0); err != nil {
+		s.logger.Error("failed to record assessment run failure", zap.Error(err))
+	}
+}
+
+// calculateRisk derives an assessment's overall risk, risk score and PQC
+// readiness from the findings and analyzer errors of a run over assets.
+func calculateRisk(findings []model.Finding, assets []string, errs []model.AnalyzerError) (overallRisk string, riskScore float64, pqcReadiness float64) {
+	pqcReadiness = pqcReadinessOf(findings, assets, errs)
 	if len(findings) == 0 {
-		return "LOW", 0, 100.0
+		return "LOW", 0, pqcReadiness
 	}
 
 	var critCount, highCount, medCount int
@@ -203,18 +409,32 @@ func (s *AssessmentService) calculateRisk(findings []model.Finding) (overallRisk
 	default:
 		overallRisk = "LOW"
 	}
+	return overallRisk, riskScore, pqcReadiness
+}
 
-	// PQC readiness is inverse of missing-PQC findings
-	missingPqc := 0
+// pqcReadinessOf returns the percentage of assets that are PQC-ready: that
+// have no missing-PQC finding and were analyzed without errors. An asset an
+// analyzer failed on, or that no analyzer supports, was not shown to be
+// ready, so it counts as not ready. Analyzers may report several keys per
+// asset, so count assets, not findings.
+func pqcReadinessOf(findings []model.Finding, assets []string, errs []model.AnalyzerError) float64 {
+	if len(assets) == 0 {
+		return 100
+	}
+	notReady := make(map[string]bool)
 	for _, f := range findings {
 		if f.Category == "MISSING_PQC" {
-			missingPqc++
+			notReady[f.AffectedAsset] = true
 		}
 	}
-	totalAssets := len(findings) / 2 // each asset generates 2 findings
-	if totalAssets > 0 {
-		pqcReadiness = float64(totalAssets-missingPqc) / float64(totalAssets) * 100
+	for _, e := range errs {
+		notReady[e.Asset] = true
 	}
-
-	return overallRisk, riskScore, pqcReadiness
+	ready := 0
+	for _, asset := range assets {
+		if !notReady[asset] {
+			ready++
+		}
+	}
+	return float64(ready) / float64(len(assets)) * 100
 }
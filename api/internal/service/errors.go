@@ -0,0 +1,25 @@
+package service
+
+import "github.com/quantun-opensource/qrap/api/internal/repository"
+
+// Domain error kinds, shared with the repository layer. Services wrap them
+// in an *Error; test for them with errors.Is.
+var (
+	// ErrNotFound: the resource does not exist or is outside the caller's
+	// organization scope.
+	ErrNotFound = repository.ErrNotFound
+	// ErrConflict: the write conflicts with another resource, such as a
+	// duplicate name.
+	ErrConflict = repository.ErrConflict
+	// ErrInvalidState: the operation does not apply to the resource's
+	// current state.
+	ErrInvalidState = repository.ErrInvalidState
+	// ErrValidation: the input was rejected.
+	ErrValidation = repository.ErrValidation
+	// ErrPreconditionFailed: a conditional write's If-Match version is no
+	// longer current.
+	ErrPreconditionFailed = repository.ErrPreconditionFailed
+)
+
+// Error is a domain error with a client-safe message; see repository.Error.
+type Error = repository.Error
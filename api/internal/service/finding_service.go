@@ -6,26 +6,43 @@ import (
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
-	"github.com/quantun-opensource/qrap/api/internal/model"
 	"github.com/quantun-opensource/qrap/api/internal/repository"
+	"github.com/quantun-opensource/qrap/api/model"
 )
 
 type FindingService struct {
-	repo   *repository.FindingRepository
-	logger *zap.Logger
+	repo           *repository.FindingRepository
+	assessmentRepo *repository.AssessmentRepository
+	logger         *zap.Logger
 }
 
-func NewFindingService(repo *repository.FindingRepository, logger *zap.Logger) *FindingService {
-	return &FindingService{repo: repo, logger: logger}
+func NewFindingService(repo *repository.FindingRepository, assessmentRepo *repository.AssessmentRepository, logger *zap.Logger) *FindingService {
+	return &FindingService{repo: repo, assessmentRepo: assessmentRepo, logger: logger}
 }
 
 func (s *FindingService) Get(ctx context.Context, id uuid.UUID) (*model.Finding, error) {
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *FindingService) ListByAssessment(ctx context.Context, assessmentID uuid.UUID, riskLevel, category string, offset, limit int) ([]model.Finding, int, error) {
-	if limit <= 0 || limit > 100 {
-		limit = 50
+// List returns the findings matching filter. Assessments named in the filter
+// that are outside the caller's scope are not found rather than empty.
+func (s *FindingService) List(ctx context.Context, filter model.FindingFilter, page repository.Page) ([]model.Finding, repository.PageInfo, error) {
+	if page.Limit <= 0 || page.Limit > 100 {
+		page.Limit = 50
 	}
-	return s.repo.ListByAssessment(ctx, assessmentID, riskLevel, category, offset, limit)
+	for _, id := range filter.AssessmentIDs {
+		if _, err := s.assessmentRepo.GetByID(ctx, id); err != nil {
+			return nil, repository.PageInfo{}, err
+		}
+	}
+	return s.repo.List(ctx, filter, page)
+}
+
+// UpdateStatus records a finding's triage status.
+func (s *FindingService) UpdateStatus(ctx context.Context, id uuid.UUID, status string) (*model.Finding, error) {
+	if err := s.repo.UpdateStatus(ctx, id, status); err != nil {
+		return nil, err
+	}
+	s.logger.Info("finding status updated", zap.String("id", id.String()), zap.String("status", status))
+	return s.repo.GetByID(ctx, id)
 }
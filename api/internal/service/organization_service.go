@@ -7,17 +7,22 @@ import (
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
-	"github.com/quantun-opensource/qrap/api/internal/model"
 	"github.com/quantun-opensource/qrap/api/internal/repository"
+	"github.com/quantun-opensource/qrap/api/internal/tenant"
+	"github.com/quantun-opensource/qrap/api/model"
 )
 
 type OrganizationService struct {
-	repo   *repository.OrganizationRepository
-	logger *zap.Logger
+	repo          *repository.OrganizationRepository
+	members       *repository.MembershipRepository
+	restoreWindow time.Duration
+	logger        *zap.Logger
 }
 
-func NewOrganizationService(repo *repository.OrganizationRepository, logger *zap.Logger) *OrganizationService {
-	return &OrganizationService{repo: repo, logger: logger}
+// NewOrganizationService returns a service whose deleted organizations can
+// be restored for restoreWindow.
+func NewOrganizationService(repo *repository.OrganizationRepository, members *repository.MembershipRepository, restoreWindow time.Duration, logger *zap.Logger) *OrganizationService {
+	return &OrganizationService{repo: repo, members: members, restoreWindow: restoreWindow, logger: logger}
 }
 
 func (s *OrganizationService) Create(ctx context.Context, req *model.CreateOrganizationRequest) (*model.Organization, error) {
@@ -29,7 +34,8 @@ func (s *OrganizationService) Create(ctx context.Context, req *model.CreateOrgan
 		CreatedAt:   time.Now().UTC(),
 	}
 
-	if err := s.repo.Create(ctx, org); err != nil {
+	// The authenticated creator becomes the first member.
+	if err := s.repo.Create(ctx, org, tenant.FromContext(ctx).Subject); err != nil {
 		s.logger.Error("failed to create organization", zap.Error(err))
 		return nil, err
 	}
@@ -42,9 +48,76 @@ func (s *OrganizationService) Get(ctx context.Context, id uuid.UUID) (*model.Org
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *OrganizationService) List(ctx context.Context, offset, limit int) ([]model.Organization, int, error) {
-	if limit <= 0 || limit > 100 {
-		limit = 50
+func (s *OrganizationService) List(ctx context.Context, page repository.Page) ([]model.Organization, repository.PageInfo, error) {
+	if page.Limit <= 0 || page.Limit > 100 {
+		page.Limit = 50
 	}
-	return s.repo.List(ctx, offset, limit)
+	return s.repo.List(ctx, page)
+}
+
+// Update applies req to the organization. ifMatch lists the acceptable
+// updated_at versions; nil accepts any.
+func (s *OrganizationService) Update(ctx context.Context, id uuid.UUID, req *model.UpdateOrganizationRequest, updatedBy string, ifMatch []time.Time) (*model.Organization, error) {
+	org, err := s.repo.Update(ctx, id, req, updatedBy, ifMatch)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("organization updated", zap.String("id", id.String()), zap.String("by", updatedBy))
+	return org, nil
+}
+
+// Delete soft-deletes the organization and its assessments. Organizations
+// deleted longer ago than the restore window are purged along the way.
+func (s *OrganizationService) Delete(ctx context.Context, id uuid.UUID, deletedBy string, ifMatch []time.Time) error {
+	if err := s.repo.Delete(ctx, id, deletedBy, ifMatch); err != nil {
+		return err
+	}
+	s.logger.Info("organization deleted", zap.String("id", id.String()), zap.String("by", deletedBy))
+
+	if n, err := s.repo.Purge(ctx, time.Now().Add(-s.restoreWindow)); err != nil {
+		s.logger.Warn("failed to purge deleted organizations", zap.Error(err))
+	} else if n > 0 {
+		s.logger.Info("purged deleted organizations", zap.Int64("count", n))
+	}
+	return nil
+}
+
+// Restore undeletes an organization deleted within the restore window.
+func (s *OrganizationService) Restore(ctx context.Context, id uuid.UUID, restoredBy string) (*model.Organization, error) {
+	org, err := s.repo.Restore(ctx, id, restoredBy, time.Now().Add(-s.restoreWindow))
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("organization restored", zap.String("id", id.String()), zap.String("by", restoredBy))
+	return org, nil
+}
+
+// AddMember grants subject access to the organization.
+func (s *OrganizationService) AddMember(ctx context.Context, orgID uuid.UUID, subject, createdBy string) (*model.Membership, error) {
+	m := &model.Membership{
+		OrganizationID: orgID,
+		Subject:        subject,
+		CreatedBy:      createdBy,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := s.members.Add(ctx, m); err != nil {
+		return nil, err
+	}
+	s.logger.Info("organization member added", zap.String("organization_id", orgID.String()), zap.String("subject", subject))
+	return m, nil
+}
+
+func (s *OrganizationService) ListMembers(ctx context.Context, orgID uuid.UUID) ([]model.Membership, error) {
+	if _, err := s.repo.GetByID(ctx, orgID); err != nil {
+		return nil, err
+	}
+	return s.members.ListByOrganization(ctx, orgID)
+}
+
+func (s *OrganizationService) RemoveMember(ctx context.Context, orgID uuid.UUID, subject string) error {
+	if err := s.members.Remove(ctx, orgID, subject); err != nil {
+		return err
+	}
+	s.logger.Info("organization member removed", zap.String("organization_id", orgID.String()), zap.String("subject", subject))
+	return nil
 }
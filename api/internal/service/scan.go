@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/quantun-opensource/qrap/api/internal/analyzer"
+	"github.com/quantun-opensource/qrap/api/internal/validate"
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// Scan runs the assessment pipeline for a entirely in memory: it analyzes
+// a's target assets with analyzers and sets a's status and results as Run
+// would store them. The findings and analyzer errors are returned.
+func Scan(ctx context.Context, analyzers *analyzer.Registry, a *model.Assessment) *analyzer.RunResult {
+	started := time.Now().UTC()
+	a.Status = "IN_PROGRESS"
+	a.StartedAt = &started
+
+	result := analyze(ctx, analyzers, a, nil)
+	for i := range result.Findings {
+		result.Findings[i].Status = "OPEN"
+		result.Findings[i].CreatedAt = result.Findings[i].DiscoveredAt
+	}
+	overallRisk, riskScore, pqcReadiness := calculateRisk(result.Findings, a.TargetAssets, result.Errors)
+
+	completed := time.Now().UTC()
+	a.Status = "COMPLETED"
+	a.OverallRisk = &overallRisk
+	a.RiskScore = riskScore
+	a.PqcReadiness = pqcReadiness
+	a.AssetsScanned = len(a.TargetAssets)
+	a.CompletedAt = &completed
+	a.UpdatedAt = completed
+	return result
+}
+
+// Summarize counts findings by risk level, as GetWithSummary reports them
+// for a stored assessment.
+func Summarize(a *model.Assessment, findings []model.Finding) *model.AssessmentSummary {
+	s := &model.AssessmentSummary{
+		TotalFindings: len(findings),
+		PqcReadiness:  a.PqcReadiness,
+		AssetsScanned: a.AssetsScanned,
+	}
+	for _, f := range findings {
+		switch f.RiskLevel {
+		case "CRITICAL":
+			s.CriticalFindings++
+		case "HIGH":
+			s.HighFindings++
+		case "MEDIUM":
+			s.MediumFindings++
+		case "LOW":
+			s.LowFindings++
+		}
+	}
+	return s
+}
+
+// NewScanBundle returns the bundle of a scanned assessment, ready to be
+// written out and imported later.
+func NewScanBundle(a *model.Assessment, result *analyzer.RunResult) *model.ScanBundle {
+	b := &model.ScanBundle{
+		Version:           model.ScanBundleVersion,
+		Name:              a.Name,
+		TargetAssets:      a.TargetAssets,
+		EnabledAnalyzers:  a.EnabledAnalyzers,
+		DisabledAnalyzers: a.DisabledAnalyzers,
+		Analyzers:         result.Analyzers,
+		Errors:            result.Errors,
+		RiskScore:         a.RiskScore,
+		Summary:           Summarize(a, result.Findings),
+		Findings:          []model.BundleFinding{},
+	}
+	if a.OrganizationID != uuid.Nil {
+		b.OrganizationID = a.OrganizationID.String()
+	}
+	if a.OverallRisk != nil {
+		b.OverallRisk = *a.OverallRisk
+	}
+	if a.StartedAt != nil {
+		b.StartedAt = a.StartedAt.Format(time.RFC3339)
+	}
+	if a.CompletedAt != nil {
+		b.CompletedAt = a.CompletedAt.Format(time.RFC3339)
+	}
+	if b.Analyzers == nil {
+		b.Analyzers = []string{}
+	}
+	if b.Errors == nil {
+		b.Errors = []model.AnalyzerError{}
+	}
+	for i := range result.Findings {
+		b.Findings = append(b.Findings, model.NewBundleFinding(&result.Findings[i]))
+	}
+	return b
+}
+
+// CheckScanBundle adds the rules of a scan bundle that its validate tags
+// cannot express to v, which has already applied the tags: the format
+// version, the timestamps and their order, and each finding and analyzer
+// error, which must be about one of the target assets.
+func CheckScanBundle(v *validate.Validator, b *model.ScanBundle) {
+	if b.Version != model.ScanBundleVersion {
+		v.Addf("version", "must be %d", model.ScanBundleVersion)
+	}
+	started, startedOK := checkTime(v, "started_at", b.StartedAt)
+	completed, completedOK := checkTime(v, "completed_at", b.CompletedAt)
+	if startedOK && completedOK && started.After(completed) {
+		v.Add("started_at", "must not be later than completed_at")
+	}
+	tooMany := false
+	if len(b.Findings) > model.MaxBundleFindings {
+		v.Addf("findings", "must contain at most %d items", model.MaxBundleFindings)
+		tooMany = true
+	}
+	if len(b.Errors) > model.MaxBundleErrors {
+		v.Addf("errors", "must contain at most %d items", model.MaxBundleErrors)
+		tooMany = true
+	}
+	if tooMany {
+		return
+	}
+	targets := make(map[string]bool, len(b.TargetAssets))
+	for _, asset := range b.TargetAssets {
+		targets[asset] = true
+	}
+	for i := range b.Findings {
+		prefix := fmt.Sprintf("findings[%d].", i)
+		checkStruct(v, prefix, &b.Findings[i])
+		if asset := b.Findings[i].AffectedAsset; asset != "" && !targets[asset] {
+			v.Add(prefix+"affected_asset", "must be one of target_assets")
+		}
+		checkTime(v, prefix+"discovered_at", b.Findings[i].DiscoveredAt)
+	}
+	for i := range b.Errors {
+		prefix := fmt.Sprintf("errors[%d].", i)
+		checkStruct(v, prefix, &b.Errors[i])
+		if asset := b.Errors[i].Asset; asset != "" && !targets[asset] {
+			v.Add(prefix+"asset", "must be one of target_assets")
+		}
+	}
+}
+
+// checkStruct applies the validate tags of s, recording each problem with
+// its field name after prefix.
+func checkStruct(v *validate.Validator, prefix string, s interface{}) {
+	var errs validate.Errors
+	if errors.As(validate.Struct(s), &errs) {
+		for _, e := range errs {
+			v.Add(prefix+e.Field, e.Message)
+		}
+	}
+}
+
+// checkTime records a problem with field unless s is empty or an RFC 3339
+// time, and returns the time and whether s held one.
+func checkTime(v *validate.Validator, field, s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		v.Add(field, "must be an RFC 3339 time")
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quantun-opensource/qrap/api/internal/analyzer"
+	"github.com/quantun-opensource/qrap/api/internal/validate"
+	"github.com/quantun-opensource/qrap/api/model"
+)
+
+// stubAnalyzer reports one finding of category on assets with its prefix,
+// or fails with err.
+type stubAnalyzer struct {
+	name, prefix, category string
+	err                    error
+}
+
+func (s *stubAnalyzer) Name() string               { return s.name }
+func (s *stubAnalyzer) Supports(asset string) bool { return strings.HasPrefix(asset, s.prefix) }
+
+func (s *stubAnalyzer) Analyze(ctx context.Context, asset string) ([]model.Finding, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []model.Finding{{Category: s.category, RiskLevel: "HIGH", Title: s.name, Description: "stub"}}, nil
+}
+
+func newRegistry(t *testing.T, analyzers ...analyzer.Analyzer) *analyzer.Registry {
+	t.Helper()
+	r := analyzer.NewRegistry(time.Second)
+	for _, a := range analyzers {
+		if err := r.Register(a, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+func TestScan_Readiness(t *testing.T) {
+	failing := errors.New("connection refused")
+	cases := []struct {
+		name      string
+		analyzers []analyzer.Analyzer
+		assets    []string
+		risk      string
+		readiness float64
+	}{
+		{
+			name:      "all analyzers fail",
+			analyzers: []analyzer.Analyzer{&stubAnalyzer{name: "a", prefix: "x", err: failing}, &stubAnalyzer{name: "b", prefix: "x", err: failing}},
+			assets:    []string{"x1", "x2"},
+			risk:      "LOW",
+			readiness: 0,
+		},
+		{
+			name:      "one of two analyzers fails",
+			analyzers: []analyzer.Analyzer{&stubAnalyzer{name: "a", prefix: "x", category: "WEAK_ALGORITHM"}, &stubAnalyzer{name: "b", prefix: "x1", err: failing}},
+			assets:    []string{"x1", "x2"},
+			risk:      "HIGH",
+			readiness: 50,
+		},
+		{
+			name:      "unsupported asset",
+			analyzers: []analyzer.Analyzer{&stubAnalyzer{name: "a", prefix: "x", category: "WEAK_ALGORITHM"}},
+			assets:    []string{"x1", "y1"},
+			risk:      "HIGH",
+			readiness: 50,
+		},
+		{
+			name:      "missing PQC",
+			analyzers: []analyzer.Analyzer{&stubAnalyzer{name: "a", prefix: "x", category: "MISSING_PQC"}},
+			assets:    []string{"x1", "x2"},
+			risk:      "HIGH",
+			readiness: 0,
+		},
+		{
+			name:      "ready",
+			analyzers: []analyzer.Analyzer{&stubAnalyzer{name: "a", prefix: "x", category: "WEAK_ALGORITHM"}},
+			assets:    []string{"x1"},
+			risk:      "HIGH",
+			readiness: 100,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &model.Assessment{Name: "scan", TargetAssets: tc.assets}
+			Scan(context.Background(), newRegistry(t, tc.analyzers...), a)
+			if a.Status != "COMPLETED" || *a.OverallRisk != tc.risk || a.PqcReadiness != tc.readiness {
+				t.Errorf("status %s, risk %s, readiness %v; want COMPLETED, %s, %v", a.Status, *a.OverallRisk, a.PqcReadiness, tc.risk, tc.readiness)
+			}
+		})
+	}
+}
+
+func TestCheckScanBundle(t *testing.T) {
+	valid := func() *model.ScanBundle {
+		return &model.ScanBundle{
+			Version:      model.ScanBundleVersion,
+			TargetAssets: []string{"tls://a.example:443"},
+			StartedAt:    "2026-01-15T10:00:00Z",
+			CompletedAt:  "2026-01-15T10:05:00Z",
+			Findings: []model.BundleFinding{{Category: "MISSING_PQC", RiskLevel: "HIGH", Title: "t", Description: "d",
+				AffectedAsset: "tls://a.example:443"}},
+			Errors: []model.AnalyzerError{{Analyzer: "tls", Asset: "tls://a.example:443", Error: "connection refused"}},
+		}
+	}
+	cases := []struct {
+		name   string
+		change func(b *model.ScanBundle)
+		want   []string
+	}{
+		{"valid", func(*model.ScanBundle) {}, nil},
+		{"no times", func(b *model.ScanBundle) { b.StartedAt, b.CompletedAt = "", "" }, nil},
+		{"bad version", func(b *model.ScanBundle) { b.Version = 2 }, []string{"version"}},
+		{"started after completed", func(b *model.ScanBundle) { b.StartedAt = "2026-01-15T11:00:00Z" }, []string{"started_at"}},
+		{"bad time", func(b *model.ScanBundle) { b.CompletedAt = "yesterday" }, []string{"completed_at"}},
+		{"asset not targeted", func(b *model.ScanBundle) { b.Findings[0].AffectedAsset = "tls://b.example:443" }, []string{"findings[0].affected_asset"}},
+		{"invalid finding", func(b *model.ScanBundle) { b.Findings[0].RiskLevel = "SEVERE" }, []string{"findings[0].risk_level"}},
+		{"error asset not targeted", func(b *model.ScanBundle) { b.Errors[0].Asset = "tls://b.example:443" }, []string{"errors[0].asset"}},
+		{"empty error", func(b *model.ScanBundle) { b.Errors[0].Asset, b.Errors[0].Error = "", "" }, []string{"errors[0].asset", "errors[0].error"}},
+		{"long error", func(b *model.ScanBundle) { b.Errors[0].Error = strings.Repeat("x", 131073) }, []string{"errors[0].error"}},
+		{"too many errors", func(b *model.ScanBundle) {
+			b.Errors = make([]model.AnalyzerError, model.MaxBundleErrors+1)
+		}, []string{"errors"}},
+	}
+	for _, tc := range cases {
+		b := valid()
+		tc.change(b)
+		var v validate.Validator
+		CheckScanBundle(&v, b)
+		var got []string
+		var errs validate.Errors
+		if errors.As(v.Err(), &errs) {
+			for _, e := range errs {
+				got = append(got, e.Field)
+			}
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: fields %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Package tenant carries the caller's organization scope through the request
+// context. Repositories read the scope and restrict every query to the
+// organizations the caller is a member of; rows outside it are reported as
+// not found, so other tenants' IDs cannot be probed.
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+type contextKey struct{}
+
+// Scope is the set of organizations a caller may access.
+type Scope struct {
+	// Subject is the authenticated identity the scope was resolved for.
+	Subject string
+	// Unrestricted grants access to every organization. It is only set when
+	// authentication is disabled and for internal system work.
+	Unrestricted bool
+	// OrganizationIDs lists the caller's organizations.
+	OrganizationIDs []uuid.UUID
+}
+
+// Allows reports whether the scope includes organization id.
+func (s Scope) Allows(id uuid.UUID) bool {
+	return s.Unrestricted || slices.Contains(s.OrganizationIDs, id)
+}
+
+// WithScope returns a copy of ctx carrying s.
+func WithScope(ctx context.Context, s Scope) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the scope stored in ctx. A context without a scope
+// yields an empty scope that allows nothing.
+func FromContext(ctx context.Context) Scope {
+	s, _ := ctx.Value(contextKey{}).(Scope)
+	return s
+}
+
+// MembershipLookup resolves the organizations a subject belongs to.
+type MembershipLookup interface {
+	OrganizationsForSubject(ctx context.Context, subject string) ([]uuid.UUID, error)
+}
+
+// Middleware resolves the authenticated caller's organization scope and
+// stores it in the request context. It must run after qmw.Auth. Requests
+// that skipped authentication get an empty scope.
+func Middleware(members MembershipLookup, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if qmw.AuthMethodFromContext(ctx) == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			subject := qmw.SubjectFromContext(ctx)
+			orgs, err := members.OrganizationsForSubject(ctx, subject)
+			if err != nil {
+				logger.Error("failed to resolve organization scope", zap.String("subject", subject), zap.Error(err))
+				qmw.WriteProblem(w, http.StatusInternalServerError,
+					qmw.NewProblem(r, http.StatusInternalServerError, "failed to resolve organization scope"))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithScope(ctx, Scope{Subject: subject, OrganizationIDs: orgs})))
+		})
+	}
+}
+
+// Unscoped gives every request an unrestricted scope. It is used in place of
+// Middleware when authentication is disabled.
+func Unscoped(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithScope(r.Context(), Scope{Unrestricted: true})))
+	})
+}
@@ -0,0 +1,76 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	qmw "github.com/quantun-opensource/qrap/shared/go/middleware"
+)
+
+type fakeMembers map[string][]uuid.UUID
+
+func (f fakeMembers) OrganizationsForSubject(ctx context.Context, subject string) ([]uuid.UUID, error) {
+	if subject == "broken" {
+		return nil, errors.New("database down")
+	}
+	return f[subject], nil
+}
+
+func authenticated(r *http.Request, subject string) *http.Request {
+	ctx := context.WithValue(r.Context(), qmw.ContextKeySubject, subject)
+	ctx = context.WithValue(ctx, qmw.ContextKeyAuthMethod, qmw.AuthMethodJWT)
+	return r.WithContext(ctx)
+}
+
+func serve(h func(http.Handler) http.Handler, r *http.Request) (Scope, int) {
+	var got Scope
+	rec := httptest.NewRecorder()
+	h(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+	})).ServeHTTP(rec, r)
+	return got, rec.Code
+}
+
+func TestMiddleware(t *testing.T) {
+	orgA, orgB := uuid.New(), uuid.New()
+	mw := Middleware(fakeMembers{"alice": {orgA}}, zap.NewNop())
+
+	s, _ := serve(mw, authenticated(httptest.NewRequest("GET", "/", nil), "alice"))
+	if s.Subject != "alice" || s.Unrestricted || !s.Allows(orgA) || s.Allows(orgB) {
+		t.Errorf("alice scope = %+v", s)
+	}
+
+	s, _ = serve(mw, authenticated(httptest.NewRequest("GET", "/", nil), "mallory"))
+	if s.Allows(orgA) || s.Allows(orgB) {
+		t.Errorf("non-member scope = %+v", s)
+	}
+
+	// Skipped authentication must not widen the scope.
+	s, _ = serve(mw, httptest.NewRequest("GET", "/", nil))
+	if s.Unrestricted || s.Allows(orgA) {
+		t.Errorf("unauthenticated scope = %+v", s)
+	}
+
+	if _, code := serve(mw, authenticated(httptest.NewRequest("GET", "/", nil), "broken")); code != http.StatusInternalServerError {
+		t.Errorf("lookup failure: expected 500, got %d", code)
+	}
+}
+
+func TestUnscoped(t *testing.T) {
+	s, _ := serve(Unscoped, httptest.NewRequest("GET", "/", nil))
+	if !s.Unrestricted || !s.Allows(uuid.New()) {
+		t.Errorf("Unscoped scope = %+v", s)
+	}
+}
+
+func TestFromContext_Empty(t *testing.T) {
+	if FromContext(context.Background()).Allows(uuid.New()) {
+		t.Error("a context without a scope must allow nothing")
+	}
+}
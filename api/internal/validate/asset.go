@@ -0,0 +1,106 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxAssetLength matches the findings.affected_asset column.
+const MaxAssetLength = 512
+
+var (
+	schemePattern = regexp.MustCompile(`^[a-z][a-z0-9+.-]*$`)
+	labelPattern  = regexp.MustCompile(`^[A-Za-z0-9_]([A-Za-z0-9_-]*[A-Za-z0-9_])?$`)
+)
+
+var errAssetSyntax = errors.New("must be a URI such as tls://host:443, a host or host:port, or a file path")
+
+// Asset checks the syntax of an assessment target. It accepts
+//
+//   - URIs with a scheme and a host, such as tls://example.com:443,
+//     ssh://10.0.0.5 or jwks+https://idp.example.com/keys
+//   - file URIs, whose scheme is file or ends in +file, with a path, such
+//     as dnssec+file:///zones/example.com.zone
+//   - hosts and host:port pairs, such as api-gateway or example.com:8443
+//   - absolute or ./ and ../ relative file paths
+//
+// Whether an analyzer handles the asset is decided when the assessment
+// runs; Asset only rejects references no analyzer could use.
+func Asset(s string) error {
+	if s == "" {
+		return errors.New("must not be empty")
+	}
+	if utf8.RuneCountInString(s) > MaxAssetLength {
+		return fmt.Errorf("must be at most %d characters", MaxAssetLength)
+	}
+	if strings.IndexFunc(s, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0 {
+		return errors.New("must not contain spaces or control characters")
+	}
+
+	if scheme, _, ok := strings.Cut(s, "://"); ok {
+		if !schemePattern.MatchString(scheme) {
+			return errAssetSyntax
+		}
+		u, err := url.Parse(s)
+		if err != nil {
+			return errAssetSyntax
+		}
+		if scheme == "file" || strings.HasSuffix(scheme, "+file") {
+			if u.Path == "" {
+				return errors.New("file URI must have a path")
+			}
+			return nil
+		}
+		if u.Host == "" || !validHostPort(u.Host) {
+			return errAssetSyntax
+		}
+		return nil
+	}
+
+	if strings.HasPrefix(s, "/") || strings.HasPrefix(s, "./") || strings.HasPrefix(s, "../") {
+		return nil
+	}
+	if !validHostPort(s) {
+		return errAssetSyntax
+	}
+	return nil
+}
+
+// validHostPort reports whether s is a host name or IP address with an
+// optional port.
+func validHostPort(s string) bool {
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		return net.ParseIP(s[1:len(s)-1]) != nil
+	}
+	host := s
+	if strings.Contains(s, ":") && net.ParseIP(s) == nil {
+		h, port, err := net.SplitHostPort(s)
+		if err != nil {
+			return false
+		}
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return false
+		}
+		host = h
+	}
+	if net.ParseIP(host) != nil {
+		return true
+	}
+	if len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if len(label) > 63 || !labelPattern.MatchString(label) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Decode reads a single JSON object from r into dst, a pointer to a request
+// struct. Unknown fields, values of the wrong type and malformed JSON are
+// reported as Errors, so every failure of Decode is the client's. Decode
+// does not apply the validate tags; see Struct.
+func Decode(r io.Reader, dst interface{}) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if dec.More() {
+		return Errors{{Message: "request body must contain a single JSON object"}}
+	}
+	return nil
+}
+
+// decodeError describes a json.Decoder error as Errors.
+func decodeError(err error) Errors {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.Is(err, io.EOF):
+		return Errors{{Message: "request body is required"}}
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return Errors{{Message: "request body must be a JSON object"}}
+		}
+		return Errors{{Field: typeErr.Field, Message: "must be " + jsonType(typeErr.Type.Kind())}}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return Errors{{Message: "request body is not valid JSON"}}
+	}
+	// encoding/json has no error type for unknown fields.
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return Errors{{Field: strings.Trim(field, `"`), Message: "is not a known field"}}
+	}
+	return Errors{{Message: fmt.Sprintf("invalid request body: %v", err)}}
+}
+
+// jsonType names the JSON type that decodes into a Go kind.
+func jsonType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	case reflect.Bool:
+		return "a boolean"
+	default:
+		return "a number"
+	}
+}
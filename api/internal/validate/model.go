@@ -0,0 +1,13 @@
+package validate
+
+import "github.com/quantun-opensource/qrap/api/model"
+
+// The public model package depends only on the standard library and uuid,
+// so its enums are registered here rather than by the package itself.
+func init() {
+	RegisterEnum("assessment_status", model.AssessmentStatuses)
+	RegisterEnum("finding_category", model.FindingCategories)
+	RegisterEnum("risk_level", model.RiskLevels)
+	RegisterEnum("finding_status", model.FindingStatuses)
+	RegisterEnum("finding_sort", model.FindingSorts)
+}
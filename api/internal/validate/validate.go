@@ -0,0 +1,251 @@
+// Package validate checks decoded API requests and reports every problem
+// with a request at once, keyed by the JSON name of the offending field.
+//
+// Request types declare their field rules in `validate` struct tags:
+//
+//	Name   string   `json:"name" validate:"required,max=255"`
+//	Assets []string `json:"target_assets" validate:"max=1000,dive,asset"`
+//
+// Rules are separated by commas:
+//
+//	required   the field must be set and not empty
+//	notempty   the field may be omitted (nil) but not set to an empty value
+//	max=N      strings hold at most N characters, slices at most N items
+//	uuid       the string is a UUID
+//	enum=NAME  the string is a value of the enum registered as NAME
+//	asset      the string is an asset reference (see Asset)
+//	dive       the rules after it apply to each element of a slice
+//
+// Apart from required and notempty, rules skip empty and omitted values;
+// every rule after dive applies to every element. Rules that span fields
+// or depend on the time are checked by the caller after Struct, adding to
+// the same Validator.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// FieldError is one problem with a request. Field is the JSON name of the
+// field, with an index for slice elements ("target_assets[2]"), or empty
+// for a problem with the request as a whole.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) String() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// Errors is every problem found with a request.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Validator collects field errors. The zero value is ready to use.
+type Validator struct {
+	errs Errors
+}
+
+// Add records a problem with field.
+func (v *Validator) Add(field, message string) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: message})
+}
+
+// Addf records a problem with field, formatting the message.
+func (v *Validator) Addf(field, format string, args ...interface{}) {
+	v.Add(field, fmt.Sprintf(format, args...))
+}
+
+// Err returns the problems recorded so far as Errors, or nil if there are
+// none.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// Struct applies the validate tags of s, a pointer to a struct.
+func (v *Validator) Struct(s interface{}) {
+	rv := reflect.Indirect(reflect.ValueOf(s))
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag, ok := f.Tag.Lookup("validate")
+		if !ok || !f.IsExported() {
+			continue
+		}
+		v.field(jsonName(f), rv.Field(i), strings.Split(tag, ","))
+	}
+}
+
+// Struct validates s, a pointer to a struct, and returns Errors or nil.
+func Struct(s interface{}) error {
+	var v Validator
+	v.Struct(s)
+	return v.Err()
+}
+
+// field applies rules to the value of one struct field.
+func (v *Validator) field(name string, value reflect.Value, rules []string) {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			if slices.Contains(rules, "required") {
+				v.Add(name, "is required")
+			}
+			return
+		}
+		value = value.Elem()
+	}
+	isSlice := value.Kind() == reflect.Slice
+	empty := value.Len() == 0
+	for i, rule := range rules {
+		switch rule {
+		case "required":
+			if empty {
+				v.Add(name, "is required")
+				return
+			}
+		case "notempty":
+			if empty && (!isSlice || !value.IsNil()) {
+				v.Add(name, "must not be empty")
+				return
+			}
+		case "dive":
+			for j := 0; j < value.Len(); j++ {
+				elem := value.Index(j)
+				v.value(fmt.Sprintf("%s[%d]", name, j), elem.String(), elem.Len() == 0, rules[i+1:], false)
+			}
+			return
+		default:
+			if !empty {
+				if isSlice {
+					v.sliceRule(name, value.Len(), rule)
+				} else {
+					v.value(name, value.String(), false, []string{rule}, true)
+				}
+			}
+		}
+	}
+}
+
+// sliceRule applies a rule that constrains a whole slice.
+func (v *Validator) sliceRule(name string, n int, rule string) {
+	if limit, ok := strings.CutPrefix(rule, "max="); ok {
+		if n > atoi(rule, limit) {
+			v.Addf(name, "must contain at most %s items", limit)
+		}
+		return
+	}
+	panic(fmt.Sprintf("validate: rule %q does not apply to slices", rule))
+}
+
+// value applies string rules to s. Empty values fail every rule unless
+// skipEmpty is set, in which case the caller has already skipped them.
+func (v *Validator) value(name, s string, empty bool, rules []string, skipEmpty bool) {
+	for _, rule := range rules {
+		if empty && !skipEmpty {
+			v.Add(name, "must not be empty")
+			return
+		}
+		key, arg, _ := strings.Cut(rule, "=")
+		switch key {
+		case "max":
+			if utf8.RuneCountInString(s) > atoi(rule, arg) {
+				v.Addf(name, "must be at most %s characters", arg)
+				return
+			}
+		case "uuid":
+			v.UUID(name, s)
+		case "enum":
+			v.Enum(name, s, arg)
+		case "asset":
+			v.Asset(name, s)
+		default:
+			panic(fmt.Sprintf("validate: unknown rule %q", rule))
+		}
+	}
+}
+
+// UUID records a problem with field unless s is a UUID.
+func (v *Validator) UUID(field, s string) {
+	if _, err := uuid.Parse(s); err != nil {
+		v.Add(field, "must be a UUID")
+	}
+}
+
+// Enum records a problem with field unless s is a value of the enum
+// registered as name.
+func (v *Validator) Enum(field, s, name string) {
+	values := enumValues(name)
+	if !slices.Contains(values, s) {
+		v.Addf(field, "must be one of %s", strings.Join(values, ", "))
+	}
+}
+
+// Asset records a problem with field unless s is an asset reference.
+func (v *Validator) Asset(field, s string) {
+	if err := Asset(s); err != nil {
+		v.Add(field, err.Error())
+	}
+}
+
+var (
+	enumsMu sync.RWMutex
+	enums   = map[string][]string{}
+)
+
+// RegisterEnum makes values available to the enum=name rule. Packages that
+// own an enum register it from an init function; the enums of the public
+// model package are registered by this package (see model.go).
+func RegisterEnum(name string, values []string) {
+	enumsMu.Lock()
+	defer enumsMu.Unlock()
+	enums[name] = values
+}
+
+func enumValues(name string) []string {
+	enumsMu.RLock()
+	defer enumsMu.RUnlock()
+	values, ok := enums[name]
+	if !ok {
+		panic(fmt.Sprintf("validate: enum %q is not registered", name))
+	}
+	return values
+}
+
+// jsonName returns the name f has in JSON.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func atoi(rule, s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		panic(fmt.Sprintf("validate: invalid rule %q", rule))
+	}
+	return n
+}
@@ -0,0 +1,135 @@
+package validate
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterEnum("color", []string{"RED", "GREEN"})
+}
+
+type request struct {
+	Name     string   `json:"name" validate:"required,max=5"`
+	Nick     *string  `json:"nick" validate:"notempty,max=3"`
+	OwnerID  string   `json:"owner_id" validate:"uuid"`
+	Color    string   `json:"color" validate:"enum=color"`
+	Assets   []string `json:"assets" validate:"max=2,dive,asset"`
+	Tags     []string `json:"tags" validate:"notempty"`
+	Internal string
+}
+
+func fields(err error) []string {
+	var errs Errors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+	var out []string
+	for _, e := range errs {
+		out = append(out, e.Field)
+	}
+	return out
+}
+
+func TestStruct(t *testing.T) {
+	nick := "ok"
+	valid := request{Name: "abc", Nick: &nick, OwnerID: "550e8400-e29b-41d4-a716-446655440000", Color: "GREEN",
+		Assets: []string{"tls://example.com:443", "api-gateway"}}
+	if err := Struct(&valid); err != nil {
+		t.Errorf("valid request: %v", err)
+	}
+	if err := Struct(&request{Name: "x"}); err != nil {
+		t.Errorf("omitted optional fields: %v", err)
+	}
+
+	empty, long := "", "toolong"
+	cases := []struct {
+		req  request
+		want []string
+	}{
+		{request{}, []string{"name"}},
+		{request{Name: "ünïcø"}, nil}, // five characters, more bytes
+		{request{Name: "abcdef", Nick: &empty}, []string{"name", "nick"}},
+		{request{Name: "a", Nick: &long, OwnerID: "x", Color: "BLUE"}, []string{"nick", "owner_id", "color"}},
+		{request{Name: "a", Assets: []string{"tls://", "", "a b"}}, []string{"assets", "assets[0]", "assets[1]", "assets[2]"}},
+		{request{Name: "a", Tags: []string{}}, []string{"tags"}},
+	}
+	for _, tc := range cases {
+		if got := fields(Struct(&tc.req)); !slices.Equal(got, tc.want) {
+			t.Errorf("%+v: fields %v, want %v", tc.req, got, tc.want)
+		}
+	}
+}
+
+func TestErrorsMessage(t *testing.T) {
+	err := Struct(&request{Name: "abcdef", Color: "BLUE"})
+	want := "name: must be at most 5 characters; color: must be one of RED, GREEN"
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var req request
+	if err := Decode(strings.NewReader(`{"name":"a","assets":["x:1"]}`), &req); err != nil || req.Name != "a" {
+		t.Fatalf("Decode = %v, %+v", err, req)
+	}
+	cases := map[string]FieldError{
+		``:                          {Message: "request body is required"},
+		`{"name":`:                  {Message: "request body is not valid JSON"},
+		`[1]`:                       {Message: "request body must be a JSON object"},
+		`{"name":1}`:                {Field: "name", Message: "must be a string"},
+		`{"name":"a","admin":true}`: {Field: "admin", Message: "is not a known field"},
+		`{"name":"a"} {}`:           {Message: "request body must contain a single JSON object"},
+	}
+	for body, want := range cases {
+		var req request
+		err := Decode(strings.NewReader(body), &req)
+		var errs Errors
+		if !errors.As(err, &errs) || len(errs) != 1 || errs[0] != want {
+			t.Errorf("%q: error %v, want %v", body, err, want)
+		}
+	}
+}
+
+func TestAsset(t *testing.T) {
+	for _, ok := range []string{
+		"tls://example.com:443",
+		"tls://10.0.0.1",
+		"tls://[2001:db8::1]:443",
+		"ssh://bastion.internal:22",
+		"jwks+https://idp.example.com/.well-known/jwks.json",
+		"dnssec+file:///zones/example.com.zone",
+		"file:///etc/ssl/cert.pem",
+		"example.com:8443",
+		"api-gateway",
+		"legacy_app.example.com",
+		"10.0.0.5:22",
+		"/etc/ssh/sshd_config",
+		"./certs/server.pem",
+	} {
+		if err := Asset(ok); err != nil {
+			t.Errorf("%q rejected: %v", ok, err)
+		}
+	}
+	for _, bad := range []string{
+		"",
+		"tls://",
+		"tls://example.com:0",
+		"tls://example.com:99999",
+		"TLS://example.com",
+		"dnssec+file://",
+		"example.com:port",
+		"-bad-.example.com",
+		"host name",
+		"tls://exa\tmple.com",
+		"http://user@",
+		strings.Repeat("a", MaxAssetLength+1),
+	} {
+		if err := Asset(bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
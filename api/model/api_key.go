@@ -0,0 +1,82 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is a database-backed API key. Only a salted hash of the secret is
+// stored; Prefix is the non-secret id embedded in the key.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Salt       []byte     `json:"-"`
+	KeyHash    []byte     `json:"-"`
+	Subject    string     `json:"subject"`
+	Role       string     `json:"role"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// CreateAPIKeyRequest creates a key for the caller. An empty Role is the
+// caller's role; nil Scopes grant the role's full permissions.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=255"`
+	Role      string     `json:"role" validate:"enum=role"`
+	Scopes    []string   `json:"scopes" validate:"notempty,max=50,dive,max=100"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type APIKeyResponse struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	Prefix     string    `json:"prefix"`
+	Subject    string    `json:"subject"`
+	Role       string    `json:"role"`
+	Scopes     []string  `json:"scopes,omitempty"`
+	ExpiresAt  *string   `json:"expires_at,omitempty"`
+	LastUsedAt *string   `json:"last_used_at,omitempty"`
+	RevokedAt  *string   `json:"revoked_at,omitempty"`
+	CreatedAt  string    `json:"created_at"`
+}
+
+// APIKeySecretResponse is returned by create and rotate, the only times the
+// secret is ever shown.
+type APIKeySecretResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+type APIKeyListResponse struct {
+	APIKeys []APIKeyResponse `json:"api_keys"`
+}
+
+func (k *APIKey) ToResponse() APIKeyResponse {
+	return APIKeyResponse{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Subject:    k.Subject,
+		Role:       k.Role,
+		Scopes:     k.Scopes,
+		ExpiresAt:  formatOptionalTime(k.ExpiresAt),
+		LastUsedAt: formatOptionalTime(k.LastUsedAt),
+		RevokedAt:  formatOptionalTime(k.RevokedAt),
+		CreatedAt:  k.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(time.RFC3339)
+	return &s
+}
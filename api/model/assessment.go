@@ -0,0 +1,97 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Assessment struct {
+	ID                uuid.UUID  `json:"id"`
+	Name              string     `json:"name"`
+	OrganizationID    uuid.UUID  `json:"organization_id"`
+	Status            string     `json:"status"`
+	OverallRisk       *string    `json:"overall_risk"`
+	RiskScore         float64    `json:"risk_score"`
+	TargetAssets      []string   `json:"target_assets"`
+	EnabledAnalyzers  []string   `json:"enabled_analyzers"`
+	DisabledAnalyzers []string   `json:"disabled_analyzers"`
+	AssetsScanned     int        `json:"assets_scanned"`
+	PqcReadiness      float64    `json:"pqc_readiness"`
+	StartedAt         *time.Time `json:"started_at"`
+	CompletedAt       *time.Time `json:"completed_at"`
+	CreatedBy         string     `json:"created_by"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedBy         string     `json:"updated_by"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// AssessmentStatuses mirrors the assessment_status database enum.
+var AssessmentStatuses = []string{"DRAFT", "IN_PROGRESS", "COMPLETED", "ARCHIVED"}
+
+type CreateAssessmentRequest struct {
+	Name              string   `json:"name" validate:"required,max=255"`
+	OrganizationID    string   `json:"organization_id" validate:"required,uuid"`
+	TargetAssets      []string `json:"target_assets" validate:"max=1000,dive,asset"`
+	EnabledAnalyzers  []string `json:"enabled_analyzers" validate:"max=100,dive,max=100"`
+	DisabledAnalyzers []string `json:"disabled_analyzers" validate:"max=100,dive,max=100"`
+	CreatedBy         string   `json:"created_by" validate:"max=255"`
+}
+
+// UpdateAssessmentRequest is a partial update of a DRAFT assessment: nil
+// fields are left as they are.
+type UpdateAssessmentRequest struct {
+	Name         *string  `json:"name" validate:"notempty,max=255"`
+	TargetAssets []string `json:"target_assets" validate:"max=1000,dive,asset"`
+}
+
+type AssessmentResponse struct {
+	ID                uuid.UUID          `json:"id"`
+	Name              string             `json:"name"`
+	OrganizationID    uuid.UUID          `json:"organization_id"`
+	Status            string             `json:"status"`
+	OverallRisk       *string            `json:"overall_risk,omitempty"`
+	RiskScore         float64            `json:"risk_score"`
+	TargetAssets      []string           `json:"target_assets"`
+	EnabledAnalyzers  []string           `json:"enabled_analyzers,omitempty"`
+	DisabledAnalyzers []string           `json:"disabled_analyzers,omitempty"`
+	Summary           *AssessmentSummary `json:"summary,omitempty"`
+	CreatedAt         string             `json:"created_at"`
+	UpdatedAt         string             `json:"updated_at"`
+}
+
+type AssessmentSummary struct {
+	TotalFindings    int     `json:"total_findings"`
+	CriticalFindings int     `json:"critical_findings"`
+	HighFindings     int     `json:"high_findings"`
+	MediumFindings   int     `json:"medium_findings"`
+	LowFindings      int     `json:"low_findings"`
+	PqcReadiness     float64 `json:"pqc_readiness_percentage"`
+	AssetsScanned    int     `json:"assets_scanned"`
+}
+
+type AssessmentListResponse struct {
+	Assessments []AssessmentResponse `json:"assessments"`
+	// TotalCount is omitted unless counted (see qmw.Pagination.Count).
+	TotalCount *int    `json:"total_count,omitempty"`
+	Offset     int     `json:"offset"`
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+}
+
+func (a *Assessment) ToResponse() AssessmentResponse {
+	return AssessmentResponse{
+		ID:                a.ID,
+		Name:              a.Name,
+		OrganizationID:    a.OrganizationID,
+		Status:            a.Status,
+		OverallRisk:       a.OverallRisk,
+		RiskScore:         a.RiskScore,
+		TargetAssets:      a.TargetAssets,
+		EnabledAnalyzers:  a.EnabledAnalyzers,
+		DisabledAnalyzers: a.DisabledAnalyzers,
+		CreatedAt:         a.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:         a.UpdatedAt.Format(time.RFC3339),
+	}
+}
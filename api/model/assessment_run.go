@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AnalyzerError records a single analyzer failure during an assessment run.
+// Analyzer is empty for an asset no enabled analyzer supports. The bound on
+// Error leaves room for a plugin's captured stderr (at most 64 KiB).
+type AnalyzerError struct {
+	Analyzer string `json:"analyzer" validate:"max=100"`
+	Asset    string `json:"asset" validate:"required,max=512"`
+	Error    string `json:"error" validate:"required,max=131072"`
+}
+
+type AssessmentRun struct {
+	ID            uuid.UUID       `json:"id"`
+	AssessmentID  uuid.UUID       `json:"assessment_id"`
+	Status        string          `json:"status"`
+	Analyzers     []string        `json:"analyzers"`
+	Errors        []AnalyzerError `json:"errors"`
+	FindingsCount int             `json:"findings_count"`
+	StartedAt     time.Time       `json:"started_at"`
+	CompletedAt   *time.Time      `json:"completed_at"`
+	CreatedBy     string          `json:"created_by"`
+}
+
+type AssessmentRunResponse struct {
+	ID            uuid.UUID       `json:"id"`
+	AssessmentID  uuid.UUID       `json:"assessment_id"`
+	Status        string          `json:"status"`
+	Analyzers     []string        `json:"analyzers"`
+	Errors        []AnalyzerError `json:"errors"`
+	FindingsCount int             `json:"findings_count"`
+	StartedAt     string          `json:"started_at"`
+	CompletedAt   *string         `json:"completed_at,omitempty"`
+}
+
+type AssessmentRunListResponse struct {
+	Runs []AssessmentRunResponse `json:"runs"`
+}
+
+func (r *AssessmentRun) ToResponse() AssessmentRunResponse {
+	resp := AssessmentRunResponse{
+		ID:            r.ID,
+		AssessmentID:  r.AssessmentID,
+		Status:        r.Status,
+		Analyzers:     r.Analyzers,
+		Errors:        r.Errors,
+		FindingsCount: r.FindingsCount,
+		StartedAt:     r.StartedAt.Format(time.RFC3339),
+	}
+	if resp.Analyzers == nil {
+		resp.Analyzers = []string{}
+	}
+	if resp.Errors == nil {
+		resp.Errors = []AnalyzerError{}
+	}
+	if r.CompletedAt != nil {
+		completed := r.CompletedAt.Format(time.RFC3339)
+		resp.CompletedAt = &completed
+	}
+	return resp
+}
@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+// ScanBundleVersion is the version of the scan bundle format.
+const ScanBundleVersion = 1
+
+// MaxBundleFindings caps the findings of an imported bundle.
+const MaxBundleFindings = 10000
+
+// MaxBundleErrors caps the analyzer errors of an imported bundle.
+const MaxBundleErrors = 10000
+
+// ScanBundle is the result of an offline scan (qrap scan --format json):
+// what was scanned, the findings and the analyzer errors. POST
+// /api/v1/assessments/import stores it as a COMPLETED assessment of
+// OrganizationID, which scans may leave empty for the importer to fill in.
+//
+// OverallRisk, RiskScore and Summary describe the scan to readers of the
+// file; the server recomputes them from the findings on import.
+type ScanBundle struct {
+	Version           int                `json:"version"`
+	OrganizationID    string             `json:"organization_id,omitempty" validate:"required,uuid"`
+	Name              string             `json:"name" validate:"required,max=255"`
+	TargetAssets      []string           `json:"target_assets" validate:"max=1000,dive,asset"`
+	EnabledAnalyzers  []string           `json:"enabled_analyzers,omitempty" validate:"max=100,dive,max=100"`
+	DisabledAnalyzers []string           `json:"disabled_analyzers,omitempty" validate:"max=100,dive,max=100"`
+	Analyzers         []string           `json:"analyzers" validate:"max=100,dive,max=100"`
+	Errors            []AnalyzerError    `json:"errors"`
+	OverallRisk       string             `json:"overall_risk"`
+	RiskScore         float64            `json:"risk_score"`
+	Summary           *AssessmentSummary `json:"summary,omitempty"`
+	StartedAt         string             `json:"started_at"`
+	CompletedAt       string             `json:"completed_at"`
+	Findings          []BundleFinding    `json:"findings"`
+}
+
+// BundleFinding is a finding of a ScanBundle. Imported findings get new
+// IDs and start OPEN.
+type BundleFinding struct {
+	Category             string  `json:"category" validate:"required,enum=finding_category"`
+	RiskLevel            string  `json:"risk_level" validate:"required,enum=risk_level"`
+	Title                string  `json:"title" validate:"required,max=512"`
+	Description          string  `json:"description" validate:"required"`
+	AffectedAsset        string  `json:"affected_asset" validate:"required,max=512"`
+	CurrentAlgorithm     *string `json:"current_algorithm,omitempty" validate:"max=100"`
+	RecommendedAlgorithm *string `json:"recommended_algorithm,omitempty" validate:"max=100"`
+	Remediation          *string `json:"remediation,omitempty"`
+	DiscoveredAt         string  `json:"discovered_at,omitempty"`
+}
+
+// NewBundleFinding returns f as a bundle finding.
+func NewBundleFinding(f *Finding) BundleFinding {
+	return BundleFinding{
+		Category:             f.Category,
+		RiskLevel:            f.RiskLevel,
+		Title:                f.Title,
+		Description:          f.Description,
+		AffectedAsset:        f.AffectedAsset,
+		CurrentAlgorithm:     f.CurrentAlgorithm,
+		RecommendedAlgorithm: f.RecommendedAlgorithm,
+		Remediation:          f.Remediation,
+		DiscoveredAt:         f.DiscoveredAt.Format(time.RFC3339),
+	}
+}
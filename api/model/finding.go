@@ -0,0 +1,117 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FindingCategories mirrors the finding_category database enum.
+var FindingCategories = []string{
+	"WEAK_ALGORITHM",
+	"SHORT_KEY_LENGTH",
+	"DEPRECATED_PROTOCOL",
+	"MISSING_PQC",
+	"CERTIFICATE_EXPIRY",
+	"HARVEST_NOW_DECRYPT_LATER",
+}
+
+// RiskLevels mirrors the risk_level database enum, most severe first.
+var RiskLevels = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW", "INFO"}
+
+// FindingStatuses mirrors the finding_status database enum: a finding's
+// triage state. New findings are OPEN.
+var FindingStatuses = []string{"OPEN", "ACKNOWLEDGED", "RESOLVED", "FALSE_POSITIVE", "ACCEPTED_RISK"}
+
+// FindingSorts are the accepted values of the findings sort parameter. A
+// leading '-' reverses the order; "risk_level" lists the most severe first.
+var FindingSorts = []string{
+	"risk_level", "-risk_level",
+	"discovered_at", "-discovered_at",
+	"created_at", "-created_at",
+	"affected_asset", "-affected_asset",
+}
+
+type Finding struct {
+	ID                   uuid.UUID `json:"id"`
+	AssessmentID         uuid.UUID `json:"assessment_id"`
+	Category             string    `json:"category"`
+	RiskLevel            string    `json:"risk_level"`
+	Title                string    `json:"title"`
+	Description          string    `json:"description"`
+	AffectedAsset        string    `json:"affected_asset"`
+	CurrentAlgorithm     *string   `json:"current_algorithm"`
+	RecommendedAlgorithm *string   `json:"recommended_algorithm"`
+	Remediation          *string   `json:"remediation"`
+	Status               string    `json:"status"`
+	DiscoveredAt         time.Time `json:"discovered_at"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+// FindingFilter selects findings for a listing. Zero fields do not filter;
+// values within a field are alternatives.
+type FindingFilter struct {
+	AssessmentIDs   []uuid.UUID
+	OrganizationIDs []uuid.UUID
+	RiskLevels      []string
+	Categories      []string
+	Statuses        []string
+	// Algorithms match current_algorithm, case-insensitively.
+	Algorithms []string
+	// Asset matches affected_asset by prefix, or as a glob if it contains
+	// '*' or '?'.
+	Asset            string
+	DiscoveredAfter  *time.Time
+	DiscoveredBefore *time.Time
+	// Query is a full-text search over title, description and remediation,
+	// in web search syntax ("quoted phrases", OR, -excluded).
+	Query string
+	// Sort is one of FindingSorts; empty means "risk_level".
+	Sort string
+}
+
+type FindingResponse struct {
+	ID                   uuid.UUID `json:"id"`
+	AssessmentID         uuid.UUID `json:"assessment_id"`
+	Category             string    `json:"category"`
+	RiskLevel            string    `json:"risk_level"`
+	Title                string    `json:"title"`
+	Description          string    `json:"description"`
+	AffectedAsset        string    `json:"affected_asset"`
+	CurrentAlgorithm     *string   `json:"current_algorithm,omitempty"`
+	RecommendedAlgorithm *string   `json:"recommended_algorithm,omitempty"`
+	Remediation          *string   `json:"remediation,omitempty"`
+	Status               string    `json:"status"`
+	DiscoveredAt         string    `json:"discovered_at"`
+}
+
+type UpdateFindingStatusRequest struct {
+	Status string `json:"status" validate:"required,enum=finding_status"`
+}
+
+type FindingListResponse struct {
+	Findings []FindingResponse `json:"findings"`
+	// TotalCount is omitted unless counted (see qmw.Pagination.Count).
+	TotalCount *int    `json:"total_count,omitempty"`
+	Offset     int     `json:"offset"`
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+}
+
+func (f *Finding) ToResponse() FindingResponse {
+	return FindingResponse{
+		ID:                   f.ID,
+		AssessmentID:         f.AssessmentID,
+		Category:             f.Category,
+		RiskLevel:            f.RiskLevel,
+		Title:                f.Title,
+		Description:          f.Description,
+		AffectedAsset:        f.AffectedAsset,
+		CurrentAlgorithm:     f.CurrentAlgorithm,
+		RecommendedAlgorithm: f.RecommendedAlgorithm,
+		Remediation:          f.Remediation,
+		Status:               f.Status,
+		DiscoveredAt:         f.DiscoveredAt.Format(time.RFC3339),
+	}
+}
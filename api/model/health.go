@@ -0,0 +1,6 @@
+package model
+
+type HealthResponse struct {
+	Status  string `json:"status"`
+	Service string `json:"service"`
+}
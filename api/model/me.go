@@ -0,0 +1,10 @@
+package model
+
+// MeResponse describes the caller's identity and what it may do.
+type MeResponse struct {
+	Subject       string   `json:"subject"`
+	Role          string   `json:"role"`
+	AuthMethod    string   `json:"auth_method"`
+	Authenticated bool     `json:"authenticated"`
+	Permissions   []string `json:"permissions"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Membership grants a subject access to an organization's data.
+type Membership struct {
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Subject        string    `json:"subject"`
+	CreatedBy      string    `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type AddMemberRequest struct {
+	Subject string `json:"subject" validate:"required,max=255"`
+}
+
+type MembershipResponse struct {
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Subject        string    `json:"subject"`
+	CreatedBy      string    `json:"created_by"`
+	CreatedAt      string    `json:"created_at"`
+}
+
+type MembershipListResponse struct {
+	Members []MembershipResponse `json:"members"`
+}
+
+func (m *Membership) ToResponse() MembershipResponse {
+	return MembershipResponse{
+		OrganizationID: m.OrganizationID,
+		Subject:        m.Subject,
+		CreatedBy:      m.CreatedBy,
+		CreatedAt:      m.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -17,9 +17,16 @@ type Organization struct {
 }
 
 type CreateOrganizationRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	CreatedBy   string `json:"created_by"`
+	Name        string `json:"name" validate:"required,max=255"`
+	Description string `json:"description" validate:"max=4096"`
+	CreatedBy   string `json:"created_by" validate:"max=255"`
+}
+
+// UpdateOrganizationRequest is a partial update: nil fields are left as
+// they are.
+type UpdateOrganizationRequest struct {
+	Name        *string `json:"name" validate:"notempty,max=255"`
+	Description *string `json:"description" validate:"max=4096"`
 }
 
 type OrganizationResponse struct {
@@ -32,9 +39,12 @@ type OrganizationResponse struct {
 
 type OrganizationListResponse struct {
 	Organizations []OrganizationResponse `json:"organizations"`
-	TotalCount    int                    `json:"total_count"`
-	Offset        int                    `json:"offset"`
-	Limit         int                    `json:"limit"`
+	// TotalCount is omitted unless counted (see qmw.Pagination.Count).
+	TotalCount *int    `json:"total_count,omitempty"`
+	Offset     int     `json:"offset"`
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
 }
 
 func (o *Organization) ToResponse() OrganizationResponse {
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// RevokeRequest revokes either one token by jti or every token of a subject
+// issued at or before a cutoff. Exactly one of JTI and Subject must be set.
+type RevokeRequest struct {
+	JTI       string     `json:"jti,omitempty" validate:"max=255"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Subject   string     `json:"subject,omitempty" validate:"max=255"`
+	Before    *time.Time `json:"before,omitempty"`
+}
+
+type RevocationResponse struct {
+	JTI       string  `json:"jti,omitempty"`
+	ExpiresAt *string `json:"expires_at,omitempty"`
+	Subject   string  `json:"subject,omitempty"`
+	Before    *string `json:"before,omitempty"`
+	RevokedBy string  `json:"revoked_by"`
+}
@@ -0,0 +1,9 @@
+-- QRAP Analyzer registry rollback
+
+DROP TABLE IF EXISTS assessment_runs;
+
+ALTER TABLE assessments
+    DROP COLUMN IF EXISTS disabled_analyzers,
+    DROP COLUMN IF EXISTS enabled_analyzers;
+
+DROP TYPE IF EXISTS assessment_run_status;
@@ -0,0 +1,25 @@
+-- QRAP Analyzer registry -- per-assessment analyzer selection and run records
+
+CREATE TYPE assessment_run_status AS ENUM (
+    'RUNNING', 'COMPLETED', 'FAILED'
+);
+
+ALTER TABLE assessments
+    ADD COLUMN enabled_analyzers  TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN disabled_analyzers TEXT[] NOT NULL DEFAULT '{}';
+
+-- ---- Assessment runs table ----
+
+CREATE TABLE assessment_runs (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    assessment_id   UUID NOT NULL REFERENCES assessments(id) ON DELETE CASCADE,
+    status          assessment_run_status NOT NULL DEFAULT 'RUNNING',
+    analyzers       TEXT[] NOT NULL DEFAULT '{}',
+    errors          JSONB NOT NULL DEFAULT '[]',
+    findings_count  INTEGER NOT NULL DEFAULT 0,
+    started_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at    TIMESTAMPTZ,
+    created_by      VARCHAR(255) NOT NULL DEFAULT 'system'
+);
+
+CREATE INDEX idx_assessment_runs_assessment ON assessment_runs (assessment_id, started_at DESC);
@@ -0,0 +1,3 @@
+-- QRAP Multi-tenancy rollback
+
+DROP TABLE IF EXISTS organization_members;
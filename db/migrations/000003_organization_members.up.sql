@@ -0,0 +1,16 @@
+-- QRAP Multi-tenancy -- organization membership
+
+CREATE TABLE organization_members (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    subject         VARCHAR(255) NOT NULL,
+    created_by      VARCHAR(255) NOT NULL DEFAULT 'system',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, subject)
+);
+
+CREATE INDEX idx_organization_members_subject ON organization_members (subject);
+
+-- Existing organizations keep their creator as a member so nobody loses
+-- access to data they created before scoping was enforced.
+INSERT INTO organization_members (organization_id, subject, created_by, created_at)
+SELECT id, created_by, 'system', created_at FROM organizations;
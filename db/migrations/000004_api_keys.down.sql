@@ -0,0 +1,3 @@
+-- QRAP API keys rollback
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,20 @@
+-- QRAP API keys -- hashed, database-backed keys with lifecycle metadata
+
+CREATE TABLE api_keys (
+    id            UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name          VARCHAR(255) NOT NULL,
+    prefix        VARCHAR(32) NOT NULL UNIQUE,
+    salt          BYTEA NOT NULL,
+    key_hash      BYTEA NOT NULL,
+    subject       VARCHAR(255) NOT NULL,
+    role          VARCHAR(64) NOT NULL,
+    scopes        TEXT[],
+    expires_at    TIMESTAMPTZ,
+    last_used_at  TIMESTAMPTZ,
+    revoked_at    TIMESTAMPTZ,
+    created_by    VARCHAR(255) NOT NULL DEFAULT 'system',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_api_keys_subject ON api_keys (subject, created_at DESC);
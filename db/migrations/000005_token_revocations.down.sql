@@ -0,0 +1,4 @@
+-- QRAP token revocation rollback
+
+DROP TABLE IF EXISTS revoked_subjects;
+DROP TABLE IF EXISTS revoked_tokens;
@@ -0,0 +1,17 @@
+-- QRAP token revocation -- jti denylist and per-subject cutoffs checked by Auth
+
+CREATE TABLE revoked_tokens (
+    jti         VARCHAR(255) PRIMARY KEY,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    revoked_by  VARCHAR(255) NOT NULL DEFAULT 'system',
+    revoked_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE revoked_subjects (
+    subject         VARCHAR(255) PRIMARY KEY,
+    revoked_before  TIMESTAMPTZ NOT NULL,
+    revoked_by      VARCHAR(255) NOT NULL DEFAULT 'system',
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- QRAP rate limits rollback
+
+DROP TABLE IF EXISTS rate_limits;
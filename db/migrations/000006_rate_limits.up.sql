@@ -0,0 +1,9 @@
+-- QRAP rate limits -- request counters shared by every API replica
+
+CREATE TABLE rate_limits (
+    key           VARCHAR(512) PRIMARY KEY,
+    window_start  TIMESTAMPTZ NOT NULL,
+    count         INTEGER NOT NULL
+);
+
+CREATE INDEX idx_rate_limits_window_start ON rate_limits (window_start);
@@ -0,0 +1,3 @@
+-- QRAP rate limit token buckets rollback
+
+DROP TABLE IF EXISTS rate_limit_buckets;
@@ -0,0 +1,10 @@
+-- QRAP rate limit token buckets -- shared by every API replica
+
+CREATE TABLE rate_limit_buckets (
+    key         VARCHAR(512) PRIMARY KEY,
+    tokens      DOUBLE PRECISION NOT NULL,
+    allowed     BOOLEAN NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
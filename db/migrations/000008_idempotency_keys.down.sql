@@ -0,0 +1,3 @@
+-- QRAP idempotency keys rollback
+
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,13 @@
+-- QRAP idempotency keys -- responses replayed for retried POST requests
+
+CREATE TABLE idempotency_keys (
+    key          VARCHAR(512) PRIMARY KEY,
+    fingerprint  VARCHAR(64) NOT NULL,
+    status_code  INTEGER,
+    headers      JSONB NOT NULL DEFAULT '{}',
+    body         BYTEA,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,5 @@
+-- QRAP keyset pagination indexes rollback
+
+DROP INDEX IF EXISTS idx_findings_assessment_order;
+DROP INDEX IF EXISTS idx_assessments_created;
+DROP INDEX IF EXISTS idx_organizations_created;
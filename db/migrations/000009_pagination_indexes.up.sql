@@ -0,0 +1,5 @@
+-- QRAP keyset pagination indexes -- one per listing sort order
+
+CREATE INDEX idx_organizations_created ON organizations (created_at DESC, id DESC);
+CREATE INDEX idx_assessments_created ON assessments (created_at DESC, id DESC);
+CREATE INDEX idx_findings_assessment_order ON findings (assessment_id, risk_level, discovered_at DESC, id DESC);
@@ -0,0 +1,13 @@
+-- QRAP finding triage status, full-text search and filter indexes rollback
+
+DROP INDEX IF EXISTS idx_findings_discovered;
+DROP INDEX IF EXISTS idx_findings_asset;
+DROP INDEX IF EXISTS idx_findings_algorithm;
+DROP INDEX IF EXISTS idx_findings_status;
+DROP INDEX IF EXISTS idx_findings_search;
+
+ALTER TABLE findings
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS status;
+
+DROP TYPE IF EXISTS finding_status;
@@ -0,0 +1,19 @@
+-- QRAP finding triage status, full-text search and filter indexes
+
+CREATE TYPE finding_status AS ENUM (
+    'OPEN', 'ACKNOWLEDGED', 'RESOLVED', 'FALSE_POSITIVE', 'ACCEPTED_RISK'
+);
+
+ALTER TABLE findings
+    ADD COLUMN status finding_status NOT NULL DEFAULT 'OPEN',
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', title), 'A') ||
+        setweight(to_tsvector('english', description), 'B') ||
+        setweight(to_tsvector('english', coalesce(remediation, '')), 'C')
+    ) STORED;
+
+CREATE INDEX idx_findings_search ON findings USING GIN (search_vector);
+CREATE INDEX idx_findings_status ON findings (status);
+CREATE INDEX idx_findings_algorithm ON findings (lower(current_algorithm));
+CREATE INDEX idx_findings_asset ON findings (affected_asset text_pattern_ops);
+CREATE INDEX idx_findings_discovered ON findings (discovered_at DESC, id DESC);
@@ -0,0 +1,17 @@
+-- QRAP soft delete rollback
+
+DELETE FROM organizations WHERE deleted_at IS NOT NULL;
+DELETE FROM assessments WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_assessments_deleted;
+DROP INDEX IF EXISTS idx_organizations_deleted;
+DROP INDEX IF EXISTS idx_organizations_name;
+ALTER TABLE organizations ADD CONSTRAINT organizations_name_key UNIQUE (name);
+
+ALTER TABLE assessments
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS deleted_by;
+
+ALTER TABLE organizations
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS deleted_by;
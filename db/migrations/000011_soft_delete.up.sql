@@ -0,0 +1,16 @@
+-- QRAP soft delete -- deleted organizations and assessments stay restorable until purged
+
+ALTER TABLE organizations
+    ADD COLUMN deleted_by VARCHAR(255),
+    ADD COLUMN deleted_at TIMESTAMPTZ;
+
+ALTER TABLE assessments
+    ADD COLUMN deleted_by VARCHAR(255),
+    ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- A deleted organization must not keep its name from being reused.
+ALTER TABLE organizations DROP CONSTRAINT organizations_name_key;
+CREATE UNIQUE INDEX idx_organizations_name ON organizations (name) WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_organizations_deleted ON organizations (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_assessments_deleted ON assessments (deleted_at) WHERE deleted_at IS NOT NULL;
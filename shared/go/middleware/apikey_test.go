@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, id, err := GenerateAPIKey("qrap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, "qrap_"+id+"_") || len(id) != 12 {
+		t.Fatalf("unexpected key layout: %q (id %q)", key, id)
+	}
+	if got, ok := APIKeyID(key); !ok || got != id {
+		t.Errorf("APIKeyID = %q, %v; want %q", got, ok, id)
+	}
+	other, _, _ := GenerateAPIKey("qrap")
+	if other == key {
+		t.Error("two generated keys are equal")
+	}
+
+	for _, bad := range []string{"", "qrap", "qrap_abc_secret", "qrap_zzzzzzzzzzzz_secret", "qrap_0123456789ab_"} {
+		if _, ok := APIKeyID(bad); ok {
+			t.Errorf("APIKeyID(%q) accepted", bad)
+		}
+	}
+	if _, _, err := GenerateAPIKey("bad_prefix"); err == nil {
+		t.Error("prefix containing _ accepted")
+	}
+}
+
+func TestHashAPIKey(t *testing.T) {
+	key, _, _ := GenerateAPIKey("qrap")
+	salt, err := NewAPIKeySalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := HashAPIKey(key, salt)
+	if !CheckAPIKeyHash(key, salt, hash) {
+		t.Error("hash does not verify")
+	}
+	if CheckAPIKeyHash(key+"x", salt, hash) {
+		t.Error("wrong key verifies")
+	}
+	otherSalt, _ := NewAPIKeySalt()
+	if CheckAPIKeyHash(key, otherSalt, hash) {
+		t.Error("wrong salt verifies")
+	}
+}
+
+type funcStore func(ctx context.Context, key string) (*APIKeyEntry, error)
+
+func (f funcStore) LookupAPIKey(ctx context.Context, key string) (*APIKeyEntry, error) {
+	return f(ctx, key)
+}
+
+func TestAuth_APIKeyStore(t *testing.T) {
+	store := funcStore(func(ctx context.Context, key string) (*APIKeyEntry, error) {
+		switch key {
+		case "stored":
+			return &APIKeyEntry{Subject: "ci", Role: "viewer", Scopes: []string{"findings:read"}}, nil
+		case "broken":
+			return nil, errors.New("database down")
+		}
+		return nil, nil
+	})
+	mw := Auth(AuthConfig{
+		APIKeys:     []APIKeyEntry{{Key: "static", Subject: "svc", Role: "admin"}},
+		APIKeyStore: store,
+	})
+
+	var subject string
+	var scopes []string
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject = SubjectFromContext(r.Context())
+		scopes = ScopesFromContext(r.Context())
+	}))
+	call := func(key string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "ApiKey "+key)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := call("static"); code != http.StatusOK || subject != "svc" || scopes != nil {
+		t.Errorf("static key: %d %q %v", code, subject, scopes)
+	}
+	if code := call("stored"); code != http.StatusOK || subject != "ci" || !slices.Equal(scopes, []string{"findings:read"}) {
+		t.Errorf("stored key: %d %q %v", code, subject, scopes)
+	}
+	if code := call("unknown"); code != http.StatusUnauthorized {
+		t.Errorf("unknown key: expected 401, got %d", code)
+	}
+	if code := call("broken"); code != http.StatusServiceUnavailable {
+		t.Errorf("store failure: expected 503, got %d", code)
+	}
+}
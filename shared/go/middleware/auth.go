@@ -1,21 +1,33 @@
 // Package middleware provides shared HTTP middleware for Quantun services.
 //
-// Authentication supports two modes:
-//   - JWT Bearer tokens: validated using HMAC-SHA256 with a configurable secret
+// Authentication supports three modes:
+//   - JWT Bearer tokens: validated using HMAC-SHA256 with a configurable secret,
+//     or RS256/ES256/EdDSA, post-quantum ML-DSA-65 or the hybrid
+//     ML-DSA-65-Ed25519 composite against public keys from a KeySource
 //   - API keys: validated against a configurable set of valid keys
+//   - Session cookies: HMAC-signed cookies issued to browsers after an OIDC
+//     login, see SessionManager
 //
-// Both modes extract claims/identity and inject them into the request context.
+// All modes extract claims/identity and inject them into the request context.
 package middleware
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/hmac"
+	"crypto/mldsa"
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -32,14 +44,18 @@ const (
 	ContextKeyRole contextKey = "auth.role"
 	// ContextKeyAuthMethod holds how the request was authenticated.
 	ContextKeyAuthMethod contextKey = "auth.method"
+	// ContextKeyScopes holds the permission scopes of a restricted API key.
+	ContextKeyScopes contextKey = "auth.scopes"
 )
 
 // AuthMethod describes how a request was authenticated.
 type AuthMethod string
 
 const (
-	AuthMethodJWT    AuthMethod = "jwt"
-	AuthMethodAPIKey AuthMethod = "api_key"
+	AuthMethodJWT     AuthMethod = "jwt"
+	AuthMethodAPIKey  AuthMethod = "api_key"
+	AuthMethodSession AuthMethod = "session"
+	AuthMethodMTLS    AuthMethod = "mtls"
 )
 
 // JWTClaims represents the payload of a JWT token.
@@ -58,20 +74,51 @@ type APIKeyEntry struct {
 	Key     string
 	Subject string
 	Role    string
+	// Scopes, if non-nil, limits the key to these permissions of its role.
+	Scopes []string
 }
 
 // AuthConfig configures the authentication middleware.
 type AuthConfig struct {
 	// JWTSecret is the HMAC-SHA256 secret for JWT validation.
-	// If empty, JWT auth is disabled.
+	// If empty, HS256 tokens are rejected.
 	JWTSecret string
 
+	// JWTKeys verifies RS256, ES256, EdDSA, ML-DSA-65 and ML-DSA-65-Ed25519
+	// tokens by their "kid" header. If nil, asymmetric tokens are rejected.
+	// If both JWTSecret and JWTKeys are unset, JWT auth is disabled.
+	JWTKeys KeySource
+
+	// JWTAlgorithms narrows the accepted "alg" values, e.g. to
+	// {"ML-DSA-65-Ed25519"} once every issuer signs post-quantum tokens.
+	// If empty, every algorithm in SupportedJWTAlgorithms that the configured
+	// key material can verify is accepted.
+	JWTAlgorithms []string
+
 	// JWTIssuer is the expected "iss" claim. If empty, issuer is not checked.
 	JWTIssuer string
 
-	// APIKeys is a list of valid API keys. If empty, API key auth is disabled.
+	// APIKeys is a list of valid API keys.
 	APIKeys []APIKeyEntry
 
+	// APIKeyStore looks up API keys that are not in APIKeys, e.g. hashed keys
+	// in a database. If both are unset, API key auth is disabled.
+	APIKeyStore APIKeyStore
+
+	// Sessions validates browser session cookies, used when a request has no
+	// Authorization header. If nil, session auth is disabled.
+	Sessions *SessionManager
+
+	// ClientCerts maps verified TLS client certificates to identities. It
+	// is used when a request has no Authorization header; the server must
+	// verify client certificates (tls.Config.ClientCAs). If empty, mTLS auth
+	// is disabled.
+	ClientCerts []ClientCertMapping
+
+	// Revocations, if set, is checked for every JWT and session so revoked
+	// tokens are rejected before they expire.
+	Revocations RevocationStore
+
 	// SkipPaths are URL paths that bypass authentication (e.g., /health).
 	SkipPaths []string
 
@@ -82,8 +129,14 @@ type AuthConfig struct {
 // Auth returns a Chi-compatible middleware that enforces authentication.
 //
 // It checks the Authorization header for:
-//   - "Bearer <jwt>" -- validates the JWT using HMAC-SHA256
-//   - "ApiKey <key>" -- validates against the configured API key list
+//   - "Bearer <jwt>" -- validates the JWT using HMAC-SHA256 or a public key
+//   - "ApiKey <key>" -- validates against the configured API keys and store
+//
+// Without an Authorization header it falls back to a verified TLS client
+// certificate matching ClientCerts, then to the session cookie, if sessions
+// are configured. Cookie-authenticated requests with unsafe methods
+// must pass a cross-origin check (Sec-Fetch-Site / Origin), so other sites
+// cannot ride the browser's session.
 //
 // On success, it injects subject, role, and auth method into the context.
 // On failure, it returns 401 Unauthorized.
@@ -98,9 +151,17 @@ func Auth(cfg AuthConfig) func(http.Handler) http.Handler {
 		skipSet[p] = true
 	}
 
-	// Build list of keys for constant-time iteration (no map lookup timing leak).
-	apiKeyList := make([]APIKeyEntry, len(cfg.APIKeys))
-	copy(apiKeyList, cfg.APIKeys)
+	// Static keys are compared in constant time (no map lookup timing leak).
+	var apiKeys APIKeyStores
+	if len(cfg.APIKeys) > 0 {
+		apiKeys = append(apiKeys, StaticAPIKeys(slices.Clone(cfg.APIKeys)))
+	}
+	if cfg.APIKeyStore != nil {
+		apiKeys = append(apiKeys, cfg.APIKeyStore)
+	}
+
+	verifier := jwtVerifier{secret: cfg.JWTSecret, keys: cfg.JWTKeys, issuer: cfg.JWTIssuer, algorithms: cfg.JWTAlgorithms}
+	csrf := http.NewCrossOriginProtection()
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -111,14 +172,55 @@ func Auth(cfg AuthConfig) func(http.Handler) http.Handler {
 			}
 
 			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" && len(cfg.ClientCerts) > 0 {
+				if id, cert := clientCertIdentity(r, cfg.ClientCerts); id != nil {
+					// A subject cutoff also covers certificates issued before it.
+					if !checkRevocation(w, r, cfg.Revocations, logger, "", id.Subject, cert.NotBefore.Unix()) {
+						return
+					}
+					logger.Debug("authenticated request",
+						zap.String("subject", id.Subject),
+						zap.String("role", id.Role),
+						zap.String("method", string(AuthMethodMTLS)),
+						zap.String("path", r.URL.Path),
+					)
+					next.ServeHTTP(w, r.WithContext(withIdentity(r.Context(), id.Subject, id.Role, AuthMethodMTLS)))
+					return
+				} else if cert != nil {
+					logger.Debug("client certificate not mapped to an identity",
+						zap.String("cn", cert.Subject.CommonName),
+						zap.String("remote_addr", r.RemoteAddr),
+					)
+				}
+			}
+			if authHeader == "" && cfg.Sessions != nil {
+				sess, err := cfg.Sessions.FromRequest(r)
+				if err != nil {
+					logger.Debug("session validation failed",
+						zap.Error(err),
+						zap.String("remote_addr", r.RemoteAddr),
+					)
+					writeProblem(w, r, http.StatusUnauthorized, "missing Authorization header or session")
+					return
+				}
+				if !checkRevocation(w, r, cfg.Revocations, logger, sess.ID, sess.Subject, sess.IssuedAt) {
+					return
+				}
+				if err := csrf.Check(r); err != nil {
+					writeProblem(w, r, http.StatusForbidden, "cross-origin request rejected")
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(withIdentity(r.Context(), sess.Subject, sess.Role, AuthMethodSession)))
+				return
+			}
 			if authHeader == "" {
-				writeAuthError(w, http.StatusUnauthorized, "missing Authorization header")
+				writeProblem(w, r, http.StatusUnauthorized, "missing Authorization header")
 				return
 			}
 
 			parts := strings.SplitN(authHeader, " ", 2)
 			if len(parts) != 2 {
-				writeAuthError(w, http.StatusUnauthorized, "malformed Authorization header")
+				writeProblem(w, r, http.StatusUnauthorized, "malformed Authorization header")
 				return
 			}
 
@@ -129,21 +231,25 @@ func Auth(cfg AuthConfig) func(http.Handler) http.Handler {
 				subject string
 				role    string
 				method  AuthMethod
+				scopes  []string
 			)
 
 			switch strings.ToLower(scheme) {
 			case "bearer":
-				if cfg.JWTSecret == "" {
-					writeAuthError(w, http.StatusUnauthorized, "JWT authentication not configured")
+				if !verifier.configured() {
+					writeProblem(w, r, http.StatusUnauthorized, "JWT authentication not configured")
 					return
 				}
-				claims, err := validateJWT(credential, cfg.JWTSecret, cfg.JWTIssuer)
+				claims, err := verifier.validate(r.Context(), credential)
 				if err != nil {
 					logger.Debug("JWT validation failed",
 						zap.Error(err),
 						zap.String("remote_addr", r.RemoteAddr),
 					)
-					writeAuthError(w, http.StatusUnauthorized, "invalid or expired token")
+					writeProblem(w, r, http.StatusUnauthorized, "invalid or expired token")
+					return
+				}
+				if !checkRevocation(w, r, cfg.Revocations, logger, claims.JTI, claims.Subject, claims.IssuedAt) {
 					return
 				}
 				subject = claims.Subject
@@ -151,33 +257,33 @@ func Auth(cfg AuthConfig) func(http.Handler) http.Handler {
 				method = AuthMethodJWT
 
 			case "apikey":
-				if len(apiKeyList) == 0 {
-					writeAuthError(w, http.StatusUnauthorized, "API key authentication not configured")
+				if len(apiKeys) == 0 {
+					writeProblem(w, r, http.StatusUnauthorized, "API key authentication not configured")
+					return
+				}
+				entry, ok, err := validateAPIKey(r.Context(), credential, apiKeys)
+				if err != nil {
+					logger.Error("API key lookup failed", zap.Error(err))
+					writeProblem(w, r, http.StatusServiceUnavailable, "API key validation unavailable")
 					return
 				}
-				entry, ok := validateAPIKey(credential, apiKeyList)
 				if !ok {
 					logger.Debug("API key validation failed",
 						zap.String("remote_addr", r.RemoteAddr),
 					)
-					writeAuthError(w, http.StatusUnauthorized, "invalid API key")
+					writeProblem(w, r, http.StatusUnauthorized, "invalid API key")
 					return
 				}
 				subject = entry.Subject
 				role = entry.Role
 				method = AuthMethodAPIKey
+				scopes = entry.Scopes
 
 			default:
-				writeAuthError(w, http.StatusUnauthorized, fmt.Sprintf("unsupported auth scheme: %s", scheme))
+				writeProblem(w, r, http.StatusUnauthorized, fmt.Sprintf("unsupported auth scheme: %s", scheme))
 				return
 			}
 
-			// Inject identity into request context
-			ctx := r.Context()
-			ctx = context.WithValue(ctx, ContextKeySubject, subject)
-			ctx = context.WithValue(ctx, ContextKeyRole, role)
-			ctx = context.WithValue(ctx, ContextKeyAuthMethod, method)
-
 			logger.Debug("authenticated request",
 				zap.String("subject", subject),
 				zap.String("role", role),
@@ -185,11 +291,46 @@ func Auth(cfg AuthConfig) func(http.Handler) http.Handler {
 				zap.String("path", r.URL.Path),
 			)
 
+			ctx := withIdentity(r.Context(), subject, role, method)
+			if scopes != nil {
+				ctx = context.WithValue(ctx, ContextKeyScopes, scopes)
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// checkRevocation writes an error response and returns false if the token
+// has been revoked or the store cannot be consulted.
+func checkRevocation(w http.ResponseWriter, r *http.Request, store RevocationStore, logger *zap.Logger, jti, subject string, issuedAt int64) bool {
+	if store == nil {
+		return true
+	}
+	revoked, err := store.IsRevoked(r.Context(), jti, subject, issuedAt)
+	if err != nil {
+		logger.Error("revocation check failed", zap.Error(err))
+		writeProblem(w, r, http.StatusServiceUnavailable, "token revocation check unavailable")
+		return false
+	}
+	if revoked {
+		logger.Debug("revoked token rejected",
+			zap.String("subject", subject),
+			zap.String("jti", jti),
+			zap.String("remote_addr", r.RemoteAddr),
+		)
+		writeProblem(w, r, http.StatusUnauthorized, "token has been revoked")
+		return false
+	}
+	return true
+}
+
+// withIdentity injects the authenticated identity into ctx.
+func withIdentity(ctx context.Context, subject, role string, method AuthMethod) context.Context {
+	ctx = context.WithValue(ctx, ContextKeySubject, subject)
+	ctx = context.WithValue(ctx, ContextKeyRole, role)
+	return context.WithValue(ctx, ContextKeyAuthMethod, method)
+}
+
 // RequireRole returns middleware that enforces the request was authenticated
 // with one of the specified roles. Must be used after Auth middleware.
 func RequireRole(roles ...string) func(http.Handler) http.Handler {
@@ -202,7 +343,7 @@ func RequireRole(roles ...string) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			role, _ := r.Context().Value(ContextKeyRole).(string)
 			if !roleSet[role] {
-				writeAuthError(w, http.StatusForbidden, "insufficient permissions")
+				writeProblem(w, r, http.StatusForbidden, "insufficient permissions")
 				return
 			}
 			next.ServeHTTP(w, r)
@@ -228,19 +369,96 @@ func AuthMethodFromContext(ctx context.Context) AuthMethod {
 	return m
 }
 
-// --- JWT helpers (minimal, dependency-free HMAC-SHA256) ---
+// ScopesFromContext returns the scopes of a restricted API key, or nil when
+// the identity is not restricted beyond its role.
+func ScopesFromContext(ctx context.Context) []string {
+	s, _ := ctx.Value(ContextKeyScopes).([]string)
+	return s
+}
+
+// --- JWT helpers (minimal, standard library only) ---
+
+// jwtVerifier holds the keys and expectations for validating tokens.
+type jwtVerifier struct {
+	secret     string
+	keys       KeySource
+	issuer     string
+	algorithms []string // empty allows all supported algorithms
+}
+
+func (v jwtVerifier) configured() bool {
+	return v.secret != "" || v.keys != nil
+}
+
+// VerifyJWT checks a compact JWT's algorithm and signature against keys and
+// returns its decoded claims JSON, for tokens whose claims are not JWTClaims,
+// such as OIDC ID tokens. algorithms narrows the allow-list as in
+// AuthConfig.JWTAlgorithms. The caller must validate exp, iss, aud and any
+// other claims.
+func VerifyJWT(ctx context.Context, token string, keys KeySource, algorithms []string) ([]byte, error) {
+	return jwtVerifier{keys: keys, algorithms: algorithms}.verify(ctx, token)
+}
+
+// validateJWT validates an HS256 token against secret. See jwtVerifier.validate.
+func validateJWT(tokenString, secret, expectedIssuer string) (*JWTClaims, error) {
+	return jwtVerifier{secret: secret, issuer: expectedIssuer}.validate(context.Background(), tokenString)
+}
 
-// validateJWT parses and validates a compact JWT (header.payload.signature).
-// Only HS256 (HMAC-SHA256) is supported. The "none" algorithm is explicitly rejected.
+// validate parses and validates a compact JWT (header.payload.signature).
+//
+// The algorithm allow-list is HS256 (when a secret is configured) and RS256,
+// ES256, EdDSA, ML-DSA-65 and ML-DSA-65-Ed25519 (when a KeySource is
+// configured), further narrowed by the configured algorithms. The "none"
+// algorithm and anything else is explicitly rejected, as is a key whose type
+// or declared algorithm does not match the token header, so a public key can
+// never be used as an HMAC secret and a hybrid key never accepts a token
+// carrying only one of its two signatures.
 //
 // Validates: signature, algorithm, expiration (exp), not-before (nbf), and issuer (iss).
-func validateJWT(tokenString, secret, expectedIssuer string) (*JWTClaims, error) {
+func (v jwtVerifier) validate(ctx context.Context, tokenString string) (*JWTClaims, error) {
+	claimsJSON, err := v.verify(ctx, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims JWTClaims
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return nil, fmt.Errorf("invalid JWT claims: %w", err)
+	}
+
+	now := time.Now().Unix()
+
+	// Check expiration (exp)
+	if claims.ExpiresAt > 0 && now > claims.ExpiresAt {
+		return nil, fmt.Errorf("JWT expired at %d, current time %d", claims.ExpiresAt, now)
+	}
+
+	// Check not-before (nbf) -- token must not be used before this time
+	if claims.NotBefore > 0 && now < claims.NotBefore {
+		return nil, fmt.Errorf("JWT not valid before %d, current time %d", claims.NotBefore, now)
+	}
+
+	// Check issuer
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return nil, fmt.Errorf("JWT issuer mismatch: got %q, want %q", claims.Issuer, v.issuer)
+	}
+
+	// Require subject claim
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("JWT missing required 'sub' claim")
+	}
+
+	return &claims, nil
+}
+
+// verify checks a compact JWT's algorithm and signature and returns the
+// decoded claims JSON. It does not validate any claims.
+func (v jwtVerifier) verify(ctx context.Context, tokenString string) ([]byte, error) {
 	parts := strings.Split(tokenString, ".")
 	if len(parts) != 3 {
 		return nil, fmt.Errorf("invalid JWT format")
 	}
 
-	// Verify header declares HS256
 	headerJSON, err := base64URLDecode(parts[0])
 	if err != nil {
 		return nil, fmt.Errorf("invalid JWT header encoding: %w", err)
@@ -249,91 +467,112 @@ func validateJWT(tokenString, secret, expectedIssuer string) (*JWTClaims, error)
 	var header struct {
 		Alg string `json:"alg"`
 		Typ string `json:"typ"`
+		Kid string `json:"kid"`
 	}
 	if err := json.Unmarshal(headerJSON, &header); err != nil {
 		return nil, fmt.Errorf("invalid JWT header: %w", err)
 	}
 
-	// Explicitly reject "none" algorithm and any algorithm that is not HS256
+	// Explicitly reject "none" algorithm and any algorithm not on the allow-list
 	if strings.EqualFold(header.Alg, "none") {
 		return nil, fmt.Errorf("JWT algorithm 'none' is not permitted")
 	}
-	if header.Alg != "HS256" {
-		return nil, fmt.Errorf("unsupported JWT algorithm: %s", header.Alg)
+	if len(v.algorithms) > 0 && !slices.Contains(v.algorithms, header.Alg) {
+		return nil, fmt.Errorf("JWT algorithm %s is not allowed", header.Alg)
 	}
 
-	// Verify signature
 	signingInput := parts[0] + "." + parts[1]
 	signature, err := base64URLDecode(parts[2])
 	if err != nil {
 		return nil, fmt.Errorf("invalid JWT signature encoding: %w", err)
 	}
 
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write([]byte(signingInput))
-	expectedSig := mac.Sum(nil)
+	switch header.Alg {
+	case "HS256":
+		if v.secret == "" {
+			return nil, fmt.Errorf("unsupported JWT algorithm: %s", header.Alg)
+		}
+		mac := hmac.New(sha256.New, []byte(v.secret))
+		mac.Write([]byte(signingInput))
+		expectedSig := mac.Sum(nil)
+		if subtle.ConstantTimeCompare(signature, expectedSig) != 1 {
+			return nil, fmt.Errorf("invalid JWT signature")
+		}
+
+	case "RS256", "ES256", "EdDSA", AlgMLDSA65, AlgMLDSA65Ed25519:
+		if v.keys == nil {
+			return nil, fmt.Errorf("unsupported JWT algorithm: %s", header.Alg)
+		}
+		key, err := v.keys.PublicKey(ctx, header.Kid)
+		if err != nil {
+			return nil, err
+		}
+		if err := verifySignature(header.Alg, key, []byte(signingInput), signature); err != nil {
+			return nil, err
+		}
 
-	if subtle.ConstantTimeCompare(signature, expectedSig) != 1 {
-		return nil, fmt.Errorf("invalid JWT signature")
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm: %s", header.Alg)
 	}
 
-	// Decode claims
 	claimsJSON, err := base64URLDecode(parts[1])
 	if err != nil {
 		return nil, fmt.Errorf("invalid JWT claims encoding: %w", err)
 	}
+	return claimsJSON, nil
+}
 
-	var claims JWTClaims
-	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
-		return nil, fmt.Errorf("invalid JWT claims: %w", err)
-	}
-
-	now := time.Now().Unix()
-
-	// Check expiration (exp)
-	if claims.ExpiresAt > 0 && now > claims.ExpiresAt {
-		return nil, fmt.Errorf("JWT expired at %d, current time %d", claims.ExpiresAt, now)
-	}
-
-	// Check not-before (nbf) -- token must not be used before this time
-	if claims.NotBefore > 0 && now < claims.NotBefore {
-		return nil, fmt.Errorf("JWT not valid before %d, current time %d", claims.NotBefore, now)
+// verifySignature checks an asymmetric JWS signature. The key's type must
+// match alg, and if the key declares an algorithm it must equal alg.
+func verifySignature(alg string, key *PublicKey, signingInput, signature []byte) error {
+	if key.Algorithm != "" && key.Algorithm != alg {
+		return fmt.Errorf("JWT algorithm %s does not match key %q algorithm %s", alg, key.KeyID, key.Algorithm)
 	}
-
-	// Check issuer
-	if expectedIssuer != "" && claims.Issuer != expectedIssuer {
-		return nil, fmt.Errorf("JWT issuer mismatch: got %q, want %q", claims.Issuer, expectedIssuer)
+	if algorithmForKey(key.Key) != alg {
+		return fmt.Errorf("JWT algorithm %s does not match key %q type", alg, key.KeyID)
 	}
 
-	// Require subject claim
-	if claims.Subject == "" {
-		return nil, fmt.Errorf("JWT missing required 'sub' claim")
-	}
-
-	return &claims, nil
-}
-
-// validateAPIKey checks a key against the configured list using constant-time
-// comparison for every entry to prevent timing side-channel attacks.
-//
-// Unlike a map lookup (which returns early on miss and leaks timing info about
-// key existence), this iterates over all entries, performing constant-time
-// comparison on each one, so the total time is independent of which key
-// (if any) matches.
-func validateAPIKey(key string, keys []APIKeyEntry) (*APIKeyEntry, bool) {
-	keyBytes := []byte(key)
-	var matched *APIKeyEntry
-
-	for i := range keys {
-		if subtle.ConstantTimeCompare(keyBytes, []byte(keys[i].Key)) == 1 {
-			matched = &keys[i]
+	digest := sha256.Sum256(signingInput)
+	switch k := key.Key.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("invalid JWT signature")
 		}
+	case *ecdsa.PublicKey:
+		// JWS ES256 signatures are the fixed-width concatenation r || s.
+		if len(signature) != 64 {
+			return fmt.Errorf("invalid JWT signature")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return fmt.Errorf("invalid JWT signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, signingInput, signature) {
+			return fmt.Errorf("invalid JWT signature")
+		}
+	case *mldsa.PublicKey:
+		if err := mldsa.Verify(k, signingInput, signature, nil); err != nil {
+			return fmt.Errorf("invalid JWT signature")
+		}
+	case *HybridPublicKey:
+		return k.Verify(signingInput, signature)
+	default:
+		return fmt.Errorf("unsupported key type %T", key.Key)
 	}
+	return nil
+}
 
-	if matched == nil {
-		return nil, false
+// validateAPIKey looks key up in store. StaticAPIKeys compares against every
+// configured key in constant time; hashed stores compare salted hashes in
+// constant time.
+func validateAPIKey(ctx context.Context, key string, store APIKeyStore) (*APIKeyEntry, bool, error) {
+	entry, err := store.LookupAPIKey(ctx, key)
+	if err != nil {
+		return nil, false, err
 	}
-	return matched, true
+	return entry, entry != nil, nil
 }
 
 // base64URLDecode decodes a base64url-encoded string (no padding).
@@ -348,10 +587,12 @@ func base64URLDecode(s string) ([]byte, error) {
 	return base64.URLEncoding.DecodeString(s)
 }
 
-func writeAuthError(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+// newJTI returns a random token ID so issued tokens can be revoked
+// individually.
+func newJTI() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
 }
 
 // CreateJWT creates a signed JWT token (HS256) for testing or internal service-to-service auth.
@@ -364,6 +605,7 @@ func CreateJWT(secret, subject, role, issuer string, duration time.Duration) (st
 		ExpiresAt: now.Add(duration).Unix(),
 		NotBefore: now.Unix(),
 		Issuer:    issuer,
+		JTI:       newJTI(),
 	}
 
 	header := map[string]string{"alg": "HS256", "typ": "JWT"}
@@ -388,3 +630,67 @@ func CreateJWT(secret, subject, role, issuer string, duration time.Duration) (st
 
 	return signingInput + "." + sigB64, nil
 }
+
+// CreateSignedJWT creates a JWT signed with an asymmetric private key: an
+// *rsa.PrivateKey (RS256), *ecdsa.PrivateKey on P-256 (ES256),
+// ed25519.PrivateKey (EdDSA), an ML-DSA-65 *mldsa.PrivateKey (ML-DSA-65) or
+// *HybridPrivateKey (ML-DSA-65-Ed25519). kid is placed in the header so
+// verifiers can select the matching public key.
+func CreateSignedJWT(key crypto.Signer, kid, subject, role, issuer string, duration time.Duration) (string, error) {
+	alg := algorithmForKey(key.Public())
+	if alg == "" {
+		return "", fmt.Errorf("unsupported signing key type %T", key)
+	}
+
+	now := time.Now()
+	claims := JWTClaims{
+		Subject:   subject,
+		Role:      role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(duration).Unix(),
+		NotBefore: now.Unix(),
+		Issuer:    issuer,
+		JTI:       newJTI(),
+	}
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("marshal header: %w", err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("marshal claims: %w", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	var signature []byte
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(k, []byte(signingInput))
+	case *mldsa.PrivateKey, *HybridPrivateKey:
+		// Both sign the message itself rather than a digest.
+		if signature, err = key.Sign(rand.Reader, []byte(signingInput), nil); err != nil {
+			return "", fmt.Errorf("sign token: %w", err)
+		}
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signingInput))
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return "", fmt.Errorf("sign token: %w", err)
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	default:
+		digest := sha256.Sum256([]byte(signingInput))
+		if signature, err = key.Sign(rand.Reader, digest[:], crypto.SHA256); err != nil {
+			return "", fmt.Errorf("sign token: %w", err)
+		}
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
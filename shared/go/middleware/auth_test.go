@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
@@ -268,13 +269,13 @@ func TestAuth_APIKey_TimingSafe(t *testing.T) {
 	}
 
 	// Valid key -- should match the last entry
-	entry, ok := validateAPIKey("key-ccc", keys)
+	entry, ok, _ := validateAPIKey(context.Background(), "key-ccc", StaticAPIKeys(keys))
 	if !ok || entry.Subject != "svc-c" {
 		t.Errorf("expected match for key-ccc, got ok=%v, entry=%v", ok, entry)
 	}
 
 	// Invalid key -- should not match any
-	_, ok = validateAPIKey("key-zzz", keys)
+	_, ok, _ = validateAPIKey(context.Background(), "key-zzz", StaticAPIKeys(keys))
 	if ok {
 		t.Error("expected no match for invalid key")
 	}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCursorCodec(t *testing.T) {
+	c := NewCursorCodec([]byte("cursor-secret"))
+	cur := Cursor{Keys: []string{"HIGH", "2026-10-18T09:00:00.123456Z", "0b6e3f1c-7a1d-4c52-9e0f-3d2a1b4c5d6e"}, Backward: true}
+	s := c.Encode("findings", cur)
+
+	got, err := c.Decode("findings", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Backward || strings.Join(got.Keys, ",") != strings.Join(cur.Keys, ",") {
+		t.Errorf("round trip = %+v", got)
+	}
+
+	payload, sig, _ := strings.Cut(s, ".")
+	for name, bad := range map[string]string{
+		"other listing":  "",
+		"tampered":       payload[:len(payload)-2] + "AA." + sig,
+		"unsigned":       payload,
+		"other secret":   NewCursorCodec([]byte("other")).Encode("findings", cur),
+		"no keys":        c.Encode("findings", Cursor{}),
+		"not base64 sig": payload + ".!!",
+	} {
+		list := "findings"
+		if name == "other listing" {
+			list, bad = "assessments", s
+		}
+		if _, err := c.Decode(list, bad); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: expected ErrInvalidCursor, got %v", name, err)
+		}
+	}
+}
+
+func TestParsePagination(t *testing.T) {
+	for _, tt := range []struct {
+		query string
+		want  Pagination
+	}{
+		{"", Pagination{Offset: 0, Limit: DefaultPageLimit, Count: true}},
+		{"offset=-1&limit=1000", Pagination{Offset: 0, Limit: MaxPageLimit, Count: true}},
+		{"offset=5&limit=10&count=false", Pagination{Offset: 5, Limit: 10}},
+		{"cursor=abc", Pagination{Limit: DefaultPageLimit, Cursor: "abc"}},
+		{"cursor=abc&count=true", Pagination{Limit: DefaultPageLimit, Cursor: "abc", Count: true}},
+	} {
+		if got := ParsePagination(httptest.NewRequest("GET", "/?"+tt.query, nil)); got != tt.want {
+			t.Errorf("%q: got %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// IdempotencyKeyHeader is the request header that makes a request safe to
+// retry.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds client-chosen keys.
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyRecord is what an IdempotencyStore keeps for one key.
+type IdempotencyRecord struct {
+	// Fingerprint identifies the request (method, path and body).
+	Fingerprint string
+	// Completed is false while the first request is still being handled.
+	Completed bool
+	// StatusCode, Header and Body are the response to replay.
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// IdempotencyStore keeps idempotency keys and their responses.
+type IdempotencyStore interface {
+	// Reserve claims key for a request with fingerprint until expiresAt. If
+	// the key is already claimed and has not expired, it returns the
+	// existing record and false; otherwise a new pending record and true.
+	Reserve(ctx context.Context, key, fingerprint string, expiresAt time.Time) (*IdempotencyRecord, bool, error)
+	// Complete stores the response for a reserved key.
+	Complete(ctx context.Context, key string, rec *IdempotencyRecord) error
+	// Release drops a reservation so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyConfig configures the Idempotency middleware.
+type IdempotencyConfig struct {
+	// Store keeps keys and responses. Required.
+	Store IdempotencyStore
+	// TTL is how long a key and its response are kept. Default: 24 hours.
+	TTL time.Duration
+	// Paths are the URL path patterns the middleware applies to (see
+	// RateLimitRule.Path). If empty, it applies to every path.
+	Paths []string
+	// Logger for store errors. If nil, a no-op logger is used.
+	Logger *zap.Logger
+}
+
+// replayedHeaders are the response headers stored with a response; others
+// (rate limits, security headers) are set afresh on replay. ETag is kept so a
+// retried create can still send If-Match for the version it created.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// Idempotency returns middleware that makes POST requests carrying an
+// Idempotency-Key header safe to retry. It must run after Auth: keys are
+// scoped to the authenticated subject.
+//
+// The first request with a key is handled normally and its response is
+// stored. A later request with the same key and the same method, path and
+// body gets the stored response, with "Idempotent-Replayed: true", and is
+// not handled again. The same key with a different request gets 422, and a
+// retry while the first request is still running gets 409. 5xx responses
+// are not stored, so the request can be retried.
+func Idempotency(cfg IdempotencyConfig) func(http.Handler) http.Handler {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" || !idempotentPath(cfg.Paths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				writeProblem(w, r, http.StatusBadRequest, "Idempotency-Key exceeds maximum length")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeProblem(w, r, http.StatusBadRequest, "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			storeKey := SubjectFromContext(r.Context()) + "|" + key
+			fingerprint := requestFingerprint(r, body)
+			rec, reserved, err := cfg.Store.Reserve(r.Context(), storeKey, fingerprint, time.Now().Add(cfg.TTL))
+			if err != nil {
+				logger.Error("idempotency store failed", zap.Error(err))
+				writeProblem(w, r, http.StatusServiceUnavailable, "idempotency check unavailable")
+				return
+			}
+			if !reserved {
+				switch {
+				case rec.Fingerprint != fingerprint:
+					writeProblem(w, r, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+				case !rec.Completed:
+					w.Header().Set("Retry-After", "1")
+					writeProblem(w, r, http.StatusConflict, "a request with this Idempotency-Key is still being processed")
+				default:
+					for name, values := range rec.Header {
+						w.Header()[name] = values
+					}
+					w.Header().Set("Idempotent-Replayed", "true")
+					w.WriteHeader(rec.StatusCode)
+					w.Write(rec.Body)
+				}
+				return
+			}
+
+			// Release the key if the handler panics, so a retry is not
+			// stuck with 409 until the key expires.
+			completed := false
+			defer func() {
+				if !completed {
+					if err := cfg.Store.Release(context.WithoutCancel(r.Context()), storeKey); err != nil {
+						logger.Error("failed to release idempotency key", zap.Error(err))
+					}
+				}
+			}()
+
+			rw := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+			if rw.status >= 500 {
+				return
+			}
+
+			header := make(http.Header)
+			for _, name := range replayedHeaders {
+				if v := rw.Header().Values(name); len(v) > 0 {
+					header[http.CanonicalHeaderKey(name)] = v
+				}
+			}
+			err = cfg.Store.Complete(context.WithoutCancel(r.Context()), storeKey, &IdempotencyRecord{
+				Fingerprint: fingerprint,
+				Completed:   true,
+				StatusCode:  rw.status,
+				Header:      header,
+				Body:        rw.body.Bytes(),
+			})
+			if err != nil {
+				logger.Error("failed to store idempotent response", zap.Error(err))
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+func idempotentPath(patterns []string, path string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if matchPathPattern(p, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestFingerprint hashes what must match for a retry to be replayed.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+"\n"+r.URL.Path+"\n"+strconv.Itoa(len(body))+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter passes a response through while keeping a copy.
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore for a single process.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*memoryIdempotencyRecord
+	now     func() time.Time
+}
+
+type memoryIdempotencyRecord struct {
+	IdempotencyRecord
+	expiresAt time.Time
+}
+
+// NewMemoryIdempotencyStore returns an empty in-memory store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: make(map[string]*memoryIdempotencyRecord), now: time.Now}
+}
+
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, expiresAt time.Time) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for k, rec := range s.records {
+		if !now.Before(rec.expiresAt) {
+			delete(s.records, k)
+		}
+	}
+	if rec, ok := s.records[key]; ok {
+		copied := rec.IdempotencyRecord
+		return &copied, false, nil
+	}
+	s.records[key] = &memoryIdempotencyRecord{IdempotencyRecord: IdempotencyRecord{Fingerprint: fingerprint}, expiresAt: expiresAt}
+	return &IdempotencyRecord{Fingerprint: fingerprint}, true, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, key string, rec *IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[key]; ok {
+		existing.IdempotencyRecord = *rec
+	}
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
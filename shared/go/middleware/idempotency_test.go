@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	var calls atomic.Int32
+	var release chan struct{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if release != nil {
+			<-release
+		}
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/things/"+strings.Repeat("x", int(n)))
+		w.Header().Set("ETag", `"v`+string('0'+rune(n))+`"`)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"call":` + string('0'+rune(n)) + `}`))
+	})
+	store := NewMemoryIdempotencyStore()
+	h := Idempotency(IdempotencyConfig{Store: store, Paths: []string{"/things", "/fail"}})(handler)
+
+	do := func(method, path, key, subject, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		if subject != "" {
+			req = req.WithContext(withIdentity(req.Context(), subject, "admin", AuthMethodJWT))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := do("POST", "/things", "k1", "alice", `{"name":"a"}`)
+	if first.Code != http.StatusCreated || first.Body.String() != `{"call":1}` {
+		t.Fatalf("first: %d %s", first.Code, first.Body)
+	}
+
+	retry := do("POST", "/things", "k1", "alice", `{"name":"a"}`)
+	if retry.Code != http.StatusCreated || retry.Body.String() != `{"call":1}` || retry.Header().Get("Location") != "/things/x" || retry.Header().Get("ETag") != `"v1"` || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry: %d %s %v", retry.Code, retry.Body, retry.Header())
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want 1", calls.Load())
+	}
+
+	if rec := do("POST", "/things", "k1", "alice", `{"name":"b"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("different body: expected 422, got %d", rec.Code)
+	}
+	if rec := do("POST", "/things", "k1", "bob", `{"name":"b"}`); rec.Code != http.StatusCreated {
+		t.Errorf("same key, other subject: expected 201, got %d", rec.Code)
+	}
+	if rec := do("POST", "/things", "", "alice", `{"name":"a"}`); rec.Code != http.StatusCreated || calls.Load() != 3 {
+		t.Errorf("no key: %d, %d calls", rec.Code, calls.Load())
+	}
+	if rec := do("POST", "/other", "k9", "alice", `{}`); rec.Header().Get("Idempotent-Replayed") != "" || do("POST", "/other", "k9", "alice", `{}`).Header().Get("Idempotent-Replayed") != "" {
+		t.Error("path outside Paths was replayed")
+	}
+	if rec := do("POST", "/things", strings.Repeat("k", 256), "alice", `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("long key: expected 400, got %d", rec.Code)
+	}
+
+	// Server errors are not stored, so the retry runs again.
+	before := calls.Load()
+	do("POST", "/fail", "k2", "alice", `{}`)
+	do("POST", "/fail", "k2", "alice", `{}`)
+	if calls.Load() != before+2 {
+		t.Errorf("5xx was replayed")
+	}
+
+	// A retry while the first request is running is told to wait.
+	release = make(chan struct{})
+	done := make(chan int)
+	go func() { done <- do("POST", "/things", "k3", "alice", `{}`).Code }()
+	for calls.Load() == before+2 {
+		time.Sleep(time.Millisecond)
+	}
+	if rec := do("POST", "/things", "k3", "alice", `{}`); rec.Code != http.StatusConflict {
+		t.Errorf("concurrent retry: expected 409, got %d", rec.Code)
+	}
+	close(release)
+	if code := <-done; code != http.StatusCreated {
+		t.Errorf("first concurrent request: %d", code)
+	}
+}
+
+func TestMemoryIdempotencyStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryIdempotencyStore()
+	now := time.Unix(1_700_000_000, 0)
+	s.now = func() time.Time { return now }
+
+	if _, ok, _ := s.Reserve(ctx, "k", "f1", now.Add(time.Hour)); !ok {
+		t.Fatal("first reserve failed")
+	}
+	if rec, ok, _ := s.Reserve(ctx, "k", "f2", now.Add(time.Hour)); ok || rec.Fingerprint != "f1" || rec.Completed {
+		t.Errorf("second reserve: %+v, %v", rec, ok)
+	}
+	now = now.Add(time.Hour)
+	if _, ok, _ := s.Reserve(ctx, "k", "f2", now.Add(time.Hour)); !ok {
+		t.Error("expired key not reusable")
+	}
+}
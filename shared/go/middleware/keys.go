@@ -0,0 +1,532 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/mldsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for RS256 verification.
+const minRSAKeyBits = 2048
+
+// maxJWKSBytes caps the size of a fetched or loaded JWKS document.
+const maxJWKSBytes = 1 << 20
+
+// PublicKey is a JWT verification key.
+type PublicKey struct {
+	// KeyID matches the token header "kid".
+	KeyID string
+	// Algorithm, if set, is the only JWT "alg" this key may verify.
+	Algorithm string
+	// Key is an *rsa.PublicKey, *ecdsa.PublicKey (P-256), ed25519.PublicKey,
+	// *mldsa.PublicKey (ML-DSA-65) or *HybridPublicKey.
+	Key crypto.PublicKey
+}
+
+// KeySource resolves the verification key for a token's "kid" header.
+type KeySource interface {
+	// PublicKey returns the key with the given kid. An empty kid matches
+	// only when the source holds exactly one key.
+	PublicKey(ctx context.Context, kid string) (*PublicKey, error)
+}
+
+// ErrUnknownKey is returned when no key matches a token's kid.
+var ErrUnknownKey = errors.New("no verification key matches token kid")
+
+// StaticKeySet is a fixed KeySource, e.g. keys loaded from a file at startup.
+type StaticKeySet struct {
+	keys []PublicKey
+}
+
+// NewStaticKeySet returns a KeySource over keys.
+func NewStaticKeySet(keys ...PublicKey) *StaticKeySet {
+	return &StaticKeySet{keys: keys}
+}
+
+// PublicKey implements KeySource.
+func (s *StaticKeySet) PublicKey(ctx context.Context, kid string) (*PublicKey, error) {
+	return findKey(s.keys, kid)
+}
+
+func findKey(keys []PublicKey, kid string) (*PublicKey, error) {
+	if kid == "" {
+		if len(keys) == 1 {
+			return &keys[0], nil
+		}
+		return nil, fmt.Errorf("%w: token has no kid and %d keys are configured", ErrUnknownKey, len(keys))
+	}
+	for i := range keys {
+		if keys[i].KeyID == kid {
+			return &keys[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+}
+
+// LoadKeyFile reads verification keys from a JWKS (JSON) or PEM file.
+func LoadKeyFile(path string) ([]PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	return ParseKeys(data)
+}
+
+// ParseKeys parses a JWKS document or one or more PEM public keys or
+// certificates. PEM keys are assigned their RFC 7638 thumbprint as kid.
+func ParseKeys(data []byte) ([]PublicKey, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return ParseJWKS(data)
+	}
+	return ParsePublicKeysPEM(data)
+}
+
+// ParsePublicKeysPEM parses PUBLIC KEY and CERTIFICATE blocks.
+func ParsePublicKeysPEM(data []byte) ([]PublicKey, error) {
+	var keys []PublicKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		var pub crypto.PublicKey
+		switch block.Type {
+		case "PUBLIC KEY":
+			k, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("parse public key: %w", err)
+			}
+			pub = k
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("parse certificate: %w", err)
+			}
+			pub = cert.PublicKey
+		default:
+			continue
+		}
+		if err := checkVerificationKey(pub); err != nil {
+			return nil, err
+		}
+		kid, err := keyThumbprint(pub)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, PublicKey{KeyID: kid, Key: pub})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no PEM public keys found")
+	}
+	return keys, nil
+}
+
+// jwkKey is the subset of RFC 7517 members needed for verification keys.
+type jwkKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	Pub string `json:"pub"`
+}
+
+// ParseJWKS parses a JWK Set. Keys that are not signature keys, use an
+// unsupported key type or curve, or are too weak are skipped, so one exotic
+// key in an identity provider's set does not disable the rest.
+func ParseJWKS(data []byte) ([]PublicKey, error) {
+	var doc struct {
+		Keys []jwkKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+	if doc.Keys == nil {
+		return nil, fmt.Errorf("invalid JWKS: missing \"keys\"")
+	}
+	var keys []PublicKey
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		if checkVerificationKey(pub) != nil {
+			continue
+		}
+		if k.Alg != "" && algorithmForKey(pub) != k.Alg {
+			continue
+		}
+		keys = append(keys, PublicKey{KeyID: k.Kid, Algorithm: k.Alg, Key: pub})
+	}
+	return keys, nil
+}
+
+func (k jwkKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64URLDecode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64URLDecode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64URLDecode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64URLDecode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("invalid P-256 coordinates")
+		}
+		// Validate via the uncompressed point encoding so off-curve points are rejected.
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, fmt.Errorf("invalid P-256 point: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64URLDecode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key length")
+		}
+		return ed25519.PublicKey(x), nil
+	case "AKP":
+		// Algorithm Key Pair (draft-ietf-cose-dilithium): the encoding of
+		// "pub" depends on "alg", so alg is mandatory.
+		pub, err := base64URLDecode(k.Pub)
+		if err != nil {
+			return nil, err
+		}
+		switch k.Alg {
+		case AlgMLDSA65:
+			return mldsa.NewPublicKey(mldsa.MLDSA65(), pub)
+		case AlgMLDSA65Ed25519:
+			return NewHybridPublicKey(pub)
+		default:
+			return nil, fmt.Errorf("unsupported AKP algorithm %q", k.Alg)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// checkVerificationKey rejects key types and sizes validateJWT does not accept.
+func checkVerificationKey(pub crypto.PublicKey) error {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return fmt.Errorf("RSA key is %d bits, minimum is %d", k.N.BitLen(), minRSAKeyBits)
+		}
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return fmt.Errorf("unsupported ECDSA curve %s", k.Curve.Params().Name)
+		}
+	case ed25519.PublicKey, *HybridPublicKey:
+	case *mldsa.PublicKey:
+		if k.Parameters() != mldsa.MLDSA65() {
+			return fmt.Errorf("unsupported ML-DSA parameter set %s", k.Parameters())
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return nil
+}
+
+// algorithmForKey returns the JWT alg a verification key is used with.
+func algorithmForKey(pub crypto.PublicKey) string {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return "RS256"
+	case *ecdsa.PublicKey:
+		return "ES256"
+	case ed25519.PublicKey:
+		return "EdDSA"
+	case *mldsa.PublicKey:
+		if k.Parameters() == mldsa.MLDSA65() {
+			return AlgMLDSA65
+		}
+	case *HybridPublicKey:
+		return AlgMLDSA65Ed25519
+	}
+	return ""
+}
+
+// keyThumbprint computes the RFC 7638 JWK thumbprint (SHA-256, base64url).
+func keyThumbprint(pub crypto.PublicKey) (string, error) {
+	enc := base64.RawURLEncoding.EncodeToString
+	var members string
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, enc(big.NewInt(int64(k.E)).Bytes()), enc(k.N.Bytes()))
+	case *ecdsa.PublicKey:
+		x, y := k.X.FillBytes(make([]byte, 32)), k.Y.FillBytes(make([]byte, 32))
+		members = fmt.Sprintf(`{"crv":"P-256","kty":"EC","x":%q,"y":%q}`, enc(x), enc(y))
+	case ed25519.PublicKey:
+		members = fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":%q}`, enc(k))
+	case *mldsa.PublicKey:
+		members = fmt.Sprintf(`{"alg":%q,"kty":"AKP","pub":%q}`, AlgMLDSA65, enc(k.Bytes()))
+	case *HybridPublicKey:
+		members = fmt.Sprintf(`{"alg":%q,"kty":"AKP","pub":%q}`, AlgMLDSA65Ed25519, enc(k.Bytes()))
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", pub)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return enc(sum[:]), nil
+}
+
+// RemoteKeySetConfig configures a RemoteKeySet.
+type RemoteKeySetConfig struct {
+	// URL of the JWKS document.
+	URL string
+	// Client is used for fetches. Default: 10 second timeout.
+	Client *http.Client
+	// RefreshInterval is how often the set is refetched in the background.
+	// Default: 15 minutes.
+	RefreshInterval time.Duration
+	// MinRefreshInterval rate-limits refetches triggered by an unknown kid.
+	// Default: 1 minute.
+	MinRefreshInterval time.Duration
+	// RetainRemoved keeps keys that disappeared from the document usable for
+	// this long, so tokens signed just before a rotation still verify.
+	// Default: 1 hour.
+	RetainRemoved time.Duration
+	// Logger for refresh failures. If nil, a no-op logger is used.
+	Logger *zap.Logger
+}
+
+type remoteKey struct {
+	key       PublicKey
+	removedAt time.Time // zero while the key is in the current document
+}
+
+// RemoteKeySet is a KeySource backed by a JWKS URL. It caches the key set,
+// refreshes it in the background, and refetches on demand when a token
+// names an unknown kid (for keys published after the last refresh). A failed
+// refresh keeps the previously fetched keys.
+type RemoteKeySet struct {
+	cfg RemoteKeySetConfig
+
+	mu          sync.RWMutex
+	keys        []remoteKey
+	lastAttempt time.Time
+
+	fetchMu sync.Mutex // serializes fetches
+	done    chan struct{}
+	once    sync.Once
+	now     func() time.Time
+}
+
+// NewRemoteKeySet fetches the key set once and starts background refresh.
+// It returns an error if the initial fetch fails. Call Stop on shutdown.
+func NewRemoteKeySet(ctx context.Context, cfg RemoteKeySetConfig) (*RemoteKeySet, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("JWKS URL is required")
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = 15 * time.Minute
+	}
+	if cfg.MinRefreshInterval <= 0 {
+		cfg.MinRefreshInterval = time.Minute
+	}
+	if cfg.RetainRemoved <= 0 {
+		cfg.RetainRemoved = time.Hour
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+
+	s := &RemoteKeySet{cfg: cfg, done: make(chan struct{}), now: time.Now}
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		ticker := time.NewTicker(cfg.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), s.fetchTimeout())
+				if err := s.Refresh(ctx); err != nil {
+					cfg.Logger.Warn("JWKS refresh failed", zap.String("url", cfg.URL), zap.Error(err))
+				}
+				cancel()
+			case <-s.done:
+				return
+			}
+		}
+	}()
+	return s, nil
+}
+
+// Stop ends background refresh.
+func (s *RemoteKeySet) Stop() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// PublicKey implements KeySource. An unknown kid triggers a refetch, at most
+// once per MinRefreshInterval however many requests name unknown kids at
+// once. The refetch is not bound to ctx, so a client that hangs up cannot
+// cut it short for the requests waiting on it.
+func (s *RemoteKeySet) PublicKey(ctx context.Context, kid string) (*PublicKey, error) {
+	if k, err := s.lookup(kid); err == nil {
+		return k, nil
+	}
+
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+	// Another request may have refetched while this one waited.
+	if k, err := s.lookup(kid); err == nil {
+		return k, nil
+	}
+	s.mu.RLock()
+	recent := s.now().Sub(s.lastAttempt) < s.cfg.MinRefreshInterval
+	s.mu.RUnlock()
+	if !recent {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.fetchTimeout())
+		defer cancel()
+		if err := s.refresh(ctx); err != nil {
+			s.cfg.Logger.Warn("JWKS refresh for unknown kid failed", zap.String("kid", kid), zap.Error(err))
+		}
+	}
+	return s.lookup(kid)
+}
+
+func (s *RemoteKeySet) lookup(kid string) (*PublicKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]PublicKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		// Retained keys still verify by kid but never match an empty kid.
+		if k.removedAt.IsZero() || kid != "" {
+			keys = append(keys, k.key)
+		}
+	}
+	return findKey(keys, kid)
+}
+
+// Refresh fetches the key set now. Keys missing from the new document are
+// retained for RetainRemoved.
+func (s *RemoteKeySet) Refresh(ctx context.Context) error {
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+	return s.refresh(ctx)
+}
+
+// fetchTimeout bounds refreshes that no caller's context bounds.
+func (s *RemoteKeySet) fetchTimeout() time.Duration {
+	if s.cfg.Client.Timeout > 0 {
+		return s.cfg.Client.Timeout + time.Second
+	}
+	return 10 * time.Second
+}
+
+// refresh is Refresh with fetchMu held.
+func (s *RemoteKeySet) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	s.lastAttempt = s.now()
+	s.mu.Unlock()
+
+	fetched, err := s.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := make(map[string]bool, len(fetched))
+	next := make([]remoteKey, 0, len(fetched)+len(s.keys))
+	for _, k := range fetched {
+		current[k.KeyID] = true
+		next = append(next, remoteKey{key: k})
+	}
+	for _, old := range s.keys {
+		if current[old.key.KeyID] || old.key.KeyID == "" {
+			continue
+		}
+		if old.removedAt.IsZero() {
+			old.removedAt = now
+		}
+		if now.Sub(old.removedAt) < s.cfg.RetainRemoved {
+			next = append(next, old)
+		}
+	}
+	s.keys = next
+	return nil
+}
+
+func (s *RemoteKeySet) fetch(ctx context.Context) ([]PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build JWKS request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read JWKS: %w", err)
+	}
+	if len(data) > maxJWKSBytes {
+		return nil, fmt.Errorf("JWKS exceeds %d bytes", maxJWKSBytes)
+	}
+	return ParseJWKS(data)
+}
@@ -0,0 +1,293 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// jwkJSON renders a public key as a JWK map for test JWKS documents.
+func jwkJSON(t *testing.T, kid string, pub crypto.PublicKey) map[string]string {
+	t.Helper()
+	enc := base64.RawURLEncoding.EncodeToString
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "n": enc(k.N.Bytes()), "e": enc(big.NewInt(int64(k.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": enc(k.X.FillBytes(make([]byte, 32))), "y": enc(k.Y.FillBytes(make([]byte, 32)))}
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "kid": kid, "crv": "Ed25519", "x": enc(k)}
+	}
+	t.Fatalf("unsupported key %T", pub)
+	return nil
+}
+
+func authStatus(t *testing.T, cfg AuthConfig, token string) int {
+	t.Helper()
+	handler := Auth(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest("GET", "/api/v1/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAuth_AsymmetricJWT(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	keys := NewStaticKeySet(
+		PublicKey{KeyID: "rsa-1", Key: rsaKey.Public()},
+		PublicKey{KeyID: "ec-1", Key: ecKey.Public()},
+		PublicKey{KeyID: "ed-1", Algorithm: "EdDSA", Key: edKey.Public()},
+	)
+	cfg := AuthConfig{JWTKeys: keys, JWTIssuer: "quantun"}
+
+	for kid, signer := range map[string]crypto.Signer{"rsa-1": rsaKey, "ec-1": ecKey, "ed-1": edKey} {
+		token, err := CreateSignedJWT(signer, kid, "user-1", "analyst", "quantun", time.Hour)
+		if err != nil {
+			t.Fatalf("%s: CreateSignedJWT failed: %v", kid, err)
+		}
+		if code := authStatus(t, cfg, token); code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", kid, code)
+		}
+	}
+
+	// Signed by the EC key but naming the RSA key's kid.
+	token, _ := CreateSignedJWT(ecKey, "rsa-1", "user-1", "analyst", "quantun", time.Hour)
+	if code := authStatus(t, cfg, token); code != http.StatusUnauthorized {
+		t.Errorf("key type mismatch: expected 401, got %d", code)
+	}
+
+	token, _ = CreateSignedJWT(rsaKey, "unknown", "user-1", "analyst", "quantun", time.Hour)
+	if code := authStatus(t, cfg, token); code != http.StatusUnauthorized {
+		t.Errorf("unknown kid: expected 401, got %d", code)
+	}
+
+	// HS256 is not accepted when only public keys are configured.
+	hs, _ := CreateJWT("any-secret", "user-1", "analyst", "quantun", time.Hour)
+	if code := authStatus(t, cfg, hs); code != http.StatusUnauthorized {
+		t.Errorf("HS256 without secret: expected 401, got %d", code)
+	}
+}
+
+func TestValidate_PublicKeyAsHMACSecretRejected(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	der, _ := x509.MarshalPKIXPublicKey(rsaKey.Public())
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	// Classic algorithm confusion: an HS256 token whose "secret" is the
+	// public key the server uses for RS256.
+	forged, _ := CreateJWT(pemKey, "attacker", "admin", "", time.Hour)
+	v := jwtVerifier{keys: NewStaticKeySet(PublicKey{Key: rsaKey.Public()})}
+	if _, err := v.validate(context.Background(), forged); err == nil {
+		t.Fatal("expected HS256 token to be rejected when only public keys are configured")
+	}
+}
+
+func TestParseKeys_PEMAndJWKS(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(ecKey.Public())
+	keys, err := ParseKeys(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("ParseKeys(PEM) = %v, %v", keys, err)
+	}
+	// RFC 7638 thumbprint of the same key as a JWK.
+	thumb, _ := keyThumbprint(ecKey.Public())
+	if keys[0].KeyID != thumb || len(thumb) != 43 {
+		t.Errorf("kid = %q, want thumbprint %q", keys[0].KeyID, thumb)
+	}
+	token, _ := CreateSignedJWT(ecKey, thumb, "svc", "service", "", time.Hour)
+	if code := authStatus(t, AuthConfig{JWTKeys: NewStaticKeySet(keys...)}, token); code != http.StatusOK {
+		t.Errorf("PEM key: expected 200, got %d", code)
+	}
+
+	weak, _ := rsa.GenerateKey(rand.Reader, 1024)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	doc, _ := json.Marshal(map[string]interface{}{"keys": []interface{}{
+		jwkJSON(t, "weak", weak.Public()),
+		jwkJSON(t, "ed", edKey.Public()),
+		map[string]string{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+		map[string]string{"kty": "EC", "kid": "enc", "use": "enc", "crv": "P-256", "x": "AA", "y": "AA"},
+		map[string]string{"kty": "EC", "kid": "off-curve", "crv": "P-256", "x": base64.RawURLEncoding.EncodeToString(make([]byte, 32)), "y": base64.RawURLEncoding.EncodeToString(make([]byte, 32))},
+	}})
+	keys, err = ParseKeys(doc)
+	if err != nil {
+		t.Fatalf("ParseKeys(JWKS) failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0].KeyID != "ed" {
+		t.Errorf("expected only the Ed25519 key to be accepted, got %+v", keys)
+	}
+}
+
+// jwksServer serves a mutable JWKS document and counts fetches.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	doc     []byte
+	fail    bool
+	fetches int
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetches++
+		if s.fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(s.doc)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) publish(t *testing.T, keys map[string]crypto.PublicKey) {
+	var list []interface{}
+	for kid, k := range keys {
+		list = append(list, jwkJSON(t, kid, k))
+	}
+	doc, _ := json.Marshal(map[string]interface{}{"keys": list})
+	s.mu.Lock()
+	s.doc = doc
+	s.mu.Unlock()
+}
+
+func TestRemoteKeySet_Rotation(t *testing.T) {
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	srv := newJWKSServer(t)
+	srv.publish(t, map[string]crypto.PublicKey{"old": oldKey.Public()})
+
+	ks, err := NewRemoteKeySet(context.Background(), RemoteKeySetConfig{
+		URL:                srv.URL,
+		RefreshInterval:    time.Hour,
+		MinRefreshInterval: time.Minute,
+		RetainRemoved:      10 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewRemoteKeySet failed: %v", err)
+	}
+	defer ks.Stop()
+
+	now := time.Now()
+	ks.now = func() time.Time { return now }
+	cfg := AuthConfig{JWTKeys: ks}
+	oldToken, _ := CreateSignedJWT(oldKey, "old", "user-1", "viewer", "", time.Hour)
+	newToken, _ := CreateSignedJWT(newKey, "new", "user-1", "viewer", "", time.Hour)
+
+	// The provider rotates: "new" is published and "old" withdrawn.
+	srv.publish(t, map[string]crypto.PublicKey{"new": newKey.Public()})
+
+	// An unknown kid triggers a refetch once the minimum interval has passed.
+	now = now.Add(2 * time.Minute)
+	if code := authStatus(t, cfg, newToken); code != http.StatusOK {
+		t.Fatalf("token with newly published kid: expected 200, got %d", code)
+	}
+	// Tokens signed with the withdrawn key keep working for RetainRemoved.
+	if code := authStatus(t, cfg, oldToken); code != http.StatusOK {
+		t.Errorf("token with recently withdrawn kid: expected 200, got %d", code)
+	}
+
+	// Unknown kids within MinRefreshInterval do not hammer the provider.
+	fetches := srv.fetches
+	bogus, _ := CreateSignedJWT(newKey, "bogus", "user-1", "viewer", "", time.Hour)
+	authStatus(t, cfg, bogus)
+	authStatus(t, cfg, bogus)
+	if srv.fetches != fetches {
+		t.Errorf("unknown kid refetched %d times within MinRefreshInterval", srv.fetches-fetches)
+	}
+
+	// A failed refresh keeps the current keys.
+	srv.mu.Lock()
+	srv.fail = true
+	srv.mu.Unlock()
+	if err := ks.Refresh(context.Background()); err == nil {
+		t.Error("expected refresh against failing server to error")
+	}
+	if code := authStatus(t, cfg, newToken); code != http.StatusOK {
+		t.Errorf("after failed refresh: expected 200, got %d", code)
+	}
+
+	// Once the grace period has passed, the withdrawn key is dropped.
+	srv.mu.Lock()
+	srv.fail = false
+	srv.mu.Unlock()
+	now = now.Add(11 * time.Minute)
+	if err := ks.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.PublicKey(context.Background(), "old"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("withdrawn key after grace period: err = %v, want ErrUnknownKey", err)
+	}
+}
+
+// TestRemoteKeySet_ConcurrentUnknownKids checks that requests naming unknown
+// kids at once share one refetch, and that it does not depend on their
+// contexts.
+func TestRemoteKeySet_ConcurrentUnknownKids(t *testing.T) {
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+	srv := newJWKSServer(t)
+	srv.publish(t, map[string]crypto.PublicKey{"old": oldKey.Public()})
+	ks, err := NewRemoteKeySet(context.Background(), RemoteKeySetConfig{URL: srv.URL, RefreshInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ks.Stop()
+	now := time.Now().Add(2 * time.Minute)
+	ks.now = func() time.Time { return now }
+	srv.publish(t, map[string]crypto.PublicKey{"old": oldKey.Public(), "new": newKey.Public()})
+
+	// The callers have already gone away.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		kid := "new"
+		if i%2 == 1 {
+			kid = fmt.Sprintf("bogus-%d", i)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ks.PublicKey(ctx, kid); kid == "new" && err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("newly published kid: %v", err)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.fetches != 2 {
+		t.Errorf("fetches = %d, want 2 (initial and one refetch)", srv.fetches)
+	}
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Client certificate attributes a ClientCertMapping can match.
+const (
+	ClientCertMatchCN    = "cn"    // subject common name
+	ClientCertMatchDNS   = "dns"   // DNS name SAN (case-insensitive)
+	ClientCertMatchURI   = "uri"   // URI SAN, e.g. a SPIFFE ID
+	ClientCertMatchEmail = "email" // email address SAN
+)
+
+// ClientCertMapping maps a verified client certificate to an identity.
+type ClientCertMapping struct {
+	// Match is one of the ClientCertMatch constants; Value must equal the
+	// certificate's attribute of that kind exactly.
+	Match string
+	Value string
+
+	// Subject is the identity to authenticate as. If empty, Value is used.
+	Subject string
+	Role    string
+}
+
+func (m ClientCertMapping) matches(cert *x509.Certificate) bool {
+	switch m.Match {
+	case ClientCertMatchCN:
+		return cert.Subject.CommonName == m.Value
+	case ClientCertMatchDNS:
+		return slices.ContainsFunc(cert.DNSNames, func(n string) bool { return strings.EqualFold(n, m.Value) })
+	case ClientCertMatchURI:
+		return slices.ContainsFunc(cert.URIs, func(u *url.URL) bool { return u.String() == m.Value })
+	case ClientCertMatchEmail:
+		return slices.Contains(cert.EmailAddresses, m.Value)
+	}
+	return false
+}
+
+// ParseClientCertMappings parses mapping configuration strings in the format
+// "match:value=subject:role", e.g. "uri:spiffe://mesh/ns/ci/sa/scanner=scanner:assessor".
+// The value may contain ':' and '='; the subject and role are taken from
+// after the last '='. An empty subject means the value itself.
+func ParseClientCertMappings(entries []string) ([]ClientCertMapping, error) {
+	var result []ClientCertMapping
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		match, rest, ok := strings.Cut(entry, ":")
+		eq := strings.LastIndex(rest, "=")
+		if !ok || eq < 0 {
+			return nil, fmt.Errorf("client certificate mapping %q: expected match:value=subject:role", entry)
+		}
+		value, identity := rest[:eq], rest[eq+1:]
+		colon := strings.LastIndex(identity, ":")
+		if colon < 0 {
+			return nil, fmt.Errorf("client certificate mapping %q: expected match:value=subject:role", entry)
+		}
+		m := ClientCertMapping{
+			Match:   strings.ToLower(strings.TrimSpace(match)),
+			Value:   strings.TrimSpace(value),
+			Subject: strings.TrimSpace(identity[:colon]),
+			Role:    strings.TrimSpace(identity[colon+1:]),
+		}
+		switch m.Match {
+		case ClientCertMatchCN, ClientCertMatchDNS, ClientCertMatchURI, ClientCertMatchEmail:
+		default:
+			return nil, fmt.Errorf("client certificate mapping %q: unknown match %q (want cn, dns, uri or email)", entry, m.Match)
+		}
+		if m.Value == "" || m.Role == "" {
+			return nil, fmt.Errorf("client certificate mapping %q: value and role are required", entry)
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// clientCertIdentity returns the mapping for the request's verified client
+// certificate, or nil if there is none or no mapping matches. Only
+// certificates the TLS server verified against its client CAs are
+// considered; unverified peer certificates are ignored.
+func clientCertIdentity(r *http.Request, mappings []ClientCertMapping) (*ClientCertMapping, *x509.Certificate) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	for i := range mappings {
+		if mappings[i].matches(cert) {
+			m := mappings[i]
+			if m.Subject == "" {
+				m.Subject = m.Value
+			}
+			return &m, cert
+		}
+	}
+	return nil, cert
+}
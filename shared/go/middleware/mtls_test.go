@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// testCA issues client certificates for mTLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, cn string, uris ...string) tls.Certificate {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, u := range uris {
+		parsed, _ := url.Parse(u)
+		tmpl.URIs = append(tmpl.URIs, parsed)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestParseClientCertMappings(t *testing.T) {
+	got, err := ParseClientCertMappings([]string{
+		"uri:spiffe://mesh/ns/ci/sa/scanner=scanner:assessor",
+		" cn:reporting=:viewer ",
+		"DNS:api.internal=svc:admin",
+		"",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ClientCertMapping{
+		{Match: "uri", Value: "spiffe://mesh/ns/ci/sa/scanner", Subject: "scanner", Role: "assessor"},
+		{Match: "cn", Value: "reporting", Subject: "", Role: "viewer"},
+		{Match: "dns", Value: "api.internal", Subject: "svc", Role: "admin"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d mappings, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("mapping %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"reporting=svc:viewer", "cn:reporting", "cn:reporting=svc", "ou:ops=svc:viewer", "cn:=svc:viewer"} {
+		if _, err := ParseClientCertMappings([]string{bad}); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestAuth_MTLS(t *testing.T) {
+	ca := newTestCA(t)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	revocations := NewMemoryRevocationStore()
+	mw := Auth(AuthConfig{
+		JWTSecret: "test-secret",
+		ClientCerts: []ClientCertMapping{
+			{Match: ClientCertMatchURI, Value: "spiffe://mesh/ns/ci/sa/scanner", Subject: "scanner", Role: "assessor"},
+			{Match: ClientCertMatchCN, Value: "reporting", Role: "viewer"},
+		},
+		Revocations: revocations,
+		SkipPaths:   []string{"/health"},
+	})
+	srv := httptest.NewUnstartedServer(mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, SubjectFromContext(r.Context())+" "+RoleFromContext(r.Context())+" "+string(AuthMethodFromContext(r.Context())))
+	})))
+	srv.TLS = &tls.Config{ClientCAs: pool, ClientAuth: tls.VerifyClientCertIfGiven}
+	srv.StartTLS()
+	defer srv.Close()
+
+	client := func(certs ...tls.Certificate) *http.Client {
+		tr := srv.Client().Transport.(*http.Transport).Clone()
+		tr.TLSClientConfig.Certificates = certs
+		return &http.Client{Transport: tr}
+	}
+	get := func(c *http.Client, path string) (int, string) {
+		t.Helper()
+		resp, err := c.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if code, body := get(client(ca.issue(t, "scanner-pod", "spiffe://mesh/ns/ci/sa/scanner")), "/"); code != http.StatusOK || body != "scanner assessor mtls" {
+		t.Errorf("URI SAN: %d %q", code, body)
+	}
+	if code, body := get(client(ca.issue(t, "reporting")), "/"); code != http.StatusOK || body != "reporting viewer mtls" {
+		t.Errorf("CN with default subject: %d %q", code, body)
+	}
+	if code, _ := get(client(ca.issue(t, "unknown")), "/"); code != http.StatusUnauthorized {
+		t.Errorf("unmapped certificate: expected 401, got %d", code)
+	}
+	if code, _ := get(client(), "/"); code != http.StatusUnauthorized {
+		t.Errorf("no certificate: expected 401, got %d", code)
+	}
+	if code, _ := get(client(ca.issue(t, "unknown")), "/health"); code != http.StatusOK {
+		t.Errorf("skip path: expected 200, got %d", code)
+	}
+
+	// An explicit Authorization header takes precedence over the certificate.
+	req, _ := http.NewRequest("GET", srv.URL+"/", nil)
+	req.Header.Set("Authorization", "Bearer not-a-token")
+	resp, err := client(ca.issue(t, "reporting")).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("bad bearer with mapped certificate: expected 401, got %d", resp.StatusCode)
+	}
+
+	// Revoking the subject covers certificates issued before the cutoff.
+	revocations.RevokeSubject(t.Context(), "reporting", time.Now())
+	if code, _ := get(client(ca.issue(t, "reporting")), "/"); code != http.StatusUnauthorized {
+		t.Errorf("revoked subject: expected 401, got %d", code)
+	}
+}
+
+func TestClientCertIdentity_RequiresVerifiedChain(t *testing.T) {
+	ca := newTestCA(t)
+	cert, _ := x509.ParseCertificate(ca.issue(t, "reporting").Certificate[0])
+	mappings := []ClientCertMapping{{Match: ClientCertMatchCN, Value: "reporting", Role: "viewer"}}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	if id, _ := clientCertIdentity(r, mappings); id != nil {
+		t.Error("unverified peer certificate was accepted")
+	}
+	r.TLS.VerifiedChains = [][]*x509.Certificate{{cert, ca.cert}}
+	if id, _ := clientCertIdentity(r, mappings); id == nil || id.Subject != "reporting" {
+		t.Errorf("verified certificate: %+v", id)
+	}
+}
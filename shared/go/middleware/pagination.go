@@ -20,13 +20,21 @@ const (
 type Pagination struct {
 	Offset int
 	Limit  int
+	// Cursor is the opaque "cursor" parameter (see CursorCodec). When set,
+	// the listing pages by keyset from the cursor and Offset is ignored.
+	Cursor string
+	// Count reports whether the total number of items should be counted.
+	Count bool
 }
 
-// ParsePagination extracts and validates offset/limit query parameters.
+// ParsePagination extracts and validates offset/limit/cursor/count query
+// parameters.
 //
 // Rules:
 //   - offset defaults to 0, must be >= 0, capped at MaxOffset (1,000,000)
 //   - limit defaults to DefaultPageLimit (20), clamped to [1, MaxPageLimit (100)]
+//   - count defaults to true without a cursor and false with one; "count=true"
+//     or "count=false" overrides it
 func ParsePagination(r *http.Request) Pagination {
 	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
 	if err != nil || offset < 0 {
@@ -44,5 +52,11 @@ func ParsePagination(r *http.Request) Pagination {
 		limit = MaxPageLimit
 	}
 
-	return Pagination{Offset: offset, Limit: limit}
+	cursor := r.URL.Query().Get("cursor")
+	count, err := strconv.ParseBool(r.URL.Query().Get("count"))
+	if err != nil {
+		count = cursor == ""
+	}
+
+	return Pagination{Offset: offset, Limit: limit, Cursor: cursor, Count: count}
 }
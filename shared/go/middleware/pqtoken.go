@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/mldsa"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// Post-quantum JWT algorithms.
+const (
+	// AlgMLDSA65 is a pure ML-DSA-65 (FIPS 204) signature over the JWS
+	// signing input.
+	AlgMLDSA65 = "ML-DSA-65"
+	// AlgMLDSA65Ed25519 is a composite signature: ML-DSA-65 and Ed25519 both
+	// sign the token, and both must verify. The token stays secure as long as
+	// either algorithm is unbroken.
+	AlgMLDSA65Ed25519 = "ML-DSA-65-Ed25519"
+)
+
+// SupportedJWTAlgorithms lists every JWT "alg" the middleware can verify.
+var SupportedJWTAlgorithms = []string{"HS256", "RS256", "ES256", "EdDSA", AlgMLDSA65, AlgMLDSA65Ed25519}
+
+// hybridEd25519Prefix domain-separates the Ed25519 half of a composite
+// signature, so it cannot be stripped off and replayed as a plain EdDSA token.
+// The ML-DSA half is separated the same way through its FIPS 204 context string.
+var hybridEd25519Prefix = []byte(AlgMLDSA65Ed25519 + "\x00")
+
+// HybridPublicKey verifies ML-DSA-65-Ed25519 composite signatures.
+type HybridPublicKey struct {
+	MLDSA   *mldsa.PublicKey
+	Ed25519 ed25519.PublicKey
+}
+
+// NewHybridPublicKey decodes the concatenation of an ML-DSA-65 public key
+// and an Ed25519 public key, as produced by Bytes.
+func NewHybridPublicKey(encoding []byte) (*HybridPublicKey, error) {
+	if len(encoding) != mldsa.MLDSA65PublicKeySize+ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid %s public key length %d", AlgMLDSA65Ed25519, len(encoding))
+	}
+	pq, err := mldsa.NewPublicKey(mldsa.MLDSA65(), encoding[:mldsa.MLDSA65PublicKeySize])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s public key: %w", AlgMLDSA65Ed25519, err)
+	}
+	ed := make(ed25519.PublicKey, ed25519.PublicKeySize)
+	copy(ed, encoding[mldsa.MLDSA65PublicKeySize:])
+	return &HybridPublicKey{MLDSA: pq, Ed25519: ed}, nil
+}
+
+// Bytes returns the ML-DSA-65 public key followed by the Ed25519 public key.
+func (k *HybridPublicKey) Bytes() []byte {
+	return append(k.MLDSA.Bytes(), k.Ed25519...)
+}
+
+// Verify checks a composite signature over message.
+func (k *HybridPublicKey) Verify(message, signature []byte) error {
+	if len(signature) != mldsa.MLDSA65SignatureSize+ed25519.SignatureSize {
+		return fmt.Errorf("invalid JWT signature")
+	}
+	pqSig, edSig := signature[:mldsa.MLDSA65SignatureSize], signature[mldsa.MLDSA65SignatureSize:]
+	// Check both halves before deciding so the result does not reveal which failed.
+	pqErr := mldsa.Verify(k.MLDSA, message, pqSig, &mldsa.Options{Context: AlgMLDSA65Ed25519})
+	edOK := ed25519.Verify(k.Ed25519, append(hybridEd25519Prefix[:len(hybridEd25519Prefix):len(hybridEd25519Prefix)], message...), edSig)
+	if pqErr != nil || !edOK {
+		return fmt.Errorf("invalid JWT signature")
+	}
+	return nil
+}
+
+// HybridPrivateKey signs ML-DSA-65-Ed25519 composite tokens. It implements
+// crypto.Signer, so it can be passed to CreateSignedJWT.
+type HybridPrivateKey struct {
+	MLDSA   *mldsa.PrivateKey
+	Ed25519 ed25519.PrivateKey
+}
+
+// GenerateHybridKey generates a new ML-DSA-65 + Ed25519 key pair.
+func GenerateHybridKey() (*HybridPrivateKey, error) {
+	pq, err := mldsa.GenerateKey(mldsa.MLDSA65())
+	if err != nil {
+		return nil, fmt.Errorf("generate ML-DSA-65 key: %w", err)
+	}
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate Ed25519 key: %w", err)
+	}
+	return &HybridPrivateKey{MLDSA: pq, Ed25519: ed}, nil
+}
+
+// Public returns the *HybridPublicKey.
+func (k *HybridPrivateKey) Public() crypto.PublicKey {
+	return &HybridPublicKey{MLDSA: k.MLDSA.PublicKey(), Ed25519: k.Ed25519.Public().(ed25519.PublicKey)}
+}
+
+// Sign returns the composite signature of message. The message is signed
+// directly; opts must not request pre-hashing.
+func (k *HybridPrivateKey) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts != nil && opts.HashFunc() != 0 {
+		return nil, fmt.Errorf("%s signs messages directly, not digests", AlgMLDSA65Ed25519)
+	}
+	pqSig, err := k.MLDSA.Sign(rand, message, &mldsa.Options{Context: AlgMLDSA65Ed25519})
+	if err != nil {
+		return nil, fmt.Errorf("ML-DSA-65 sign: %w", err)
+	}
+	edSig := ed25519.Sign(k.Ed25519, append(hybridEd25519Prefix[:len(hybridEd25519Prefix):len(hybridEd25519Prefix)], message...))
+	return append(pqSig, edSig...), nil
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/mldsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// resign replaces a token's signature.
+func resign(token string, sig []byte) string {
+	return token[:strings.LastIndex(token, ".")+1] + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func tokenSignature(t *testing.T, token string) []byte {
+	t.Helper()
+	sig, err := base64URLDecode(token[strings.LastIndex(token, ".")+1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func mustDecodeHeader(t *testing.T, token string) []byte {
+	t.Helper()
+	h, err := base64URLDecode(token[:strings.Index(token, ".")])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestAuth_MLDSA65(t *testing.T) {
+	key, err := mldsa.GenerateKey(mldsa.MLDSA65())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := AuthConfig{JWTKeys: NewStaticKeySet(PublicKey{KeyID: "pq-1", Key: key.PublicKey()}), JWTIssuer: "quantun"}
+
+	token, err := CreateSignedJWT(key, "pq-1", "user-1", "analyst", "quantun", time.Hour)
+	if err != nil {
+		t.Fatalf("CreateSignedJWT failed: %v", err)
+	}
+	if !strings.Contains(string(mustDecodeHeader(t, token)), `"alg":"ML-DSA-65"`) {
+		t.Errorf("header = %s, want alg ML-DSA-65", mustDecodeHeader(t, token))
+	}
+	if code := authStatus(t, cfg, token); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	sig := tokenSignature(t, token)
+	sig[0] ^= 1
+	if code := authStatus(t, cfg, resign(token, sig)); code != http.StatusUnauthorized {
+		t.Errorf("tampered signature: expected 401, got %d", code)
+	}
+
+	// ML-DSA-44 keys are not on the allow-list.
+	weak, _ := mldsa.GenerateKey(mldsa.MLDSA44())
+	if _, err := CreateSignedJWT(weak, "", "user-1", "analyst", "", time.Hour); err == nil {
+		t.Error("expected ML-DSA-44 signing key to be rejected")
+	}
+	if err := checkVerificationKey(weak.PublicKey()); err == nil {
+		t.Error("expected ML-DSA-44 verification key to be rejected")
+	}
+}
+
+func TestAuth_HybridMLDSA65Ed25519(t *testing.T) {
+	key, err := GenerateHybridKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := key.Public().(*HybridPublicKey)
+	cfg := AuthConfig{JWTKeys: NewStaticKeySet(PublicKey{KeyID: "hybrid-1", Key: pub})}
+
+	token, err := CreateSignedJWT(key, "hybrid-1", "user-1", "assessor", "", time.Hour)
+	if err != nil {
+		t.Fatalf("CreateSignedJWT failed: %v", err)
+	}
+	if code := authStatus(t, cfg, token); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	sig := tokenSignature(t, token)
+	if len(sig) != mldsa.MLDSA65SignatureSize+ed25519.SignatureSize {
+		t.Fatalf("composite signature is %d bytes", len(sig))
+	}
+	// Both halves must verify: corrupting either one fails the token.
+	for _, i := range []int{0, mldsa.MLDSA65SignatureSize} {
+		bad := append([]byte(nil), sig...)
+		bad[i] ^= 1
+		if code := authStatus(t, cfg, resign(token, bad)); code != http.StatusUnauthorized {
+			t.Errorf("corrupted byte %d: expected 401, got %d", i, code)
+		}
+	}
+	if code := authStatus(t, cfg, resign(token, sig[:mldsa.MLDSA65SignatureSize])); code != http.StatusUnauthorized {
+		t.Errorf("ML-DSA half only: expected 401, got %d", code)
+	}
+}
+
+func TestAuth_HybridHalvesNotReusable(t *testing.T) {
+	key, _ := GenerateHybridKey()
+	token, _ := CreateSignedJWT(key, "k", "user-1", "admin", "", time.Hour)
+	sig := tokenSignature(t, token)
+	pqSig, edSig := sig[:mldsa.MLDSA65SignatureSize], sig[mldsa.MLDSA65SignatureSize:]
+
+	// Relabel the token with a single-algorithm header and keep one half of
+	// the composite signature. The halves are bound to the composite
+	// algorithm, so neither verifies on its own.
+	relabel := func(alg string, sig []byte) string {
+		header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": "k"})
+		parts := strings.Split(token, ".")
+		return base64.RawURLEncoding.EncodeToString(header) + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString(sig)
+	}
+	pqCfg := AuthConfig{JWTKeys: NewStaticKeySet(PublicKey{KeyID: "k", Key: key.MLDSA.PublicKey()})}
+	if code := authStatus(t, pqCfg, relabel(AlgMLDSA65, pqSig)); code != http.StatusUnauthorized {
+		t.Errorf("stripped ML-DSA half: expected 401, got %d", code)
+	}
+	edCfg := AuthConfig{JWTKeys: NewStaticKeySet(PublicKey{KeyID: "k", Key: key.Ed25519.Public()})}
+	if code := authStatus(t, edCfg, relabel("EdDSA", edSig)); code != http.StatusUnauthorized {
+		t.Errorf("stripped Ed25519 half: expected 401, got %d", code)
+	}
+
+	// A hybrid key never verifies a token claiming a weaker algorithm.
+	v := jwtVerifier{keys: NewStaticKeySet(PublicKey{KeyID: "k", Key: key.Public()})}
+	if _, err := v.validate(context.Background(), relabel("EdDSA", edSig)); err == nil {
+		t.Error("expected EdDSA header against hybrid key to be rejected")
+	}
+}
+
+func TestAuth_AlgorithmAllowList(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	hybrid, _ := GenerateHybridKey()
+	cfg := AuthConfig{
+		JWTSecret: "secret",
+		JWTKeys: NewStaticKeySet(
+			PublicKey{KeyID: "ed", Key: edKey.Public()},
+			PublicKey{KeyID: "hybrid", Key: hybrid.Public()},
+		),
+		JWTAlgorithms: []string{AlgMLDSA65Ed25519},
+	}
+
+	pq, _ := CreateSignedJWT(hybrid, "hybrid", "user-1", "viewer", "", time.Hour)
+	if code := authStatus(t, cfg, pq); code != http.StatusOK {
+		t.Errorf("allowed algorithm: expected 200, got %d", code)
+	}
+	ed, _ := CreateSignedJWT(edKey, "ed", "user-1", "viewer", "", time.Hour)
+	if code := authStatus(t, cfg, ed); code != http.StatusUnauthorized {
+		t.Errorf("EdDSA outside allow-list: expected 401, got %d", code)
+	}
+	hs, _ := CreateJWT("secret", "user-1", "viewer", "", time.Hour)
+	if code := authStatus(t, cfg, hs); code != http.StatusUnauthorized {
+		t.Errorf("HS256 outside allow-list: expected 401, got %d", code)
+	}
+
+	// "none" stays rejected even if someone lists it.
+	cfg.JWTAlgorithms = []string{"none"}
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"attacker"}`)) + "."
+	if code := authStatus(t, cfg, none); code != http.StatusUnauthorized {
+		t.Errorf("alg none: expected 401, got %d", code)
+	}
+}
+
+func TestParseKeys_PostQuantum(t *testing.T) {
+	pqKey, _ := mldsa.GenerateKey(mldsa.MLDSA65())
+	hybrid, _ := GenerateHybridKey()
+	enc := base64.RawURLEncoding.EncodeToString
+
+	doc, _ := json.Marshal(map[string]interface{}{"keys": []interface{}{
+		map[string]string{"kty": "AKP", "kid": "pq", "alg": AlgMLDSA65, "pub": enc(pqKey.PublicKey().Bytes())},
+		map[string]string{"kty": "AKP", "kid": "hybrid", "alg": AlgMLDSA65Ed25519, "pub": enc(hybrid.Public().(*HybridPublicKey).Bytes())},
+		map[string]string{"kty": "AKP", "kid": "no-alg", "pub": enc(pqKey.PublicKey().Bytes())},
+		map[string]string{"kty": "AKP", "kid": "truncated", "alg": AlgMLDSA65, "pub": enc(pqKey.PublicKey().Bytes()[:100])},
+	}})
+	keys, err := ParseKeys(doc)
+	if err != nil {
+		t.Fatalf("ParseKeys(JWKS) failed: %v", err)
+	}
+	if len(keys) != 2 || keys[0].KeyID != "pq" || keys[1].KeyID != "hybrid" {
+		t.Fatalf("expected the ML-DSA-65 and hybrid keys, got %+v", keys)
+	}
+	cfg := AuthConfig{JWTKeys: NewStaticKeySet(keys...)}
+	pqToken, _ := CreateSignedJWT(pqKey, "pq", "svc", "service", "", time.Hour)
+	hyToken, _ := CreateSignedJWT(hybrid, "hybrid", "svc", "service", "", time.Hour)
+	for name, token := range map[string]string{"pq": pqToken, "hybrid": hyToken} {
+		if code := authStatus(t, cfg, token); code != http.StatusOK {
+			t.Errorf("%s JWKS key: expected 200, got %d", name, code)
+		}
+	}
+
+	// ML-DSA-65 SubjectPublicKeyInfo PEM keys get an AKP thumbprint kid.
+	der, err := x509.MarshalPKIXPublicKey(pqKey.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err = ParseKeys(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("ParseKeys(PEM) = %v, %v", keys, err)
+	}
+	token, _ := CreateSignedJWT(pqKey, keys[0].KeyID, "svc", "service", "", time.Hour)
+	if code := authStatus(t, AuthConfig{JWTKeys: NewStaticKeySet(keys...)}, token); code != http.StatusOK {
+		t.Errorf("PEM ML-DSA-65 key: expected 200, got %d", code)
+	}
+}
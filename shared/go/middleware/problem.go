@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details bodies.
+const ProblemContentType = "application/problem+json"
+
+// ContextKeyRequestID holds the ID of the request, as set by RequestID.
+const ContextKeyRequestID contextKey = "request.id"
+
+// Problem is an RFC 7807 problem details body. Error repeats Detail under
+// the member error bodies have always used, so existing clients that read
+// "error" keep working.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	Error     string `json:"error"`
+}
+
+// NewProblem returns the problem details for answering r with status.
+func NewProblem(r *http.Request, status int, detail string) Problem {
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: RequestIDFromContext(r.Context()),
+		Error:     detail,
+	}
+}
+
+// WriteProblem writes body, a Problem or a struct embedding one to add
+// extension members, as a problem+json response with status.
+func WriteProblem(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeProblem answers r with a problem carrying status and detail.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	WriteProblem(w, status, NewProblem(r, status, detail))
+}
+
+// RequestID returns middleware that stores the ID that get returns for each
+// request (for example chi's middleware.GetReqID) in the context, where
+// problem responses pick it up, and echoes it in the X-Request-ID response
+// header. Requests with no ID pass through unchanged.
+func RequestID(get func(context.Context) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := get(r.Context())
+			if id == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("X-Request-ID", id)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ContextKeyRequestID, id)))
+		})
+	}
+}
+
+// RequestIDFromContext returns the request ID stored by RequestID, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ContextKeyRequestID).(string)
+	return id
+}
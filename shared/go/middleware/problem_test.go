@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProblemCarriesRequestID(t *testing.T) {
+	get := func(context.Context) string { return "req-42" }
+	h := RequestID(get)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, r, http.StatusConflict, "name taken")
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/organizations", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, ProblemContentType)
+	}
+	if id := rec.Header().Get("X-Request-ID"); id != "req-42" {
+		t.Errorf("X-Request-ID = %q, want req-42", id)
+	}
+	var p Problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	want := Problem{
+		Type:      "about:blank",
+		Title:     "Conflict",
+		Status:    http.StatusConflict,
+		Detail:    "name taken",
+		Instance:  "/api/v1/organizations",
+		RequestID: "req-42",
+		Error:     "name taken",
+	}
+	if p != want {
+		t.Errorf("problem = %+v, want %+v", p, want)
+	}
+}
+
+func TestRequestIDAbsent(t *testing.T) {
+	h := RequestID(func(context.Context) string { return "" })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := RequestIDFromContext(r.Context()); id != "" {
+			t.Errorf("request ID = %q, want empty", id)
+		}
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if _, ok := rec.Header()["X-Request-Id"]; ok {
+		t.Error("X-Request-ID set without a request ID")
+	}
+}
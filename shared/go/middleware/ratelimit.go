@@ -1,10 +1,32 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"go.uber.org/zap"
+)
+
+// RateLimitAlgorithm selects how requests are counted.
+type RateLimitAlgorithm string
+
+const (
+	// RateLimitFixedWindow allows RequestsPerWindow requests per fixed
+	// window. A client can send up to twice the limit across a window
+	// boundary.
+	RateLimitFixedWindow RateLimitAlgorithm = "fixed_window"
+	// RateLimitTokenBucket allows bursts of up to RequestsPerWindow requests
+	// and refills one request every Window/RequestsPerWindow, so a burst never
+	// exceeds the limit and sustained traffic is held to the limit.
+	RateLimitTokenBucket RateLimitAlgorithm = "token_bucket"
 )
 
 // RateLimitConfig configures the rate limiter.
@@ -13,14 +35,29 @@ type RateLimitConfig struct {
 	RequestsPerWindow int
 	// Window is the time window for rate limiting.
 	Window time.Duration
+	// Algorithm selects fixed window (default) or token bucket counting.
+	Algorithm RateLimitAlgorithm
+	// Rules override RequestsPerWindow and Window for matching requests. The
+	// first matching rule applies, and each rule has its own budget.
+	Rules []RateLimitRule
 	// KeyFunc extracts the rate limit key from a request (e.g., IP, API key).
 	// If nil, defaults to the remote address.
 	KeyFunc func(r *http.Request) string
+	// Name namespaces this limiter's keys, so several limiters can share a
+	// store without sharing budgets.
+	Name string
+	// Store holds the request counts. Use a shared store (e.g. Postgres) when
+	// several replicas serve the same clients, so the limit applies across
+	// all of them. If nil, counts are kept in memory for this process.
+	Store RateLimitStore
 	// CleanupInterval controls how often expired entries are purged. Default: 5 minutes.
 	CleanupInterval time.Duration
-	// MaxEntries caps the number of tracked keys to prevent unbounded memory growth.
-	// When this limit is reached, new keys are rate-limited immediately. Default: 100000.
+	// MaxEntries caps the number of tracked keys in the default in-memory
+	// store to prevent unbounded memory growth. When this limit is reached,
+	// new keys are rate-limited immediately. Default: 100000.
 	MaxEntries int
+	// Logger for store errors. If nil, a no-op logger is used.
+	Logger *zap.Logger
 }
 
 // DefaultRateLimitConfig returns a reasonable default rate limit config.
@@ -34,18 +71,274 @@ func DefaultRateLimitConfig() RateLimitConfig {
 	}
 }
 
+// RateLimitRule gives matching requests their own limit. Empty Method, Path
+// and Role match anything.
+type RateLimitRule struct {
+	// Method is an HTTP method, e.g. "POST".
+	Method string
+	// Path is a URL path pattern in which a "{name}" or "*" segment matches
+	// any single segment, e.g. "/api/v1/assessments/{id}/run".
+	Path string
+	// Role is the authenticated role (see RoleFromContext).
+	Role string
+
+	Limit  int
+	Window time.Duration
+}
+
+func (rule RateLimitRule) matches(r *http.Request) bool {
+	if rule.Method != "" && !strings.EqualFold(rule.Method, r.Method) {
+		return false
+	}
+	if rule.Role != "" && rule.Role != RoleFromContext(r.Context()) {
+		return false
+	}
+	return rule.Path == "" || matchPathPattern(rule.Path, r.URL.Path)
+}
+
+// String returns the rule in the format read by ParseRateLimitRules. It
+// also names the rule's budget in the store.
+func (rule RateLimitRule) String() string {
+	selector := strings.TrimSpace(rule.Method + " " + rule.Path)
+	if rule.Role != "" {
+		selector += "@" + rule.Role
+	}
+	return fmt.Sprintf("%s=%d/%s", selector, rule.Limit, rule.Window)
+}
+
+func matchPathPattern(pattern, path string) bool {
+	ps := strings.Split(strings.TrimSuffix(pattern, "/"), "/")
+	ss := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	if len(ps) != len(ss) {
+		return false
+	}
+	for i, p := range ps {
+		wildcard := p == "*" || (strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}"))
+		if !wildcard && p != ss[i] || wildcard && ss[i] == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseRateLimit parses a limit in the format "requests/window", e.g.
+// "100/1m".
+func ParseRateLimit(s string) (int, time.Duration, error) {
+	n, w, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("rate limit %q: expected requests/window, e.g. 100/1m", s)
+	}
+	limit, err := strconv.Atoi(n)
+	if err != nil || limit <= 0 {
+		return 0, 0, fmt.Errorf("rate limit %q: requests must be a positive integer", s)
+	}
+	window, err := time.ParseDuration(w)
+	if err != nil || window <= 0 {
+		return 0, 0, fmt.Errorf("rate limit %q: window must be a positive duration", s)
+	}
+	return limit, window, nil
+}
+
+// ParseRateLimitRules parses rule configuration strings in the format
+// "[METHOD] [PATH][@ROLE]=requests/window", for example
+// "POST /api/v1/assessments/{id}/run=10/1m" or "@admin=1000/1m".
+func ParseRateLimitRules(entries []string) ([]RateLimitRule, error) {
+	var rules []RateLimitRule
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		eq := strings.LastIndex(entry, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("rate limit rule %q: expected [METHOD] [PATH][@ROLE]=requests/window", entry)
+		}
+		var rule RateLimitRule
+		var err error
+		if rule.Limit, rule.Window, err = ParseRateLimit(entry[eq+1:]); err != nil {
+			return nil, fmt.Errorf("rate limit rule %q: %w", entry, err)
+		}
+		selector := strings.TrimSpace(entry[:eq])
+		if at := strings.LastIndex(selector, "@"); at >= 0 {
+			rule.Role = strings.TrimSpace(selector[at+1:])
+			selector = selector[:at]
+		}
+		switch fields := strings.Fields(selector); len(fields) {
+		case 0:
+		case 1:
+			if strings.HasPrefix(fields[0], "/") {
+				rule.Path = fields[0]
+			} else {
+				rule.Method = strings.ToUpper(fields[0])
+			}
+		case 2:
+			rule.Method, rule.Path = strings.ToUpper(fields[0]), fields[1]
+		default:
+			return nil, fmt.Errorf("rate limit rule %q: expected [METHOD] [PATH][@ROLE]=requests/window", entry)
+		}
+		if rule.Method == "" && rule.Path == "" && rule.Role == "" {
+			return nil, fmt.Errorf("rate limit rule %q: a method, path or role is required", entry)
+		}
+		if rule.Path != "" && !strings.HasPrefix(rule.Path, "/") {
+			return nil, fmt.Errorf("rate limit rule %q: path must start with /", entry)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// SubjectKey keys requests by authenticated subject, so clients sharing an
+// address (e.g. behind NAT) have separate budgets, and falls back to the
+// remote IP for unauthenticated requests. Use it with a limiter that runs
+// after Auth.
+func SubjectKey(r *http.Request) string {
+	if subject := SubjectFromContext(r.Context()); subject != "" {
+		return "sub:" + subject
+	}
+	return IPKey(r)
+}
+
+// IPKey keys requests by client IP address, ignoring the port and any
+// authenticated subject. Use it for per-address limits that must hold
+// wherever the limiter runs relative to Auth.
+func IPKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// RateLimitStore counts requests per key. Implementations must make each
+// method atomic, since concurrent requests (possibly on other replicas) race
+// on the same key.
+type RateLimitStore interface {
+	// Increment counts one request for key. If key's current window started
+	// at least window ago, a new window starts now. It returns the count in
+	// the current window, including this request, and when it started.
+	Increment(ctx context.Context, key string, window time.Duration) (count int, windowStart time.Time, err error)
+	// TakeToken takes one token from key's bucket, which holds up to limit
+	// tokens and refills limit tokens per window; a new bucket starts full.
+	// It returns the tokens left, and if the bucket was empty, how long
+	// until a token is available (no token is taken then).
+	TakeToken(ctx context.Context, key string, limit int, window time.Duration) (remaining int, wait time.Duration, err error)
+	// Purge drops windows that started, and buckets last used, before before.
+	Purge(ctx context.Context, before time.Time) error
+}
+
+// ErrRateLimitStoreFull is returned by a RateLimitStore that cannot track
+// another key. The request is rate-limited.
+var ErrRateLimitStoreFull = errors.New("rate limit store full")
+
 type rateLimitEntry struct {
 	count    int
 	windowAt time.Time
 }
 
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// MemoryRateLimitStore is a RateLimitStore for a single process.
+type MemoryRateLimitStore struct {
+	mu         sync.Mutex
+	entries    map[string]*rateLimitEntry
+	buckets    map[string]*tokenBucket
+	maxEntries int
+	now        func() time.Time
+}
+
+// NewMemoryRateLimitStore returns an empty in-memory store that tracks at
+// most maxEntries keys (unlimited if maxEntries <= 0).
+func NewMemoryRateLimitStore(maxEntries int) *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		entries:    make(map[string]*rateLimitEntry),
+		buckets:    make(map[string]*tokenBucket),
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+func (s *MemoryRateLimitStore) full() bool {
+	return s.maxEntries > 0 && len(s.entries)+len(s.buckets) >= s.maxEntries
+}
+
+func (s *MemoryRateLimitStore) Increment(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	now := s.now()
+
+	// The check and update happen under a single lock hold to prevent
+	// TOCTOU race conditions.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[key]
+	if !exists || now.Sub(entry.windowAt) >= window {
+		// Check max entries cap to prevent unbounded memory growth
+		if !exists && s.full() {
+			return 0, time.Time{}, ErrRateLimitStoreFull
+		}
+		s.entries[key] = &rateLimitEntry{count: 1, windowAt: now}
+		return 1, now, nil
+	}
+	entry.count++
+	return entry.count, entry.windowAt, nil
+}
+
+func (s *MemoryRateLimitStore) TakeToken(ctx context.Context, key string, limit int, window time.Duration) (int, time.Duration, error) {
+	now := s.now()
+	rate := float64(limit) / window.Seconds()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, exists := s.buckets[key]
+	if !exists {
+		if s.full() {
+			return 0, 0, ErrRateLimitStoreFull
+		}
+		b = &tokenBucket{tokens: float64(limit), updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+	if b.tokens < 1 {
+		return 0, tokenWait(b.tokens, rate), nil
+	}
+	b.tokens--
+	return int(b.tokens), 0, nil
+}
+
+// tokenWait returns how long a bucket holding tokens (< 1) and refilling at
+// rate tokens per second takes to hold one token.
+func tokenWait(tokens, rate float64) time.Duration {
+	return time.Duration((1 - tokens) / rate * float64(time.Second))
+}
+
+func (s *MemoryRateLimitStore) Purge(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, entry := range s.entries {
+		if entry.windowAt.Before(before) {
+			delete(s.entries, key)
+		}
+	}
+	for key, b := range s.buckets {
+		if b.updated.Before(before) {
+			delete(s.buckets, key)
+		}
+	}
+	return nil
+}
+
 // RateLimiter holds the state for rate limiting and exposes a Stop method
 // to cleanly shut down the background cleanup goroutine.
 type RateLimiter struct {
-	mu      sync.Mutex
-	entries map[string]*rateLimitEntry
-	cfg     RateLimitConfig
-	done    chan struct{}
+	store     RateLimitStore
+	cfg       RateLimitConfig
+	maxWindow time.Duration
+	logger    *zap.Logger
+	done      chan struct{}
 }
 
 // NewRateLimiter creates a rate limiter with a background cleanup goroutine.
@@ -57,6 +350,9 @@ func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
 	if cfg.Window <= 0 {
 		cfg.Window = time.Minute
 	}
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = RateLimitFixedWindow
+	}
 	if cfg.KeyFunc == nil {
 		cfg.KeyFunc = func(r *http.Request) string {
 			return r.RemoteAddr
@@ -68,11 +364,23 @@ func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
 	if cfg.MaxEntries <= 0 {
 		cfg.MaxEntries = 100_000
 	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryRateLimitStore(cfg.MaxEntries)
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
 
 	rl := &RateLimiter{
-		entries: make(map[string]*rateLimitEntry),
-		cfg:     cfg,
-		done:    make(chan struct{}),
+		store:     cfg.Store,
+		cfg:       cfg,
+		maxWindow: cfg.Window,
+		logger:    logger,
+		done:      make(chan struct{}),
+	}
+	for _, rule := range cfg.Rules {
+		rl.maxWindow = max(rl.maxWindow, rule.Window)
 	}
 
 	// Background cleanup goroutine -- stops when done is closed
@@ -97,70 +405,82 @@ func (rl *RateLimiter) Stop() {
 	close(rl.done)
 }
 
-// cleanup removes expired entries under lock.
+// cleanup removes expired entries from the store.
 func (rl *RateLimiter) cleanup() {
-	now := time.Now()
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-	for key, entry := range rl.entries {
-		if now.Sub(entry.windowAt) > rl.cfg.Window*2 {
-			delete(rl.entries, key)
+	if err := rl.store.Purge(context.Background(), time.Now().Add(-rl.maxWindow*2)); err != nil {
+		rl.logger.Warn("failed to purge rate limit entries", zap.Error(err))
+	}
+}
+
+// limitFor returns the store key, limit and window for r: those of the first
+// matching rule, each rule counting separately, or the default.
+func (rl *RateLimiter) limitFor(r *http.Request) (string, int, time.Duration) {
+	key := rl.cfg.KeyFunc(r)
+	if rl.cfg.Name != "" {
+		key = rl.cfg.Name + "|" + key
+	}
+	for _, rule := range rl.cfg.Rules {
+		if rule.matches(r) {
+			return rule.String() + "|" + key, rule.Limit, rule.Window
 		}
 	}
+	return key, rl.cfg.RequestsPerWindow, rl.cfg.Window
+}
+
+// rateLimitResult is the outcome of counting one request.
+type rateLimitResult struct {
+	limit      int
+	remaining  int
+	window     time.Duration
+	limited    bool
+	retryAfter time.Duration
+}
+
+// take counts r against its budget.
+func (rl *RateLimiter) take(r *http.Request) (rateLimitResult, error) {
+	key, limit, window := rl.limitFor(r)
+	res := rateLimitResult{limit: limit, window: window}
+	if rl.cfg.Algorithm == RateLimitTokenBucket {
+		remaining, wait, err := rl.store.TakeToken(r.Context(), key, limit, window)
+		res.remaining, res.limited, res.retryAfter = remaining, wait > 0, wait
+		return res, err
+	}
+
+	count, windowStart, err := rl.store.Increment(r.Context(), key, window)
+	res.remaining = max(limit-count, 0)
+	if count > limit {
+		res.limited = true
+		res.retryAfter = max(window-time.Since(windowStart), 0)
+	}
+	return res, err
 }
 
 // Middleware returns the HTTP middleware function.
+//
+// If the store fails, the request is let through and the error logged: an
+// unavailable counter should not take the API down with it.
 func (rl *RateLimiter) Middleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			key := rl.cfg.KeyFunc(r)
-			now := time.Now()
-
-			// Entire rate-limit check + update is done under a single lock hold
-			// to prevent TOCTOU race conditions.
-			rl.mu.Lock()
-
-			entry, exists := rl.entries[key]
-			if !exists || now.Sub(entry.windowAt) > rl.cfg.Window {
-				// Check max entries cap to prevent unbounded memory growth
-				if !exists && len(rl.entries) >= rl.cfg.MaxEntries {
-					rl.mu.Unlock()
-					// Too many tracked keys -- reject to prevent DoS via memory exhaustion
-					setRateLimitHeaders(w, rl.cfg.RequestsPerWindow, 0, rl.cfg.Window)
-					w.Header().Set("Retry-After", "60")
-					writeAuthError(w, http.StatusTooManyRequests, "rate limit exceeded")
-					return
-				}
-
-				rl.entries[key] = &rateLimitEntry{count: 1, windowAt: now}
-				rl.mu.Unlock()
-
-				setRateLimitHeaders(w, rl.cfg.RequestsPerWindow, rl.cfg.RequestsPerWindow-1, rl.cfg.Window)
-				next.ServeHTTP(w, r)
+			res, err := rl.take(r)
+			if errors.Is(err, ErrRateLimitStoreFull) {
+				// Too many tracked keys -- reject to prevent DoS via memory exhaustion
+				setRateLimitHeaders(w, res.limit, 0, res.window)
+				w.Header().Set("Retry-After", "60")
+				writeProblem(w, r, http.StatusTooManyRequests, "rate limit exceeded")
 				return
 			}
-
-			entry.count++
-			count := entry.count
-			windowStart := entry.windowAt
-			allowed := count <= rl.cfg.RequestsPerWindow
-
-			rl.mu.Unlock()
-
-			remaining := rl.cfg.RequestsPerWindow - count
-			if remaining < 0 {
-				remaining = 0
+			if err != nil {
+				rl.logger.Error("rate limit store failed; allowing request", zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
 			}
 
-			setRateLimitHeaders(w, rl.cfg.RequestsPerWindow, remaining, rl.cfg.Window)
+			setRateLimitHeaders(w, res.limit, res.remaining, res.window)
 
-			if !allowed {
-				retryAfter := rl.cfg.Window - now.Sub(windowStart)
-				if retryAfter < 0 {
-					retryAfter = 0
-				}
-				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
-				writeAuthError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			if res.limited {
+				w.Header().Set("Retry-After", strconv.Itoa(max(int(math.Ceil(res.retryAfter.Seconds())), 1)))
+				writeProblem(w, r, http.StatusTooManyRequests, "rate limit exceeded")
 				return
 			}
 
@@ -169,8 +489,8 @@ func (rl *RateLimiter) Middleware() func(http.Handler) http.Handler {
 	}
 }
 
-// RateLimit returns middleware that enforces per-key rate limiting using a
-// sliding window counter stored in memory.
+// RateLimit returns middleware that enforces per-key rate limiting with the
+// configured algorithm and store (a fixed window in memory by default).
 //
 // When the limit is exceeded, it returns 429 Too Many Requests with
 // Retry-After and X-RateLimit-* headers.
@@ -0,0 +1,245 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryRateLimitStore(2)
+	now := time.Unix(1_700_000_000, 0)
+	s.now = func() time.Time { return now }
+
+	for want := 1; want <= 3; want++ {
+		if n, start, _ := s.Increment(ctx, "a", time.Minute); n != want || !start.Equal(now) {
+			t.Fatalf("increment %d: count %d, start %v", want, n, start)
+		}
+	}
+	s.Increment(ctx, "b", time.Minute)
+	if _, _, err := s.Increment(ctx, "c", time.Minute); !errors.Is(err, ErrRateLimitStoreFull) {
+		t.Errorf("third key: expected ErrRateLimitStoreFull, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if n, start, _ := s.Increment(ctx, "a", time.Minute); n != 1 || !start.Equal(now) {
+		t.Errorf("after window: count %d, start %v", n, start)
+	}
+
+	s.Purge(ctx, now)
+	if _, _, err := s.Increment(ctx, "c", time.Minute); err != nil {
+		t.Errorf("after purge: %v", err)
+	}
+}
+
+// sharedStore simulates a store shared by several replicas, or one that is
+// unavailable.
+type sharedStore struct {
+	*MemoryRateLimitStore
+	err error
+}
+
+func (s *sharedStore) Increment(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	if s.err != nil {
+		return 0, time.Time{}, s.err
+	}
+	return s.MemoryRateLimitStore.Increment(ctx, key, window)
+}
+
+func (s *sharedStore) TakeToken(ctx context.Context, key string, limit int, window time.Duration) (int, time.Duration, error) {
+	if s.err != nil {
+		return 0, 0, s.err
+	}
+	return s.MemoryRateLimitStore.TakeToken(ctx, key, limit, window)
+}
+
+func TestRateLimiter_Store(t *testing.T) {
+	store := &sharedStore{MemoryRateLimitStore: NewMemoryRateLimitStore(0)}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	// Two replicas sharing one store share one budget.
+	var replicas []http.Handler
+	for range 2 {
+		rl := NewRateLimiter(RateLimitConfig{RequestsPerWindow: 3, Window: time.Minute, Store: store})
+		defer rl.Stop()
+		replicas = append(replicas, rl.Middleware()(ok))
+	}
+	var last *httptest.ResponseRecorder
+	for i := range 4 {
+		last = httptest.NewRecorder()
+		replicas[i%2].ServeHTTP(last, httptest.NewRequest("GET", "/", nil))
+		if want := http.StatusOK; i < 3 && last.Code != want {
+			t.Fatalf("request %d: expected %d, got %d", i+1, want, last.Code)
+		}
+	}
+	if last.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over the shared limit: expected 429, got %d", last.Code)
+	}
+	if last.Header().Get("X-RateLimit-Limit") != "3" || last.Header().Get("X-RateLimit-Remaining") != "0" || last.Header().Get("Retry-After") == "" {
+		t.Errorf("429 headers = %v", last.Header())
+	}
+
+	// An unavailable store lets requests through.
+	store.err = errors.New("connection refused")
+	rec := httptest.NewRecorder()
+	replicas[0].ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("store error: expected 200, got %d", rec.Code)
+	}
+}
+
+func TestMemoryRateLimitStore_TokenBucket(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryRateLimitStore(0)
+	now := time.Unix(1_700_000_000, 0)
+	s.now = func() time.Time { return now }
+
+	// A new bucket allows a burst of the full limit, then nothing.
+	for want := 3; want >= 0; want-- {
+		if left, wait, _ := s.TakeToken(ctx, "a", 4, time.Minute); left != want || wait != 0 {
+			t.Fatalf("burst: left %d, wait %v", left, wait)
+		}
+	}
+	if _, wait, _ := s.TakeToken(ctx, "a", 4, time.Minute); wait != 15*time.Second {
+		t.Fatalf("empty bucket: wait %v, want 15s", wait)
+	}
+
+	// One token refills every window/limit; waiting does not overfill.
+	now = now.Add(15 * time.Second)
+	if left, wait, _ := s.TakeToken(ctx, "a", 4, time.Minute); left != 0 || wait != 0 {
+		t.Errorf("after refill: left %d, wait %v", left, wait)
+	}
+	now = now.Add(time.Hour)
+	if left, _, _ := s.TakeToken(ctx, "a", 4, time.Minute); left != 3 {
+		t.Errorf("after idle: left %d, want 3", left)
+	}
+}
+
+func TestRateLimiter_TokenBucketNoBoundaryBurst(t *testing.T) {
+	rl := NewRateLimiter(RateLimitConfig{RequestsPerWindow: 5, Window: time.Minute, Algorithm: RateLimitTokenBucket})
+	defer rl.Stop()
+	h := rl.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	allowed := 0
+	for range 10 {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if rec.Code == http.StatusOK {
+			allowed++
+		} else if rec.Header().Get("Retry-After") != "12" {
+			t.Errorf("Retry-After = %q, want 12", rec.Header().Get("Retry-After"))
+		}
+	}
+	if allowed != 5 {
+		t.Errorf("allowed %d of a burst of 10, want 5", allowed)
+	}
+}
+
+func TestParseRateLimitRules(t *testing.T) {
+	rules, err := ParseRateLimitRules([]string{
+		"post /api/v1/assessments/{id}/run=10/1m",
+		"@admin=1000/1m",
+		"GET@viewer=50/30s",
+		"/api/v1/findings=200/1m",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RateLimitRule{
+		{Method: "POST", Path: "/api/v1/assessments/{id}/run", Limit: 10, Window: time.Minute},
+		{Role: "admin", Limit: 1000, Window: time.Minute},
+		{Method: "GET", Role: "viewer", Limit: 50, Window: 30 * time.Second},
+		{Path: "/api/v1/findings", Limit: 200, Window: time.Minute},
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Errorf("rule %d = %+v, want %+v", i, rules[i], want[i])
+		}
+	}
+	if s := rules[0].String(); s != "POST /api/v1/assessments/{id}/run=10/1m0s" {
+		t.Errorf("String() = %q", s)
+	}
+
+	for _, bad := range []string{"/x", "=10/1m", "/x=10", "/x=0/1m", "/x=10/0s", "GET x y=1/1m", "GET api=1/1m"} {
+		if _, err := ParseRateLimitRules([]string{bad}); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestIPKey(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "198.51.100.1:4242"
+	alice := req.WithContext(withIdentity(req.Context(), "alice", "viewer", AuthMethodJWT))
+	if got := IPKey(alice); got != "ip:198.51.100.1" {
+		t.Errorf("IPKey with subject = %q, want ip:198.51.100.1", got)
+	}
+	other := req.Clone(req.Context())
+	other.RemoteAddr = "198.51.100.1:5353"
+	if IPKey(other) != IPKey(req) {
+		t.Error("IPKey differs by port")
+	}
+	if got := SubjectKey(alice); got != "sub:alice" {
+		t.Errorf("SubjectKey = %q, want sub:alice", got)
+	}
+}
+
+func TestRateLimiter_RulesAndSubjectKey(t *testing.T) {
+	rl := NewRateLimiter(RateLimitConfig{
+		RequestsPerWindow: 3,
+		Window:            time.Minute,
+		Algorithm:         RateLimitTokenBucket,
+		KeyFunc:           SubjectKey,
+		Rules: []RateLimitRule{
+			{Method: "POST", Path: "/assessments/{id}/run", Limit: 1, Window: time.Minute},
+			{Role: "admin", Limit: 10, Window: time.Minute},
+		},
+	})
+	defer rl.Stop()
+	h := rl.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	do := func(method, path, subject, role string) (int, string) {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = "198.51.100.1:4242"
+		if subject != "" {
+			req = req.WithContext(withIdentity(req.Context(), subject, role, AuthMethodJWT))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code, rec.Header().Get("X-RateLimit-Limit")
+	}
+
+	// The run route has its own, stricter budget.
+	if code, limit := do("POST", "/assessments/a1/run", "alice", "assessor"); code != http.StatusOK || limit != "1" {
+		t.Errorf("first run: %d, limit %s", code, limit)
+	}
+	if code, _ := do("POST", "/assessments/a2/run", "alice", "assessor"); code != http.StatusTooManyRequests {
+		t.Errorf("second run: expected 429, got %d", code)
+	}
+	if code, limit := do("GET", "/assessments/a1", "alice", "assessor"); code != http.StatusOK || limit != "3" {
+		t.Errorf("read after runs: %d, limit %s", code, limit)
+	}
+
+	// Subjects behind the same address have separate budgets; anonymous
+	// requests share the address's.
+	for range 3 {
+		do("GET", "/findings", "bob", "viewer")
+	}
+	if code, _ := do("GET", "/findings", "bob", "viewer"); code != http.StatusTooManyRequests {
+		t.Errorf("bob over limit: expected 429, got %d", code)
+	}
+	if code, _ := do("GET", "/findings", "carol", "viewer"); code != http.StatusOK {
+		t.Errorf("carol from the same address: expected 200, got %d", code)
+	}
+	if code, _ := do("GET", "/findings", "", ""); code != http.StatusOK {
+		t.Errorf("anonymous: expected 200, got %d", code)
+	}
+
+	// Roles can have larger budgets.
+	if code, limit := do("GET", "/findings", "root", "admin"); code != http.StatusOK || limit != "10" {
+		t.Errorf("admin: %d, limit %s", code, limit)
+	}
+}
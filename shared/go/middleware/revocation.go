@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RevocationStore records revoked tokens. Auth consults it for every JWT and
+// session when AuthConfig.Revocations is set.
+//
+// A token is revoked when its jti was revoked, or when it was issued (iat)
+// at or before its subject's revocation cutoff, compared in whole seconds.
+// Since iat has second precision, a token issued in the same second as the
+// cutoff may predate it and is revoked too. Tokens without an iat are
+// treated as issued at the Unix epoch, so a subject cutoff always covers
+// them.
+type RevocationStore interface {
+	// IsRevoked reports whether the token identified by jti, subject and
+	// issuedAt (Unix seconds) has been revoked.
+	IsRevoked(ctx context.Context, jti, subject string, issuedAt int64) (bool, error)
+	// RevokeToken revokes one token until expiresAt, after which the token
+	// is rejected by its exp claim anyway and the entry can be dropped.
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	// RevokeSubject revokes every token for subject issued at or before
+	// before.
+	// A later cutoff replaces an earlier one; an earlier one is ignored.
+	RevokeSubject(ctx context.Context, subject string, before time.Time) error
+}
+
+// MemoryRevocationStore is a RevocationStore for a single process. Entries
+// are lost on restart.
+type MemoryRevocationStore struct {
+	mu       sync.Mutex
+	tokens   map[string]time.Time
+	subjects map[string]time.Time
+	now      func() time.Time
+}
+
+// NewMemoryRevocationStore returns an empty in-memory store.
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		tokens:   make(map[string]time.Time),
+		subjects: make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, jti, subject string, issuedAt int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if jti != "" {
+		if exp, ok := s.tokens[jti]; ok && s.now().Before(exp) {
+			return true, nil
+		}
+	}
+	if before, ok := s.subjects[subject]; ok && issuedAt <= before.Unix() {
+		return true, nil
+	}
+	return false, nil
+}
+
+func (s *MemoryRevocationStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for k, exp := range s.tokens {
+		if !now.Before(exp) {
+			delete(s.tokens, k)
+		}
+	}
+	if expiresAt.After(s.tokens[jti]) {
+		s.tokens[jti] = expiresAt
+	}
+	return nil
+}
+
+func (s *MemoryRevocationStore) RevokeSubject(ctx context.Context, subject string, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if before.After(s.subjects[subject]) {
+		s.subjects[subject] = before
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryRevocationStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryRevocationStore()
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	s.RevokeToken(ctx, "jti-1", now.Add(time.Hour))
+	if revoked, _ := s.IsRevoked(ctx, "jti-1", "alice", now.Unix()); !revoked {
+		t.Error("revoked jti accepted")
+	}
+	if revoked, _ := s.IsRevoked(ctx, "jti-2", "alice", now.Unix()); revoked {
+		t.Error("unrelated jti rejected")
+	}
+
+	// Entries lapse at the token's expiry and are purged on the next write.
+	s.now = func() time.Time { return now.Add(2 * time.Hour) }
+	if revoked, _ := s.IsRevoked(ctx, "jti-1", "alice", now.Unix()); revoked {
+		t.Error("entry outlived the token's exp")
+	}
+	s.RevokeToken(ctx, "jti-3", now.Add(3*time.Hour))
+	if _, ok := s.tokens["jti-1"]; ok {
+		t.Error("expired entry not purged")
+	}
+
+	cutoff := now.Add(-time.Minute)
+	s.RevokeSubject(ctx, "bob", cutoff)
+	s.RevokeSubject(ctx, "bob", cutoff.Add(-time.Hour)) // an earlier cutoff is ignored
+	if revoked, _ := s.IsRevoked(ctx, "", "bob", cutoff.Unix()-1); !revoked {
+		t.Error("token issued before the cutoff accepted")
+	}
+	// iat has second precision, so a token from the cutoff's second is
+	// revoked even when the cutoff falls later in that second, as in the
+	// Postgres store.
+	if revoked, _ := s.IsRevoked(ctx, "", "bob", cutoff.Unix()); !revoked {
+		t.Error("token issued in the cutoff's second accepted")
+	}
+	if revoked, _ := s.IsRevoked(ctx, "", "bob", cutoff.Unix()+1); revoked {
+		t.Error("token issued after the cutoff rejected")
+	}
+	if revoked, _ := s.IsRevoked(ctx, "", "bob", 0); !revoked {
+		t.Error("token without iat accepted")
+	}
+}
+
+func jtiOf(t *testing.T, token string) string {
+	t.Helper()
+	payload, err := base64URLDecode(strings.Split(token, ".")[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims JWTClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.JTI == "" {
+		t.Fatal("token has no jti")
+	}
+	return claims.JTI
+}
+
+func TestAuth_Revocation(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryRevocationStore()
+	sessions := newTestSessions(t)
+	handler := Auth(AuthConfig{JWTSecret: "jwt-secret", Sessions: sessions, Revocations: store})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }),
+	)
+	bearer := func(token string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t1, _ := CreateJWT("jwt-secret", "alice", "viewer", "", time.Hour)
+	t2, _ := CreateJWT("jwt-secret", "alice", "viewer", "", time.Hour)
+	store.RevokeToken(ctx, jtiOf(t, t1), time.Now().Add(time.Hour))
+	if code := bearer(t1); code != http.StatusUnauthorized {
+		t.Errorf("revoked jti: expected 401, got %d", code)
+	}
+	if code := bearer(t2); code != http.StatusOK {
+		t.Errorf("other token: expected 200, got %d", code)
+	}
+
+	// Revoking the subject from a point in the future covers every token
+	// issued so far, including browser sessions.
+	cookie := sessionCookie(t, sessions, Session{Subject: "alice", Role: "viewer"})
+	store.RevokeSubject(ctx, "alice", time.Now().Add(time.Second))
+	if code := bearer(t2); code != http.StatusUnauthorized {
+		t.Errorf("revoked subject: expected 401, got %d", code)
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("revoked session: expected 401, got %d", rec.Code)
+	}
+}
@@ -89,14 +89,15 @@ type CORSConfig struct {
 func DefaultCORSConfig() CORSConfig {
 	return CORSConfig{
 		AllowedOrigins: []string{},
-		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders: []string{
 			"Accept",
 			"Authorization",
 			"Content-Type",
+			"If-Match",
 			"X-Request-ID",
 		},
-		ExposedHeaders:   []string{"X-Request-ID"},
+		ExposedHeaders:   []string{"ETag", "X-Request-ID"},
 		AllowCredentials: false,
 		MaxAge:           86400,
 	}
@@ -194,7 +195,7 @@ func MaxBodySize(maxBytes int64) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.ContentLength > maxBytes {
-				writeAuthError(w, http.StatusRequestEntityTooLarge,
+				writeProblem(w, r, http.StatusRequestEntityTooLarge,
 					"request body too large (max "+strconv.FormatInt(maxBytes, 10)+" bytes)")
 				return
 			}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// minSessionSecretBytes is the shortest accepted session signing secret.
+const minSessionSecretBytes = 32
+
+// sessionMACLabel domain-separates sealed cookie MACs from HS256 JWTs signed
+// with the same secret.
+const sessionMACLabel = "quantun-session\x00"
+
+// sessionPurpose is the Seal purpose of session cookies.
+const sessionPurpose = "session"
+
+// Session is the identity carried by a browser session cookie.
+type Session struct {
+	// ID identifies the session for revocation (see RevocationStore).
+	ID        string `json:"jti"`
+	Subject   string `json:"sub"`
+	Role      string `json:"role"`
+	Email     string `json:"email,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// SessionConfig configures a SessionManager.
+type SessionConfig struct {
+	// Secret signs session cookies. At least 32 bytes.
+	Secret string
+	// CookieName defaults to "qrap_session".
+	CookieName string
+	// TTL is the session lifetime. Default: 8 hours.
+	TTL time.Duration
+	// Insecure drops the Secure cookie attribute so sessions work over plain
+	// HTTP during local development. Never set in production.
+	Insecure bool
+}
+
+// SessionManager issues and validates stateless, HMAC-signed session cookies
+// for browser clients. Cookies are HttpOnly, SameSite=Lax and Secure.
+type SessionManager struct {
+	cfg SessionConfig
+	now func() time.Time
+}
+
+// NewSessionManager validates cfg and fills in defaults.
+func NewSessionManager(cfg SessionConfig) (*SessionManager, error) {
+	if len(cfg.Secret) < minSessionSecretBytes {
+		return nil, fmt.Errorf("session secret must be at least %d bytes", minSessionSecretBytes)
+	}
+	if cfg.CookieName == "" {
+		cfg.CookieName = "qrap_session"
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 8 * time.Hour
+	}
+	return &SessionManager{cfg: cfg, now: time.Now}, nil
+}
+
+// TTL returns the session lifetime.
+func (m *SessionManager) TTL() time.Duration { return m.cfg.TTL }
+
+// Issue sets a session cookie for s, filling in ID, IssuedAt and ExpiresAt.
+func (m *SessionManager) Issue(w http.ResponseWriter, s Session) error {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	s.ID = base64.RawURLEncoding.EncodeToString(id)
+	now := m.now()
+	s.IssuedAt = now.Unix()
+	s.ExpiresAt = now.Add(m.cfg.TTL).Unix()
+	value, err := m.Seal(sessionPurpose, s)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, m.Cookie(m.cfg.CookieName, value, "/", int(m.cfg.TTL.Seconds())))
+	return nil
+}
+
+// Clear removes the session cookie.
+func (m *SessionManager) Clear(w http.ResponseWriter) {
+	http.SetCookie(w, m.Cookie(m.cfg.CookieName, "", "/", -1))
+}
+
+// FromRequest returns the session in the request's cookie. It fails if the
+// cookie is missing, tampered with or expired.
+func (m *SessionManager) FromRequest(r *http.Request) (*Session, error) {
+	c, err := r.Cookie(m.cfg.CookieName)
+	if err != nil {
+		return nil, fmt.Errorf("no session cookie")
+	}
+	var s Session
+	if err := m.Open(sessionPurpose, c.Value, &s); err != nil {
+		return nil, err
+	}
+	if m.now().Unix() > s.ExpiresAt {
+		return nil, fmt.Errorf("session expired at %d", s.ExpiresAt)
+	}
+	if s.Subject == "" {
+		return nil, fmt.Errorf("session missing subject")
+	}
+	return &s, nil
+}
+
+// Seal encodes v as base64url(JSON) "." base64url(HMAC-SHA256). It is also
+// used for short-lived cookies such as OIDC login state; purpose is bound
+// into the MAC so a value sealed for one purpose cannot be opened as another.
+func (m *SessionManager) Seal(purpose string, v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("marshal session: %w", err)
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(m.mac(purpose, payload)), nil
+}
+
+// Open verifies a value produced by Seal for purpose and decodes it into v.
+func (m *SessionManager) Open(purpose, value string, v interface{}) error {
+	payload, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return fmt.Errorf("malformed session cookie")
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, m.mac(purpose, payload)) {
+		return fmt.Errorf("invalid session signature")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return fmt.Errorf("invalid session encoding: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid session: %w", err)
+	}
+	return nil
+}
+
+// Cookie builds a cookie with the manager's security attributes. maxAge < 0
+// deletes the cookie.
+func (m *SessionManager) Cookie(name, value, path string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   !m.cfg.Insecure,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (m *SessionManager) mac(purpose, payload string) []byte {
+	h := hmac.New(sha256.New, []byte(m.cfg.Secret))
+	h.Write([]byte(sessionMACLabel))
+	h.Write([]byte(purpose + "\x00"))
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
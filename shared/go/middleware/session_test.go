@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSessionSecret = "0123456789abcdef0123456789abcdef"
+
+func newTestSessions(t *testing.T) *SessionManager {
+	t.Helper()
+	m, err := NewSessionManager(SessionConfig{Secret: testSessionSecret, TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// sessionCookie issues a session and returns the Set-Cookie value.
+func sessionCookie(t *testing.T, m *SessionManager, s Session) *http.Cookie {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	if err := m.Issue(rec, s); err != nil {
+		t.Fatal(err)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected one cookie, got %d", len(cookies))
+	}
+	return cookies[0]
+}
+
+func TestSessionManager_CookieAttributes(t *testing.T) {
+	if _, err := NewSessionManager(SessionConfig{Secret: "short"}); err == nil {
+		t.Error("expected short secret to be rejected")
+	}
+	c := sessionCookie(t, newTestSessions(t), Session{Subject: "user-1", Role: "analyst"})
+	if c.Name != "qrap_session" || !c.HttpOnly || !c.Secure || c.SameSite != http.SameSiteLaxMode || c.Path != "/" {
+		t.Errorf("unexpected cookie attributes: %+v", c)
+	}
+	if c.MaxAge != 3600 {
+		t.Errorf("MaxAge = %d, want 3600", c.MaxAge)
+	}
+}
+
+func TestAuth_SessionCookie(t *testing.T) {
+	m := newTestSessions(t)
+	var gotSubject, gotRole string
+	var gotMethod AuthMethod
+	handler := Auth(AuthConfig{JWTSecret: "jwt-secret", Sessions: m})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSubject = SubjectFromContext(r.Context())
+		gotRole = RoleFromContext(r.Context())
+		gotMethod = AuthMethodFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(req *http.Request) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	cookie := sessionCookie(t, m, Session{Subject: "user-1", Role: "analyst"})
+	req := httptest.NewRequest("GET", "/api/v1/assessments", nil)
+	req.AddCookie(cookie)
+	if code := serve(req); code != http.StatusOK {
+		t.Fatalf("valid session: expected 200, got %d", code)
+	}
+	if gotSubject != "user-1" || gotRole != "analyst" || gotMethod != AuthMethodSession {
+		t.Errorf("identity = %q/%q/%q", gotSubject, gotRole, gotMethod)
+	}
+
+	// An Authorization header takes precedence over the cookie.
+	token, _ := CreateJWT("jwt-secret", "svc", "service", "", time.Hour)
+	req = httptest.NewRequest("GET", "/api/v1/assessments", nil)
+	req.AddCookie(cookie)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if code := serve(req); code != http.StatusOK || gotMethod != AuthMethodJWT {
+		t.Errorf("bearer with cookie: code %d method %q", code, gotMethod)
+	}
+
+	// Tampered payload.
+	payload, sig, _ := strings.Cut(cookie.Value, ".")
+	forged := Session{Subject: "user-1", Role: "admin", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	forgedValue, _ := (&SessionManager{cfg: SessionConfig{Secret: "attacker-secret-attacker-secret!!"}}).Seal(sessionPurpose, forged)
+	forgedPayload, _, _ := strings.Cut(forgedValue, ".")
+	for name, value := range map[string]string{
+		"swapped payload": forgedPayload + "." + sig,
+		"wrong key":       forgedValue,
+		"no signature":    payload,
+	} {
+		req = httptest.NewRequest("GET", "/api/v1/assessments", nil)
+		req.AddCookie(&http.Cookie{Name: cookie.Name, Value: value})
+		if code := serve(req); code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", name, code)
+		}
+	}
+
+	// Expired session.
+	m.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	req = httptest.NewRequest("GET", "/api/v1/assessments", nil)
+	req.AddCookie(cookie)
+	if code := serve(req); code != http.StatusUnauthorized {
+		t.Errorf("expired session: expected 401, got %d", code)
+	}
+}
+
+func TestAuth_SessionCrossOriginRejected(t *testing.T) {
+	m := newTestSessions(t)
+	handler := Auth(AuthConfig{Sessions: m})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	cookie := sessionCookie(t, m, Session{Subject: "user-1", Role: "admin"})
+
+	cases := []struct {
+		method, site string
+		want         int
+	}{
+		{"POST", "same-origin", http.StatusOK},
+		{"POST", "cross-site", http.StatusForbidden},
+		{"DELETE", "cross-site", http.StatusForbidden},
+		{"GET", "cross-site", http.StatusOK},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, "/api/v1/organizations", nil)
+		req.AddCookie(cookie)
+		req.Header.Set("Sec-Fetch-Site", tc.site)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s from %s: expected %d, got %d", tc.method, tc.site, tc.want, rec.Code)
+		}
+	}
+}
+
+func TestSessionManager_PurposeBinding(t *testing.T) {
+	m := newTestSessions(t)
+	value, _ := m.Seal("oidc-login", map[string]string{"sub": "user-1"})
+	var s Session
+	if err := m.Open(sessionPurpose, value, &s); err == nil {
+		t.Error("expected value sealed for another purpose to be rejected")
+	}
+	if err := m.Open("oidc-login", value, &s); err != nil || s.Subject != "user-1" {
+		t.Errorf("Open = %v, %+v", err, s)
+	}
+}
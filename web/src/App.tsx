@@ -19,6 +19,14 @@ export const App: React.FC = () => {
             <NavLink to="/findings">Findings</NavLink>
             <NavLink to="/hndl">HNDL Analysis</NavLink>
           </div>
+          <div className="qtn-nav__auth">
+            <a href="/api/v1/auth/login">Sign in</a>
+            <form method="post" action="/api/v1/auth/logout">
+              <button type="submit" className="qtn-btn">
+                Sign out
+              </button>
+            </form>
+          </div>
         </nav>
         <main className="qtn-main">
           <Routes>